@@ -0,0 +1,100 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AnswerProfile is a named set of answer formatting choices for an audience,
+// e.g. concise bullet points without code for executives. Empty fields leave
+// the choice to the default prompt.
+type AnswerProfile struct {
+	Language  string `yaml:"language"`  // language answers are written in
+	Tone      string `yaml:"tone"`      // free-form, e.g. friendly or formal
+	Length    string `yaml:"length"`    // concise, standard or detailed
+	Detail    string `yaml:"detail"`    // basic or technical
+	Format    string `yaml:"format"`    // prose or bullets
+	Code      *bool  `yaml:"code"`      // whether code and commands may be included
+	Citations string `yaml:"citations"` // inline, list (sources listed under the answer) or none
+}
+
+// validate checks the profile's fields hold known choices
+func (p AnswerProfile) validate() error {
+	fields := []struct {
+		name, value string
+		choices     []string
+	}{
+		{"length", p.Length, []string{"concise", "standard", "detailed"}},
+		{"detail", p.Detail, []string{"basic", "technical"}},
+		{"format", p.Format, []string{"prose", "bullets"}},
+		{"citations", p.Citations, []string{"inline", "list", "none"}},
+	}
+	for _, field := range fields {
+		if field.value != "" && !slices.Contains(field.choices, field.value) {
+			return fmt.Errorf("%s must be one of: %s", field.name, strings.Join(field.choices, ", "))
+		}
+	}
+	return nil
+}
+
+// LoadAnswerProfiles reads a YAML mapping of profile names to answer
+// profiles, rejecting unknown fields and choices. Names are lowercased.
+func LoadAnswerProfiles(path string) (map[string]AnswerProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answer profiles: %w", err)
+	}
+
+	var raw map[string]AnswerProfile
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&raw); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse answer profiles: %w", err)
+	}
+
+	profiles := make(map[string]AnswerProfile, len(raw))
+	for name, profile := range raw {
+		if err := profile.validate(); err != nil {
+			return nil, fmt.Errorf("answer profile %q: %w", name, err)
+		}
+		profiles[strings.ToLower(name)] = profile
+	}
+	return profiles, nil
+}
+
+// AnswerProfiles loads the profiles at AnswerProfilesFile, or returns nil
+// when none are configured
+func (c *Config) AnswerProfiles() (map[string]AnswerProfile, error) {
+	if c.AnswerProfilesFile == "" {
+		return nil, nil
+	}
+	return LoadAnswerProfiles(c.AnswerProfilesFile)
+}
+
+// validateAnswerProfiles checks the profile file loads and that the default
+// and per-channel profiles are defined in it
+func (c *Config) validateAnswerProfiles() error {
+	profiles, err := c.AnswerProfiles()
+	if err != nil {
+		return err
+	}
+
+	if name := c.DefaultAnswerProfile; name != "" {
+		if _, ok := profiles[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("DEFAULT_ANSWER_PROFILE %q is not defined", name)
+		}
+	}
+	for channel, name := range c.ChannelAnswerProfiles {
+		if _, ok := profiles[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("profile %q of channel %s is not defined", name, channel)
+		}
+	}
+	return nil
+}
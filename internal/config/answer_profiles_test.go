@@ -0,0 +1,105 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeProfiles writes an answer profiles file and returns its path
+func writeProfiles(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write profiles: %v", err)
+	}
+	return path
+}
+
+func TestLoadAnswerProfiles(t *testing.T) {
+	path := writeProfiles(t, `
+Exec:
+  length: concise
+  format: bullets
+  code: false
+engineer:
+  length: detailed
+  detail: technical
+  code: true
+  citations: list
+`)
+
+	profiles, err := LoadAnswerProfiles(path)
+	if err != nil {
+		t.Fatalf("LoadAnswerProfiles returned error: %v", err)
+	}
+
+	exec, ok := profiles["exec"]
+	if !ok {
+		t.Fatalf("Expected profile names to be lowercased, got %v", profiles)
+	}
+	if exec.Length != "concise" || exec.Format != "bullets" || exec.Code == nil || *exec.Code {
+		t.Errorf("Unexpected exec profile: %+v", exec)
+	}
+	if engineer := profiles["engineer"]; engineer.Citations != "list" || engineer.Code == nil || !*engineer.Code {
+		t.Errorf("Unexpected engineer profile: %+v", engineer)
+	}
+}
+
+func TestLoadAnswerProfiles_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "unknown length", content: "exec:\n  length: tiny\n"},
+		{name: "unknown citation style", content: "exec:\n  citations: footnotes\n"},
+		{name: "unknown field", content: "exec:\n  lenght: concise\n"},
+		{name: "not a mapping", content: "- exec\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := LoadAnswerProfiles(writeProfiles(t, tt.content)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestValidate_AnswerProfiles(t *testing.T) {
+	path := writeProfiles(t, "exec:\n  length: concise\n")
+
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{name: "defined profiles", modify: func(c *Config) {
+			c.AnswerProfilesFile = path
+			c.DefaultAnswerProfile = "Exec"
+			c.ChannelAnswerProfiles = map[string]string{"C1": "exec"}
+		}},
+		{name: "undefined default profile", modify: func(c *Config) {
+			c.AnswerProfilesFile = path
+			c.DefaultAnswerProfile = "engineer"
+		}, wantErr: true},
+		{name: "undefined channel profile", modify: func(c *Config) {
+			c.AnswerProfilesFile = path
+			c.ChannelAnswerProfiles = map[string]string{"C1": "engineer"}
+		}, wantErr: true},
+		{name: "profile without file", modify: func(c *Config) { c.DefaultAnswerProfile = "exec" }, wantErr: true},
+		{name: "missing file", modify: func(c *Config) { c.AnswerProfilesFile = path + ".missing" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := LoadTestConfig()
+			tt.modify(cfg)
+
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -1,71 +1,773 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	// Slack configuration
-	SlackBotToken      string
-	SlackSigningSecret string
-	SlackAppToken      string
-	SlackChannelID     string
-	TriggerEmoji       string
+	SlackBotToken        string
+	SlackSigningSecret   string
+	SlackAppToken        string
+	SlackChannelID       string
+	MonitoredChannels    []string
+	SlackAPIURL          string
+	TriggerEmoji         string
+	BotStatusEnabled     bool
+	ProcessFileReactions bool
+	GreetOnJoin          bool
+	DMEnabled            bool
+	AnswerableSubtypes   []string
+
+	// Noisy thread suppression; 0 answers threads of any length
+	MaxThreadRepliesForAnswer int
+	NoisyThreadReaction       string
+	ForceAnswerEmoji          string
+
+	// Reactions to messages older than this many days are ignored unless they
+	// use ForceAnswerEmoji; 0 answers messages of any age
+	MaxReactedMessageAgeDays int
+
+	// React to answered questions with white_check_mark, or x for fallback answers
+	OutcomeReactions bool
+
+	// What happens to an answer whose question is deleted: none, annotate or delete
+	DeletedSourceAction string
+
+	// How far back polling looks for trigger reactions missed while the bot was offline
+	MissedReactionLookback time.Duration
 
 	// Confluence configuration
-	ConfluenceBaseURL  string
-	ConfluenceUsername string
-	ConfluenceAPIToken string
-	ConfluenceSpaceKey string
+	ConfluenceBaseURL   string
+	ConfluenceUsername  string
+	ConfluenceAPIToken  string
+	ConfluenceSpaceKey  string
+	ConfluenceQueryMode string
+	// ConfluenceSpaceKeys lists the spaces to search, ConfluenceSpaceKey when empty;
+	// with ConfluencePerSpaceSearch each is queried separately
+	ConfluenceSpaceKeys      []string
+	ConfluencePerSpaceSearch bool
+	ConfluenceAPIVersion     string
+	ConfluenceTimeout        time.Duration
+	IncludePageComments      bool
+	IncludeRecentPages       bool
+	RecentPagesDaysBack      int
+	RecentPageScore          float64
+
+	// ConfluenceMissingSpace is what happens at startup when a configured space
+	// doesn't exist: "fallback" warns and searches without it, "fail" exits
+	ConfluenceMissingSpace string
+
+	// GitHub code search configuration; GitHub is searched when a token and
+	// owner are set, across the owner's repositories unless GitHubRepo or
+	// GitHubRepos allowlist some of them
+	GitHubToken  string
+	GitHubOwner  string
+	GitHubRepo   string
+	GitHubRepos  []string
+	GitHubAPIURL string
 
 	// Server configuration
-	Port string
-	Env  string
+	Port          string
+	Env           string
+	AdminAPIToken string
 
 	// Database configuration
 	DBPath string
 
+	// Status command configuration
+	StatusShowCounters bool
+
+	// Answer formatting configuration
+	AnswerAttribution   bool
+	LongAnswerStrategy  string
+	ProgressPlaceholder bool
+	PartialResultsNote  bool
+	ShowConfidence      bool
+	ResponseUsername    string
+	ResponseIconEmoji   string
+	ResponseIconURL     string
+
+	// Canvas publishing configuration
+	CanvasPublishEnabled   bool
+	CanvasPublishThreshold float64
+
+	// Weekly reports: every WeeklyReportSchedule a Confluence page summarising
+	// the week's inquiries is created in WeeklyReportSpaceKey, one per channel in
+	// WeeklyReportChannels or a single one across channels when it is empty
+	WeeklyReportSpaceKey     string
+	WeeklyReportParentPageID string
+	WeeklyReportChannels     []string
+	WeeklyReportSchedule     string
+	WeeklyReportTimezone     string
+
+	// FAQ publishing: admins can publish helpful answers as Confluence pages
+	// in FAQSpaceKey, under FAQParentPageID when it is set
+	FAQSpaceKey     string
+	FAQParentPageID string
+
+	// Post the top Confluence page scoring at least DirectDocThreshold instead of generating an answer
+	PreferDirectDocs   bool
+	DirectDocThreshold float64
+
+	// Answers whose best source scores below AutoPostConfidence are flagged as
+	// low confidence, and below SuggestConfidence replaced by links
+	AutoPostConfidence float64
+	SuggestConfidence  float64
+
+	// Expert routing: inquiries answered below AutoPostConfidence are also
+	// forwarded to DefaultExpertUserID
+	ExpertRoutingEnabled bool
+	DefaultExpertUserID  string
+
+	// Answer confidence scoring: generated answers are scored 0-1 from token
+	// log-probabilities or, without them, by asking the model. Answers scoring
+	// below LowAnswerConfidence are routed like low-confidence ones.
+	AnswerConfidenceScoring bool
+	LowAnswerConfidence     float64
+
+	// Regexes for boilerplate stripped from the start or end of generated answers
+	AnswerStripPatterns []string
+
+	// Answer refresh configuration
+	AnswerTTLDays              int
+	AnswerRefreshEmoji         string
+	AnswerRefreshCheckInterval time.Duration
+	StaleReprocessInterval     time.Duration
+	PageVersionCheckInterval   time.Duration
+
+	// Follow-up watching: for FollowUpWindow after an answer its thread is
+	// checked every FollowUpPollInterval for the asker's unresolved follow-ups
+	FollowUpWindow            time.Duration
+	FollowUpPollInterval      time.Duration
+	MaxFollowUpWatchers       int
+	FollowUpEscalationContact string
+
+	// Office hours configuration
+	OfficeHours         string
+	OfficeHoursTimezone string
+	OfficeHoursMode     string
+	officeHours         *parsedOfficeHours // OfficeHours as parsed by Validate
+
+	// Cross-channel deduplication configuration
+	CrossChannelDedup       bool
+	CrossChannelDedupWindow time.Duration
+
+	// Share one answer between identical inquiries being answered at the same time
+	CoalesceInquiries bool
+
+	// Inquiry categorization configuration
+	InquiryClassifier string
+
+	// Inquiry queue configuration
+	MaxConcurrentInquiries int
+	MaxQueueDepth          int
+	MaxInquiriesPerHour    int
+	MaxInquiryRetries      int
+
+	// Inquiries still processing after this long at startup were interrupted
+	ProcessingTimeoutMinutes int
+
 	// AI/Search configuration
-	SimilarityThreshold float64
-	MaxSearchResults    int
-	SearchDaysBack      int
+	SimilarityThreshold   float64
+	MinResultCount        int
+	ThresholdDecayStep    float64
+	MinThreshold          float64
+	MaxSearchResults      int
+	SnippetWindow         int
+	MaxContentBytes       int
+	SearchDaysBack        int
+	SearchThreads         bool
+	SlackSearchIndex      bool
+	SearchChannelNames    bool // search in:#name rather than in:<channel ID>
+	EnrichConcurrency     int  // Slack author lookups made at once for search results
+	SynonymDictFile       string
+	SlackNoiseUserIDs     []string
+	SlackNoisePatterns    []string
+	SearchCacheTTL        time.Duration
+	ChannelRelevanceBoost float64
+	FeedbackReranking     bool
+	SourceWeighting       bool
+	SourceWeightInterval  time.Duration
+	FeedbackBoost         float64
+
+	// Search timeouts, per source and for SearchAll as a whole
+	SlackSearchTimeout      time.Duration
+	ConfluenceSearchTimeout time.Duration
+	GitHubSearchTimeout     time.Duration
+	SearchTotalTimeout      time.Duration
 
 	// LiteLLM configuration
 	LiteLLMAPIKey  string
 	LiteLLMBaseURL string
+	LLMProvider    string
 	LLMModel       string
 	LLMTemperature float64
 	LLMMaxTokens   int
+	LLMTimeout     time.Duration
+
+	// Embeddings; a dimension of 0 accepts vectors of any length
+	EmbeddingModel      string
+	EmbeddingDimensions int
+
+	// Search results whose embeddings are at least SemanticDedupThreshold
+	// similar to a higher scored result are dropped when enabled
+	SemanticDedupEnabled   bool
+	SemanticDedupThreshold float64
+
+	// Model selection
+	LLMAllowedModels []string
+	EmojiModels      map[string]string
+	ChannelModels    map[string]string
+
+	// Answer formatting profiles defined in AnswerProfilesFile, chosen per
+	// command with --profile, per channel, or by default
+	AnswerProfilesFile    string
+	DefaultAnswerProfile  string
+	ChannelAnswerProfiles map[string]string
+
+	// Ensemble answers, opted into per channel or emoji with the "ensemble" model
+	EnsembleModels     []string
+	EnsembleJudgeModel string
+
+	// Images shared with an inquiry are sent to LLMVisionModel when enabled
+	LLMVisionEnabled bool
+	LLMVisionModel   string
+
+	// A ShadowSamplingRate share of answered inquiries is also answered by
+	// ShadowLLMModel, whose answer is stored for comparison but never posted
+	ShadowLLMModel     string
+	ShadowSamplingRate float64
+
+	// LLM context budget
+	LLMMaxContextChars   int
+	LLMMustHaveThreshold float64
+	ContextSourceOrder   string // docs_first, chat_first or by_score
+
+	// Debug configuration
+	DebugStoreRawResponses       bool
+	DebugRawResponseRetention    int
+	DebugStoreSearchExplanations bool
+
+	// JSON lines file every answer request to the LLM is audited to (empty disables)
+	AuditLogPath string
+
+	// Inquiry fields (channel, user, category) sent to LiteLLM as tags for spend
+	// attribution; user IDs are sent hashed unless LLMMetadataHashUsers is off
+	LLMMetadataHeaders   []string
+	LLMMetadataHashUsers bool
+
+	// Metrics configuration
+	MetricsBackend string
+	StatsDAddr     string
 }
 
 // Load loads configuration from environment variables
 func Load() *Config {
 	return &Config{
-		SlackBotToken:       getEnv("SLACK_BOT_TOKEN", ""),
-		SlackSigningSecret:  getEnv("SLACK_SIGNING_SECRET", ""),
-		SlackAppToken:       getEnv("SLACK_APP_TOKEN", ""),
-		SlackChannelID:      getEnv("SLACK_CHANNEL_ID", ""),
-		TriggerEmoji:        getEnv("TRIGGER_EMOJI", "eyes"),
-		ConfluenceBaseURL:   getEnv("CONFLUENCE_BASE_URL", ""),
-		ConfluenceUsername:  getEnv("CONFLUENCE_USERNAME", ""),
-		ConfluenceAPIToken:  getEnv("CONFLUENCE_API_TOKEN", ""),
-		ConfluenceSpaceKey:  getEnv("CONFLUENCE_SPACE_KEY", "DOCS"),
-		Port:                getEnv("PORT", "8080"),
-		Env:                 getEnv("ENV", "development"),
-		DBPath:              getEnv("DB_PATH", "./data/inquiries.db"),
-		SimilarityThreshold: getEnvFloat("SIMILARITY_THRESHOLD", 0.7),
-		MaxSearchResults:    getEnvInt("MAX_SEARCH_RESULTS", 10),
-		SearchDaysBack:      getEnvInt("SEARCH_DAYS_BACK", 90),
-		LiteLLMAPIKey:       getEnv("LITELLM_API_KEY", ""),
-		LiteLLMBaseURL:      getEnv("LITELLM_BASE_URL", "https://litellm.mercari.in"),
-		LLMModel:            getEnv("LLM_MODEL", "gpt-4o-mini"),
-		LLMTemperature:      getEnvFloat("LLM_TEMPERATURE", 0.3),
-		LLMMaxTokens:        getEnvInt("LLM_MAX_TOKENS", 1000),
+		SlackBotToken:        getEnv("SLACK_BOT_TOKEN", ""),
+		SlackSigningSecret:   getEnv("SLACK_SIGNING_SECRET", ""),
+		SlackAppToken:        getEnv("SLACK_APP_TOKEN", ""),
+		SlackChannelID:       getEnv("SLACK_CHANNEL_ID", ""),
+		MonitoredChannels:    getEnvList("MONITORED_CHANNELS"),
+		SlackAPIURL:          getEnv("SLACK_API_URL", ""),
+		TriggerEmoji:         getEnv("TRIGGER_EMOJI", "eyes"),
+		BotStatusEnabled:     getEnvBool("BOT_STATUS_ENABLED", false),
+		ProcessFileReactions: getEnvBool("PROCESS_FILE_REACTIONS", false),
+		GreetOnJoin:          getEnvBool("GREET_ON_JOIN", false),
+		DMEnabled:            getEnvBool("DM_ENABLED", false),
+		AnswerableSubtypes:   getEnvList("ANSWERABLE_MESSAGE_SUBTYPES"),
+
+		MaxThreadRepliesForAnswer: getEnvInt("MAX_THREAD_REPLIES_FOR_ANSWER", 0),
+		NoisyThreadReaction:       getEnv("NOISY_THREAD_REACTION", ""),
+		ForceAnswerEmoji:          getEnv("FORCE_ANSWER_EMOJI", ""),
+		MaxReactedMessageAgeDays:  getEnvInt("MAX_REACTED_MESSAGE_AGE_DAYS", 0),
+
+		OutcomeReactions: getEnvBool("OUTCOME_REACTIONS", false),
+
+		DeletedSourceAction: getEnv("DELETED_SOURCE_ACTION", "none"),
+
+		MissedReactionLookback: getEnvDuration("MISSED_REACTION_LOOKBACK", 24*time.Hour),
+
+		ConfluenceBaseURL:        getEnv("CONFLUENCE_BASE_URL", ""),
+		ConfluenceUsername:       getEnv("CONFLUENCE_USERNAME", ""),
+		ConfluenceAPIToken:       getEnv("CONFLUENCE_API_TOKEN", ""),
+		ConfluenceSpaceKey:       getEnv("CONFLUENCE_SPACE_KEY", "DOCS"),
+		ConfluenceQueryMode:      getEnv("CONFLUENCE_QUERY_MODE", "phrase"),
+		ConfluenceSpaceKeys:      getEnvList("CONFLUENCE_SPACE_KEYS"),
+		ConfluencePerSpaceSearch: getEnvBool("CONFLUENCE_PER_SPACE_SEARCH", false),
+		ConfluenceMissingSpace:   getEnv("CONFLUENCE_MISSING_SPACE", "fallback"),
+		ConfluenceAPIVersion:     getEnv("CONFLUENCE_API_VERSION", "auto"),
+		ConfluenceTimeout:        getEnvDuration("CONFLUENCE_TIMEOUT", 15*time.Second),
+		IncludePageComments:      getEnvBool("INCLUDE_PAGE_COMMENTS", false),
+		IncludeRecentPages:       getEnvBool("INCLUDE_RECENT_PAGES", false),
+		RecentPagesDaysBack:      getEnvInt("RECENT_PAGES_DAYS_BACK", 7),
+		RecentPageScore:          getEnvFloat("RECENT_PAGE_SCORE", 0.6),
+		GitHubToken:              getEnv("GITHUB_TOKEN", ""),
+		GitHubOwner:              getEnv("GITHUB_OWNER", ""),
+		GitHubRepo:               getEnv("GITHUB_REPO", ""),
+		GitHubRepos:              getEnvList("GITHUB_REPOS"),
+		GitHubAPIURL:             getEnv("GITHUB_API_URL", "https://api.github.com"),
+		Port:                     getEnv("PORT", "8080"),
+		Env:                      getEnv("ENV", "development"),
+		AdminAPIToken:            getEnv("ADMIN_API_TOKEN", ""),
+		DBPath:                   getEnv("DB_PATH", "./data/inquiries.db"),
+		StatusShowCounters:       getEnvBool("STATUS_SHOW_COUNTERS", true),
+
+		AnswerAttribution:          getEnvBool("ANSWER_ATTRIBUTION", false),
+		ProgressPlaceholder:        getEnvBool("PROGRESS_PLACEHOLDER", false),
+		PartialResultsNote:         getEnvBool("PARTIAL_RESULTS_NOTE", false),
+		ShowConfidence:             getEnvBool("SHOW_CONFIDENCE", false),
+		LongAnswerStrategy:         getEnv("LONG_ANSWER_STRATEGY", "split"),
+		ResponseUsername:           getEnv("RESPONSE_USERNAME", ""),
+		ResponseIconEmoji:          getEnv("RESPONSE_ICON_EMOJI", ""),
+		ResponseIconURL:            getEnv("RESPONSE_ICON_URL", ""),
+		CanvasPublishEnabled:       getEnvBool("CANVAS_PUBLISH_ENABLED", false),
+		CanvasPublishThreshold:     getEnvFloat("CANVAS_PUBLISH_THRESHOLD", 0.9),
+		WeeklyReportSpaceKey:       getEnv("WEEKLY_REPORT_SPACE_KEY", ""),
+		WeeklyReportParentPageID:   getEnv("WEEKLY_REPORT_PARENT_PAGE_ID", ""),
+		WeeklyReportChannels:       getEnvList("WEEKLY_REPORT_CHANNELS"),
+		WeeklyReportSchedule:       getEnv("WEEKLY_REPORT_SCHEDULE", "Fri 17:00"),
+		WeeklyReportTimezone:       getEnv("WEEKLY_REPORT_TIMEZONE", "UTC"),
+		FAQSpaceKey:                getEnv("FAQ_SPACE_KEY", ""),
+		FAQParentPageID:            getEnv("FAQ_PARENT_PAGE_ID", ""),
+		PreferDirectDocs:           getEnvBool("PREFER_DIRECT_DOCS", false),
+		DirectDocThreshold:         getEnvFloat("DIRECT_DOC_THRESHOLD", 0.95),
+		AutoPostConfidence:         getEnvFloat("AUTO_POST_CONFIDENCE", 0),
+		SuggestConfidence:          getEnvFloat("SUGGEST_CONFIDENCE", 0),
+		ExpertRoutingEnabled:       getEnvBool("EXPERT_ROUTING_ENABLED", false),
+		AnswerConfidenceScoring:    getEnvBool("ANSWER_CONFIDENCE_SCORING", false),
+		LowAnswerConfidence:        getEnvFloat("LOW_ANSWER_CONFIDENCE", 0.5),
+		AnswerStripPatterns:        getEnvPatterns("ANSWER_STRIP_PATTERNS"),
+		DefaultExpertUserID:        getEnv("DEFAULT_EXPERT_USER_ID", ""),
+		AnswerTTLDays:              getEnvInt("ANSWER_TTL_DAYS", 0),
+		AnswerRefreshEmoji:         getEnv("ANSWER_REFRESH_EMOJI", "arrows_counterclockwise"),
+		AnswerRefreshCheckInterval: getEnvDuration("ANSWER_REFRESH_CHECK_INTERVAL", time.Hour),
+		StaleReprocessInterval:     getEnvDuration("STALE_REPROCESS_INTERVAL", 6*time.Hour),
+		PageVersionCheckInterval:   getEnvDuration("PAGE_VERSION_CHECK_INTERVAL", 0),
+		FollowUpWindow:             getEnvDuration("FOLLOW_UP_WINDOW", 0),
+		FollowUpPollInterval:       getEnvDuration("FOLLOW_UP_POLL_INTERVAL", time.Minute),
+		MaxFollowUpWatchers:        getEnvInt("MAX_FOLLOW_UP_WATCHERS", 50),
+		FollowUpEscalationContact:  getEnv("FOLLOW_UP_ESCALATION_CONTACT", ""),
+		CrossChannelDedup:          getEnvBool("CROSS_CHANNEL_DEDUP", false),
+		CrossChannelDedupWindow:    getEnvDuration("CROSS_CHANNEL_DEDUP_WINDOW", 24*time.Hour),
+		CoalesceInquiries:          getEnvBool("COALESCE_INQUIRIES", false),
+		OfficeHours:                getEnv("OFFICE_HOURS", ""),
+		OfficeHoursTimezone:        getEnv("OFFICE_HOURS_TIMEZONE", "UTC"),
+		OfficeHoursMode:            getEnv("OFFICE_HOURS_MODE", "defer"),
+		InquiryClassifier:          getEnv("INQUIRY_CLASSIFIER", "rules"),
+		MaxConcurrentInquiries:     getEnvInt("MAX_CONCURRENT_INQUIRIES", 4),
+		MaxQueueDepth:              getEnvInt("MAX_QUEUE_DEPTH", 100),
+		MaxInquiriesPerHour:        getEnvInt("MAX_INQUIRIES_PER_HOUR", 20),
+		MaxInquiryRetries:          getEnvInt("MAX_INQUIRY_RETRIES", 3),
+		ProcessingTimeoutMinutes:   getEnvInt("PROCESSING_TIMEOUT_MINUTES", 10),
+		SimilarityThreshold:        getEnvFloat("SIMILARITY_THRESHOLD", 0.7),
+		MinResultCount:             getEnvInt("MIN_RESULT_COUNT", 1),
+		ThresholdDecayStep:         getEnvFloat("THRESHOLD_DECAY_STEP", 0.1),
+		MinThreshold:               getEnvFloat("MIN_THRESHOLD", 0.2),
+		MaxSearchResults:           getEnvInt("MAX_SEARCH_RESULTS", 10),
+		SnippetWindow:              getEnvInt("SNIPPET_WINDOW", 100),
+		MaxContentBytes:            getEnvInt("MAX_CONTENT_BYTES", 2000),
+		SearchCacheTTL:             getEnvDuration("SEARCH_CACHE_TTL", time.Hour),
+		SlackSearchTimeout:         getEnvDuration("SLACK_SEARCH_TIMEOUT", 10*time.Second),
+		ConfluenceSearchTimeout:    getEnvDuration("CONFLUENCE_SEARCH_TIMEOUT", 10*time.Second),
+		GitHubSearchTimeout:        getEnvDuration("GITHUB_SEARCH_TIMEOUT", 10*time.Second),
+		SearchTotalTimeout:         getEnvDuration("SEARCH_TOTAL_TIMEOUT", 15*time.Second),
+		SearchDaysBack:             getEnvInt("SEARCH_DAYS_BACK", 90),
+		SearchThreads:              getEnvBool("SEARCH_THREADS", false),
+		SlackSearchIndex:           getEnvBool("SLACK_SEARCH_INDEX", false),
+		SearchChannelNames:         getEnvBool("SLACK_SEARCH_CHANNEL_NAMES", true),
+		EnrichConcurrency:          getEnvInt("SLACK_ENRICH_CONCURRENCY", 4),
+		SynonymDictFile:            getEnv("SYNONYM_DICT_FILE", ""),
+		SlackNoiseUserIDs:          getEnvList("SLACK_NOISE_USER_IDS"),
+		SlackNoisePatterns:         getEnvPatterns("SLACK_NOISE_PATTERNS"),
+		ChannelRelevanceBoost:      getEnvFloat("CHANNEL_RELEVANCE_BOOST", 0.2),
+		FeedbackReranking:          getEnvBool("FEEDBACK_RERANKING", false),
+		FeedbackBoost:              getEnvFloat("FEEDBACK_BOOST", 0.2),
+		SourceWeighting:            getEnvBool("SOURCE_WEIGHTING", false),
+		SourceWeightInterval:       getEnvDuration("SOURCE_WEIGHT_INTERVAL", 7*24*time.Hour),
+		LiteLLMAPIKey:              getEnv("LITELLM_API_KEY", ""),
+		LiteLLMBaseURL:             getEnv("LITELLM_BASE_URL", "https://litellm.mercari.in"),
+		LLMProvider:                getEnv("LLM_PROVIDER", "openai"),
+		LLMModel:                   getEnv("LLM_MODEL", "gpt-4o-mini"),
+		LLMTemperature:             getEnvFloat("LLM_TEMPERATURE", 0.3),
+		LLMMaxTokens:               getEnvInt("LLM_MAX_TOKENS", 1000),
+		LLMTimeout:                 getEnvDuration("LLM_TIMEOUT", 30*time.Second),
+		EmbeddingModel:             getEnv("EMBEDDING_MODEL", "text-embedding-3-small"),
+		EmbeddingDimensions:        getEnvInt("EMBEDDING_DIMENSIONS", 1536),
+		SemanticDedupEnabled:       getEnvBool("SEMANTIC_DEDUP_ENABLED", false),
+		SemanticDedupThreshold:     getEnvFloat("SEMANTIC_DEDUP_THRESHOLD", 0.92),
+
+		LLMAllowedModels: getEnvList("LLM_ALLOWED_MODELS"),
+		EmojiModels:      getEnvMap("EMOJI_MODELS"),
+		ChannelModels:    getEnvMap("CHANNEL_MODELS"),
+
+		AnswerProfilesFile:    getEnv("ANSWER_PROFILES_FILE", ""),
+		DefaultAnswerProfile:  getEnv("DEFAULT_ANSWER_PROFILE", ""),
+		ChannelAnswerProfiles: getEnvMap("CHANNEL_ANSWER_PROFILES"),
+
+		EnsembleModels:     getEnvList("ENSEMBLE_MODELS"),
+		EnsembleJudgeModel: getEnv("ENSEMBLE_JUDGE_MODEL", ""),
+
+		LLMVisionEnabled: getEnvBool("LLM_VISION_ENABLED", false),
+		LLMVisionModel:   getEnv("LLM_VISION_MODEL", ""),
+
+		ShadowLLMModel:     getEnv("SHADOW_LLM_MODEL", ""),
+		ShadowSamplingRate: getEnvFloat("SHADOW_SAMPLING_RATE", 0),
+
+		LLMMaxContextChars:   getEnvInt("LLM_MAX_CONTEXT_CHARS", 8000),
+		LLMMustHaveThreshold: getEnvFloat("LLM_MUST_HAVE_THRESHOLD", 0.8),
+		ContextSourceOrder:   getEnv("CONTEXT_SOURCE_ORDER", "chat_first"),
+
+		DebugStoreRawResponses:    getEnvBool("DEBUG_STORE_RAW_RESPONSES", false),
+		DebugRawResponseRetention: getEnvInt("DEBUG_RAW_RESPONSE_RETENTION", 500),
+
+		DebugStoreSearchExplanations: getEnvBool("DEBUG_STORE_SEARCH_EXPLANATIONS", false),
+
+		AuditLogPath: getEnv("AUDIT_LOG_PATH", ""),
+
+		LLMMetadataHeaders:   getEnvList("LLM_METADATA_HEADERS"),
+		LLMMetadataHashUsers: getEnvBool("LLM_METADATA_HASH_USERS", true),
+
+		MetricsBackend: getEnv("METRICS_BACKEND", "none"),
+		StatsDAddr:     getEnv("STATSD_ADDR", "127.0.0.1:8125"),
 	}
 }
 
+// Validate checks that configuration values are within their allowed ranges
+func (c *Config) Validate() error {
+	var problems []string
+
+	if c.Port == "" {
+		problems = append(problems, "PORT must not be empty")
+	}
+	if c.DBPath == "" {
+		problems = append(problems, "DB_PATH must not be empty")
+	}
+	if c.TriggerEmoji == "" {
+		problems = append(problems, "TRIGGER_EMOJI must not be empty")
+	}
+	if c.MissedReactionLookback <= 0 {
+		problems = append(problems, "MISSED_REACTION_LOOKBACK must be positive")
+	}
+	switch c.DeletedSourceAction {
+	case "none", "annotate", "delete":
+	default:
+		problems = append(problems, "DELETED_SOURCE_ACTION must be one of: none, annotate, delete")
+	}
+	switch c.LongAnswerStrategy {
+	case "split", "snippet":
+	default:
+		problems = append(problems, "LONG_ANSWER_STRATEGY must be one of: split, snippet")
+	}
+	if c.ResponseIconEmoji != "" && c.ResponseIconURL != "" {
+		problems = append(problems, "RESPONSE_ICON_EMOJI and RESPONSE_ICON_URL cannot both be set")
+	}
+	if c.CanvasPublishThreshold < 0 || c.CanvasPublishThreshold > 1 {
+		problems = append(problems, "CANVAS_PUBLISH_THRESHOLD must be between 0 and 1")
+	}
+	if c.DirectDocThreshold < 0 || c.DirectDocThreshold > 1 {
+		problems = append(problems, "DIRECT_DOC_THRESHOLD must be between 0 and 1")
+	}
+	if c.AutoPostConfidence < 0 || c.AutoPostConfidence > 1 {
+		problems = append(problems, "AUTO_POST_CONFIDENCE must be between 0 and 1")
+	}
+	if c.SuggestConfidence < 0 || c.SuggestConfidence > c.AutoPostConfidence {
+		problems = append(problems, "SUGGEST_CONFIDENCE must be between 0 and AUTO_POST_CONFIDENCE")
+	}
+	if c.ExpertRoutingEnabled && c.DefaultExpertUserID == "" {
+		problems = append(problems, "DEFAULT_EXPERT_USER_ID must be set when EXPERT_ROUTING_ENABLED is enabled")
+	}
+	if c.LowAnswerConfidence < 0 || c.LowAnswerConfidence > 1 {
+		problems = append(problems, "LOW_ANSWER_CONFIDENCE must be between 0 and 1")
+	}
+	if c.SimilarityThreshold < 0 || c.SimilarityThreshold > 1 {
+		problems = append(problems, "SIMILARITY_THRESHOLD must be between 0 and 1")
+	}
+	if c.MinResultCount < 0 {
+		problems = append(problems, "MIN_RESULT_COUNT must not be negative")
+	}
+	if c.MinResultCount > 0 && c.ThresholdDecayStep <= 0 {
+		problems = append(problems, "THRESHOLD_DECAY_STEP must be positive when MIN_RESULT_COUNT is set")
+	}
+	if c.MinThreshold < 0 || c.MinThreshold > 1 {
+		problems = append(problems, "MIN_THRESHOLD must be between 0 and 1")
+	}
+	if c.IncludeRecentPages && c.RecentPagesDaysBack <= 0 {
+		problems = append(problems, "RECENT_PAGES_DAYS_BACK must be positive when INCLUDE_RECENT_PAGES is enabled")
+	}
+	if c.RecentPageScore < 0 || c.RecentPageScore > 1 {
+		problems = append(problems, "RECENT_PAGE_SCORE must be between 0 and 1")
+	}
+	if c.MaxSearchResults <= 0 {
+		problems = append(problems, "MAX_SEARCH_RESULTS must be positive")
+	}
+	if c.EnrichConcurrency <= 0 {
+		problems = append(problems, "SLACK_ENRICH_CONCURRENCY must be positive")
+	}
+	if _, err := c.WeeklyReport(); err != nil {
+		problems = append(problems, fmt.Sprintf("WEEKLY_REPORT_SCHEDULE is invalid: %v", err))
+	}
+	if err := c.parseOfficeHours(); err != nil {
+		problems = append(problems, fmt.Sprintf("OFFICE_HOURS is invalid: %v", err))
+	}
+	switch c.OfficeHoursMode {
+	case "defer", "links":
+	default:
+		problems = append(problems, "OFFICE_HOURS_MODE must be one of: defer, links")
+	}
+	switch c.ConfluenceAPIVersion {
+	case "auto", "cloud", "dc7", "dc8":
+	default:
+		problems = append(problems, "CONFLUENCE_API_VERSION must be one of: auto, cloud, dc7, dc8")
+	}
+	switch c.MetricsBackend {
+	case "none", "prometheus":
+	case "statsd":
+		if c.StatsDAddr == "" {
+			problems = append(problems, "STATSD_ADDR must not be empty when METRICS_BACKEND is statsd")
+		}
+	default:
+		problems = append(problems, "METRICS_BACKEND must be one of: prometheus, statsd, none")
+	}
+	switch c.InquiryClassifier {
+	case "rules", "llm":
+	default:
+		problems = append(problems, "INQUIRY_CLASSIFIER must be one of: rules, llm")
+	}
+	if c.MaxConcurrentInquiries <= 0 {
+		problems = append(problems, "MAX_CONCURRENT_INQUIRIES must be positive")
+	}
+	if c.MaxThreadRepliesForAnswer < 0 {
+		problems = append(problems, "MAX_THREAD_REPLIES_FOR_ANSWER must not be negative")
+	}
+	if c.MaxReactedMessageAgeDays < 0 {
+		problems = append(problems, "MAX_REACTED_MESSAGE_AGE_DAYS must not be negative")
+	}
+	if c.ForceAnswerEmoji != "" && c.ForceAnswerEmoji == c.TriggerEmoji {
+		problems = append(problems, "FORCE_ANSWER_EMOJI must differ from TRIGGER_EMOJI")
+	}
+	if c.MaxQueueDepth < 0 {
+		problems = append(problems, "MAX_QUEUE_DEPTH must not be negative")
+	}
+	if c.MaxInquiriesPerHour < 0 {
+		problems = append(problems, "MAX_INQUIRIES_PER_HOUR must not be negative")
+	}
+	if c.MaxInquiryRetries < 0 {
+		problems = append(problems, "MAX_INQUIRY_RETRIES must not be negative")
+	}
+	if c.ProcessingTimeoutMinutes <= 0 {
+		problems = append(problems, "PROCESSING_TIMEOUT_MINUTES must be positive")
+	}
+	if c.EmbeddingModel == "" {
+		problems = append(problems, "EMBEDDING_MODEL must not be empty")
+	}
+	if c.EmbeddingDimensions < 0 {
+		problems = append(problems, "EMBEDDING_DIMENSIONS must not be negative")
+	}
+	if c.SemanticDedupEnabled && (c.SemanticDedupThreshold <= 0 || c.SemanticDedupThreshold > 1) {
+		problems = append(problems, "SEMANTIC_DEDUP_THRESHOLD must be greater than 0 and at most 1 when SEMANTIC_DEDUP_ENABLED is set")
+	}
+	if c.SourceWeighting && c.SourceWeightInterval <= 0 {
+		problems = append(problems, "SOURCE_WEIGHT_INTERVAL must be positive when SOURCE_WEIGHTING is enabled")
+	}
+	if len(c.EnsembleModels) == 1 {
+		problems = append(problems, "ENSEMBLE_MODELS must list at least two models")
+	}
+	if c.SnippetWindow <= 0 {
+		problems = append(problems, "SNIPPET_WINDOW must be positive")
+	}
+	if c.MaxContentBytes < 0 {
+		problems = append(problems, "MAX_CONTENT_BYTES must not be negative")
+	}
+	if c.SlackSearchTimeout <= 0 || c.ConfluenceSearchTimeout <= 0 || c.GitHubSearchTimeout <= 0 || c.SearchTotalTimeout <= 0 {
+		problems = append(problems, "SLACK_SEARCH_TIMEOUT, CONFLUENCE_SEARCH_TIMEOUT, GITHUB_SEARCH_TIMEOUT and SEARCH_TOTAL_TIMEOUT must be positive")
+	}
+	if c.GitHubOwner == "" && (c.GitHubRepo != "" || len(c.GitHubRepos) > 0) {
+		problems = append(problems, "GITHUB_REPO and GITHUB_REPOS require GITHUB_OWNER")
+	}
+	for _, repo := range c.GitHubRepos {
+		if strings.Contains(repo, "/") {
+			problems = append(problems, fmt.Sprintf("GITHUB_REPOS entry %q must be a repository name within GITHUB_OWNER", repo))
+		}
+	}
+	if c.SearchCacheTTL < 0 {
+		problems = append(problems, "SEARCH_CACHE_TTL must not be negative")
+	}
+	if c.SearchDaysBack <= 0 {
+		problems = append(problems, "SEARCH_DAYS_BACK must be positive")
+	}
+	if c.SynonymDictFile != "" {
+		if _, err := os.Stat(c.SynonymDictFile); err != nil {
+			problems = append(problems, fmt.Sprintf("SYNONYM_DICT_FILE is not readable: %v", err))
+		}
+	}
+	if err := c.validateAnswerProfiles(); err != nil {
+		problems = append(problems, fmt.Sprintf("ANSWER_PROFILES_FILE is invalid: %v", err))
+	}
+	if _, err := c.SlackNoiseRegexps(); err != nil {
+		problems = append(problems, fmt.Sprintf("SLACK_NOISE_PATTERNS is invalid: %v", err))
+	}
+	if _, _, err := c.AnswerStripRegexps(); err != nil {
+		problems = append(problems, fmt.Sprintf("ANSWER_STRIP_PATTERNS is invalid: %v", err))
+	}
+	if c.ChannelRelevanceBoost < 0 {
+		problems = append(problems, "CHANNEL_RELEVANCE_BOOST must not be negative")
+	}
+	if c.FeedbackBoost < 0 {
+		problems = append(problems, "FEEDBACK_BOOST must not be negative")
+	}
+	switch c.ConfluenceQueryMode {
+	case "phrase", "any", "all":
+	default:
+		problems = append(problems, "CONFLUENCE_QUERY_MODE must be one of phrase, any, all")
+	}
+	switch c.ConfluenceMissingSpace {
+	case "fallback", "fail":
+	default:
+		problems = append(problems, "CONFLUENCE_MISSING_SPACE must be one of fallback, fail")
+	}
+	switch c.LLMProvider {
+	case "openai", "anthropic":
+	default:
+		problems = append(problems, "LLM_PROVIDER must be one of openai, anthropic")
+	}
+	if c.LLMTemperature < 0 || c.LLMTemperature > 2 {
+		problems = append(problems, "LLM_TEMPERATURE must be between 0 and 2")
+	}
+	if c.LLMMaxTokens <= 0 {
+		problems = append(problems, "LLM_MAX_TOKENS must be positive")
+	}
+	if c.LLMMaxContextChars < 0 {
+		problems = append(problems, "LLM_MAX_CONTEXT_CHARS must not be negative")
+	}
+	if c.LLMVisionEnabled && c.LLMVisionModel == "" {
+		problems = append(problems, "LLM_VISION_ENABLED requires LLM_VISION_MODEL")
+	}
+	if c.ShadowSamplingRate < 0 || c.ShadowSamplingRate > 1 {
+		problems = append(problems, "SHADOW_SAMPLING_RATE must be between 0 and 1")
+	}
+	if c.ShadowSamplingRate > 0 && c.ShadowLLMModel == "" {
+		problems = append(problems, "SHADOW_SAMPLING_RATE requires SHADOW_LLM_MODEL")
+	}
+	if c.LLMMustHaveThreshold < 0 || c.LLMMustHaveThreshold > 1 {
+		problems = append(problems, "LLM_MUST_HAVE_THRESHOLD must be between 0 and 1")
+	}
+	switch c.ContextSourceOrder {
+	case "docs_first", "chat_first", "by_score":
+	default:
+		problems = append(problems, "CONTEXT_SOURCE_ORDER must be one of: docs_first, chat_first, by_score")
+	}
+	for _, field := range c.LLMMetadataHeaders {
+		switch field {
+		case "channel", "user", "category":
+		default:
+			problems = append(problems, fmt.Sprintf("LLM_METADATA_HEADERS contains unknown field %q; use channel, user, category", field))
+		}
+	}
+	if c.CrossChannelDedup && c.CrossChannelDedupWindow <= 0 {
+		problems = append(problems, "CROSS_CHANNEL_DEDUP_WINDOW must be positive when CROSS_CHANNEL_DEDUP is enabled")
+	}
+	if c.AnswerTTLDays < 0 {
+		problems = append(problems, "ANSWER_TTL_DAYS must not be negative")
+	}
+	if c.AnswerTTLDays > 0 && c.AnswerRefreshCheckInterval <= 0 {
+		problems = append(problems, "ANSWER_REFRESH_CHECK_INTERVAL must be positive when ANSWER_TTL_DAYS is set")
+	}
+	if c.FollowUpWindow < 0 {
+		problems = append(problems, "FOLLOW_UP_WINDOW must not be negative")
+	}
+	if c.FollowUpWindow > 0 && c.FollowUpPollInterval <= 0 {
+		problems = append(problems, "FOLLOW_UP_POLL_INTERVAL must be positive when FOLLOW_UP_WINDOW is set")
+	}
+	if c.FollowUpWindow > 0 && c.MaxFollowUpWatchers <= 0 {
+		problems = append(problems, "MAX_FOLLOW_UP_WATCHERS must be positive when FOLLOW_UP_WINDOW is set")
+	}
+	if c.StaleReprocessInterval < 0 {
+		problems = append(problems, "STALE_REPROCESS_INTERVAL must not be negative")
+	}
+	if c.PageVersionCheckInterval < 0 {
+		problems = append(problems, "PAGE_VERSION_CHECK_INTERVAL must not be negative")
+	}
+	if c.LLMTimeout <= 0 || c.ConfluenceTimeout <= 0 {
+		problems = append(problems, "LLM_TIMEOUT and CONFLUENCE_TIMEOUT must be positive")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+// parsedOfficeHours is a schedule with the settings it was parsed from
+type parsedOfficeHours struct {
+	spec     string
+	timezone string
+	schedule *OfficeHours
+}
+
+// OfficeHoursSchedule returns the configured office hours, as parsed by
+// Validate unless they were changed since. It returns nil when office hours
+// are disabled.
+func (c *Config) OfficeHoursSchedule() (*OfficeHours, error) {
+	if p := c.officeHours; p != nil && p.spec == c.OfficeHours && p.timezone == c.OfficeHoursTimezone {
+		return p.schedule, nil
+	}
+	return parseOfficeHoursSetting(c.OfficeHours, c.OfficeHoursTimezone)
+}
+
+// parseOfficeHours parses the configured office hours once, for
+// OfficeHoursSchedule to return on every inquiry
+func (c *Config) parseOfficeHours() error {
+	schedule, err := parseOfficeHoursSetting(c.OfficeHours, c.OfficeHoursTimezone)
+	if err != nil {
+		return err
+	}
+	c.officeHours = &parsedOfficeHours{spec: c.OfficeHours, timezone: c.OfficeHoursTimezone, schedule: schedule}
+	return nil
+}
+
+// parseOfficeHoursSetting parses spec in timezone, or returns nil for an empty spec
+func parseOfficeHoursSetting(spec, timezone string) (*OfficeHours, error) {
+	if strings.TrimSpace(spec) == "" {
+		return nil, nil
+	}
+	return ParseOfficeHours(spec, timezone)
+}
+
+// AnswerStripRegexps compiles the configured answer boilerplate patterns,
+// anchored to the start and to the end of an answer
+func (c *Config) AnswerStripRegexps() (leading, trailing []*regexp.Regexp, err error) {
+	for _, pattern := range c.AnswerStripPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		leading = append(leading, regexp.MustCompile(`^(?:`+pattern+`)`))
+		trailing = append(trailing, regexp.MustCompile(`(?:`+pattern+`)$`))
+	}
+	return leading, trailing, nil
+}
+
+// SlackNoiseRegexps compiles the configured Slack noise patterns
+func (c *Config) SlackNoiseRegexps() ([]*regexp.Regexp, error) {
+	patterns := make([]*regexp.Regexp, 0, len(c.SlackNoisePatterns))
+	for _, pattern := range c.SlackNoisePatterns {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", pattern, err)
+		}
+		patterns = append(patterns, compiled)
+	}
+	return patterns, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -90,3 +792,59 @@ func getEnvFloat(key string, defaultValue float64) float64 {
 	}
 	return defaultValue
 }
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvList parses a comma-separated list, skipping empty entries
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvPatterns parses a list of regexes separated by ";;", since commas are
+// common inside regexes (e.g. {2,5}), skipping empty entries
+func getEnvPatterns(key string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(os.Getenv(key), ";;") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// getEnvMap parses a comma-separated list of key:value pairs
+func getEnvMap(key string) map[string]string {
+	values := make(map[string]string)
+	for _, pair := range getEnvList(key) {
+		k, v, found := strings.Cut(pair, ":")
+		if !found {
+			continue
+		}
+		if k, v = strings.TrimSpace(k), strings.TrimSpace(v); k != "" && v != "" {
+			values[k] = v
+		}
+	}
+	return values
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if durationValue, err := time.ParseDuration(value); err == nil {
+			return durationValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,140 @@
+package config
+
+import (
+	"context"
+	"os"
+	"slices"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLoadTestConfig_Validates(t *testing.T) {
+	cfg := LoadTestConfig()
+
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Expected test config to be valid, got: %v", err)
+	}
+
+	if cfg.Env != "test" {
+		t.Errorf("Expected Env 'test', got '%s'", cfg.Env)
+	}
+	if cfg.SlackBotToken != "" || cfg.ConfluenceAPIToken != "" || cfg.LiteLLMAPIKey != "" {
+		t.Error("Expected external service credentials to be empty")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+	}{
+		{name: "threshold above 1", modify: func(c *Config) { c.SimilarityThreshold = 1.5 }},
+		{name: "zero max results", modify: func(c *Config) { c.MaxSearchResults = 0 }},
+		{name: "zero enrich concurrency", modify: func(c *Config) { c.EnrichConcurrency = 0 }},
+		{name: "negative days back", modify: func(c *Config) { c.SearchDaysBack = -1 }},
+		{name: "unknown query mode", modify: func(c *Config) { c.ConfluenceQueryMode = "fuzzy" }},
+		{name: "unknown missing space handling", modify: func(c *Config) { c.ConfluenceMissingSpace = "ignore" }},
+		{name: "empty trigger emoji", modify: func(c *Config) { c.TriggerEmoji = "" }},
+		{name: "zero LLM timeout", modify: func(c *Config) { c.LLMTimeout = 0 }},
+		{name: "single ensemble model", modify: func(c *Config) { c.EnsembleModels = []string{"gpt-4o"} }},
+		{name: "vision without model", modify: func(c *Config) { c.LLMVisionEnabled = true }},
+		{name: "shadow sampling without model", modify: func(c *Config) { c.ShadowSamplingRate = 0.1 }},
+		{name: "shadow sampling rate above 1", modify: func(c *Config) { c.ShadowLLMModel = "gpt-4o"; c.ShadowSamplingRate = 1.5 }},
+		{name: "unknown metadata field", modify: func(c *Config) { c.LLMMetadataHeaders = []string{"email"} }},
+		{name: "expert routing without expert", modify: func(c *Config) { c.ExpertRoutingEnabled = true }},
+		{name: "answer confidence above 1", modify: func(c *Config) { c.LowAnswerConfidence = 1.5 }},
+		{name: "zero semantic dedup threshold", modify: func(c *Config) { c.SemanticDedupEnabled = true; c.SemanticDedupThreshold = 0 }},
+		{name: "GitHub repo without owner", modify: func(c *Config) { c.GitHubRepo = "infra" }},
+		{name: "GitHub repos with owner", modify: func(c *Config) {
+			c.GitHubOwner = "kouzoh"
+			c.GitHubRepos = []string{"kouzoh/infra"}
+		}},
+		{name: "invalid noise pattern", modify: func(c *Config) { c.SlackNoisePatterns = []string{"deploy ("} }},
+		{name: "invalid answer strip pattern", modify: func(c *Config) { c.AnswerStripPatterns = []string{"(?i)hope this helps["} }},
+		{name: "two response icons", modify: func(c *Config) {
+			c.ResponseIconEmoji = ":owl:"
+			c.ResponseIconURL = "https://example.com/owl.png"
+		}},
+		{name: "invalid weekly report schedule", modify: func(c *Config) {
+			c.WeeklyReportSpaceKey = "REPORTS"
+			c.WeeklyReportSchedule = "Friday"
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := LoadTestConfig()
+			tt.modify(cfg)
+
+			if err := cfg.Validate(); err == nil {
+				t.Error("Expected validation error")
+			}
+		})
+	}
+}
+
+func TestLoad_AnswerStripPatternsKeepCommas(t *testing.T) {
+	t.Setenv("ANSWER_STRIP_PATTERNS", `(?i)^sure[,!]\s*;; (?i)\n{2,}i hope this helps[.!]? ;;`)
+
+	cfg := Load()
+	want := []string{`(?i)^sure[,!]\s*`, `(?i)\n{2,}i hope this helps[.!]?`}
+	if !slices.Equal(cfg.AnswerStripPatterns, want) {
+		t.Errorf("Expected patterns %q, got %q", want, cfg.AnswerStripPatterns)
+	}
+}
+
+func TestLoad_SlackNoisePatternsKeepCommas(t *testing.T) {
+	t.Setenv("SLACK_NOISE_PATTERNS", `^deploy(ed)? v\d+;;[a-f0-9]{7,40}`)
+
+	cfg := Load()
+	want := []string{`^deploy(ed)? v\d+`, `[a-f0-9]{7,40}`}
+	if !slices.Equal(cfg.SlackNoisePatterns, want) {
+		t.Errorf("Expected patterns %q, got %q", want, cfg.SlackNoisePatterns)
+	}
+}
+
+func TestWatchForReload(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan *Config, 1)
+	LoadTestConfig().WatchForReload(ctx, func(cfg *Config) {
+		reloaded <- cfg
+	})
+
+	t.Setenv("PORT", "9090")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+
+	select {
+	case cfg := <-reloaded:
+		if cfg.Port != "9090" {
+			t.Errorf("Expected reloaded Port '9090', got '%s'", cfg.Port)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected reload callback after SIGHUP")
+	}
+}
+
+func TestWatchForReload_InvalidConfigIgnored(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan *Config, 1)
+	LoadTestConfig().WatchForReload(ctx, func(cfg *Config) {
+		reloaded <- cfg
+	})
+
+	t.Setenv("SIMILARITY_THRESHOLD", "2")
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatalf("Failed to send SIGHUP: %v", err)
+	}
+
+	select {
+	case <-reloaded:
+		t.Error("Expected invalid configuration not to be applied")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -0,0 +1,163 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // office hours timezones must resolve in minimal containers
+)
+
+// OfficeHours is a weekly schedule of windows in a fixed timezone
+type OfficeHours struct {
+	Location *time.Location
+	Windows  []OfficeHoursWindow
+}
+
+// OfficeHoursWindow is a daily time range applied to a set of weekdays.
+// Start is inclusive, End is exclusive; windows cannot span midnight.
+type OfficeHoursWindow struct {
+	Days  [7]bool // indexed by time.Weekday
+	Start time.Duration
+	End   time.Duration
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// ParseOfficeHours parses a schedule such as "Mon-Fri 09:00-18:00; Sat 10:00-12:00"
+// in the given IANA timezone. Days may be single days, ranges or comma-separated lists.
+func ParseOfficeHours(spec, timezone string) (*OfficeHours, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	hours := &OfficeHours{Location: location}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+
+		fields := strings.Fields(part)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid office hours window %q: expected \"<days> <HH:MM>-<HH:MM>\"", part)
+		}
+
+		window, err := parseOfficeHoursWindow(fields[0], fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid office hours window %q: %w", part, err)
+		}
+		hours.Windows = append(hours.Windows, window)
+	}
+
+	if len(hours.Windows) == 0 {
+		return nil, fmt.Errorf("office hours schedule has no windows")
+	}
+
+	return hours, nil
+}
+
+// parseOfficeHoursWindow parses the days and time range of a single window
+func parseOfficeHoursWindow(days, timeRange string) (OfficeHoursWindow, error) {
+	var window OfficeHoursWindow
+
+	for _, day := range strings.Split(strings.ToLower(days), ",") {
+		from, to, isRange := strings.Cut(day, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return window, fmt.Errorf("unknown day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return window, fmt.Errorf("unknown day %q", to)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			window.Days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	startText, endText, ok := strings.Cut(timeRange, "-")
+	if !ok {
+		return window, fmt.Errorf("time range must be HH:MM-HH:MM")
+	}
+	start, err := parseClock(startText)
+	if err != nil {
+		return window, err
+	}
+	end, err := parseClock(endText)
+	if err != nil {
+		return window, err
+	}
+	if end <= start {
+		return window, fmt.Errorf("end time must be after start time")
+	}
+	window.Start, window.End = start, end
+
+	return window, nil
+}
+
+// parseClock parses HH:MM into an offset from midnight; 24:00 is allowed as an end time
+func parseClock(value string) (time.Duration, error) {
+	if value == "24:00" {
+		return 24 * time.Hour, nil
+	}
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q", value)
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// IsOpen reports whether t falls inside any office hours window
+func (o *OfficeHours) IsOpen(t time.Time) bool {
+	local := t.In(o.Location)
+	sinceMidnight := local.Sub(startOfDay(local))
+
+	for _, window := range o.Windows {
+		if window.Days[local.Weekday()] && sinceMidnight >= window.Start && sinceMidnight < window.End {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns t if office hours are open at t, otherwise the start of the next window
+func (o *OfficeHours) NextOpen(t time.Time) time.Time {
+	if o.IsOpen(t) {
+		return t
+	}
+
+	local := t.In(o.Location)
+	var next time.Time
+	for offset := 0; offset <= 7; offset++ {
+		day := startOfDay(local.AddDate(0, 0, offset))
+		for _, window := range o.Windows {
+			if !window.Days[day.Weekday()] {
+				continue
+			}
+			start := day.Add(window.Start)
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+
+	return next
+}
+
+// startOfDay returns midnight of t's day in t's location
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
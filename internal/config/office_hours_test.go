@@ -0,0 +1,125 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseOfficeHours_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		timezone string
+	}{
+		{name: "unknown timezone", spec: "Mon-Fri 09:00-18:00", timezone: "Mars/Olympus"},
+		{name: "unknown day", spec: "Mon-Fry 09:00-18:00", timezone: "UTC"},
+		{name: "missing time range", spec: "Mon-Fri", timezone: "UTC"},
+		{name: "end before start", spec: "Mon 18:00-09:00", timezone: "UTC"},
+		{name: "malformed time", spec: "Mon 9am-5pm", timezone: "UTC"},
+		{name: "no windows", spec: " ; ", timezone: "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseOfficeHours(tt.spec, tt.timezone); err == nil {
+				t.Error("Expected parse error")
+			}
+		})
+	}
+}
+
+func TestOfficeHours_IsOpenAcrossTimezones(t *testing.T) {
+	// Wednesday 2024-01-10 01:30 UTC is 10:30 Wednesday in Tokyo and 20:30 Tuesday in New York
+	instant := time.Date(2024, 1, 10, 1, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		timezone string
+		spec     string
+		expected bool
+	}{
+		{timezone: "Asia/Tokyo", spec: "Mon-Fri 09:00-18:00", expected: true},
+		{timezone: "America/New_York", spec: "Mon-Fri 09:00-18:00", expected: false},
+		{timezone: "UTC", spec: "Mon-Fri 09:00-18:00", expected: false},
+		{timezone: "America/New_York", spec: "Tue 20:00-21:00", expected: true},
+		{timezone: "Asia/Tokyo", spec: "Sat,Sun 00:00-24:00", expected: false},
+		{timezone: "Asia/Tokyo", spec: "Fri-Wed 10:30-10:31", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.timezone+" "+tt.spec, func(t *testing.T) {
+			hours, err := ParseOfficeHours(tt.spec, tt.timezone)
+			if err != nil {
+				t.Fatalf("ParseOfficeHours returned error: %v", err)
+			}
+			if open := hours.IsOpen(instant); open != tt.expected {
+				t.Errorf("Expected IsOpen=%v, got %v", tt.expected, open)
+			}
+		})
+	}
+}
+
+func TestOfficeHours_NextOpen(t *testing.T) {
+	hours, err := ParseOfficeHours("Mon-Fri 09:00-18:00; Sat 10:00-12:00", "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("ParseOfficeHours returned error: %v", err)
+	}
+	tokyo := hours.Location
+
+	tests := []struct {
+		name     string
+		from     time.Time
+		expected time.Time
+	}{
+		{
+			name:     "already open",
+			from:     time.Date(2024, 1, 10, 11, 0, 0, 0, tokyo),
+			expected: time.Date(2024, 1, 10, 11, 0, 0, 0, tokyo),
+		},
+		{
+			name:     "before opening",
+			from:     time.Date(2024, 1, 10, 7, 0, 0, 0, tokyo),
+			expected: time.Date(2024, 1, 10, 9, 0, 0, 0, tokyo),
+		},
+		{
+			name:     "friday evening to saturday window",
+			from:     time.Date(2024, 1, 12, 19, 0, 0, 0, tokyo),
+			expected: time.Date(2024, 1, 13, 10, 0, 0, 0, tokyo),
+		},
+		{
+			name:     "saturday afternoon to monday",
+			from:     time.Date(2024, 1, 13, 13, 0, 0, 0, tokyo),
+			expected: time.Date(2024, 1, 15, 9, 0, 0, 0, tokyo),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if next := hours.NextOpen(tt.from); !next.Equal(tt.expected) {
+				t.Errorf("Expected next open %v, got %v", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestOfficeHoursSchedule_ParsedOnValidate(t *testing.T) {
+	cfg := LoadTestConfig()
+	cfg.OfficeHours = "Mon-Fri 09:00-18:00"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate returned error: %v", err)
+	}
+
+	first, err := cfg.OfficeHoursSchedule()
+	if err != nil || first == nil {
+		t.Fatalf("Expected a schedule, got %v (%v)", first, err)
+	}
+	if again, _ := cfg.OfficeHoursSchedule(); again != first {
+		t.Error("Expected the schedule parsed by Validate to be reused")
+	}
+
+	// A schedule changed after validation is parsed afresh
+	cfg.OfficeHours = "Sat 10:00-12:00"
+	changed, err := cfg.OfficeHoursSchedule()
+	if err != nil || changed == first || len(changed.Windows) != 1 || !changed.Windows[0].Days[time.Saturday] {
+		t.Errorf("Expected the changed schedule, got %+v (%v)", changed, err)
+	}
+}
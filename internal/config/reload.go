@@ -0,0 +1,47 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/joho/godotenv"
+	"github.com/sirupsen/logrus"
+)
+
+// WatchForReload reloads the configuration whenever the process receives
+// SIGHUP. The .env file, if any, is re-read over the current environment; a
+// new configuration that passes Validate is handed to reloadFn, an invalid one
+// is logged and ignored. The signal handler is registered before
+// WatchForReload returns and is removed when ctx is cancelled.
+func (c *Config) WatchForReload(ctx context.Context, reloadFn func(*Config)) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(signals)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				logrus.Info("Received SIGHUP, reloading configuration")
+
+				if err := godotenv.Overload(); err != nil {
+					logrus.WithError(err).Debug("No .env file to reload, using process environment")
+				}
+
+				newCfg := Load()
+				if err := newCfg.Validate(); err != nil {
+					logrus.WithError(err).Error("Reloaded configuration is invalid, keeping current configuration")
+					continue
+				}
+
+				reloadFn(newCfg)
+				logrus.Info("Configuration reloaded")
+			}
+		}
+	}()
+}
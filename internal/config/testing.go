@@ -0,0 +1,76 @@
+package config
+
+import "time"
+
+// LoadTestConfig returns a fully populated configuration for tests. All
+// external service credentials are empty so no real API is ever called, and
+// timeouts are short so tests against fake servers fail fast.
+func LoadTestConfig() *Config {
+	return &Config{
+		TriggerEmoji:               "eyes",
+		DeletedSourceAction:        "none",
+		MissedReactionLookback:     24 * time.Hour,
+		ConfluenceSpaceKey:         "DOCS",
+		ConfluenceQueryMode:        "phrase",
+		ConfluenceMissingSpace:     "fallback",
+		ConfluenceAPIVersion:       "auto",
+		ConfluenceTimeout:          100 * time.Millisecond,
+		RecentPagesDaysBack:        7,
+		RecentPageScore:            0.6,
+		GitHubAPIURL:               "https://api.github.com",
+		Port:                       "8080",
+		Env:                        "test",
+		DBPath:                     "file::memory:",
+		StatusShowCounters:         true,
+		LongAnswerStrategy:         "split",
+		CanvasPublishThreshold:     0.9,
+		WeeklyReportSchedule:       "Fri 17:00",
+		WeeklyReportTimezone:       "UTC",
+		DirectDocThreshold:         0.95,
+		LowAnswerConfidence:        0.5,
+		AnswerRefreshEmoji:         "arrows_counterclockwise",
+		AnswerRefreshCheckInterval: time.Hour,
+		CrossChannelDedupWindow:    24 * time.Hour,
+		FollowUpPollInterval:       time.Minute,
+		MaxFollowUpWatchers:        50,
+		OfficeHoursTimezone:        "UTC",
+		OfficeHoursMode:            "defer",
+		InquiryClassifier:          "rules",
+		MaxConcurrentInquiries:     1,
+		MaxQueueDepth:              10,
+		MaxInquiriesPerHour:        20,
+		MaxInquiryRetries:          3,
+		ProcessingTimeoutMinutes:   10,
+		SimilarityThreshold:        0.7,
+		MinResultCount:             1,
+		ThresholdDecayStep:         0.1,
+		MinThreshold:               0.2,
+		MaxSearchResults:           10,
+		EnrichConcurrency:          4,
+		SnippetWindow:              100,
+		MaxContentBytes:            2000,
+		SearchCacheTTL:             time.Hour,
+		SlackSearchTimeout:         100 * time.Millisecond,
+		ConfluenceSearchTimeout:    100 * time.Millisecond,
+		GitHubSearchTimeout:        100 * time.Millisecond,
+		SearchTotalTimeout:         150 * time.Millisecond,
+		SearchDaysBack:             90,
+		ChannelRelevanceBoost:      0.2,
+		FeedbackBoost:              0.2,
+		SourceWeightInterval:       7 * 24 * time.Hour,
+		LLMProvider:                "openai",
+		LLMModel:                   "gpt-4o-mini",
+		LLMTemperature:             0.3,
+		LLMMaxTokens:               1000,
+		LLMTimeout:                 100 * time.Millisecond,
+		EmbeddingModel:             "text-embedding-3-small",
+		EmbeddingDimensions:        1536,
+		EmojiModels:                map[string]string{},
+		ChannelModels:              map[string]string{},
+		LLMMaxContextChars:         8000,
+		LLMMustHaveThreshold:       0.8,
+		ContextSourceOrder:         "chat_first",
+		LLMMetadataHashUsers:       true,
+		MetricsBackend:             "none",
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// WeeklyReportSchedule is the weekday and time of day weekly reports are generated at
+type WeeklyReportSchedule struct {
+	Day      time.Weekday
+	At       time.Duration // offset from midnight
+	Location *time.Location
+}
+
+// ParseWeeklyReportSchedule parses a schedule such as "Fri 17:00" in the given IANA timezone
+func ParseWeeklyReportSchedule(spec, timezone string) (*WeeklyReportSchedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected \"<day> <HH:MM>\", got %q", spec)
+	}
+	day, ok := weekdayNames[strings.ToLower(fields[0])]
+	if !ok {
+		return nil, fmt.Errorf("unknown day %q", fields[0])
+	}
+	at, err := parseClock(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	if at >= 24*time.Hour {
+		return nil, fmt.Errorf("invalid time %q", fields[1])
+	}
+
+	return &WeeklyReportSchedule{Day: day, At: at, Location: location}, nil
+}
+
+// Next returns the first scheduled time after t
+func (w *WeeklyReportSchedule) Next(t time.Time) time.Time {
+	local := t.In(w.Location)
+	days := (int(w.Day) - int(local.Weekday()) + 7) % 7
+	hour, minute := int(w.At/time.Hour), int(w.At%time.Hour/time.Minute)
+
+	next := time.Date(local.Year(), local.Month(), local.Day()+days, hour, minute, 0, 0, w.Location)
+	if !next.After(t) {
+		next = next.AddDate(0, 0, 7)
+	}
+	return next
+}
+
+// WeeklyReport parses the weekly report schedule. It returns nil when weekly
+// reports are disabled.
+func (c *Config) WeeklyReport() (*WeeklyReportSchedule, error) {
+	if c.WeeklyReportSpaceKey == "" {
+		return nil, nil
+	}
+	return ParseWeeklyReportSchedule(c.WeeklyReportSchedule, c.WeeklyReportTimezone)
+}
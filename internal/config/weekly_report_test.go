@@ -0,0 +1,65 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWeeklyReportSchedule_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		timezone string
+	}{
+		{name: "unknown timezone", spec: "Fri 17:00", timezone: "Mars/Olympus"},
+		{name: "unknown day", spec: "Fry 17:00", timezone: "UTC"},
+		{name: "missing time", spec: "Fri", timezone: "UTC"},
+		{name: "malformed time", spec: "Fri 5pm", timezone: "UTC"},
+		{name: "time past midnight", spec: "Fri 24:00", timezone: "UTC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseWeeklyReportSchedule(tt.spec, tt.timezone); err == nil {
+				t.Error("Expected parse error")
+			}
+		})
+	}
+}
+
+func TestWeeklyReportSchedule_Next(t *testing.T) {
+	schedule, err := ParseWeeklyReportSchedule("Fri 17:00", "Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("ParseWeeklyReportSchedule returned error: %v", err)
+	}
+	tokyo := schedule.Location
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{name: "earlier in the week", now: time.Date(2024, 5, 1, 9, 0, 0, 0, tokyo), want: time.Date(2024, 5, 3, 17, 0, 0, 0, tokyo)},
+		{name: "earlier the same day", now: time.Date(2024, 5, 3, 16, 59, 0, 0, tokyo), want: time.Date(2024, 5, 3, 17, 0, 0, 0, tokyo)},
+		{name: "at the scheduled time", now: time.Date(2024, 5, 3, 17, 0, 0, 0, tokyo), want: time.Date(2024, 5, 10, 17, 0, 0, 0, tokyo)},
+		{name: "later in the week", now: time.Date(2024, 5, 4, 9, 0, 0, 0, tokyo), want: time.Date(2024, 5, 10, 17, 0, 0, 0, tokyo)},
+		{name: "other timezone", now: time.Date(2024, 5, 3, 9, 0, 0, 0, time.UTC), want: time.Date(2024, 5, 10, 17, 0, 0, 0, tokyo)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schedule.Next(tt.now); !got.Equal(tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWeeklyReport_DisabledWithoutSpace(t *testing.T) {
+	cfg := LoadTestConfig()
+	cfg.WeeklyReportSchedule = "invalid"
+
+	if schedule, err := cfg.WeeklyReport(); schedule != nil || err != nil {
+		t.Errorf("Expected no schedule without WEEKLY_REPORT_SPACE_KEY, got %v (%v)", schedule, err)
+	}
+}
@@ -5,24 +5,33 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/services"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 // Handler handles HTTP requests
 type Handler struct {
 	inquiry *services.InquiryService
 	slack   *services.SlackService
-	config  *config.Config
+
+	// configMu guards config, which Reload replaces
+	configMu sync.RWMutex
+	config   *config.Config
 }
 
 // SlackEvent represents a Slack event
@@ -32,16 +41,23 @@ type SlackEvent struct {
 	Type      string `json:"type"`
 	Event     struct {
 		Type           string `json:"type"`
+		Subtype        string `json:"subtype"`
 		Channel        string `json:"channel"`
+		ChannelType    string `json:"channel_type"`
 		User           string `json:"user"`
+		BotID          string `json:"bot_id"`
 		Text           string `json:"text"`
 		Timestamp      string `json:"ts"`
+		ThreadTS       string `json:"thread_ts"`
 		EventTimestamp string `json:"event_ts"`
 		Reaction       string `json:"reaction"`
+		DeletedTS      string `json:"deleted_ts"`
 		Item           struct {
-			Type    string `json:"type"`
-			Channel string `json:"channel"`
-			TS      string `json:"ts"`
+			Type        string `json:"type"`
+			Channel     string `json:"channel"`
+			TS          string `json:"ts"`
+			File        string `json:"file"`
+			FileComment string `json:"file_comment"`
 		} `json:"item"`
 	} `json:"event"`
 }
@@ -55,6 +71,20 @@ func New(inquiry *services.InquiryService, slack *services.SlackService, cfg *co
 	}
 }
 
+// Reload switches the handler to cfg, e.g. a rotated signing secret
+func (h *Handler) Reload(cfg *config.Config) {
+	h.configMu.Lock()
+	defer h.configMu.Unlock()
+	h.config = cfg
+}
+
+// cfg returns the current configuration
+func (h *Handler) cfg() *config.Config {
+	h.configMu.RLock()
+	defer h.configMu.RUnlock()
+	return h.config
+}
+
 // HandleSlackEvents handles Slack Events API webhooks
 func (h *Handler) HandleSlackEvents(c *gin.Context) {
 	// Verify Slack signature
@@ -77,7 +107,8 @@ func (h *Handler) HandleSlackEvents(c *gin.Context) {
 		return
 	}
 
-	// Handle events
+	// Handle events in the background: Slack retries events that aren't
+	// acknowledged within 3 seconds
 	if event.Type == "event_callback" {
 		go h.processSlackEvent(event)
 	}
@@ -116,11 +147,21 @@ func (h *Handler) HandleSlashCommands(c *gin.Context) {
 			"text":          response,
 		})
 	case "/inquiry-status":
-		response := h.generateStatusResponse()
+		response := h.generateStatusResponse(channelID)
 		c.JSON(http.StatusOK, gin.H{
 			"response_type": "ephemeral",
 			"text":          response,
 		})
+	case "/inquiry-ask", "/inquiry-search":
+		c.JSON(http.StatusOK, gin.H{
+			"response_type": "ephemeral",
+			"text":          h.queueCommandSearch(command, text, channelID, userID),
+		})
+	case "/inquiry-feedback":
+		c.JSON(http.StatusOK, gin.H{
+			"response_type": "ephemeral",
+			"text":          h.recordCommandFeedback(text, userID),
+		})
 	default:
 		c.JSON(http.StatusOK, gin.H{
 			"response_type": "ephemeral",
@@ -129,6 +170,81 @@ func (h *Handler) HandleSlashCommands(c *gin.Context) {
 	}
 }
 
+// queueCommandSearch queues the question or query of an /inquiry-ask or
+// /inquiry-search command, restricted to the sources in its --source flags
+// and answered with the profile named by --profile, and returns the
+// acknowledgement to show the user
+func (h *Handler) queueCommandSearch(command, text, channelID, userID string) string {
+	text, profile := services.ParseProfileFlag(text)
+	if profile != "" && command != "/inquiry-ask" {
+		return "❌ --profile only applies to /inquiry-ask"
+	}
+	if profile != "" && !h.inquiry.HasAnswerProfile(profile) {
+		return fmt.Sprintf("❌ unknown answer profile %q", profile)
+	}
+	query, sources, err := services.ParseSourceFlags(text)
+	if err != nil {
+		return "❌ " + err.Error()
+	}
+	if query == "" {
+		flags := "[--source=slack|confluence|github]"
+		if command == "/inquiry-ask" {
+			flags += " [--profile=name]"
+		}
+		return fmt.Sprintf("Usage: `%s <text> %s`", command, flags)
+	}
+
+	job := func(ctx context.Context) error {
+		ctx = services.WithSourceFilter(ctx, sources)
+		ctx = services.WithAnswerProfile(ctx, profile)
+		if command == "/inquiry-ask" {
+			return h.inquiry.AskFromCommand(ctx, channelID, userID, query)
+		}
+		return h.inquiry.SearchFromCommand(ctx, channelID, userID, query)
+	}
+	if err := h.inquiry.Submit(channelID, userID, job); err != nil {
+		// Submit has already told the user the queue is full
+		return "⏳ Please try again in a few minutes."
+	}
+
+	if command == "/inquiry-ask" {
+		return "🤖 Posting your question; the answer will follow in its thread."
+	}
+	return "🔍 Searching…"
+}
+
+// recordCommandFeedback records the rating of an /inquiry-feedback command and
+// returns the reply to show the user, with the answer's new average rating
+func (h *Handler) recordCommandFeedback(text, userID string) string {
+	const usage = "Usage: `/inquiry-feedback <inquiry_id> <1-5>`"
+	fields := strings.Fields(text)
+	if len(fields) != 2 {
+		return usage
+	}
+	inquiryID, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return usage
+	}
+	rating, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return usage
+	}
+
+	average, err := h.inquiry.RecordFeedback(uint(inquiryID), userID, rating)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRating):
+			return "❌ The rating must be between 1 and 5"
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Sprintf("❌ Inquiry %d not found", inquiryID)
+		default:
+			logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to record feedback")
+			return "❌ Failed to record your rating, please try again."
+		}
+	}
+	return fmt.Sprintf("✅ Rated inquiry %d %d/5; its average rating is now %.1f/5.", inquiryID, rating, average)
+}
+
 // HandleInteractiveComponents handles Slack interactive components
 func (h *Handler) HandleInteractiveComponents(c *gin.Context) {
 	// Verify Slack signature
@@ -145,52 +261,697 @@ func (h *Handler) HandleInteractiveComponents(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
+// RequireAdminToken rejects requests that don't carry the configured admin API bearer token
+func (h *Handler) RequireAdminToken(c *gin.Context) {
+	if h.cfg().AdminAPIToken == "" {
+		logrus.Warn("Admin API called but ADMIN_API_TOKEN is not configured")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "admin API not configured"})
+		return
+	}
+
+	token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg().AdminAPIToken)) != 1 {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid admin token"})
+		return
+	}
+
+	c.Next()
+}
+
+// auditUserHeader names the admin API caller in the audit log; the admin token
+// is shared, so it is the only record of who made a change
+const auditUserHeader = "X-Admin-User"
+
+// audit records a change made through the admin API by the caller of c
+func (h *Handler) audit(c *gin.Context, action, resourceType, resourceID string) {
+	userID := strings.TrimSpace(c.GetHeader(auditUserHeader))
+	if userID == "" {
+		userID = "unknown"
+	}
+	h.inquiry.RecordAudit(userID, action, resourceType, resourceID)
+}
+
+// HandleSummariseChannel generates a knowledge summary of a channel's recent activity
+func (h *Handler) HandleSummariseChannel(c *gin.Context) {
+	channelID := c.Param("id")
+
+	days := 7
+	if value := c.Query("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days must be a positive integer"})
+			return
+		}
+		days = parsed
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	summary, err := h.inquiry.SummariseChannel(c.Request.Context(), channelID, since)
+	if err != nil {
+		logrus.WithError(err).WithField("channel_id", channelID).Error("Failed to summarise channel")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to summarise channel"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"channel_id": channelID,
+		"since":      since.Format(time.RFC3339),
+		"summary":    summary,
+	})
+}
+
+// CreateInquiryRequest is the body of an API inquiry submission
+type CreateInquiryRequest struct {
+	ChannelID string `json:"channel_id" binding:"required"`
+	UserID    string `json:"user_id" binding:"required"`
+	Text      string `json:"text" binding:"required"`
+}
+
+// HandleCreateInquiry queues an inquiry submitted through the API. The answer
+// is fetched from HandleGetInquiry once processing completes.
+func (h *Handler) HandleCreateInquiry(c *gin.Context) {
+	var request CreateInquiryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel_id, user_id and text are required"})
+		return
+	}
+
+	inquiry, err := h.inquiry.CreateAPIInquiry(request.ChannelID, request.UserID, strings.TrimSpace(request.Text))
+	if err != nil {
+		logrus.WithError(err).Error("Failed to create API inquiry")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create inquiry"})
+		return
+	}
+
+	inquiryID := inquiry.ID
+	h.audit(c, "create", "inquiry", strconv.FormatUint(uint64(inquiryID), 10))
+	job := func(ctx context.Context) error {
+		return h.inquiry.ProcessAPIInquiry(ctx, inquiryID)
+	}
+	// No user ID: API callers get the rejection in the response, not in Slack
+	if err := h.inquiry.Submit(request.ChannelID, "", job); err != nil {
+		if markErr := h.inquiry.MarkInquiryFailed(inquiryID); markErr != nil {
+			logrus.WithError(markErr).WithField("inquiry_id", inquiryID).Error("Failed to mark inquiry failed")
+		}
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "inquiry queue is full, try again later", "inquiry_id": inquiryID})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"inquiry_id": inquiryID,
+		"status":     inquiry.Status,
+	})
+}
+
+// HandleGetInquiry returns the status and, once completed, the answer of an inquiry
+func (h *Handler) HandleGetInquiry(c *gin.Context) {
+	inquiryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	inquiry, err := h.inquiry.GetInquiry(uint(inquiryID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "inquiry not found"})
+			return
+		}
+		logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to load inquiry")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load inquiry"})
+		return
+	}
+
+	keywords := []string{}
+	if inquiry.SearchKeywords != "" {
+		keywords = strings.Split(inquiry.SearchKeywords, ",")
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"inquiry_id":        inquiry.ID,
+		"source":            inquiry.Source,
+		"status":            inquiry.Status,
+		"category":          inquiry.Category,
+		"model":             inquiry.Model,
+		"requested_model":   inquiry.RequestedModel,
+		"answer":            inquiry.ResponseText,
+		"confidence_score":  inquiry.ConfidenceScore,
+		"confidence_scored": inquiry.ConfidenceScored,
+		"processing_node":   inquiry.ProcessingNode,
+		"search_query":      inquiry.SearchQuery,
+		"search_keywords":   keywords,
+	})
+}
+
+// HandleTopContributors lists the users who asked the most questions, by
+// default over the last 30 days
+func (h *Handler) HandleTopContributors(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an ISO 8601 timestamp"})
+			return
+		}
+		since = parsed
+	}
+
+	limit := 10
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = parsed
+	}
+
+	contributors, err := h.inquiry.GetTopContributors(since, limit)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load top contributors")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load top contributors"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":        since.Format(time.RFC3339),
+		"contributors": contributors,
+	})
+}
+
+// HandleAnswerEfficiency summarises the tokens and context used by the answers
+// generated since the given time, by default over the last 30 days
+func (h *Handler) HandleAnswerEfficiency(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an ISO 8601 timestamp"})
+			return
+		}
+		since = parsed
+	}
+
+	report, err := h.inquiry.GetAnswerEfficiency(since)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to load answer efficiency")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load answer efficiency"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":      since.Format(time.RFC3339),
+		"max_tokens": h.cfg().LLMMaxTokens,
+		"report":     report,
+	})
+}
+
+// auditLogListLimit caps the number of entries HandleListAuditLog returns
+const auditLogListLimit = 1000
+
+// HandleListAuditLog lists the changes made through the admin API since the
+// given time, by default over the last 30 days, newest first
+func (h *Handler) HandleListAuditLog(c *gin.Context) {
+	since := time.Now().AddDate(0, 0, -30)
+	if value := c.Query("since"); value != "" {
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "since must be an ISO 8601 timestamp"})
+			return
+		}
+		since = parsed
+	}
+
+	limit := 100
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be a positive integer"})
+			return
+		}
+		limit = min(parsed, auditLogListLimit)
+	}
+
+	events, err := h.inquiry.ListAuditLog(since, limit)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list audit log")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list audit log"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"since":  since.Format(time.RFC3339),
+		"events": events,
+	})
+}
+
+// deadLetterListLimit caps the number of inquiries HandleListDeadLetter returns
+const deadLetterListLimit = 100
+
+// HandleListDeadLetter lists dead-lettered inquiries with why they last failed
+func (h *Handler) HandleListDeadLetter(c *gin.Context) {
+	inquiries, err := h.inquiry.ListDeadLetter(deadLetterListLimit)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list dead-lettered inquiries")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list dead-lettered inquiries"})
+		return
+	}
+
+	entries := make([]gin.H, 0, len(inquiries))
+	for _, inquiry := range inquiries {
+		entries = append(entries, gin.H{
+			"inquiry_id":     inquiry.ID,
+			"channel_id":     inquiry.ChannelID,
+			"text":           inquiry.MessageText,
+			"failure_reason": inquiry.FailureReason,
+			"retry_count":    inquiry.RetryCount,
+			"failed_at":      inquiry.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"inquiries": entries})
+}
+
+// HandleRequeueInquiry gives a dead-lettered inquiry a fresh retry budget and
+// queues it for reprocessing
+func (h *Handler) HandleRequeueInquiry(c *gin.Context) {
+	inquiryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	inquiry, err := h.inquiry.Requeue(uint(inquiryID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "inquiry not found"})
+		case errors.Is(err, services.ErrNotDeadLettered):
+			c.JSON(http.StatusConflict, gin.H{"error": "inquiry is not dead-lettered"})
+		default:
+			logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to requeue inquiry")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to requeue inquiry"})
+		}
+		return
+	}
+
+	requeuedID := inquiry.ID
+	h.audit(c, "requeue", "inquiry", c.Param("id"))
+	job := func(ctx context.Context) error {
+		return h.inquiry.ReprocessInquiry(ctx, requeuedID)
+	}
+	// When the queue is full the inquiry stays failed for the next batch reprocessing run
+	queued := h.inquiry.Submit(inquiry.ChannelID, "", job) == nil
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"inquiry_id": requeuedID,
+		"status":     inquiry.Status,
+		"queued":     queued,
+	})
+}
+
+// HandlePollMissedReactions queues the trigger reactions in monitored
+// channels whose events were missed, e.g. while the bot was offline
+func (h *Handler) HandlePollMissedReactions(c *gin.Context) {
+	if err := h.inquiry.PollSlackForUnprocessedReactions(c.Request.Context()); err != nil {
+		logrus.WithError(err).Error("Failed to poll for missed reactions")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to poll for missed reactions"})
+		return
+	}
+	h.audit(c, "poll_missed_reactions", "reactions", "")
+
+	c.JSON(http.StatusAccepted, gin.H{"status": "queued"})
+}
+
+// IndexChannelRequest is the body of a Slack channel backfill request
+type IndexChannelRequest struct {
+	ChannelID string `json:"channel_id" binding:"required"`
+}
+
+// HandleIndexChannel backfills the local Slack search index with a channel's history
+func (h *Handler) HandleIndexChannel(c *gin.Context) {
+	var request IndexChannelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "channel_id is required"})
+		return
+	}
+
+	if err := h.inquiry.IndexSlackChannel(c.Request.Context(), request.ChannelID); err != nil {
+		logrus.WithError(err).WithField("channel_id", request.ChannelID).Error("Failed to index channel")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to index channel"})
+		return
+	}
+	h.audit(c, "index_channel", "channel", request.ChannelID)
+
+	c.JSON(http.StatusOK, gin.H{"channel_id": request.ChannelID, "status": "indexed"})
+}
+
+// WebhookRequest is the body of a webhook subscription. The secret signs the
+// payloads and may be left out of updates to keep the current one.
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required"`
+	Secret string   `json:"secret"`
+	Events []string `json:"events" binding:"required"`
+}
+
+// HandleCreateWebhook subscribes a URL to inquiry lifecycle events
+func (h *Handler) HandleCreateWebhook(c *gin.Context) {
+	var request WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url, secret and events are required"})
+		return
+	}
+
+	webhook, err := h.inquiry.CreateWebhook(request.URL, request.Secret, request.Events)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		logrus.WithError(err).Error("Failed to create webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create webhook"})
+		return
+	}
+
+	h.audit(c, "create", "webhook", strconv.FormatUint(uint64(webhook.ID), 10))
+	c.JSON(http.StatusCreated, webhook)
+}
+
+// HandleListWebhooks lists the webhook subscriptions, without their secrets
+func (h *Handler) HandleListWebhooks(c *gin.Context) {
+	webhooks, err := h.inquiry.ListWebhooks()
+	if err != nil {
+		logrus.WithError(err).Error("Failed to list webhooks")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"webhooks": webhooks, "events": services.WebhookEvents})
+}
+
+// HandleGetWebhook returns a webhook subscription, without its secret
+func (h *Handler) HandleGetWebhook(c *gin.Context) {
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	webhook, err := h.inquiry.GetWebhook(uint(webhookID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		logrus.WithError(err).WithField("webhook_id", webhookID).Error("Failed to load webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, webhook)
+}
+
+// HandleUpdateWebhook replaces a webhook's URL, events and, when given, secret
+func (h *Handler) HandleUpdateWebhook(c *gin.Context) {
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	var request WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "url and events are required"})
+		return
+	}
+
+	webhook, err := h.inquiry.UpdateWebhook(uint(webhookID), request.URL, request.Secret, request.Events)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		case errors.Is(err, services.ErrInvalidWebhook):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			logrus.WithError(err).WithField("webhook_id", webhookID).Error("Failed to update webhook")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update webhook"})
+		}
+		return
+	}
+
+	h.audit(c, "update", "webhook", c.Param("id"))
+	c.JSON(http.StatusOK, webhook)
+}
+
+// HandleDeleteWebhook removes a webhook subscription
+func (h *Handler) HandleDeleteWebhook(c *gin.Context) {
+	webhookID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	if err := h.inquiry.DeleteWebhook(uint(webhookID)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+			return
+		}
+		logrus.WithError(err).WithField("webhook_id", webhookID).Error("Failed to delete webhook")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete webhook"})
+		return
+	}
+
+	h.audit(c, "delete", "webhook", c.Param("id"))
+	c.JSON(http.StatusOK, gin.H{"webhook_id": webhookID, "status": "deleted"})
+}
+
+// HandlePublishFAQ publishes an answered inquiry users found helpful as a
+// Confluence FAQ page
+func (h *Handler) HandlePublishFAQ(c *gin.Context) {
+	inquiryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+
+	inquiry, err := h.inquiry.PublishFAQ(c.Request.Context(), uint(inquiryID))
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "inquiry not found"})
+		case errors.Is(err, services.ErrFAQAlreadyPublished):
+			c.JSON(http.StatusConflict, gin.H{"error": "inquiry is already published to the FAQ"})
+		case errors.Is(err, services.ErrNotFAQWorthy):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "inquiry has no answer users found helpful"})
+		default:
+			logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to publish inquiry to the FAQ")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to publish inquiry to the FAQ"})
+		}
+		return
+	}
+
+	h.audit(c, "publish_faq", "inquiry", c.Param("id"))
+	c.JSON(http.StatusCreated, gin.H{
+		"inquiry_id":  inquiry.ID,
+		"faq_page_id": inquiry.FAQPageID,
+	})
+}
+
+// HandleRescoreInquiry reranks an inquiry's stored search results with the
+// current scoring configuration, persisting the new scores when ?persist=true
+func (h *Handler) HandleRescoreInquiry(c *gin.Context) {
+	inquiryID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id must be a positive integer"})
+		return
+	}
+	persist := c.Query("persist") == "true"
+
+	ranked, explanation, err := h.inquiry.RescoreInquiry(c.Request.Context(), uint(inquiryID), persist)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "inquiry not found"})
+			return
+		}
+		logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to rescore inquiry")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rescore inquiry"})
+		return
+	}
+	// A rescore without persist only previews the new ranking
+	if persist {
+		h.audit(c, "rescore", "inquiry", c.Param("id"))
+	}
+
+	results := make([]gin.H, 0, len(ranked))
+	for _, result := range ranked {
+		results = append(results, gin.H{
+			"source":    result.Source,
+			"source_id": result.SourceID,
+			"title":     result.Title,
+			"url":       result.URL,
+			"score":     result.Score,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"inquiry_id":  inquiryID,
+		"persisted":   persist,
+		"threshold":   explanation.Threshold,
+		"results":     results,
+		"explanation": explanation,
+	})
+}
+
 // processSlackEvent processes different types of Slack events
 func (h *Handler) processSlackEvent(event SlackEvent) {
-	ctx := context.Background()
-
 	switch event.Event.Type {
 	case "reaction_added":
-		h.handleReactionEvent(ctx, event, "added")
+		h.handleReactionEvent(event, "added")
 	case "reaction_removed":
-		h.handleReactionEvent(ctx, event, "removed")
+		h.handleReactionEvent(event, "removed")
+	case "member_joined_channel":
+		h.greetChannel(event.Event.Channel, event.Event.User)
 	case "message":
-		// Handle direct message events if needed
-		logrus.WithField("event", event).Debug("Received message event")
+		if event.Event.Subtype == "channel_join" {
+			h.greetChannel(event.Event.Channel, event.Event.User)
+			return
+		}
+		if event.Event.Subtype == "message_deleted" {
+			h.handleMessageDeleted(event.Event.Channel, event.Event.DeletedTS)
+			return
+		}
+		if event.Event.ChannelType == "im" && h.cfg().DMEnabled {
+			h.handleDirectMessage(event)
+			return
+		}
+		h.indexMessage(event)
 	default:
 		logrus.WithField("event_type", event.Event.Type).Debug("Unhandled event type")
 	}
 }
 
-// handleReactionEvent handles emoji reaction events
-func (h *Handler) handleReactionEvent(ctx context.Context, event SlackEvent, eventType string) {
-	if event.Event.Item.Type != "message" {
+// handleReactionEvent queues trigger emoji reactions for the inquiry workers
+func (h *Handler) handleReactionEvent(event SlackEvent, eventType string) {
+	if !h.inquiry.IsTriggerReaction(event.Event.Reaction, eventType) {
 		return
 	}
 
-	err := h.inquiry.ProcessReactionEvent(
-		ctx,
-		event.Event.Item.TS,        // message timestamp
-		event.Event.Item.Channel,   // channel ID
-		event.Event.User,           // user who added reaction
-		event.Event.Reaction,       // emoji name
-		eventType,                  // added or removed
-		event.Event.EventTimestamp, // event timestamp
-	)
+	var job func(ctx context.Context) error
+	switch event.Event.Item.Type {
+	case "message":
+		job = func(ctx context.Context) error {
+			return h.inquiry.ProcessReactionEvent(
+				ctx,
+				event.Event.Item.TS,        // message timestamp
+				event.Event.Item.Channel,   // channel ID
+				event.Event.User,           // user who added reaction
+				event.Event.Reaction,       // emoji name
+				eventType,                  // added or removed
+				event.Event.EventTimestamp, // event timestamp
+			)
+		}
+	case "file", "file_comment":
+		job = func(ctx context.Context) error {
+			return h.inquiry.ProcessFileReactionEvent(
+				ctx,
+				event.Event.Item.File,
+				event.Event.Item.FileComment,
+				event.Event.User,
+				event.Event.Reaction,
+				eventType,
+				event.Event.EventTimestamp,
+			)
+		}
+	default:
+		logrus.WithField("item_type", event.Event.Item.Type).Debug("Ignoring reaction on unsupported item type")
+		return
+	}
 
-	if err != nil {
+	if err := h.inquiry.Submit(event.Event.Item.Channel, event.Event.User, job); err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
 			"message_ts": event.Event.Item.TS,
+			"file":       event.Event.Item.File,
 			"channel":    event.Event.Item.Channel,
 			"reaction":   event.Event.Reaction,
 			"event_type": eventType,
-		}).Error("Failed to process reaction event")
+		}).Error("Failed to queue reaction event")
+	}
+}
+
+// handleDirectMessage queues plain direct messages to the bot for the inquiry
+// workers. Edits, deletions and other bots' messages carry a subtype or bot ID
+// and are skipped.
+func (h *Handler) handleDirectMessage(event SlackEvent) {
+	if event.Event.Subtype != "" || event.Event.BotID != "" {
+		return
+	}
+
+	message := services.SlackMessage{
+		ID:        event.Event.Timestamp,
+		Channel:   event.Event.Channel,
+		User:      event.Event.User,
+		Text:      event.Event.Text,
+		Timestamp: event.Event.Timestamp,
+		ThreadTS:  event.Event.ThreadTS,
+	}
+	job := func(ctx context.Context) error {
+		return h.inquiry.ProcessMessageEvent(ctx, message)
+	}
+
+	if err := h.inquiry.Submit(message.Channel, message.User, job); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"message_ts": message.Timestamp,
+			"channel":    message.Channel,
+		}).Error("Failed to queue direct message")
+	}
+}
+
+// handleMessageDeleted updates the inquiry, if any, asked in the deleted message
+func (h *Handler) handleMessageDeleted(channelID, deletedTS string) {
+	if err := h.inquiry.HandleSourceDeleted(context.Background(), channelID, deletedTS); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"channel_id": channelID,
+			"deleted_ts": deletedTS,
+		}).Error("Failed to handle deleted message")
+	}
+	if err := h.inquiry.UnindexSlackMessage(context.Background(), channelID, deletedTS); err != nil {
+		logrus.WithError(err).WithField("channel_id", channelID).Error("Failed to remove deleted message from search index")
+	}
+}
+
+// indexMessage adds a new top-level channel message to the Slack search index.
+// Edits, bot posts and thread replies are left out, as they are from a backfill.
+func (h *Handler) indexMessage(event SlackEvent) {
+	logrus.WithField("event", event).Debug("Received message event")
+	if event.Event.Subtype != "" || event.Event.BotID != "" || event.Event.ChannelType == "im" {
+		return
+	}
+	if event.Event.ThreadTS != "" && event.Event.ThreadTS != event.Event.Timestamp {
+		return
+	}
+
+	msg := services.SlackMessage{
+		ID:        event.Event.Timestamp,
+		Channel:   event.Event.Channel,
+		User:      event.Event.User,
+		Text:      event.Event.Text,
+		Timestamp: event.Event.Timestamp,
+	}
+	if err := h.inquiry.IndexSlackMessage(context.Background(), msg); err != nil {
+		logrus.WithError(err).WithField("channel_id", msg.Channel).Error("Failed to index message")
+	}
+}
+
+// greetChannel posts the help text when the bot itself joins a channel
+func (h *Handler) greetChannel(channelID, userID string) {
+	if _, err := h.inquiry.GreetChannel(channelID, userID, h.generateHelpResponse()); err != nil {
+		logrus.WithError(err).WithField("channel_id", channelID).Error("Failed to greet channel")
 	}
 }
 
 // verifySlackSignature verifies the Slack request signature
 func (h *Handler) verifySlackSignature(r *http.Request) bool {
-	if h.config.SlackSigningSecret == "" {
+	if h.cfg().SlackSigningSecret == "" {
 		logrus.Error("Slack signing secret not configured - signature verification required for security")
 		return false
 	}
@@ -235,7 +996,7 @@ func (h *Handler) verifySlackSignature(r *http.Request) bool {
 // calculateSignature calculates the HMAC signature
 func (h *Handler) calculateSignature(timestamp, body string) string {
 	baseString := "v0:" + timestamp + ":" + body
-	mac := hmac.New(sha256.New, []byte(h.config.SlackSigningSecret))
+	mac := hmac.New(sha256.New, []byte(h.cfg().SlackSigningSecret))
 	mac.Write([]byte(baseString))
 	return hex.EncodeToString(mac.Sum(nil))
 }
@@ -245,12 +1006,17 @@ func (h *Handler) generateHelpResponse() string {
 	return "*Foundation Inquiry Bot Help*\n\n" +
 		"This bot automatically answers team inquiries by searching through past Slack discussions and Confluence documentation.\n\n" +
 		"*How to use:*\n" +
-		"1. React to any message with the :" + h.config.TriggerEmoji + ": emoji to trigger an AI-powered response\n" +
+		"1. React to any message with the :" + h.cfg().TriggerEmoji + ": emoji to trigger an AI-powered response\n" +
 		"2. The bot will search for similar discussions and documentation\n" +
 		"3. An AI-generated response will be posted as a thread reply\n\n" +
 		"*Commands:*\n" +
 		"• `/inquiry-help` - Show this help message\n" +
-		"• `/inquiry-status` - Show bot status and recent activity\n\n" +
+		"• `/inquiry-status` - Show bot status and recent activity\n" +
+		"• `/inquiry-ask <question>` - Post a question and answer it in its thread\n" +
+		"• `/inquiry-search <query>` - Show what the bot finds for a query\n" +
+		"• `/inquiry-feedback <inquiry_id> <1-5>` - Rate an answer\n" +
+		"Add `--source=slack`, `--source=confluence` or `--source=github` to either to search only there, " +
+		"and `--profile=<name>` to `/inquiry-ask` to format the answer with an answer profile.\n\n" +
 		"*Features:*\n" +
 		"• Searches Slack messages from the last 90 days\n" +
 		"• Searches relevant Confluence pages\n" +
@@ -260,7 +1026,7 @@ func (h *Handler) generateHelpResponse() string {
 }
 
 // generateStatusResponse generates status information
-func (h *Handler) generateStatusResponse() string {
+func (h *Handler) generateStatusResponse(channelID string) string {
 	// Get recent inquiries
 	inquiries, err := h.inquiry.ListRecentInquiries(5)
 	if err != nil {
@@ -270,6 +1036,46 @@ func (h *Handler) generateStatusResponse() string {
 	response := "*Foundation Inquiry Bot Status*\n\n"
 	response += "✅ Bot is running and operational\n\n"
 
+	if h.cfg().StatusShowCounters {
+		stats := h.inquiry.Stats()
+		response += fmt.Sprintf("*Since %s*: %d processed, %.0f%% successful, %s average latency\n\n",
+			stats.Since.Format("Jan 2 15:04"),
+			stats.Processed,
+			stats.SuccessRate*100,
+			stats.AverageLatency.Round(time.Millisecond))
+		if stats.Rejected > 0 {
+			response += fmt.Sprintf("⚠️ %d inquiries rejected because the queue was full\n\n", stats.Rejected)
+		}
+	}
+
+	lastHour := time.Now().Add(-time.Hour)
+	if total, err := h.inquiry.GetInquiryCount("", lastHour); err == nil {
+		response += fmt.Sprintf("*Last hour*: %d inquiries", total)
+		if inChannel, err := h.inquiry.GetInquiryCount(channelID, lastHour); err == nil && channelID != "" {
+			response += fmt.Sprintf(" (%d in this channel)", inChannel)
+		}
+		response += "\n\n"
+	}
+
+	if counts, err := h.inquiry.CountByCategory(time.Now().AddDate(0, 0, -7)); err == nil && len(counts) > 0 {
+		categories := make([]string, 0, len(counts))
+		for category := range counts {
+			categories = append(categories, category)
+		}
+		sort.Slice(categories, func(i, j int) bool {
+			if counts[categories[i]] != counts[categories[j]] {
+				return counts[categories[i]] > counts[categories[j]]
+			}
+			return categories[i] < categories[j]
+		})
+
+		breakdown := make([]string, 0, len(categories))
+		for _, category := range categories {
+			breakdown = append(breakdown, fmt.Sprintf("%s %d", category, counts[category]))
+		}
+		response += fmt.Sprintf("*Last 7 days by category*: %s\n\n", strings.Join(breakdown, ", "))
+	}
+
 	if len(inquiries) == 0 {
 		response += "No recent inquiries processed."
 	} else {
@@ -279,16 +1085,24 @@ func (h *Handler) generateStatusResponse() string {
 			switch inquiry.Status {
 			case "completed":
 				status = "✅"
-			case "failed":
+			case "failed", "dead_letter":
 				status = "❌"
 			case "processing":
 				status = "⏳"
+			case "deferred", "out_of_hours":
+				status = "🌙"
+			}
+
+			category := inquiry.Category
+			if category == "" {
+				category = services.CategoryOther
 			}
 
-			response += fmt.Sprintf("%s %s - %s\n%s\n",
+			response += fmt.Sprintf("%s %s - %s [%s]\n%s\n",
 				status,
 				inquiry.CreatedAt.Format("Jan 2 15:04"),
 				inquiry.Status,
+				category,
 				inquiry.MessageText)
 		}
 	}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Supported values for METRICS_BACKEND
+const (
+	BackendNone       = "none"
+	BackendPrometheus = "prometheus"
+	BackendStatsD     = "statsd"
+)
+
+// Metrics is implemented by every metrics sink so call sites don't depend on the backend.
+// Metric names are dot-separated (e.g. "inquiry.processed"); each backend adapts them.
+type Metrics interface {
+	// Incr adds one to the named counter
+	Incr(name string, tags map[string]string)
+	// Timing records how long the named operation took
+	Timing(name string, d time.Duration, tags map[string]string)
+	// Histogram records value in the named distribution, e.g. a size or a ratio
+	Histogram(name string, value float64, tags map[string]string)
+}
+
+// New creates the metrics sink for backend. statsdAddr is only used by the StatsD backend.
+func New(backend, statsdAddr string) (Metrics, error) {
+	switch backend {
+	case "", BackendNone:
+		return Nop{}, nil
+	case BackendPrometheus:
+		return NewPrometheus(), nil
+	case BackendStatsD:
+		return NewStatsD(statsdAddr)
+	default:
+		return nil, fmt.Errorf("unknown metrics backend %q", backend)
+	}
+}
+
+// Handler returns the HTTP handler exposing m, or nil when the backend pushes its metrics instead
+func Handler(m Metrics) http.Handler {
+	if p, ok := m.(*Prometheus); ok {
+		return p.Handler()
+	}
+	return nil
+}
+
+// Nop discards every metric
+type Nop struct{}
+
+// Incr does nothing
+func (Nop) Incr(string, map[string]string) {}
+
+// Timing does nothing
+func (Nop) Timing(string, time.Duration, map[string]string) {}
+
+// Histogram does nothing
+func (Nop) Histogram(string, float64, map[string]string) {}
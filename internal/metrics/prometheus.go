@@ -0,0 +1,134 @@
+package metrics
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+)
+
+// prometheusNamespace prefixes every metric exposed to Prometheus
+const prometheusNamespace = "inquiry_bot"
+
+// Prometheus keeps metrics in a registry scraped through Handler. Collectors are
+// created on first use, with the tag keys of that first call as their labels.
+type Prometheus struct {
+	registry *prometheus.Registry
+
+	mu         sync.Mutex
+	counters   map[string]*prometheus.CounterVec
+	timings    map[string]*prometheus.HistogramVec
+	histograms map[string]*prometheus.HistogramVec
+}
+
+// NewPrometheus creates an empty Prometheus registry
+func NewPrometheus() *Prometheus {
+	return &Prometheus{
+		registry:   prometheus.NewRegistry(),
+		counters:   make(map[string]*prometheus.CounterVec),
+		timings:    make(map[string]*prometheus.HistogramVec),
+		histograms: make(map[string]*prometheus.HistogramVec),
+	}
+}
+
+// Incr adds one to the counter <name>_total
+func (p *Prometheus) Incr(name string, tags map[string]string) {
+	p.mu.Lock()
+	vec, ok := p.counters[name]
+	if !ok {
+		vec = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: prometheusNamespace,
+			Name:      prometheusName(name) + "_total",
+		}, labelNames(tags))
+		p.registry.MustRegister(vec)
+		p.counters[name] = vec
+	}
+	p.mu.Unlock()
+
+	counter, err := vec.GetMetricWith(tags)
+	if err != nil {
+		logrus.WithError(err).WithField("metric", name).Warn("Inconsistent tags for metric")
+		return
+	}
+	counter.Inc()
+}
+
+// Timing observes d in the histogram <name>_seconds
+func (p *Prometheus) Timing(name string, d time.Duration, tags map[string]string) {
+	p.mu.Lock()
+	vec, ok := p.timings[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prometheusNamespace,
+			Name:      prometheusName(name) + "_seconds",
+		}, labelNames(tags))
+		p.registry.MustRegister(vec)
+		p.timings[name] = vec
+	}
+	p.mu.Unlock()
+
+	histogram, err := vec.GetMetricWith(tags)
+	if err != nil {
+		logrus.WithError(err).WithField("metric", name).Warn("Inconsistent tags for metric")
+		return
+	}
+	histogram.Observe(d.Seconds())
+}
+
+// Histogram observes value in the histogram <name>. Names ending in "ratio"
+// hold values from 0 to 1 and get ten linear buckets; others are sizes, such
+// as token or character counts, and get doubling buckets from 16 to 32768.
+func (p *Prometheus) Histogram(name string, value float64, tags map[string]string) {
+	p.mu.Lock()
+	vec, ok := p.histograms[name]
+	if !ok {
+		vec = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: prometheusNamespace,
+			Name:      prometheusName(name),
+			Buckets:   histogramBuckets(name),
+		}, labelNames(tags))
+		p.registry.MustRegister(vec)
+		p.histograms[name] = vec
+	}
+	p.mu.Unlock()
+
+	histogram, err := vec.GetMetricWith(tags)
+	if err != nil {
+		logrus.WithError(err).WithField("metric", name).Warn("Inconsistent tags for metric")
+		return
+	}
+	histogram.Observe(value)
+}
+
+// histogramBuckets returns the buckets of the histogram name
+func histogramBuckets(name string) []float64 {
+	if strings.HasSuffix(name, "ratio") {
+		return prometheus.LinearBuckets(0.1, 0.1, 10)
+	}
+	return prometheus.ExponentialBuckets(16, 2, 12)
+}
+
+// Handler serves the registry in the Prometheus exposition format
+func (p *Prometheus) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// prometheusName turns a dot-separated metric name into a Prometheus one
+func prometheusName(name string) string {
+	return strings.ReplaceAll(name, ".", "_")
+}
+
+// labelNames returns the sorted keys of tags
+func labelNames(tags map[string]string) []string {
+	names := make([]string, 0, len(tags))
+	for key := range tags {
+		names = append(names, key)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,86 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// statsdPrefix namespaces every metric sent to StatsD
+const statsdPrefix = "inquiry_bot."
+
+// StatsD pushes metrics over UDP in the DogStatsD line format
+type StatsD struct {
+	conn net.Conn
+}
+
+// NewStatsD creates a client sending to addr (host:port)
+func NewStatsD(addr string) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to StatsD at %s: %w", addr, err)
+	}
+
+	return &StatsD{conn: conn}, nil
+}
+
+// Incr sends a counter increment
+func (s *StatsD) Incr(name string, tags map[string]string) {
+	s.send(formatCounter(name, 1, tags))
+}
+
+// Timing sends a timing in milliseconds
+func (s *StatsD) Timing(name string, d time.Duration, tags map[string]string) {
+	s.send(formatTiming(name, d, tags))
+}
+
+// Histogram sends a histogram value
+func (s *StatsD) Histogram(name string, value float64, tags map[string]string) {
+	s.send(formatHistogram(name, value, tags))
+}
+
+// Close closes the UDP connection
+func (s *StatsD) Close() error {
+	return s.conn.Close()
+}
+
+// send writes one metric line; UDP is fire-and-forget so failures are only logged
+func (s *StatsD) send(line string) {
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		logrus.WithError(err).Debug("Failed to send StatsD metric")
+	}
+}
+
+// formatCounter renders a counter as "<name>:<value>|c|#<tags>"
+func formatCounter(name string, value int64, tags map[string]string) string {
+	return fmt.Sprintf("%s%s:%d|c%s", statsdPrefix, name, value, formatTags(tags))
+}
+
+// formatTiming renders a timing as "<name>:<ms>|ms|#<tags>"
+func formatTiming(name string, d time.Duration, tags map[string]string) string {
+	return fmt.Sprintf("%s%s:%d|ms%s", statsdPrefix, name, d.Milliseconds(), formatTags(tags))
+}
+
+// formatHistogram renders a histogram value as "<name>:<value>|h|#<tags>"
+func formatHistogram(name string, value float64, tags map[string]string) string {
+	return fmt.Sprintf("%s%s:%g|h%s", statsdPrefix, name, value, formatTags(tags))
+}
+
+// formatTags renders tags as a DogStatsD "|#key:value,..." suffix, sorted by key
+func formatTags(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	pairs := make([]string, 0, len(tags))
+	for key, value := range tags {
+		pairs = append(pairs, key+":"+value)
+	}
+	sort.Strings(pairs)
+
+	return "|#" + strings.Join(pairs, ",")
+}
@@ -0,0 +1,85 @@
+package metrics
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFormatCounter(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		expected string
+	}{
+		{name: "no tags", expected: "inquiry_bot.inquiry.processed:1|c"},
+		{name: "one tag", tags: map[string]string{"status": "succeeded"}, expected: "inquiry_bot.inquiry.processed:1|c|#status:succeeded"},
+		{name: "tags sorted by key", tags: map[string]string{"status": "failed", "channel": "C1"}, expected: "inquiry_bot.inquiry.processed:1|c|#channel:C1,status:failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if line := formatCounter("inquiry.processed", 1, tt.tags); line != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, line)
+			}
+		})
+	}
+}
+
+func TestFormatTiming(t *testing.T) {
+	line := formatTiming("llm.request", 1500*time.Millisecond, map[string]string{"model": "gpt-4o"})
+	if line != "inquiry_bot.llm.request:1500|ms|#model:gpt-4o" {
+		t.Errorf("Unexpected timing line %q", line)
+	}
+
+	if line := formatTiming("search.duration", 250*time.Microsecond, nil); line != "inquiry_bot.search.duration:0|ms" {
+		t.Errorf("Expected sub-millisecond timing to round down, got %q", line)
+	}
+}
+
+func TestFormatHistogram(t *testing.T) {
+	if line := formatHistogram("llm.prompt_tokens", 1234, map[string]string{"model": "gpt-4o"}); line != "inquiry_bot.llm.prompt_tokens:1234|h|#model:gpt-4o" {
+		t.Errorf("Unexpected histogram line %q", line)
+	}
+	if line := formatHistogram("llm.context_ratio", 0.25, nil); line != "inquiry_bot.llm.context_ratio:0.25|h" {
+		t.Errorf("Unexpected histogram line %q", line)
+	}
+}
+
+func TestStatsD_SendsOverUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	client, err := NewStatsD(listener.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("NewStatsD returned error: %v", err)
+	}
+	defer client.Close()
+
+	client.Incr("inquiry.rejected", nil)
+
+	buf := make([]byte, 512)
+	_ = listener.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("Failed to read metric: %v", err)
+	}
+	if got := string(buf[:n]); got != "inquiry_bot.inquiry.rejected:1|c" {
+		t.Errorf("Unexpected packet %q", got)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if m, err := New(BackendNone, ""); err != nil || m != (Nop{}) {
+		t.Errorf("Expected Nop for backend none, got %v, %v", m, err)
+	}
+	if m, err := New(BackendPrometheus, ""); err != nil || Handler(m) == nil {
+		t.Errorf("Expected Prometheus with a handler, got %v, %v", m, err)
+	}
+	if _, err := New("graphite", ""); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+}
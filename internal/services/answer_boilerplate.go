@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxBoilerplateChars bounds what a single boilerplate match may remove, so an
+// over-broad pattern can't cut substantive text
+const maxBoilerplateChars = 200
+
+// stripAnswerBoilerplate removes the ANSWER_STRIP_PATTERNS boilerplate from the
+// start and end of a generated answer
+func (s *InquiryService) stripAnswerBoilerplate(ctx context.Context, answer string) string {
+	if len(s.cfg().AnswerStripPatterns) == 0 {
+		return answer
+	}
+	leading, trailing, err := s.cfg().AnswerStripRegexps()
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Invalid ANSWER_STRIP_PATTERNS, posting the answer as generated")
+		return answer
+	}
+
+	stripped := stripBoilerplate(answer, leading, trailing)
+	if stripped != answer {
+		s.metrics.Incr("answer.boilerplate_stripped", nil)
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"removed_chars": len(answer) - len(stripped),
+		}).Debug("Stripped boilerplate from answer")
+	}
+	return stripped
+}
+
+// stripBoilerplate repeatedly removes matches of leading from the start and of
+// trailing from the end of answer. A match is kept when it is longer than
+// maxBoilerplateChars or would leave nothing of the answer. When a leading
+// match cuts into a sentence, the rest of it is capitalized.
+func stripBoilerplate(answer string, leading, trailing []*regexp.Regexp) string {
+	answer = strings.TrimSpace(answer)
+	for changed := true; changed; {
+		changed = false
+		for _, pattern := range leading {
+			loc := pattern.FindStringIndex(answer)
+			if loc == nil || !removable(answer, loc) {
+				continue
+			}
+			answer = capitalize(strings.TrimLeft(answer[loc[1]:], ",;: \t\n"))
+			changed = true
+		}
+		for _, pattern := range trailing {
+			loc := pattern.FindStringIndex(answer)
+			if loc == nil || !removable(answer, loc) {
+				continue
+			}
+			answer = strings.TrimSpace(answer[:loc[0]])
+			changed = true
+		}
+	}
+	return answer
+}
+
+// removable reports whether the match at loc may be cut from answer
+func removable(answer string, loc []int) bool {
+	if loc[1] == loc[0] || utf8.RuneCountInString(answer[loc[0]:loc[1]]) > maxBoilerplateChars {
+		return false
+	}
+	return strings.TrimSpace(answer[:loc[0]]+answer[loc[1]:]) != ""
+}
+
+// capitalize upper-cases the first letter of text
+func capitalize(text string) string {
+	first, size := utf8.DecodeRuneInString(text)
+	if !unicode.IsLower(first) {
+		return text
+	}
+	return string(unicode.ToUpper(first)) + text[size:]
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestStripBoilerplate(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerStripPatterns = []string{
+		`(?i)based on the (provided )?context( provided)?`,
+		`(?i)i hope this helps[.!]?`,
+		`(?i)let me know if you have (any )?(other|more|further) questions[.!]?`,
+	}
+	leading, trailing, err := cfg.AnswerStripRegexps()
+	if err != nil {
+		t.Fatalf("AnswerStripRegexps returned error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{
+			name:   "leading phrase",
+			answer: "Based on the context provided, you should run `make deploy`.",
+			want:   "You should run `make deploy`.",
+		},
+		{
+			name:   "trailing phrases",
+			answer: "Run `make deploy` from main.\n\nI hope this helps! Let me know if you have any other questions.",
+			want:   "Run `make deploy` from main.",
+		},
+		{
+			name:   "both ends",
+			answer: "Based on the provided context: deploys are frozen on Fridays. I hope this helps.",
+			want:   "Deploys are frozen on Fridays.",
+		},
+		{
+			name:   "phrase inside the answer",
+			answer: "Deploys are frozen on Fridays, based on the context of the incident review. Ask #deploys for exceptions.",
+			want:   "Deploys are frozen on Fridays, based on the context of the incident review. Ask #deploys for exceptions.",
+		},
+		{
+			name:   "nothing but boilerplate",
+			answer: "I hope this helps!",
+			want:   "I hope this helps!",
+		},
+		{
+			name:   "no boilerplate",
+			answer: "Run `make deploy`.",
+			want:   "Run `make deploy`.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripBoilerplate(tt.answer, leading, trailing); got != tt.want {
+				t.Errorf("stripBoilerplate(%q) = %q, want %q", tt.answer, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripBoilerplate_OverBroadPattern(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerStripPatterns = []string{`(?s).*helps[.!]?`, `x*`}
+	leading, trailing, err := cfg.AnswerStripRegexps()
+	if err != nil {
+		t.Fatalf("AnswerStripRegexps returned error: %v", err)
+	}
+
+	answer := "Roll back with `make rollback`, then " + strings.Repeat("check every dashboard and alert, ", 8) + "which helps."
+	if got := stripBoilerplate(answer, leading, trailing); got != answer {
+		t.Errorf("Expected a match over %d characters to be kept, got %q", maxBoilerplateChars, got)
+	}
+}
+
+func TestProcessInquiry_StripsAnswerBoilerplate(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerStripPatterns = []string{`(?i)based on the context provided`, `(?i)i hope this helps!`}
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Based on the context provided, run the deploy script. I hope this helps!")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	var inquiry storage.Inquiry
+	db.First(&inquiry)
+	if inquiry.ResponseText != "Run the deploy script." {
+		t.Errorf("Expected the boilerplate to be stripped, got %q", inquiry.ResponseText)
+	}
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// DistributionSummary summarises a set of values
+type DistributionSummary struct {
+	Avg float64 `json:"avg"`
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	Max float64 `json:"max"`
+}
+
+// AnswerEfficiencyReport summarises how much the model was fed and produced for
+// the answers generated over a period, to tune LLM_MAX_TOKENS and the prompt size
+type AnswerEfficiencyReport struct {
+	Answers          int                 `json:"answers"`
+	PromptTokens     DistributionSummary `json:"prompt_tokens"`
+	CompletionTokens DistributionSummary `json:"completion_tokens"`
+	AnswerLength     DistributionSummary `json:"answer_length"`
+	ContextRatio     DistributionSummary `json:"context_ratio"`
+	// TruncatedShare is the share of answers that used all of LLM_MAX_TOKENS,
+	// which were likely cut short
+	TruncatedShare float64 `json:"truncated_share"`
+	// ContextDroppedShare is the share of answers whose prompt left some of the
+	// search result context out
+	ContextDroppedShare float64 `json:"context_dropped_share"`
+}
+
+// resultChars is the size of the context results contribute to a prompt
+func resultChars(results []storage.SearchResult) int {
+	chars := 0
+	for _, result := range results {
+		chars += len(result.Title) + len(result.Content)
+	}
+	return chars
+}
+
+// contextRatio is the share of the context of available that selected holds,
+// 1 when there is no context to include
+func contextRatio(available, selected []storage.SearchResult) float64 {
+	total := resultChars(available)
+	if total == 0 {
+		return 1
+	}
+	return float64(resultChars(selected)) / float64(total)
+}
+
+// recordEfficiency records on inquiry the tokens its answer request used and
+// the share of context it included, and observes them in histograms along
+// with the answer's length in characters
+func (s *LLMService) recordEfficiency(inquiry *storage.Inquiry, request LiteLLMRequest, usage LiteLLMUsage, answer string) {
+	inquiry.PromptTokens = usage.PromptTokens
+	inquiry.CompletionTokens = usage.CompletionTokens
+	inquiry.ContextRatio = request.contextRatio
+
+	tags := map[string]string{"model": request.Model}
+	// Not every provider reports how the tokens split between prompt and completion
+	if usage.PromptTokens > 0 || usage.CompletionTokens > 0 {
+		s.metrics.Histogram("llm.prompt_tokens", float64(usage.PromptTokens), tags)
+		s.metrics.Histogram("llm.completion_tokens", float64(usage.CompletionTokens), tags)
+	}
+	s.metrics.Histogram("llm.answer_length", float64(utf8.RuneCountInString(answer)), tags)
+	s.metrics.Histogram("llm.context_ratio", request.contextRatio, tags)
+}
+
+// GetAnswerEfficiency reports on the answers the model generated since the given time
+func (s *InquiryService) GetAnswerEfficiency(since time.Time) (*AnswerEfficiencyReport, error) {
+	var inquiries []storage.Inquiry
+	if err := s.db.Where("created_at >= ? AND status = ?", since, "completed").
+		Where("prompt_tokens > 0 OR completion_tokens > 0 OR context_ratio > 0").
+		Find(&inquiries).Error; err != nil {
+		return nil, fmt.Errorf("failed to load answered inquiries: %w", err)
+	}
+
+	report := summarizeAnswerEfficiency(inquiries, s.cfg().LLMMaxTokens)
+	return &report, nil
+}
+
+// summarizeAnswerEfficiency summarises the model answers to inquiries, counting
+// answers that used maxTokens completion tokens as truncated
+func summarizeAnswerEfficiency(inquiries []storage.Inquiry, maxTokens int) AnswerEfficiencyReport {
+	report := AnswerEfficiencyReport{Answers: len(inquiries)}
+	if len(inquiries) == 0 {
+		return report
+	}
+
+	var prompt, completion, length, ratio []float64
+	truncated, dropped := 0, 0
+	for _, inquiry := range inquiries {
+		if inquiry.PromptTokens > 0 || inquiry.CompletionTokens > 0 {
+			prompt = append(prompt, float64(inquiry.PromptTokens))
+			completion = append(completion, float64(inquiry.CompletionTokens))
+		}
+		length = append(length, float64(utf8.RuneCountInString(inquiry.ResponseText)))
+		ratio = append(ratio, inquiry.ContextRatio)
+
+		if maxTokens > 0 && inquiry.CompletionTokens >= maxTokens {
+			truncated++
+		}
+		if inquiry.ContextRatio < 1 {
+			dropped++
+		}
+	}
+
+	report.PromptTokens = summarizeDistribution(prompt)
+	report.CompletionTokens = summarizeDistribution(completion)
+	report.AnswerLength = summarizeDistribution(length)
+	report.ContextRatio = summarizeDistribution(ratio)
+	report.TruncatedShare = float64(truncated) / float64(len(inquiries))
+	report.ContextDroppedShare = float64(dropped) / float64(len(inquiries))
+	return report
+}
+
+// summarizeDistribution returns the average, nearest-rank percentiles and
+// maximum of values, all 0 without values
+func summarizeDistribution(values []float64) DistributionSummary {
+	if len(values) == 0 {
+		return DistributionSummary{}
+	}
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	var sum float64
+	for _, value := range sorted {
+		sum += value
+	}
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(rank, 0)]
+	}
+
+	return DistributionSummary{
+		Avg: sum / float64(len(sorted)),
+		P50: percentile(0.5),
+		P90: percentile(0.9),
+		Max: sorted[len(sorted)-1],
+	}
+}
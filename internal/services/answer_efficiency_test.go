@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// recordingMetrics keeps the histogram values it is sent, by metric name
+type recordingMetrics struct {
+	mu         sync.Mutex
+	histograms map[string][]float64
+}
+
+func (m *recordingMetrics) Incr(string, map[string]string) {}
+
+func (m *recordingMetrics) Timing(string, time.Duration, map[string]string) {}
+
+func (m *recordingMetrics) Histogram(name string, value float64, _ map[string]string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.histograms == nil {
+		m.histograms = make(map[string][]float64)
+	}
+	m.histograms[name] = append(m.histograms[name], value)
+}
+
+func TestContextRatio(t *testing.T) {
+	available := []storage.SearchResult{
+		{Title: "ab", Content: strings.Repeat("a", 28)},
+		{Title: "cd", Content: strings.Repeat("c", 68)},
+	}
+
+	if ratio := contextRatio(available, available[:1]); ratio != 0.3 {
+		t.Errorf("Expected 30 of 100 characters to give 0.3, got %v", ratio)
+	}
+	if ratio := contextRatio(available, available); ratio != 1 {
+		t.Errorf("Expected all the context to give 1, got %v", ratio)
+	}
+	if ratio := contextRatio(nil, nil); ratio != 1 {
+		t.Errorf("Expected 1 without context to include, got %v", ratio)
+	}
+}
+
+func TestGenerateResponse_RecordsEfficiency(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMMaxContextChars = 50
+	newFakeLLM(t, cfg, "Run the deploy script.")
+	service := NewLLMService(cfg)
+	recorder := &recordingMetrics{}
+	service.SetMetrics(recorder)
+
+	inquiry := &storage.Inquiry{MessageText: "How do I deploy?"}
+	results := []storage.SearchResult{
+		{Source: "confluence", Score: 0.9, Content: strings.Repeat("a", 40)},
+		{Source: "slack", Score: 0.5, Content: strings.Repeat("b", 60)},
+	}
+	if _, err := service.GenerateResponse(context.Background(), inquiry, results); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	if inquiry.PromptTokens != 30 || inquiry.CompletionTokens != 12 || inquiry.ContextRatio != 0.4 {
+		t.Errorf("Expected 30 prompt and 12 completion tokens with 40%% of the context, got %d, %d, %v",
+			inquiry.PromptTokens, inquiry.CompletionTokens, inquiry.ContextRatio)
+	}
+
+	expected := map[string]float64{
+		"llm.prompt_tokens":     30,
+		"llm.completion_tokens": 12,
+		"llm.answer_length":     22,
+		"llm.context_ratio":     0.4,
+	}
+	for name, value := range expected {
+		if got := recorder.histograms[name]; len(got) != 1 || got[0] != value {
+			t.Errorf("Expected %s to record %v, got %v", name, value, got)
+		}
+	}
+}
+
+func TestSummarizeAnswerEfficiency(t *testing.T) {
+	inquiries := []storage.Inquiry{
+		{PromptTokens: 1000, CompletionTokens: 200, ContextRatio: 1, ResponseText: strings.Repeat("a", 400)},
+		{PromptTokens: 3000, CompletionTokens: 500, ContextRatio: 0.5, ResponseText: strings.Repeat("é", 900)},
+		{PromptTokens: 2000, CompletionTokens: 300, ContextRatio: 1, ResponseText: strings.Repeat("a", 600)},
+		// Not every provider reports token usage
+		{ContextRatio: 0.25, ResponseText: strings.Repeat("a", 100)},
+	}
+
+	report := summarizeAnswerEfficiency(inquiries, 500)
+
+	if report.Answers != 4 {
+		t.Errorf("Expected 4 answers, got %d", report.Answers)
+	}
+	if want := (DistributionSummary{Avg: 2000, P50: 2000, P90: 3000, Max: 3000}); report.PromptTokens != want {
+		t.Errorf("Expected prompt tokens %+v, got %+v", want, report.PromptTokens)
+	}
+	if want := (DistributionSummary{Avg: 1000.0 / 3, P50: 300, P90: 500, Max: 500}); report.CompletionTokens != want {
+		t.Errorf("Expected completion tokens %+v, got %+v", want, report.CompletionTokens)
+	}
+	if want := (DistributionSummary{Avg: 500, P50: 400, P90: 900, Max: 900}); report.AnswerLength != want {
+		t.Errorf("Expected answer length in characters %+v, got %+v", want, report.AnswerLength)
+	}
+	if want := (DistributionSummary{Avg: 0.6875, P50: 0.5, P90: 1, Max: 1}); report.ContextRatio != want {
+		t.Errorf("Expected context ratio %+v, got %+v", want, report.ContextRatio)
+	}
+	if report.TruncatedShare != 0.25 {
+		t.Errorf("Expected the answer at LLM_MAX_TOKENS to count as truncated, got %v", report.TruncatedShare)
+	}
+	if report.ContextDroppedShare != 0.5 {
+		t.Errorf("Expected half the answers to have dropped context, got %v", report.ContextDroppedShare)
+	}
+
+	if empty := summarizeAnswerEfficiency(nil, 500); empty != (AnswerEfficiencyReport{}) {
+		t.Errorf("Expected an empty report without answers, got %+v", empty)
+	}
+}
+
+func TestGetAnswerEfficiency(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	db.Create(&storage.Inquiry{MessageID: "1", Status: "completed", PromptTokens: 100, CompletionTokens: 10, ContextRatio: 1, ResponseText: "answer"})
+	// Answers without a model, failed inquiries and old ones are left out
+	db.Create(&storage.Inquiry{MessageID: "2", Status: "completed", ResponseText: "links only"})
+	db.Create(&storage.Inquiry{MessageID: "3", Status: "failed", PromptTokens: 100, ContextRatio: 1})
+	old := &storage.Inquiry{MessageID: "4", Status: "completed", PromptTokens: 100, ContextRatio: 1}
+	db.Create(old)
+	db.Model(old).Update("created_at", time.Now().AddDate(0, 0, -40))
+
+	report, err := service.GetAnswerEfficiency(time.Now().AddDate(0, 0, -30))
+	if err != nil {
+		t.Fatalf("GetAnswerEfficiency returned error: %v", err)
+	}
+	if report.Answers != 1 || report.PromptTokens.Max != 100 {
+		t.Errorf("Expected only the recent model answer, got %+v", report)
+	}
+}
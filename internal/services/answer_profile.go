@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// profileFlag selects the answer profile of a command's answer
+const profileFlag = "--profile="
+
+// sourceListLimit caps the sources listed under answers whose profile cites them in a list
+const sourceListLimit = 5
+
+// answerProfileKey is the context key holding the answer profile chosen for a command
+type answerProfileKey struct{}
+
+// ParseProfileFlag splits command text into the rest of the text and the
+// answer profile named with --profile, empty without the flag
+func ParseProfileFlag(text string) (rest, profile string) {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		if name, isFlag := strings.CutPrefix(word, profileFlag); isFlag {
+			profile = strings.ToLower(name)
+			continue
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " "), profile
+}
+
+// WithAnswerProfile returns ctx answering with the named profile instead of
+// the channel's or the default one
+func WithAnswerProfile(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, answerProfileKey{}, name)
+}
+
+// HasAnswerProfile reports whether a profile named name is configured
+func (s *LLMService) HasAnswerProfile(name string) bool {
+	profiles, err := s.answerProfiles()
+	if err != nil {
+		return false
+	}
+	_, ok := profiles[strings.ToLower(name)]
+	return ok
+}
+
+// AnswerProfile returns the profile inquiry is answered with: the one chosen
+// on ctx, else its channel's, else DEFAULT_ANSWER_PROFILE. It returns nil
+// when none applies or the profiles can't be loaded.
+func (s *LLMService) AnswerProfile(ctx context.Context, inquiry *storage.Inquiry) *config.AnswerProfile {
+	name, _ := ctx.Value(answerProfileKey{}).(string)
+	if name == "" {
+		name = s.cfg().ChannelAnswerProfiles[inquiry.ChannelID]
+	}
+	if name == "" {
+		name = s.cfg().DefaultAnswerProfile
+	}
+	if name == "" {
+		return nil
+	}
+
+	profiles, err := s.answerProfiles()
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to load answer profiles, answering without one")
+		return nil
+	}
+	profile, ok := profiles[strings.ToLower(name)]
+	if !ok {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": inquiry.ID,
+			"profile":    name,
+		}).Warn("Unknown answer profile, answering without one")
+		return nil
+	}
+	return &profile
+}
+
+// answerProfiles returns the profiles at ANSWER_PROFILES_FILE, loading them
+// on first use and again whenever a reload points at a different file
+func (s *LLMService) answerProfiles() (map[string]config.AnswerProfile, error) {
+	path := s.cfg().AnswerProfilesFile
+	if path == "" {
+		return nil, nil
+	}
+
+	s.profilesMu.Lock()
+	defer s.profilesMu.Unlock()
+
+	if s.profiles != nil && s.profilesPath == path {
+		return s.profiles, nil
+	}
+	profiles, err := config.LoadAnswerProfiles(path)
+	if err != nil {
+		return nil, err
+	}
+	s.profiles, s.profilesPath = profiles, path
+	return profiles, nil
+}
+
+// profileInstructions turns a profile into prompt instructions, one per line
+func profileInstructions(profile *config.AnswerProfile) string {
+	var lines []string
+	if profile.Language != "" {
+		lines = append(lines, fmt.Sprintf("Write the response in %s.", profile.Language))
+	}
+	if profile.Tone != "" {
+		lines = append(lines, fmt.Sprintf("Use a %s tone.", profile.Tone))
+	}
+	switch profile.Length {
+	case "concise":
+		lines = append(lines, "Keep the response short, a few sentences at most.")
+	case "standard":
+		lines = append(lines, "Keep the response concise but thorough.")
+	case "detailed":
+		lines = append(lines, "Be thorough, covering caveats and edge cases.")
+	}
+	switch profile.Detail {
+	case "basic":
+		lines = append(lines, "Explain for a non-technical reader, avoiding jargon.")
+	case "technical":
+		lines = append(lines, "Include technical specifics such as configuration names, versions and commands.")
+	}
+	switch profile.Format {
+	case "bullets":
+		lines = append(lines, "Format the response as bullet points.")
+	case "prose":
+		lines = append(lines, "Write the response as short paragraphs, not bullet points.")
+	}
+	if profile.Code != nil {
+		if *profile.Code {
+			lines = append(lines, "Include code, commands or configuration snippets where they help.")
+		} else {
+			lines = append(lines, "Do not include code, commands or configuration snippets.")
+		}
+	}
+	switch profile.Citations {
+	case "inline":
+		lines = append(lines, "Cite the sources you use inline as Slack links, e.g. <url|title>.")
+	case "list":
+		lines = append(lines, "Don't link sources in the response; they are listed under it.")
+	case "none":
+		lines = append(lines, "Don't mention or link sources.")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// sourceList lists the best sources with a URL, for answers whose profile
+// cites sources in a list
+func sourceList(searchResults []storage.SearchResult) string {
+	var lines []string
+	for _, result := range searchResults {
+		if result.URL == "" {
+			continue
+		}
+		if len(lines) == sourceListLimit {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("• <%s|%s>", result.URL, result.Title))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return "*Sources:*\n" + strings.Join(lines, "\n")
+}
+
+// HasAnswerProfile reports whether a profile named name is configured
+func (s *InquiryService) HasAnswerProfile(name string) bool {
+	return s.llm.HasAnswerProfile(name)
+}
@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+const testAnswerProfiles = `
+exec:
+  length: concise
+  format: bullets
+  code: false
+  citations: none
+engineer:
+  length: detailed
+  detail: technical
+  code: true
+  citations: list
+`
+
+// answerProfilesConfig returns a test config with the exec and engineer profiles
+func answerProfilesConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "profiles.yaml")
+	if err := os.WriteFile(path, []byte(testAnswerProfiles), 0o600); err != nil {
+		t.Fatalf("Failed to write profiles: %v", err)
+	}
+	cfg := config.LoadTestConfig()
+	cfg.AnswerProfilesFile = path
+	return cfg
+}
+
+func TestParseProfileFlag(t *testing.T) {
+	rest, profile := ParseProfileFlag("--profile=Exec how do I deploy --source=slack")
+	if rest != "how do I deploy --source=slack" || profile != "exec" {
+		t.Errorf("Unexpected result %q, %q", rest, profile)
+	}
+
+	if rest, profile := ParseProfileFlag("how do I deploy"); rest != "how do I deploy" || profile != "" {
+		t.Errorf("Expected text without a flag unchanged, got %q, %q", rest, profile)
+	}
+}
+
+func TestAnswerProfile_Resolution(t *testing.T) {
+	tests := []struct {
+		name     string
+		flag     string
+		channel  string
+		fallback string
+		expected string
+	}{
+		{name: "none", expected: ""},
+		{name: "default", fallback: "exec", expected: "concise"},
+		{name: "channel over default", channel: "engineer", fallback: "exec", expected: "detailed"},
+		{name: "flag over channel", flag: "exec", channel: "engineer", expected: "concise"},
+		{name: "unknown profile", flag: "lawyer", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := answerProfilesConfig(t)
+			cfg.DefaultAnswerProfile = tt.fallback
+			if tt.channel != "" {
+				cfg.ChannelAnswerProfiles = map[string]string{"C1": tt.channel}
+			}
+			service := NewLLMService(cfg)
+
+			ctx := WithAnswerProfile(context.Background(), tt.flag)
+			profile := service.AnswerProfile(ctx, &storage.Inquiry{ChannelID: "C1"})
+
+			var length string
+			if profile != nil {
+				length = profile.Length
+			}
+			if length != tt.expected {
+				t.Errorf("Expected profile with length %q, got %+v", tt.expected, profile)
+			}
+		})
+	}
+}
+
+func TestBuildPrompt_AnswerProfile(t *testing.T) {
+	service := NewLLMService(config.LoadTestConfig())
+	code := false
+
+	plain := service.buildPrompt("How do I deploy?", "context", nil)
+	if strings.Contains(plain, "audience") {
+		t.Errorf("Expected no profile instructions without a profile, got %q", plain)
+	}
+
+	prompt := service.buildPrompt("How do I deploy?", "context", &config.AnswerProfile{
+		Language: "Japanese", Length: "concise", Format: "bullets", Code: &code,
+	})
+	for _, want := range []string{
+		"Write the response in Japanese.",
+		"Keep the response short",
+		"Format the response as bullet points.",
+		"Do not include code",
+	} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("Expected prompt to contain %q, got %q", want, prompt)
+		}
+	}
+}
+
+func TestProcessInquiry_AnswerProfileListsSources(t *testing.T) {
+	for _, profile := range []string{"engineer", "exec"} {
+		t.Run(profile, func(t *testing.T) {
+			cfg := answerProfilesConfig(t)
+			cfg.SimilarityThreshold = 0
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+				{"ts": "1.1", "text": "Deploy with make deploy", "channel": {"id": "C1"}}
+			]}}`)
+			llm := newFakeLLM(t, cfg, "Run make deploy")
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			ctx := WithAnswerProfile(context.Background(), profile)
+			if err := service.ProcessInquiry(ctx, "2.2", "C1", "U1", "How do I deploy?", "2.2", ""); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			var answer string
+			for _, call := range fake.callsTo("chat.postMessage") {
+				if call.Get("thread_ts") == "2.2" {
+					answer = call.Get("text")
+				}
+			}
+			if listed := strings.Contains(answer, "*Sources:*"); listed != (profile == "engineer") {
+				t.Errorf("Expected sources listed %v, got %q", profile == "engineer", answer)
+			}
+
+			prompt := fmt.Sprint(llm.requests[0]["messages"])
+			if want := map[string]string{"engineer": "Be thorough", "exec": "Keep the response short"}[profile]; !strings.Contains(prompt, want) {
+				t.Errorf("Expected the prompt to contain %q, got %q", want, prompt)
+			}
+		})
+	}
+}
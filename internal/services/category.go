@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Inquiry categories assigned by Categorize
+const (
+	CategoryIncident   = "incident"
+	CategoryAccess     = "access"
+	CategoryDeployment = "deployment"
+	CategoryHowTo      = "how-to"
+	CategoryOther      = "other"
+)
+
+// categoryRules lists the keywords for each category in tie-break priority order
+var categoryRules = []struct {
+	category string
+	keywords []string
+}{
+	{CategoryIncident, []string{"down", "outage", "incident", "error", "errors", "failing", "failed", "broken", "crash", "alert", "paged", "500", "502", "503", "timeout", "latency"}},
+	{CategoryAccess, []string{"access", "permission", "permissions", "grant", "role", "iam", "login", "credentials", "token", "denied", "403", "invite", "sso"}},
+	{CategoryDeployment, []string{"deploy", "deployment", "deploying", "release", "rollout", "rollback", "pipeline", "ci", "build", "spinnaker", "argocd", "helm"}},
+	{CategoryHowTo, []string{"how", "where", "guide", "docs", "documentation", "setup", "configure", "example", "tutorial"}},
+}
+
+// categoryClassifierPrompt asks the LLM to pick exactly one category
+const categoryClassifierPrompt = "Classify the internal support question into exactly one category: %s. " +
+	"Reply with the category name only."
+
+// Categorize assigns a category to inquiry text using the configured
+// classifier. The LLM classifier falls back to the rules when it fails or
+// returns an unknown category.
+func (s *InquiryService) Categorize(ctx context.Context, text string) string {
+	if s.cfg().InquiryClassifier == "llm" {
+		category, err := s.categorizeWithLLM(ctx, text)
+		if err == nil {
+			return category
+		}
+		loggerFrom(ctx).WithError(err).Warn("LLM categorization failed, falling back to rules")
+	}
+
+	return categorizeByRules(text)
+}
+
+// categorizeByRules picks the category whose keywords appear most often in text,
+// breaking ties by rule order
+func categorizeByRules(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+
+	best, bestCount := CategoryOther, 0
+	for _, rule := range categoryRules {
+		count := 0
+		for _, word := range words {
+			for _, keyword := range rule.keywords {
+				if word == keyword {
+					count++
+				}
+			}
+		}
+		if count > bestCount {
+			best, bestCount = rule.category, count
+		}
+	}
+
+	return best
+}
+
+// categorizeWithLLM asks the LLM for a category and validates the answer
+func (s *InquiryService) categorizeWithLLM(ctx context.Context, text string) (string, error) {
+	categories := make([]string, 0, len(categoryRules)+1)
+	for _, rule := range categoryRules {
+		categories = append(categories, rule.category)
+	}
+	categories = append(categories, CategoryOther)
+
+	answer, err := s.llm.Complete(ctx, fmt.Sprintf(categoryClassifierPrompt, strings.Join(categories, ", ")), text)
+	if err != nil {
+		return "", err
+	}
+
+	answer = strings.ToLower(strings.Trim(strings.TrimSpace(answer), ".\"'`"))
+	for _, category := range categories {
+		if answer == category {
+			return category, nil
+		}
+	}
+
+	return "", fmt.Errorf("unknown category %q", answer)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestCategorizeByRules(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{text: "payment-api is down and returning 503 errors, anyone looking?", expected: CategoryIncident},
+		{text: "Can someone grant me access to the prod GCP project? I get permission denied", expected: CategoryAccess},
+		{text: "How do I rollback a deploy in Spinnaker?", expected: CategoryDeployment},
+		{text: "Where can I find the docs for setting up local dev?", expected: CategoryHowTo},
+		{text: "Our CI pipeline keeps failing on the build step", expected: CategoryDeployment},
+		{text: "Thanks everyone, see you tomorrow!", expected: CategoryOther},
+		{text: "", expected: CategoryOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			if got := categorizeByRules(tt.text); got != tt.expected {
+				t.Errorf("Expected category '%s', got '%s'", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCategorize_LLM(t *testing.T) {
+	tests := []struct {
+		name     string
+		answer   string
+		expected string
+	}{
+		{name: "valid answer", answer: "Access.", expected: CategoryAccess},
+		{name: "unknown answer falls back to rules", answer: "billing", expected: CategoryDeployment},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.InquiryClassifier = "llm"
+			llm := newFakeLLM(t, cfg, tt.answer)
+			service := newTestInquiryService(cfg, nil)
+
+			if got := service.Categorize(context.Background(), "How do I rollback a deploy?"); got != tt.expected {
+				t.Errorf("Expected category '%s', got '%s'", tt.expected, got)
+			}
+			if llm.requestCount() != 1 {
+				t.Errorf("Expected 1 classification request, got %d", llm.requestCount())
+			}
+		})
+	}
+}
+
+func TestCategorize_LLMCancelled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.InquiryClassifier = "llm"
+	llm := newFakeLLM(t, cfg, "Access")
+	service := newTestInquiryService(cfg, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if got := service.Categorize(ctx, "How do I rollback a deploy?"); got != CategoryDeployment {
+		t.Errorf("Expected the rules category once the inquiry is cancelled, got '%s'", got)
+	}
+	if llm.requestCount() != 0 {
+		t.Errorf("Expected no classification request for a cancelled inquiry, got %d", llm.requestCount())
+	}
+}
+
+func TestCountByCategory(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestInquiryService(config.LoadTestConfig(), db)
+
+	db.Create(&storage.Inquiry{MessageID: "1", Category: CategoryIncident})
+	db.Create(&storage.Inquiry{MessageID: "2", Category: CategoryIncident})
+	db.Create(&storage.Inquiry{MessageID: "3", Category: CategoryAccess})
+	db.Create(&storage.Inquiry{MessageID: "4"})
+	old := &storage.Inquiry{MessageID: "5", Category: CategoryAccess}
+	db.Create(old)
+	db.Model(old).Update("created_at", time.Now().AddDate(0, 0, -30))
+
+	counts, err := service.CountByCategory(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("CountByCategory returned error: %v", err)
+	}
+
+	expected := map[string]int64{CategoryIncident: 2, CategoryAccess: 1, CategoryOther: 1}
+	if len(counts) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, counts)
+	}
+	for category, count := range expected {
+		if counts[category] != count {
+			t.Errorf("Expected %d %s inquiries, got %d", count, category, counts[category])
+		}
+	}
+}
@@ -1,13 +1,16 @@
 package services
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
 	"github.com/sirupsen/logrus"
@@ -15,18 +18,92 @@ import (
 
 // ConfluenceService handles Confluence API interactions
 type ConfluenceService struct {
-	client  *http.Client
-	config  *config.Config
-	baseURL string
+	// configMu guards client and config, which Reload replaces
+	configMu sync.RWMutex
+	client   *http.Client
+	config   *config.Config
+
+	// version is cloud, dc7 or dc8, or empty when unknown. It is resolved on
+	// first use rather than at startup so a slow Confluence doesn't hold up
+	// startup or reloads.
+	versionMu       sync.Mutex
+	version         string
+	versionResolved bool
+
+	// missingSpaces holds the configured spaces ValidateConnection found don't
+	// exist, which searches leave out
+	missingMu     sync.RWMutex
+	missingSpaces map[string]bool
+
+	// pageETagCache holds the last page GetPage fetched for each page ID, with
+	// the validators to fetch it again conditionally
+	pageCacheMu   sync.Mutex
+	pageETagCache map[string]cachedPage
+}
+
+// cachedPage is a page with the ETag and Last-Modified headers it was served with
+type cachedPage struct {
+	etag         string
+	lastModified string
+	page         ConfluencePage
+}
+
+// ErrConfluenceSpaceNotFound is returned by ValidateConnection when a configured space doesn't exist
+var ErrConfluenceSpaceNotFound = errors.New("confluence space not found")
+
+// Confluence deployment variants returned by AutoDetectVersion
+const (
+	ConfluenceCloud = "cloud"
+	ConfluenceDC7   = "dc7"
+	ConfluenceDC8   = "dc8"
+)
+
+// recentPagesLimit is the most recently modified pages SearchRecent returns
+const recentPagesLimit = 5
+
+// confluenceServerInfo is the subset of /rest/api/serverInfo used for version detection
+type confluenceServerInfo struct {
+	Version        string `json:"version"`
+	DeploymentType string `json:"deploymentType"`
 }
 
 // ConfluencePage represents a Confluence page
 type ConfluencePage struct {
-	ID      string `json:"id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	URL     string `json:"url"`
-	Author  string `json:"author"`
+	ID      string                `json:"id"`
+	Title   string                `json:"title"`
+	Content string                `json:"content"`
+	URL     string                `json:"url"`
+	Author  string                `json:"author"`
+	Version ConfluencePageVersion `json:"version"`
+	Space   ConfluencePageSpace   `json:"space"`
+}
+
+// confluenceComments is the subset of a child/comment response holding comment bodies
+type confluenceComments struct {
+	Results []struct {
+		Body struct {
+			Storage struct {
+				Value string `json:"value"`
+			} `json:"storage"`
+		} `json:"body"`
+	} `json:"results"`
+}
+
+// ConfluencePageVersion is the version of a page; Number increases with every
+// edit and By is who made it
+type ConfluencePageVersion struct {
+	Number int            `json:"number"`
+	By     ConfluenceUser `json:"by"`
+}
+
+// ConfluenceUser is the subset of a Confluence user used for attribution
+type ConfluenceUser struct {
+	DisplayName string `json:"displayName"`
+}
+
+// ConfluencePageSpace is the space a page belongs to
+type ConfluencePageSpace struct {
+	Key string `json:"key"`
 }
 
 // ConfluenceSearchResult represents search results from Confluence
@@ -37,76 +114,322 @@ type ConfluenceSearchResult struct {
 
 // NewConfluenceService creates a new Confluence service instance
 func NewConfluenceService(cfg *config.Config) *ConfluenceService {
-	return &ConfluenceService{
+	service := &ConfluenceService{
 		client: &http.Client{
-			Timeout: 15 * time.Second, // 15 second timeout for Confluence API calls
+			Timeout: cfg.ConfluenceTimeout,
 		},
-		config:  cfg,
-		baseURL: cfg.ConfluenceBaseURL,
+		config: cfg,
+	}
+
+	return service
+}
+
+// Reload switches the service to cfg, picking up the new base URL and timeout
+func (s *ConfluenceService) Reload(cfg *config.Config) {
+	s.configMu.Lock()
+	s.client = &http.Client{Timeout: cfg.ConfluenceTimeout}
+	s.config = cfg
+	s.configMu.Unlock()
+
+	s.versionMu.Lock()
+	s.version = ""
+	s.versionResolved = false
+	s.versionMu.Unlock()
+
+	s.pageCacheMu.Lock()
+	s.pageETagCache = nil
+	s.pageCacheMu.Unlock()
+}
+
+// httpClient returns the client for Confluence requests
+func (s *ConfluenceService) httpClient() *http.Client {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.client
+}
+
+// cfg returns the current configuration
+func (s *ConfluenceService) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// baseURL returns the Confluence URL API paths are relative to
+func (s *ConfluenceService) baseURL() string {
+	return s.cfg().ConfluenceBaseURL
+}
+
+// Version returns the Confluence deployment variant: cloud, dc7, dc8, or empty
+// when unknown. With CONFLUENCE_API_VERSION=auto the API is probed the first
+// time it is asked for, and again after a reload.
+func (s *ConfluenceService) Version() string {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+
+	if !s.versionResolved {
+		s.version = s.resolveVersion()
+		s.versionResolved = true
+	}
+	return s.version
+}
+
+// resolveVersion returns the version from ConfluenceAPIVersion, probing the API when it is "auto"
+func (s *ConfluenceService) resolveVersion() string {
+	cfg := s.cfg()
+	if cfg.ConfluenceAPIVersion != "auto" {
+		return cfg.ConfluenceAPIVersion
+	}
+	if cfg.ConfluenceBaseURL == "" || cfg.ConfluenceAPIToken == "" {
+		return ""
+	}
+
+	version, err := s.AutoDetectVersion()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to detect Confluence version, searching as Data Center")
+		return ""
+	}
+
+	logrus.WithField("version", version).Info("Detected Confluence version")
+	return version
+}
+
+// textField is the CQL field queries are matched against. Cloud's siteSearch
+// ranks like the site's own search box; Data Center only has text.
+func (s *ConfluenceService) textField() string {
+	if s.Version() == ConfluenceCloud {
+		return "siteSearch"
+	}
+	return "text"
+}
+
+// AutoDetectVersion probes the API to tell Confluence Cloud from Data Center 7
+// and 8. Data Center answers /rest/api/serverInfo with its version; Cloud
+// doesn't serve it, so a working /rest/api/space identifies Cloud instead.
+func (s *ConfluenceService) AutoDetectVersion() (string, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
+		return "", fmt.Errorf("missing Confluence configuration")
+	}
+
+	status, body, err := s.probe("/rest/api/serverInfo")
+	if err != nil {
+		return "", err
+	}
+
+	if status == http.StatusOK {
+		var info confluenceServerInfo
+		if err := json.Unmarshal(body, &info); err != nil {
+			return "", fmt.Errorf("failed to decode server info: %w", err)
+		}
+		if strings.EqualFold(info.DeploymentType, "cloud") {
+			return ConfluenceCloud, nil
+		}
+
+		major, _, _ := strings.Cut(info.Version, ".")
+		switch major {
+		case "7":
+			return ConfluenceDC7, nil
+		case "8", "9":
+			return ConfluenceDC8, nil
+		default:
+			return "", fmt.Errorf("unsupported Confluence version %q", info.Version)
+		}
+	}
+
+	status, _, err = s.probe("/rest/api/space?limit=1")
+	if err != nil {
+		return "", err
+	}
+	if status == http.StatusOK {
+		return ConfluenceCloud, nil
+	}
+
+	return "", fmt.Errorf("could not detect Confluence version: space probe returned %d", status)
+}
+
+// probe performs an authenticated GET against path and returns the status code and body
+func (s *ConfluenceService) probe(path string) (int, []byte, error) {
+	req, err := http.NewRequest("GET", s.baseURL()+path, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to connect to Confluence: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithError(err).Error("failed to close response body")
+		}
+	}()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to read response: %w", err)
 	}
+
+	return resp.StatusCode, body, nil
 }
 
 // SearchPages searches for pages in Confluence
 func (s *ConfluenceService) SearchPages(query string) ([]ConfluencePage, error) {
-	if s.config.ConfluenceBaseURL == "" || s.config.ConfluenceAPIToken == "" {
-		logrus.Warn("missing Confluence configuration, skipping search")
+	pages, _, err := s.SearchPagesRaw(context.Background(), query)
+	return pages, err
+}
+
+// SearchPagesRaw searches for pages in Confluence and also returns the raw
+// response body for debugging. The request is abandoned when ctx is done.
+func (s *ConfluenceService) SearchPagesRaw(ctx context.Context, query string) ([]ConfluencePage, []byte, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
+		loggerFrom(ctx).Warn("missing Confluence configuration, skipping search")
+		return []ConfluencePage{}, nil, nil
+	}
+
+	return s.searchCQL(ctx, s.buildCQL(query), s.cfg().MaxSearchResults)
+}
+
+// searchSpaceRaw is SearchPagesRaw restricted to the space with key spaceKey
+func (s *ConfluenceService) searchSpaceRaw(ctx context.Context, query, spaceKey string) ([]ConfluencePage, []byte, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
+		loggerFrom(ctx).Warn("missing Confluence configuration, skipping search")
+		return []ConfluencePage{}, nil, nil
+	}
+
+	return s.searchCQL(ctx, s.buildSpaceCQL(query, []string{spaceKey}), s.cfg().MaxSearchResults)
+}
+
+// configuredSpaceKeys returns ConfluenceSpaceKeys, or ConfluenceSpaceKey when none are listed
+func (s *ConfluenceService) configuredSpaceKeys() []string {
+	if len(s.cfg().ConfluenceSpaceKeys) > 0 {
+		return s.cfg().ConfluenceSpaceKeys
+	}
+	return []string{s.cfg().ConfluenceSpaceKey}
+}
+
+// spaceKeys returns the configured spaces to search, leaving out empty keys
+// and spaces that don't exist. Without any left, searches are site-wide.
+func (s *ConfluenceService) spaceKeys() []string {
+	s.missingMu.RLock()
+	defer s.missingMu.RUnlock()
+
+	var keys []string
+	for _, key := range s.configuredSpaceKeys() {
+		if key != "" && !s.missingSpaces[key] {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// spaceClause restricts a CQL search to spaceKeys, empty without any
+func spaceClause(spaceKeys []string) string {
+	switch len(spaceKeys) {
+	case 0:
+		return ""
+	case 1:
+		return fmt.Sprintf("space=%s", spaceKeys[0])
+	default:
+		return fmt.Sprintf("space in (%s)", strings.Join(spaceKeys, ","))
+	}
+}
+
+// withSpaceClause prefixes clause with the restriction to spaceKeys
+func withSpaceClause(spaceKeys []string, clause string) string {
+	if space := spaceClause(spaceKeys); space != "" {
+		return space + " AND " + clause
+	}
+	return clause
+}
+
+// SearchRecent returns up to recentPagesLimit pages in the configured space
+// modified in the last daysBack days, most recently modified first
+func (s *ConfluenceService) SearchRecent(daysBack int) ([]ConfluencePage, error) {
+	return s.searchRecent(context.Background(), daysBack)
+}
+
+// searchRecent is SearchRecent, abandoned when ctx is done
+func (s *ConfluenceService) searchRecent(ctx context.Context, daysBack int) ([]ConfluencePage, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
 		return []ConfluencePage{}, nil
 	}
 
+	var spaceKeys []string
+	for _, key := range s.spaceKeys() {
+		if key == s.cfg().ConfluenceSpaceKey {
+			spaceKeys = append(spaceKeys, key)
+		}
+	}
+	cql := withSpaceClause(spaceKeys, fmt.Sprintf("lastModified >= now(\"-%dd\") ORDER BY lastModified DESC", daysBack))
+	pages, _, err := s.searchCQL(ctx, cql, recentPagesLimit)
+	return pages, err
+}
+
+// searchCQL runs a CQL content search returning up to limit pages, and the raw response body
+func (s *ConfluenceService) searchCQL(ctx context.Context, cql string, limit int) ([]ConfluencePage, []byte, error) {
 	// Build the search URL
-	searchURL := fmt.Sprintf("%s/rest/api/content/search", s.baseURL)
+	searchURL := fmt.Sprintf("%s/rest/api/content/search", s.baseURL())
 
 	// Build query parameters
 	params := url.Values{}
-	// Sanitize and escape the query to prevent CQL injection
-	sanitizedQuery := s.sanitizeCQLQuery(query)
-	params.Add("cql", fmt.Sprintf("space=%s AND text ~ \"%s\"", s.config.ConfluenceSpaceKey, sanitizedQuery))
-	params.Add("limit", fmt.Sprintf("%d", s.config.MaxSearchResults))
+	params.Add("cql", cql)
+	params.Add("limit", fmt.Sprintf("%d", limit))
 	params.Add("expand", "body.storage,version,space")
 
 	// Create request
-	req, err := http.NewRequest("GET", searchURL+"?"+params.Encode(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL+"?"+params.Encode(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add authentication
-	req.SetBasicAuth(s.config.ConfluenceUsername, s.config.ConfluenceAPIToken)
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
 	req.Header.Set("Accept", "application/json")
 
 	// Execute request
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, nil, fmt.Errorf("failed to execute request: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			logrus.WithError(err).Error("failed to close response body")
+			loggerFrom(ctx).WithError(err).Error("failed to close response body")
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		logrus.WithFields(logrus.Fields{
+		loggerFrom(ctx).WithFields(logrus.Fields{
 			"status_code": resp.StatusCode,
 			"body":        string(body),
 		}).Error("Confluence API error")
-		return nil, fmt.Errorf("confluence API error: %d", resp.StatusCode)
+		return nil, nil, fmt.Errorf("confluence API error: %d", resp.StatusCode)
+	}
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	// Parse response
 	var searchResult ConfluenceSearchResult
-	if err := json.NewDecoder(resp.Body).Decode(&searchResult); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := json.Unmarshal(raw, &searchResult); err != nil {
+		return nil, nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Process results
 	pages := make([]ConfluencePage, 0, len(searchResult.Results))
 	for _, result := range searchResult.Results {
 		page := ConfluencePage{
-			ID:    result.ID,
-			Title: result.Title,
-			URL:   fmt.Sprintf("%s/pages/viewpage.action?pageId=%s", s.baseURL, result.ID),
+			ID:      result.ID,
+			Title:   result.Title,
+			URL:     fmt.Sprintf("%s/pages/viewpage.action?pageId=%s", s.baseURL(), result.ID),
+			Author:  result.Version.By.DisplayName,
+			Version: result.Version,
+			Space:   result.Space,
 		}
 
 		// Extract content from the body if available
@@ -117,17 +440,19 @@ func (s *ConfluenceService) SearchPages(query string) ([]ConfluencePage, error)
 		pages = append(pages, page)
 	}
 
-	return pages, nil
+	return pages, raw, nil
 }
 
-// GetPage retrieves a specific page from Confluence
+// GetPage retrieves a specific page from Confluence. Pages fetched before are
+// requested conditionally with If-None-Match and If-Modified-Since, and the
+// cached copy is returned when Confluence answers 304 Not Modified.
 func (s *ConfluenceService) GetPage(pageID string) (*ConfluencePage, error) {
-	if s.config.ConfluenceBaseURL == "" || s.config.ConfluenceAPIToken == "" {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
 		return nil, fmt.Errorf("missing Confluence configuration")
 	}
 
 	// Build the page URL
-	pageURL := fmt.Sprintf("%s/rest/api/content/%s", s.baseURL, pageID)
+	pageURL := fmt.Sprintf("%s/rest/api/content/%s", s.baseURL(), pageID)
 
 	// Build query parameters
 	params := url.Values{}
@@ -140,11 +465,21 @@ func (s *ConfluenceService) GetPage(pageID string) (*ConfluencePage, error) {
 	}
 
 	// Add authentication
-	req.SetBasicAuth(s.config.ConfluenceUsername, s.config.ConfluenceAPIToken)
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
 	req.Header.Set("Accept", "application/json")
 
+	cached, ok := s.cachedPage(pageID)
+	if ok {
+		if cached.etag != "" {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+		if cached.lastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.lastModified)
+		}
+	}
+
 	// Execute request
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute request: %w", err)
 	}
@@ -154,6 +489,10 @@ func (s *ConfluenceService) GetPage(pageID string) (*ConfluencePage, error) {
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotModified && ok {
+		page := cached.page
+		return &page, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("confluence API error: %d", resp.StatusCode)
 	}
@@ -164,17 +503,146 @@ func (s *ConfluenceService) GetPage(pageID string) (*ConfluencePage, error) {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
-	// Set URL
-	page.URL = fmt.Sprintf("%s/pages/viewpage.action?pageId=%s", s.baseURL, page.ID)
+	// Set URL and the last editor as author
+	page.URL = fmt.Sprintf("%s/pages/viewpage.action?pageId=%s", s.baseURL(), page.ID)
+	page.Author = page.Version.By.DisplayName
 
 	// Extract content text
 	if page.Content != "" {
 		page.Content = s.extractContentText(page.Content)
 	}
 
+	s.cachePage(pageID, resp.Header, page)
 	return &page, nil
 }
 
+// cachedPage returns the page GetPage last fetched for pageID
+func (s *ConfluenceService) cachedPage(pageID string) (cachedPage, bool) {
+	s.pageCacheMu.Lock()
+	defer s.pageCacheMu.Unlock()
+	cached, ok := s.pageETagCache[pageID]
+	return cached, ok
+}
+
+// cachePage keeps page for conditional requests when header has an ETag or
+// Last-Modified validator, and forgets the cached copy otherwise
+func (s *ConfluenceService) cachePage(pageID string, header http.Header, page ConfluencePage) {
+	etag, lastModified := header.Get("ETag"), header.Get("Last-Modified")
+
+	s.pageCacheMu.Lock()
+	defer s.pageCacheMu.Unlock()
+	if etag == "" && lastModified == "" {
+		delete(s.pageETagCache, pageID)
+		return
+	}
+	if s.pageETagCache == nil {
+		s.pageETagCache = make(map[string]cachedPage)
+	}
+	s.pageETagCache[pageID] = cachedPage{etag: etag, lastModified: lastModified, page: page}
+}
+
+// GetPageVersion returns the current version number of a page
+func (s *ConfluenceService) GetPageVersion(pageID string) (int, error) {
+	version, err := s.pageVersion(pageID)
+	if err != nil {
+		return 0, err
+	}
+	return version.Number, nil
+}
+
+// GetPageLastModifiedBy returns the display name of whoever last edited a page
+func (s *ConfluenceService) GetPageLastModifiedBy(pageID string) (string, error) {
+	version, err := s.pageVersion(pageID)
+	if err != nil {
+		return "", err
+	}
+	return version.By.DisplayName, nil
+}
+
+// pageVersion returns the current version of a page
+func (s *ConfluenceService) pageVersion(pageID string) (ConfluencePageVersion, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
+		return ConfluencePageVersion{}, fmt.Errorf("missing Confluence configuration")
+	}
+
+	pageURL := fmt.Sprintf("%s/rest/api/content/%s?expand=version", s.baseURL(), url.PathEscape(pageID))
+	req, err := http.NewRequest("GET", pageURL, nil)
+	if err != nil {
+		return ConfluencePageVersion{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return ConfluencePageVersion{}, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithError(err).Error("failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return ConfluencePageVersion{}, fmt.Errorf("confluence API error: %d", resp.StatusCode)
+	}
+
+	var page ConfluencePage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return ConfluencePageVersion{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return page.Version, nil
+}
+
+// GetPageComments returns the plain text of the comments on a page, oldest first
+func (s *ConfluenceService) GetPageComments(pageID string) ([]string, error) {
+	return s.pageComments(context.Background(), pageID)
+}
+
+// pageComments is GetPageComments, abandoned when ctx is done
+func (s *ConfluenceService) pageComments(ctx context.Context, pageID string) ([]string, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
+		return nil, fmt.Errorf("missing Confluence configuration")
+	}
+
+	commentsURL := fmt.Sprintf("%s/rest/api/content/%s/child/comment?expand=body.storage", s.baseURL(), url.PathEscape(pageID))
+	req, err := http.NewRequestWithContext(ctx, "GET", commentsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			loggerFrom(ctx).WithError(err).Error("failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("confluence API error: %d", resp.StatusCode)
+	}
+
+	var response confluenceComments
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	comments := make([]string, 0, len(response.Results))
+	for _, comment := range response.Results {
+		if text := s.extractContentText(comment.Body.Storage.Value); text != "" {
+			comments = append(comments, text)
+		}
+	}
+
+	return comments, nil
+}
+
 // extractContentText extracts plain text from Confluence storage format
 func (s *ConfluenceService) extractContentText(content string) string {
 	// This is a simplified text extraction
@@ -211,26 +679,124 @@ func (s *ConfluenceService) extractContentText(content string) string {
 	return cleanText
 }
 
-// ValidateConnection validates the Confluence connection
+// confluenceAncestor identifies the parent of a new page
+type confluenceAncestor struct {
+	ID string `json:"id"`
+}
+
+// confluenceNewPage is the request body creating a page from storage-format content
+type confluenceNewPage struct {
+	Type      string               `json:"type"`
+	Title     string               `json:"title"`
+	Space     ConfluencePageSpace  `json:"space"`
+	Ancestors []confluenceAncestor `json:"ancestors,omitempty"`
+	Body      struct {
+		Storage struct {
+			Value          string `json:"value"`
+			Representation string `json:"representation"`
+		} `json:"storage"`
+	} `json:"body"`
+}
+
+// CreatePage creates a page titled title in spaceKey from content in
+// Confluence storage format, under parentID unless it is empty
+func (s *ConfluenceService) CreatePage(ctx context.Context, spaceKey, parentID, title, content string) (*ConfluencePage, error) {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
+		return nil, fmt.Errorf("missing Confluence configuration")
+	}
+
+	newPage := confluenceNewPage{Type: "page", Title: title, Space: ConfluencePageSpace{Key: spaceKey}}
+	if parentID != "" {
+		newPage.Ancestors = []confluenceAncestor{{ID: parentID}}
+	}
+	newPage.Body.Storage.Value = content
+	newPage.Body.Storage.Representation = "storage"
+
+	body, err := json.Marshal(newPage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode page: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.baseURL()+"/rest/api/content", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithError(err).Error("failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return nil, fmt.Errorf("confluence API error: %d", resp.StatusCode)
+	}
+
+	var page ConfluencePage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	page.URL = fmt.Sprintf("%s/pages/viewpage.action?pageId=%s", s.baseURL(), page.ID)
+
+	return &page, nil
+}
+
+// ValidateConnection validates the Confluence connection and that every
+// configured space exists. Spaces that don't are left out of searches, which
+// become site-wide when none are left, and reported with ErrConfluenceSpaceNotFound.
 func (s *ConfluenceService) ValidateConnection() error {
-	if s.config.ConfluenceBaseURL == "" || s.config.ConfluenceAPIToken == "" {
+	if s.cfg().ConfluenceBaseURL == "" || s.cfg().ConfluenceAPIToken == "" {
 		return fmt.Errorf("missing Confluence configuration")
 	}
 
-	// Test connection by getting space info
-	spaceURL := fmt.Sprintf("%s/rest/api/space/%s", s.baseURL, s.config.ConfluenceSpaceKey)
+	missing := make(map[string]bool)
+	var missingKeys []string
+	for _, spaceKey := range s.configuredSpaceKeys() {
+		if spaceKey == "" {
+			continue
+		}
+		exists, err := s.spaceExists(spaceKey)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			missing[spaceKey] = true
+			missingKeys = append(missingKeys, spaceKey)
+		}
+	}
+
+	s.missingMu.Lock()
+	s.missingSpaces = missing
+	s.missingMu.Unlock()
+
+	if len(missingKeys) > 0 {
+		return fmt.Errorf("%w: %s", ErrConfluenceSpaceNotFound, strings.Join(missingKeys, ", "))
+	}
+	return nil
+}
+
+// spaceExists reports whether the space with key spaceKey exists
+func (s *ConfluenceService) spaceExists(spaceKey string) (bool, error) {
+	spaceURL := fmt.Sprintf("%s/rest/api/space/%s", s.baseURL(), url.PathEscape(spaceKey))
 
 	req, err := http.NewRequest("GET", spaceURL, nil)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.SetBasicAuth(s.config.ConfluenceUsername, s.config.ConfluenceAPIToken)
+	req.SetBasicAuth(s.cfg().ConfluenceUsername, s.cfg().ConfluenceAPIToken)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to connect to Confluence: %w", err)
+		return false, fmt.Errorf("failed to connect to Confluence: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -238,18 +804,62 @@ func (s *ConfluenceService) ValidateConnection() error {
 		}
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("invalid Confluence credentials or space: %d", resp.StatusCode)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("invalid Confluence credentials: %d", resp.StatusCode)
 	}
+}
 
-	return nil
+// buildCQL builds the CQL search clause over the configured spaces
+func (s *ConfluenceService) buildCQL(query string) string {
+	return s.buildSpaceCQL(query, s.spaceKeys())
+}
+
+// buildSpaceCQL builds the CQL search clause over spaceKeys, or the whole site
+// without any, for the configured query mode: "phrase" matches the whole
+// query, "any" OR-joins keywords and "all" AND-joins them
+func (s *ConfluenceService) buildSpaceCQL(query string, spaceKeys []string) string {
+	// Sanitize each keyword individually to prevent CQL injection
+	var keywords []string
+	for _, word := range strings.Fields(query) {
+		if keyword := s.sanitizeCQLQuery(word); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	var joiner string
+	switch s.cfg().ConfluenceQueryMode {
+	case "any":
+		joiner = " OR "
+	case "all":
+		joiner = " AND "
+	case "phrase", "":
+	default:
+		logrus.WithField("mode", s.cfg().ConfluenceQueryMode).Warn("Unknown Confluence query mode, using phrase")
+	}
+
+	field := s.textField()
+	if joiner == "" || len(keywords) < 2 {
+		return withSpaceClause(spaceKeys, fmt.Sprintf("%s ~ \"%s\"", field, s.sanitizeCQLQuery(query)))
+	}
+
+	clauses := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		clauses = append(clauses, fmt.Sprintf("%s ~ \"%s\"", field, keyword))
+	}
+
+	return withSpaceClause(spaceKeys, fmt.Sprintf("(%s)", strings.Join(clauses, joiner)))
 }
 
 // sanitizeCQLQuery sanitizes a query string to prevent CQL injection attacks
 func (s *ConfluenceService) sanitizeCQLQuery(query string) string {
 	// Remove or escape potentially dangerous CQL characters and operators
 	// CQL special characters: AND, OR, NOT, (, ), ", ', \, ~, *, ?, [, ], {, }
-	
+
 	// Replace potential CQL operators with spaces to avoid injection
 	dangerous := []string{
 		" AND ", " OR ", " NOT ",
@@ -257,20 +867,20 @@ func (s *ConfluenceService) sanitizeCQLQuery(query string) string {
 		"\"", "'", "\\",
 		"~", "*", "?",
 	}
-	
+
 	sanitized := query
 	for _, char := range dangerous {
 		sanitized = strings.ReplaceAll(sanitized, char, " ")
 	}
-	
+
 	// Remove multiple spaces and trim
 	words := strings.Fields(sanitized)
 	sanitized = strings.Join(words, " ")
-	
+
 	// Limit length to prevent extremely long queries
 	if len(sanitized) > 100 {
 		sanitized = sanitized[:100]
 	}
-	
+
 	return sanitized
 }
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// pageWithEditor is a search result and page body last edited by Ana Smith
+const pageWithEditor = `{"id": "P1", "title": "Deploying", "version": {"number": 3, "by": {"displayName": "Ana Smith"}}}`
+
+// newAuthorSearchService serves pageWithEditor from a fake Confluence
+func newAuthorSearchService(t *testing.T) *SearchService {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/rest/api/content/search":
+			_, _ = w.Write([]byte(`{"results": [` + pageWithEditor + `], "size": 1}`))
+		case "/rest/api/content/P1":
+			_, _ = w.Write([]byte(pageWithEditor))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	cfg.ConfluenceAPIVersion = ConfluenceDC8
+
+	return NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+}
+
+func TestSearchConfluence_AuthorFromLastEditor(t *testing.T) {
+	service := newAuthorSearchService(t)
+
+	results, err := service.searchConfluence(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("searchConfluence returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Author != "Ana Smith" {
+		t.Errorf("Expected the last editor as author, got %+v", results)
+	}
+}
+
+func TestGetPageLastModifiedBy(t *testing.T) {
+	service := newAuthorSearchService(t)
+
+	author, err := service.confluence.GetPageLastModifiedBy("P1")
+	if err != nil || author != "Ana Smith" {
+		t.Errorf("Expected Ana Smith, got %q (%v)", author, err)
+	}
+	if _, err := service.confluence.GetPageLastModifiedBy("missing"); err == nil {
+		t.Error("Expected error for a missing page")
+	}
+
+	page, err := service.confluence.GetPage("P1")
+	if err != nil || page.Author != "Ana Smith" {
+		t.Errorf("Expected GetPage to set the author, got %+v (%v)", page, err)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newCommentedConfluence serves a search returning page P1 and the given comments response for it
+func newCommentedConfluence(t *testing.T, commentsStatus int, comments string) *config.Config {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rest/api/content/search":
+			_, _ = w.Write([]byte(`{"results": [{"id": "P1", "title": "Deploying", "content": "<p>Run make deploy</p>"}]}`))
+		case "/rest/api/content/P1/child/comment":
+			if r.URL.Query().Get("expand") != "body.storage" {
+				t.Errorf("Expected comment bodies to be expanded, got %q", r.URL.RawQuery)
+			}
+			w.WriteHeader(commentsStatus)
+			_, _ = w.Write([]byte(comments))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	return cfg
+}
+
+const testPageComments = `{"results": [
+	{"body": {"storage": {"value": "<p>make deploy is deprecated, use <strong>deployctl</strong></p>"}}},
+	{"body": {"storage": {"value": "<p></p>"}}}
+]}`
+
+func TestGetPageComments(t *testing.T) {
+	cfg := newCommentedConfluence(t, http.StatusOK, testPageComments)
+
+	comments, err := NewConfluenceService(cfg).GetPageComments("P1")
+	if err != nil {
+		t.Fatalf("GetPageComments returned error: %v", err)
+	}
+	if len(comments) != 1 || !strings.Contains(comments[0], "deployctl") || strings.Contains(comments[0], "<") {
+		t.Errorf("Expected one plain-text comment, got %q", comments)
+	}
+}
+
+func TestSearchConfluence_IncludesPageComments(t *testing.T) {
+	tests := []struct {
+		name           string
+		include        bool
+		commentsStatus int
+		wantComments   bool
+	}{
+		{name: "enabled", include: true, commentsStatus: http.StatusOK, wantComments: true},
+		{name: "disabled", include: false, commentsStatus: http.StatusOK},
+		{name: "comments unavailable", include: true, commentsStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newCommentedConfluence(t, tt.commentsStatus, testPageComments)
+			cfg.IncludePageComments = tt.include
+			service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+			results, err := service.searchConfluence(context.Background(), "deploy", 1)
+			if err != nil {
+				t.Fatalf("searchConfluence returned error: %v", err)
+			}
+			if len(results) != 1 {
+				t.Fatalf("Expected 1 result, got %d", len(results))
+			}
+
+			content := results[0].Content
+			if !strings.HasPrefix(content, "Run make deploy") {
+				t.Errorf("Expected the page content first, got %q", content)
+			}
+			if strings.Contains(content, "deployctl") != tt.wantComments {
+				t.Errorf("Expected comments included: %v, got %q", tt.wantComments, content)
+			}
+			if strings.Contains(results[0].NormalizedContent, "deployctl") != tt.wantComments {
+				t.Errorf("Expected comments to be scored: %v, got %q", tt.wantComments, results[0].NormalizedContent)
+			}
+		})
+	}
+}
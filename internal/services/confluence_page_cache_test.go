@@ -0,0 +1,74 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestGetPage_ConditionalRequest(t *testing.T) {
+	var (
+		mu          sync.Mutex
+		conditional []string
+		full        int
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/rest/api/content/42" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		conditional = append(conditional, r.Header.Get("If-None-Match")+"|"+r.Header.Get("If-Modified-Since"))
+		if r.Header.Get("If-None-Match") == `"v7"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full++
+		w.Header().Set("ETag", `"v7"`)
+		w.Header().Set("Last-Modified", "Mon, 05 Oct 2026 10:00:00 GMT")
+		_, _ = w.Write([]byte(`{"id": "42", "title": "Deploy guide", "_links": {"webui": "/spaces/DOCS/pages/42"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	cfg.ConfluenceAPIVersion = ConfluenceDC8
+	service := NewConfluenceService(cfg)
+
+	first, err := service.GetPage("42")
+	if err != nil {
+		t.Fatalf("GetPage returned error: %v", err)
+	}
+	second, err := service.GetPage("42")
+	if err != nil {
+		t.Fatalf("GetPage returned error on 304: %v", err)
+	}
+
+	if *second != *first || second.Title != "Deploy guide" {
+		t.Errorf("Expected the cached page on 304, got %+v, want %+v", second, first)
+	}
+	if second == first {
+		t.Error("Expected a copy of the cached page, not the same pointer")
+	}
+	if full != 1 {
+		t.Errorf("Expected the page body to be downloaded once, got %d", full)
+	}
+	want := []string{"|", `"v7"|Mon, 05 Oct 2026 10:00:00 GMT`}
+	if len(conditional) != 2 || conditional[0] != want[0] || conditional[1] != want[1] {
+		t.Errorf("Expected an unconditional then a conditional request, got %q", conditional)
+	}
+
+	// Reloading the configuration forgets cached pages
+	service.Reload(cfg)
+	if _, err := service.GetPage("42"); err != nil {
+		t.Fatalf("GetPage returned error after reload: %v", err)
+	}
+	if full != 2 {
+		t.Errorf("Expected the page to be downloaded again after reload, got %d downloads", full)
+	}
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newFakeConfluenceSpaces serves the spaces in existing and records the CQL of every search
+func newFakeConfluenceSpaces(t *testing.T, cfg *config.Config, existing ...string) *[]string {
+	t.Helper()
+
+	var (
+		mu      sync.Mutex
+		queries []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/rest/api/space/"):
+			key := strings.TrimPrefix(r.URL.Path, "/rest/api/space/")
+			for _, space := range existing {
+				if space == key {
+					_, _ = w.Write([]byte(`{"key": "` + key + `"}`))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/rest/api/content/search":
+			mu.Lock()
+			queries = append(queries, r.URL.Query().Get("cql"))
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"results": [{"id": "1", "title": "Deploy guide"}], "size": 1}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	cfg.ConfluenceAPIVersion = ConfluenceDC8
+	return &queries
+}
+
+func TestValidateConnection_SpaceExists(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	queries := newFakeConfluenceSpaces(t, cfg, "DOCS")
+	service := NewConfluenceService(cfg)
+
+	if err := service.ValidateConnection(); err != nil {
+		t.Fatalf("ValidateConnection returned error: %v", err)
+	}
+	if _, err := service.SearchPages("deploy"); err != nil {
+		t.Fatalf("SearchPages returned error: %v", err)
+	}
+	if len(*queries) != 1 || !strings.HasPrefix((*queries)[0], "space=DOCS AND ") {
+		t.Errorf("Expected the search to stay in DOCS, got %v", *queries)
+	}
+}
+
+func TestValidateConnection_MissingSpaceFallsBackToSiteWide(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	queries := newFakeConfluenceSpaces(t, cfg)
+	service := NewConfluenceService(cfg)
+
+	err := service.ValidateConnection()
+	if !errors.Is(err, ErrConfluenceSpaceNotFound) || !strings.Contains(err.Error(), "DOCS") {
+		t.Fatalf("Expected ErrConfluenceSpaceNotFound naming DOCS, got %v", err)
+	}
+
+	pages, err := service.SearchPages("deploy")
+	if err != nil {
+		t.Fatalf("SearchPages returned error: %v", err)
+	}
+	if len(pages) != 1 {
+		t.Errorf("Expected the site-wide search to return the page, got %d", len(pages))
+	}
+	if len(*queries) != 1 || (*queries)[0] != `text ~ "deploy"` {
+		t.Errorf("Expected a site-wide search, got %v", *queries)
+	}
+}
+
+func TestValidateConnection_SearchesRemainingSpaces(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceSpaceKeys = []string{"DOCS", "GONE", "OPS"}
+	queries := newFakeConfluenceSpaces(t, cfg, "DOCS", "OPS")
+	service := NewConfluenceService(cfg)
+
+	if err := service.ValidateConnection(); !errors.Is(err, ErrConfluenceSpaceNotFound) {
+		t.Fatalf("Expected ErrConfluenceSpaceNotFound, got %v", err)
+	}
+	if _, _, err := service.SearchPagesRaw(context.Background(), "deploy"); err != nil {
+		t.Fatalf("SearchPagesRaw returned error: %v", err)
+	}
+	if len(*queries) != 1 || !strings.HasPrefix((*queries)[0], "space in (DOCS,OPS) AND ") {
+		t.Errorf("Expected the search to leave out GONE, got %v", *queries)
+	}
+}
+
+func TestBuildCQL_EmptySpaceKey(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceSpaceKey = ""
+	service := &ConfluenceService{config: cfg}
+
+	if got := service.buildCQL("deploy"); got != `text ~ "deploy"` {
+		t.Errorf("Expected a site-wide search without a space key, got %q", got)
+	}
+}
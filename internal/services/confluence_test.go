@@ -8,7 +8,7 @@ import (
 
 func TestSanitizeCQLQuery(t *testing.T) {
 	service := &ConfluenceService{
-		config: &config.Config{},
+		config: config.LoadTestConfig(),
 	}
 
 	tests := []struct {
@@ -80,7 +80,7 @@ func TestSanitizeCQLQuery(t *testing.T) {
 
 func TestSanitizeCQLQuery_Length(t *testing.T) {
 	service := &ConfluenceService{
-		config: &config.Config{},
+		config: config.LoadTestConfig(),
 	}
 
 	// Test that very long queries are truncated to 100 characters
@@ -97,7 +97,7 @@ func TestSanitizeCQLQuery_Length(t *testing.T) {
 
 func TestSanitizeCQLQuery_PreventInjection(t *testing.T) {
 	service := &ConfluenceService{
-		config: &config.Config{},
+		config: config.LoadTestConfig(),
 	}
 
 	// Test potential CQL injection attempts
@@ -142,4 +142,62 @@ func containsInMiddle(s, substr string) bool {
 		}
 	}
 	return false
-}
\ No newline at end of file
+}
+func TestBuildCQL(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		query    string
+		expected string
+	}{
+		{
+			name:     "phrase mode",
+			mode:     "phrase",
+			query:    "deploy service production",
+			expected: `space=DOCS AND text ~ "deploy service production"`,
+		},
+		{
+			name:     "any mode",
+			mode:     "any",
+			query:    "deploy service production",
+			expected: `space=DOCS AND (text ~ "deploy" OR text ~ "service" OR text ~ "production")`,
+		},
+		{
+			name:     "all mode",
+			mode:     "all",
+			query:    "deploy service production",
+			expected: `space=DOCS AND (text ~ "deploy" AND text ~ "service" AND text ~ "production")`,
+		},
+		{
+			name:     "any mode single keyword",
+			mode:     "any",
+			query:    "deploy",
+			expected: `space=DOCS AND text ~ "deploy"`,
+		},
+		{
+			name:     "any mode sanitizes keywords",
+			mode:     "any",
+			query:    `deploy" (service)`,
+			expected: `space=DOCS AND (text ~ "deploy" OR text ~ "service")`,
+		},
+		{
+			name:     "unknown mode falls back to phrase",
+			mode:     "fuzzy",
+			query:    "deploy service",
+			expected: `space=DOCS AND text ~ "deploy service"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ConfluenceQueryMode = tt.mode
+			service := &ConfluenceService{config: cfg}
+
+			result := service.buildCQL(tt.query)
+			if result != tt.expected {
+				t.Errorf("buildCQL(%q) = %q, expected %q", tt.query, result, tt.expected)
+			}
+		})
+	}
+}
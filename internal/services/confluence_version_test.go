@@ -0,0 +1,162 @@
+package services
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newFakeConfluence serves serverInfo and space probes with the given status
+// and body, counting the probes it receives
+func newFakeConfluence(t *testing.T, serverInfoStatus int, serverInfo string, spaceStatus int) (*config.Config, *int32) {
+	t.Helper()
+
+	var probes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		switch r.URL.Path {
+		case "/rest/api/serverInfo":
+			w.WriteHeader(serverInfoStatus)
+			_, _ = w.Write([]byte(serverInfo))
+		case "/rest/api/space":
+			w.WriteHeader(spaceStatus)
+			_, _ = w.Write([]byte(`{"results":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceUsername = "bot@example.com"
+	cfg.ConfluenceAPIToken = "token"
+	return cfg, &probes
+}
+
+func TestAutoDetectVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		serverInfoStatus int
+		serverInfo       string
+		spaceStatus      int
+		expected         string
+		wantErr          bool
+	}{
+		{
+			name:             "cloud without server info",
+			serverInfoStatus: http.StatusNotFound,
+			spaceStatus:      http.StatusOK,
+			expected:         ConfluenceCloud,
+		},
+		{
+			name:             "cloud deployment type",
+			serverInfoStatus: http.StatusOK,
+			serverInfo:       `{"version":"1000.0.0","deploymentType":"Cloud"}`,
+			expected:         ConfluenceCloud,
+		},
+		{
+			name:             "data center 7",
+			serverInfoStatus: http.StatusOK,
+			serverInfo:       `{"version":"7.19.16","deploymentType":"Server"}`,
+			expected:         ConfluenceDC7,
+		},
+		{
+			name:             "data center 8",
+			serverInfoStatus: http.StatusOK,
+			serverInfo:       `{"version":"8.5.3","deploymentType":"Server"}`,
+			expected:         ConfluenceDC8,
+		},
+		{
+			name:             "unsupported version",
+			serverInfoStatus: http.StatusOK,
+			serverInfo:       `{"version":"6.15.0","deploymentType":"Server"}`,
+			wantErr:          true,
+		},
+		{
+			name:             "both probes fail",
+			serverInfoStatus: http.StatusNotFound,
+			spaceStatus:      http.StatusUnauthorized,
+			wantErr:          true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, _ := newFakeConfluence(t, tt.serverInfoStatus, tt.serverInfo, tt.spaceStatus)
+			service := &ConfluenceService{client: http.DefaultClient, config: cfg}
+
+			version, err := service.AutoDetectVersion()
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got version %q", version)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if version != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, version)
+			}
+		})
+	}
+}
+
+func TestConfluenceVersion_ResolvedOnFirstUse(t *testing.T) {
+	cfg, probes := newFakeConfluence(t, http.StatusOK, `{"version":"8.5.3","deploymentType":"Server"}`, http.StatusOK)
+
+	service := NewConfluenceService(cfg)
+	if got := atomic.LoadInt32(probes); got != 0 {
+		t.Fatalf("expected no probes when creating the service, got %d", got)
+	}
+	if service.Version() != ConfluenceDC8 || service.Version() != ConfluenceDC8 {
+		t.Errorf("expected detected version %q, got %q", ConfluenceDC8, service.Version())
+	}
+	if got := atomic.LoadInt32(probes); got != 1 {
+		t.Errorf("expected the version to be probed once, got %d probes", got)
+	}
+
+	reloaded := *cfg
+	reloaded.ConfluenceAPIVersion = ConfluenceDC7
+	service.Reload(&reloaded)
+	if service.Version() != ConfluenceDC7 {
+		t.Errorf("expected configured version %q, got %q", ConfluenceDC7, service.Version())
+	}
+	if got := atomic.LoadInt32(probes); got != 1 {
+		t.Errorf("expected a configured version not to be probed, got %d probes", got)
+	}
+
+	unconfigured := NewConfluenceService(config.LoadTestConfig())
+	if unconfigured.Version() != "" {
+		t.Errorf("expected unknown version without Confluence configured, got %q", unconfigured.Version())
+	}
+}
+
+func TestBuildCQL_TextFieldByVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{version: ConfluenceCloud, expected: `space=DOCS AND siteSearch ~ "deploy"`},
+		{version: ConfluenceDC8, expected: `space=DOCS AND text ~ "deploy"`},
+		{version: ConfluenceDC7, expected: `space=DOCS AND text ~ "deploy"`},
+		// auto without Confluence configured can't be probed
+		{version: "auto", expected: `space=DOCS AND text ~ "deploy"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ConfluenceAPIVersion = tt.version
+			service := &ConfluenceService{config: cfg}
+
+			if got := service.buildCQL("deploy"); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
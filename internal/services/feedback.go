@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// Reactions on an answer that record feedback on it
+const (
+	feedbackHelpfulEmoji   = "+1"
+	feedbackUnhelpfulEmoji = "-1"
+)
+
+// helpfulRating is the lowest /inquiry-feedback rating that counts an answer as helpful
+const helpfulRating = 4
+
+// ErrInvalidRating is returned when recording a rating outside 1-5
+var ErrInvalidRating = errors.New("rating must be between 1 and 5")
+
+// isFeedbackReaction reports whether reaction records answer feedback
+func (s *InquiryService) isFeedbackReaction(reaction string) bool {
+	return s.cfg().FeedbackReranking && (reaction == feedbackHelpfulEmoji || reaction == feedbackUnhelpfulEmoji)
+}
+
+// recordFeedback stores userID's verdict on the answer posted at answerTS, or
+// forgets it when the reaction is removed. Reactions on messages other than
+// answers are ignored.
+func (s *InquiryService) recordFeedback(channelID, answerTS, userID, reaction, eventType string) error {
+	var inquiry storage.Inquiry
+	err := s.db.Where("channel_id = ? AND thread_timestamp = ?", channelID, answerTS).Limit(1).Find(&inquiry).Error
+	if err != nil {
+		return fmt.Errorf("failed to look up answered inquiry: %w", err)
+	}
+	if inquiry.ID == 0 {
+		return nil
+	}
+
+	helpful := reaction == feedbackHelpfulEmoji
+	if eventType != "added" {
+		return s.db.Where("inquiry_id = ? AND user_id = ? AND helpful = ?", inquiry.ID, userID, helpful).
+			Delete(&storage.Feedback{}).Error
+	}
+
+	return s.saveFeedback(&inquiry, &storage.Feedback{InquiryID: inquiry.ID, UserID: userID, Helpful: helpful})
+}
+
+// RecordFeedback stores userID's 1-5 rating of the answer to inquiryID,
+// replacing their earlier rating or reaction vote, and returns the answer's
+// average rating. Ratings of helpfulRating and above count as helpful votes.
+func (s *InquiryService) RecordFeedback(inquiryID uint, userID string, rating int) (float64, error) {
+	if rating < 1 || rating > 5 {
+		return 0, ErrInvalidRating
+	}
+
+	var inquiry storage.Inquiry
+	if err := s.db.First(&inquiry, inquiryID).Error; err != nil {
+		return 0, err
+	}
+
+	feedback := &storage.Feedback{InquiryID: inquiry.ID, UserID: userID, Helpful: rating >= helpfulRating, Rating: rating}
+	if err := s.saveFeedback(&inquiry, feedback); err != nil {
+		return 0, err
+	}
+
+	var average float64
+	if err := s.db.Model(&storage.Feedback{}).Select("COALESCE(AVG(rating), 0)").
+		Where("inquiry_id = ? AND rating > 0", inquiry.ID).Scan(&average).Error; err != nil {
+		return 0, fmt.Errorf("failed to average ratings: %w", err)
+	}
+	return average, nil
+}
+
+// saveFeedback stores feedback on inquiry's answer, replacing the user's earlier feedback
+func (s *InquiryService) saveFeedback(inquiry *storage.Inquiry, feedback *storage.Feedback) error {
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "inquiry_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"helpful", "rating", "updated_at"}),
+	}).Create(feedback).Error; err != nil {
+		return fmt.Errorf("failed to record feedback: %w", err)
+	}
+
+	// Tagging votes with the answer's confidence shows how well it predicts helpfulness
+	s.metrics.Incr("inquiry.feedback", map[string]string{
+		"helpful":    fmt.Sprint(feedback.Helpful),
+		"confidence": s.confidenceBucket(inquiry),
+	})
+	logrus.WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"user_id":    feedback.UserID,
+		"helpful":    feedback.Helpful,
+		"rating":     feedback.Rating,
+		"confidence": inquiry.ConfidenceScore,
+	}).Info("Recorded answer feedback")
+
+	return nil
+}
+
+// confidenceBucket classifies inquiry's answer confidence for metrics
+func (s *InquiryService) confidenceBucket(inquiry *storage.Inquiry) string {
+	switch {
+	case !inquiry.ConfidenceScored:
+		return "unscored"
+	case s.lowAnswerConfidence(inquiry):
+		return "low"
+	default:
+		return "high"
+	}
+}
+
+// feedbackBoost turns a result's feedback history into a score boost of up to
+// maxBoost. The helpful rate is smoothed as if every result started with one
+// helpful and one unhelpful vote, so a single vote counts for little and
+// results with a mixed or negative history get no boost.
+func feedbackBoost(helpful, unhelpful int64, maxBoost float64) float64 {
+	rate := float64(helpful+1) / float64(helpful+unhelpful+2)
+	if rate <= 0.5 {
+		return 0
+	}
+	return maxBoost * (2*rate - 1)
+}
+
+// applyFeedbackBoost raises the score of results whose past appearances in
+// answers were voted helpful
+func (s *SearchService) applyFeedbackBoost(ctx context.Context, results []storage.SearchResult) {
+	if !s.cfg().FeedbackReranking || s.cfg().FeedbackBoost == 0 || len(results) == 0 {
+		return
+	}
+
+	sourceIDs := make([]string, 0, len(results))
+	for _, result := range results {
+		sourceIDs = append(sourceIDs, result.SourceID)
+	}
+
+	// Only results that scored high enough to be used count as part of an answer
+	var rows []struct {
+		Source    string
+		SourceID  string
+		Helpful   int64
+		Unhelpful int64
+	}
+	if err := s.db.Table("search_results").
+		Select("search_results.source, search_results.source_id, "+
+			"SUM(CASE WHEN feedbacks.helpful THEN 1 ELSE 0 END) AS helpful, "+
+			"SUM(CASE WHEN feedbacks.helpful THEN 0 ELSE 1 END) AS unhelpful").
+		Joins("JOIN feedbacks ON feedbacks.inquiry_id = search_results.inquiry_id").
+		Where("search_results.deleted_at IS NULL AND search_results.source_id IN ? AND search_results.score >= ?",
+			sourceIDs, s.cfg().SimilarityThreshold).
+		Group("search_results.source, search_results.source_id").
+		Scan(&rows).Error; err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to load feedback history")
+		return
+	}
+
+	boosts := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		boosts[row.Source+":"+row.SourceID] = feedbackBoost(row.Helpful, row.Unhelpful, s.cfg().FeedbackBoost)
+	}
+	for i := range results {
+		results[i].Score += boosts[results[i].Source+":"+results[i].SourceID]
+	}
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+func TestFeedbackBoost(t *testing.T) {
+	tests := []struct {
+		name      string
+		helpful   int64
+		unhelpful int64
+		expected  float64
+	}{
+		{name: "no history", expected: 0},
+		{name: "single helpful vote", helpful: 1, expected: 0.2 / 3},
+		{name: "consistently helpful", helpful: 9, expected: 0.2 * 9 / 11},
+		{name: "mixed", helpful: 2, unhelpful: 2, expected: 0},
+		{name: "unhelpful", unhelpful: 3, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if boost := feedbackBoost(tt.helpful, tt.unhelpful, 0.2); math.Abs(boost-tt.expected) > 1e-9 {
+				t.Errorf("feedbackBoost(%d, %d) = %v, expected %v", tt.helpful, tt.unhelpful, boost, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFilterAndRankResults_FeedbackBoost(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		cfg := config.LoadTestConfig()
+		cfg.FeedbackReranking = enabled
+		db := setupTestDB(t)
+		service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+		// P1 was part of three helpful answers, P2 of an unhelpful one, and P3 was
+		// retrieved for a helpful answer but scored too low to be used in it
+		for i, seed := range []struct {
+			sourceID string
+			score    float64
+			helpful  []bool
+		}{
+			{sourceID: "P1", score: 0.9, helpful: []bool{true, true, true}},
+			{sourceID: "P2", score: 0.9, helpful: []bool{false}},
+			{sourceID: "P3", score: 0.1, helpful: []bool{true, true, true}},
+		} {
+			inquiry := &storage.Inquiry{MessageID: seed.sourceID, Status: "completed"}
+			db.Create(inquiry)
+			db.Create(&storage.SearchResult{InquiryID: inquiry.ID, Source: "confluence", SourceID: seed.sourceID, Score: seed.score})
+			for j, helpful := range seed.helpful {
+				db.Create(&storage.Feedback{InquiryID: inquiry.ID, UserID: fmt.Sprintf("U%d-%d", i, j), Helpful: helpful})
+			}
+		}
+
+		results := service.filterAndRankResults([]storage.SearchResult{
+			{Source: "confluence", SourceID: "P2", Score: 0.8},
+			{Source: "confluence", SourceID: "P3", Score: 0.78},
+			{Source: "confluence", SourceID: "P1", Score: 0.75},
+		}, "", "")
+
+		order := []string{results[0].SourceID, results[1].SourceID, results[2].SourceID}
+		expected := []string{"P2", "P3", "P1"}
+		if enabled {
+			expected = []string{"P1", "P2", "P3"}
+		}
+		for i := range expected {
+			if order[i] != expected[i] {
+				t.Errorf("enabled=%v: expected order %v, got %v", enabled, expected, order)
+				break
+			}
+		}
+	}
+}
+
+func TestProcessReactionEvent_RecordsFeedback(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.FeedbackReranking = true
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+	ctx := context.Background()
+
+	inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1", ThreadTimestamp: "9.9", Status: "completed"}
+	db.Create(inquiry)
+
+	if !service.IsTriggerReaction("+1", "removed") {
+		t.Error("Expected feedback reactions to be handled when removed too")
+	}
+
+	feedback := func() []storage.Feedback {
+		var rows []storage.Feedback
+		db.Find(&rows)
+		return rows
+	}
+
+	if err := service.ProcessReactionEvent(ctx, "9.9", "C1", "U1", "+1", "added", "10.0"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+	if rows := feedback(); len(rows) != 1 || rows[0].InquiryID != inquiry.ID || !rows[0].Helpful {
+		t.Fatalf("Expected one helpful vote, got %+v", rows)
+	}
+
+	if err := service.ProcessReactionEvent(ctx, "9.9", "C1", "U1", "-1", "added", "10.1"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+	if rows := feedback(); len(rows) != 1 || rows[0].Helpful {
+		t.Fatalf("Expected the vote to change to unhelpful, got %+v", rows)
+	}
+
+	if err := service.ProcessReactionEvent(ctx, "9.9", "C1", "U1", "-1", "removed", "10.2"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+	if rows := feedback(); len(rows) != 0 {
+		t.Fatalf("Expected the vote to be removed, got %+v", rows)
+	}
+
+	if err := service.ProcessReactionEvent(ctx, "5.5", "C1", "U1", "+1", "added", "10.3"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+	if rows := feedback(); len(rows) != 0 {
+		t.Errorf("Expected reactions on other messages to be ignored, got %+v", rows)
+	}
+}
+
+func TestRecordFeedback(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestInquiryService(config.LoadTestConfig(), db)
+
+	inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Status: "completed"}
+	db.Create(inquiry)
+	db.Create(&storage.Feedback{InquiryID: inquiry.ID, UserID: "U3", Helpful: true})
+
+	steps := []struct {
+		userID  string
+		rating  int
+		average float64
+	}{
+		{userID: "U1", rating: 5, average: 5},
+		{userID: "U2", rating: 2, average: 3.5},
+		// Rating again replaces the user's earlier rating
+		{userID: "U1", rating: 3, average: 2.5},
+		// A reaction vote is replaced by the rating
+		{userID: "U3", rating: 4, average: 3},
+	}
+	for _, step := range steps {
+		average, err := service.RecordFeedback(inquiry.ID, step.userID, step.rating)
+		if err != nil {
+			t.Fatalf("RecordFeedback(%s, %d) returned error: %v", step.userID, step.rating, err)
+		}
+		if average != step.average {
+			t.Errorf("RecordFeedback(%s, %d) average = %v, expected %v", step.userID, step.rating, average, step.average)
+		}
+	}
+
+	var votes []storage.Feedback
+	db.Order("user_id").Find(&votes)
+	if len(votes) != 3 || votes[0].Helpful || votes[1].Helpful || !votes[2].Helpful {
+		t.Errorf("Expected only the rating of 4 to count as helpful, got %+v", votes)
+	}
+
+	for _, rating := range []int{0, 6} {
+		if _, err := service.RecordFeedback(inquiry.ID, "U1", rating); !errors.Is(err, ErrInvalidRating) {
+			t.Errorf("Expected ErrInvalidRating for %d, got %v", rating, err)
+		}
+	}
+	if _, err := service.RecordFeedback(999, "U1", 5); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("Expected gorm.ErrRecordNotFound for an unknown inquiry, got %v", err)
+	}
+}
+
+func TestConfidenceBucket(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LowAnswerConfidence = 0.5
+	service := newTestInquiryService(cfg, nil)
+
+	tests := []struct {
+		name     string
+		inquiry  storage.Inquiry
+		expected string
+	}{
+		{name: "unscored", inquiry: storage.Inquiry{}, expected: "unscored"},
+		{name: "scored zero", inquiry: storage.Inquiry{ConfidenceScored: true}, expected: "low"},
+		{name: "scored low", inquiry: storage.Inquiry{ConfidenceScore: 0.2, ConfidenceScored: true}, expected: "low"},
+		{name: "scored high", inquiry: storage.Inquiry{ConfidenceScore: 0.8, ConfidenceScored: true}, expected: "high"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.confidenceBucket(&tt.inquiry); got != tt.expected {
+				t.Errorf("Expected bucket %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
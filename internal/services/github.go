@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// GitHubSource is the Source of results found by GitHubSearchPlugin
+const GitHubSource = "github"
+
+// githubCodeSearchResult is the subset of a GET /search/code response used
+type githubCodeSearchResult struct {
+	Items []githubCodeItem `json:"items"`
+}
+
+// githubCodeItem is a file matching a code search, with the matching
+// fragments returned for the text-match media type
+type githubCodeItem struct {
+	Name       string `json:"name"`
+	Path       string `json:"path"`
+	SHA        string `json:"sha"`
+	HTMLURL    string `json:"html_url"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	TextMatches []struct {
+		Fragment string `json:"fragment"`
+	} `json:"text_matches"`
+}
+
+// GitHubSearchPlugin searches the code and docs in GITHUB_OWNER's
+// repositories, or only in those GITHUB_REPO and GITHUB_REPOS allowlist
+type GitHubSearchPlugin struct {
+	// configMu guards client and config, which Reload replaces
+	configMu sync.RWMutex
+	client   *http.Client
+	config   *config.Config
+}
+
+// NewGitHubSearchPlugin creates a GitHub code search plugin
+func NewGitHubSearchPlugin(cfg *config.Config) *GitHubSearchPlugin {
+	return &GitHubSearchPlugin{
+		client: &http.Client{Timeout: cfg.GitHubSearchTimeout},
+		config: cfg,
+	}
+}
+
+// Reload switches the plugin to cfg, picking up the new repository and timeout
+func (p *GitHubSearchPlugin) Reload(cfg *config.Config) {
+	p.configMu.Lock()
+	defer p.configMu.Unlock()
+	p.client = &http.Client{Timeout: cfg.GitHubSearchTimeout}
+	p.config = cfg
+}
+
+// httpClient returns the client for GitHub requests
+func (p *GitHubSearchPlugin) httpClient() *http.Client {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	return p.client
+}
+
+// cfg returns the current configuration
+func (p *GitHubSearchPlugin) cfg() *config.Config {
+	p.configMu.RLock()
+	defer p.configMu.RUnlock()
+	return p.config
+}
+
+// Name returns the Source of GitHub results
+func (p *GitHubSearchPlugin) Name() string {
+	return GitHubSource
+}
+
+// Enabled reports whether a token and owner are configured
+func (p *GitHubSearchPlugin) Enabled() bool {
+	return p.cfg().GitHubToken != "" && p.cfg().GitHubOwner != ""
+}
+
+// repos returns the allowlisted repositories, GITHUB_REPO first, or none when
+// all of the owner's repositories are searched
+func (p *GitHubSearchPlugin) repos() []string {
+	var repos []string
+	seen := make(map[string]bool)
+	for _, repo := range append([]string{p.cfg().GitHubRepo}, p.cfg().GitHubRepos...) {
+		if repo == "" || seen[repo] {
+			continue
+		}
+		seen[repo] = true
+		repos = append(repos, repo)
+	}
+	return repos
+}
+
+// scope returns the code search qualifiers restricting a search to the
+// allowlisted repositories, or to the owner's organization without an allowlist
+func (p *GitHubSearchPlugin) scope() string {
+	repos := p.repos()
+	if len(repos) == 0 {
+		return "org:" + p.cfg().GitHubOwner
+	}
+
+	qualifiers := make([]string, 0, len(repos))
+	for _, repo := range repos {
+		qualifiers = append(qualifiers, fmt.Sprintf("repo:%s/%s", p.cfg().GitHubOwner, repo))
+	}
+	return strings.Join(qualifiers, " ")
+}
+
+// Search finds files in the searched repositories matching query with
+// GitHub's code search, one result per file linking to its blob. Files are
+// identified by their path, prefixed with the repository when several are searched.
+func (p *GitHubSearchPlugin) Search(ctx context.Context, query string, inquiryID uint) ([]storage.SearchResult, error) {
+	ctx, cancelFn := context.WithTimeout(ctx, p.cfg().GitHubSearchTimeout)
+	defer cancelFn()
+
+	searchQuery := query + " " + p.scope()
+	params := url.Values{}
+	params.Add("q", searchQuery)
+	params.Add("per_page", fmt.Sprintf("%d", p.cfg().MaxSearchResults))
+
+	searchURL := strings.TrimSuffix(p.cfg().GitHubAPIURL, "/") + "/search/code?" + params.Encode()
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.cfg().GitHubToken)
+	req.Header.Set("Accept", "application/vnd.github.text-match+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := p.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			loggerFrom(ctx).WithError(err).Error("failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"status_code": resp.StatusCode,
+			"body":        string(body),
+		}).Error("GitHub API error")
+		return nil, fmt.Errorf("github API error: %d", resp.StatusCode)
+	}
+
+	var searchResult githubCodeSearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&searchResult); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	singleRepo := len(p.repos()) == 1
+	results := make([]storage.SearchResult, 0, len(searchResult.Items))
+	for _, item := range searchResult.Items {
+		fragments := make([]string, 0, len(item.TextMatches))
+		for _, match := range item.TextMatches {
+			fragments = append(fragments, match.Fragment)
+		}
+
+		path := item.Path
+		if !singleRepo && item.Repository.FullName != "" {
+			path = item.Repository.FullName + "/" + item.Path
+		}
+		results = append(results, storage.SearchResult{
+			InquiryID:   inquiryID,
+			Source:      GitHubSource,
+			SourceID:    path,
+			Title:       path,
+			Content:     strings.Join(fragments, "\n…\n"),
+			URL:         item.HTMLURL,
+			SearchQuery: searchQuery,
+		})
+	}
+
+	return results, nil
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newFakeGitHub serves code search results for GITHUB_OWNER/GITHUB_REPO and points cfg at it
+func newFakeGitHub(t *testing.T, cfg *config.Config, status int, body string) *atomic.Int32 {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path != "/search/code" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("q"); got != "deploy payment repo:kouzoh/infra" {
+			t.Errorf("Unexpected query %q", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer gh-token" {
+			t.Errorf("Unexpected authorization %q", got)
+		}
+		if got := r.Header.Get("Accept"); got != "application/vnd.github.text-match+json" {
+			t.Errorf("Unexpected accept header %q", got)
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.GitHubAPIURL = server.URL
+	cfg.GitHubToken = "gh-token"
+	cfg.GitHubOwner = "kouzoh"
+	cfg.GitHubRepo = "infra"
+	return &requests
+}
+
+const githubCodeResults = `{"total_count": 1, "items": [{
+	"name": "deploy.sh",
+	"path": "scripts/deploy.sh",
+	"sha": "abc123",
+	"html_url": "https://github.com/kouzoh/infra/blob/abc123/scripts/deploy.sh",
+	"text_matches": [{"fragment": "# Deploy the payment service"}, {"fragment": "kubectl rollout status deploy/payment"}]
+}]}`
+
+func TestGitHubSearchPlugin_Search(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	newFakeGitHub(t, cfg, http.StatusOK, githubCodeResults)
+	plugin := NewGitHubSearchPlugin(cfg)
+
+	results, err := plugin.Search(context.Background(), "deploy payment", 7)
+	if err != nil {
+		t.Fatalf("Search returned error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("Expected 1 result, got %d", len(results))
+	}
+
+	result := results[0]
+	if result.Source != GitHubSource || result.SourceID != "scripts/deploy.sh" || result.Title != "scripts/deploy.sh" || result.InquiryID != 7 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if result.URL != "https://github.com/kouzoh/infra/blob/abc123/scripts/deploy.sh" {
+		t.Errorf("Expected the blob URL, got %q", result.URL)
+	}
+	if result.Content != "# Deploy the payment service\n…\nkubectl rollout status deploy/payment" {
+		t.Errorf("Expected the matching fragments as content, got %q", result.Content)
+	}
+	if result.SearchQuery != "deploy payment repo:kouzoh/infra" {
+		t.Errorf("Expected the code search query to be kept, got %q", result.SearchQuery)
+	}
+}
+
+func TestGitHubSearchPlugin_SearchAcrossRepos(t *testing.T) {
+	const body = `{"total_count": 2, "items": [
+		{"path": "README.md", "html_url": "https://github.com/kouzoh/infra/blob/abc/README.md", "repository": {"full_name": "kouzoh/infra"},
+		 "text_matches": [{"fragment": "## Deploying"}]},
+		{"path": "README.md", "html_url": "https://github.com/kouzoh/payments/blob/def/README.md", "repository": {"full_name": "kouzoh/payments"},
+		 "text_matches": [{"fragment": "Deploy with make deploy"}]}
+	]}`
+
+	tests := []struct {
+		name  string
+		repo  string
+		repos []string
+		query string
+	}{
+		{name: "whole organization", query: "deploy org:kouzoh"},
+		{name: "allowlist", repo: "infra", repos: []string{"payments", "infra"}, query: "deploy repo:kouzoh/infra repo:kouzoh/payments"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query = r.URL.Query().Get("q")
+				_, _ = w.Write([]byte(body))
+			}))
+			t.Cleanup(server.Close)
+
+			cfg := config.LoadTestConfig()
+			cfg.GitHubAPIURL = server.URL
+			cfg.GitHubToken = "gh-token"
+			cfg.GitHubOwner = "kouzoh"
+			cfg.GitHubRepo = tt.repo
+			cfg.GitHubRepos = tt.repos
+			plugin := NewGitHubSearchPlugin(cfg)
+			if !plugin.Enabled() {
+				t.Fatal("Expected the plugin to be enabled with a token and owner")
+			}
+
+			results, err := plugin.Search(context.Background(), "deploy", 1)
+			if err != nil {
+				t.Fatalf("Search returned error: %v", err)
+			}
+			if query != tt.query {
+				t.Errorf("Expected query %q, got %q", tt.query, query)
+			}
+			// Files with the same path in different repositories are told apart
+			if len(results) != 2 || results[0].SourceID != "kouzoh/infra/README.md" || results[1].SourceID != "kouzoh/payments/README.md" {
+				t.Errorf("Expected results identified by repository and path, got %+v", results)
+			}
+			if results[1].Title != "kouzoh/payments/README.md" || results[1].Content != "Deploy with make deploy" {
+				t.Errorf("Unexpected result %+v", results[1])
+			}
+		})
+	}
+}
+
+func TestGitHubSearchPlugin_APIError(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	newFakeGitHub(t, cfg, http.StatusForbidden, `{"message": "rate limited"}`)
+	plugin := NewGitHubSearchPlugin(cfg)
+
+	if _, err := plugin.Search(context.Background(), "deploy payment", 1); err == nil {
+		t.Error("Expected an error for a failed search")
+	}
+}
+
+func TestGitHubSearchPlugin_Enabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	plugin := NewGitHubSearchPlugin(cfg)
+	if plugin.Enabled() {
+		t.Error("Expected the plugin to be disabled without a token and repository")
+	}
+
+	cfg.GitHubToken, cfg.GitHubOwner, cfg.GitHubRepo = "gh-token", "kouzoh", "infra"
+	if !plugin.Enabled() {
+		t.Error("Expected the plugin to be enabled with a token and repository")
+	}
+
+	cfg.GitHubToken = ""
+	if plugin.Enabled() {
+		t.Error("Expected the plugin to be disabled without a token")
+	}
+}
+
+func TestSearchAll_SearchesPlugins(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0
+	cfg.SearchCacheTTL = 0
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	requests := newFakeGitHub(t, cfg, http.StatusOK, githubCodeResults)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+	service.RegisterPlugin(NewGitHubSearchPlugin(cfg))
+
+	results, status, err := service.SearchAll(context.Background(), "deploy payment", 1, "")
+	if err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Source != GitHubSource || results[0].Score == 0 {
+		t.Errorf("Expected the scored GitHub result, got %+v", results)
+	}
+	if want := []string{"slack", "confluence", GitHubSource}; !reflect.DeepEqual(status.Searched, want) {
+		t.Errorf("Expected %v searched, got %v", want, status.Searched)
+	}
+
+	// Restricting the search to Slack skips GitHub
+	before := requests.Load()
+	if _, _, err := service.SearchAll(WithSourceFilter(context.Background(), []string{"slack"}), "deploy payment", 2, ""); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	if requests.Load() != before {
+		t.Error("Expected GitHub not to be searched when the search is restricted to Slack")
+	}
+}
@@ -2,80 +2,454 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/metrics"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 	"gorm.io/gorm"
 )
 
+const (
+	botBusyStatusText  = "Busy – processing backlog"
+	botBusyStatusEmoji = ":robot_face:"
+)
+
+// Where an inquiry came from
+const (
+	InquirySourceSlack = "slack"
+	InquirySourceDM    = "dm"
+	InquirySourceAPI   = "api"
+)
+
+// ErrRateLimited is returned when a user has started MaxInquiriesPerHour inquiries in the last hour
+var ErrRateLimited = errors.New("inquiry rate limit exceeded")
+
 // InquiryService orchestrates the entire inquiry processing pipeline
 type InquiryService struct {
-	search *SearchService
-	slack  *SlackService
-	llm    *LLMService
-	db     *gorm.DB
-	config *config.Config
+	search  *SearchService
+	slack   *SlackService
+	llm     *LLMService
+	db      *gorm.DB
+	stats   *InquiryStats
+	queue   *InquiryQueue
+	metrics metrics.Metrics
+	node    string
+
+	// configMu guards config, which Reload replaces
+	configMu sync.RWMutex
+	config   *config.Config
+
+	// followUpSlots bounds the follow-up watchers running at once
+	followUpSlots chan struct{}
+	// shadowSlots bounds the shadow answers being generated at once
+	shadowSlots chan struct{}
+
+	// websocket, when set, receives every status change
+	websocket *WebSocketService
+
+	// faqMu serialises FAQ publishing
+	faqMu sync.Mutex
+
+	// answerFlight coalesces the answers to identical in-flight inquiries
+	answerFlight singleflight.Group
+
+	// webhookClient delivers inquiry lifecycle events, retrying after
+	// webhookRetryDelay, doubled on each retry
+	webhookClient     *http.Client
+	webhookRetryDelay time.Duration
 }
 
 // NewInquiryService creates a new inquiry service instance
 func NewInquiryService(search *SearchService, slack *SlackService, llm *LLMService, db *gorm.DB, cfg *config.Config) *InquiryService {
 	return &InquiryService{
-		search: search,
-		slack:  slack,
-		llm:    llm,
-		db:     db,
-		config: cfg,
+		search:  search,
+		slack:   slack,
+		llm:     llm,
+		db:      db,
+		config:  cfg,
+		stats:   NewInquiryStats(),
+		queue:   NewInquiryQueue(cfg.MaxQueueDepth),
+		metrics: metrics.Nop{},
+		node:    processingNode(),
+
+		followUpSlots: make(chan struct{}, cfg.MaxFollowUpWatchers),
+		shadowSlots:   make(chan struct{}, cfg.MaxConcurrentInquiries),
+
+		webhookClient:     &http.Client{},
+		webhookRetryDelay: time.Second,
+	}
+}
+
+// processingNode identifies this instance in multi-replica deployments
+func processingNode() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		logrus.WithError(err).Warn("Failed to read hostname, recording inquiries as processed by 'unknown'")
+		return "unknown"
+	}
+	return hostname
+}
+
+// SetMetrics makes the service report inquiry outcomes, latency and rejections to m
+func (s *InquiryService) SetMetrics(m metrics.Metrics) {
+	s.metrics = m
+}
+
+// SetWebSocket makes the service push inquiry status changes to ws's clients
+func (s *InquiryService) SetWebSocket(ws *WebSocketService) {
+	s.websocket = ws
+}
+
+// broadcastStatus pushes the inquiry's current status to WebSocket clients
+func (s *InquiryService) broadcastStatus(inquiry *storage.Inquiry) {
+	s.notifyWebhooks(inquiry)
+	if s.websocket == nil {
+		return
+	}
+	s.websocket.Broadcast(InquiryStatusEvent{ID: inquiry.ID, Status: inquiry.Status, UpdatedAt: inquiry.UpdatedAt})
+}
+
+// outcomeTag is the metric status tag for an operation that returned err
+func outcomeTag(err error) string {
+	if err != nil {
+		return "error"
 	}
+	return "ok"
 }
 
-// ProcessInquiry processes an inquiry from start to finish
-func (s *InquiryService) ProcessInquiry(ctx context.Context, messageID, channelID, userID, messageText, timestamp string) error {
+// RunWorkers processes submitted inquiries with MaxConcurrentInquiries workers until ctx is cancelled
+func (s *InquiryService) RunWorkers(ctx context.Context) {
+	s.queue.Run(ctx, s.cfg().MaxConcurrentInquiries)
+}
+
+// Submit queues job for the worker pool. When the queue is full the job is
+// dropped, the user is told to try again later and ErrQueueFull is returned.
+func (s *InquiryService) Submit(channelID, userID string, job func(ctx context.Context) error) error {
+	err := s.queue.Enqueue(func(ctx context.Context) {
+		if err := job(ctx); err != nil {
+			logrus.WithError(err).WithField("channel_id", channelID).Error("Queued inquiry failed")
+		}
+	})
+	if err == nil {
+		return nil
+	}
+
+	s.stats.RecordRejected()
+	s.metrics.Incr("inquiry.rejected", nil)
 	logrus.WithFields(logrus.Fields{
+		"channel_id":  channelID,
+		"user_id":     userID,
+		"queue_depth": s.cfg().MaxQueueDepth,
+	}).Warn("Inquiry queue full, rejecting inquiry")
+
+	if channelID != "" && userID != "" {
+		note := "⏳ I'm handling a lot of questions right now and my queue is full. Please try again in a few minutes."
+		if postErr := s.slack.PostEphemeral(channelID, userID, note); postErr != nil {
+			logrus.WithError(postErr).Error("Failed to post queue full note")
+		}
+	}
+
+	return err
+}
+
+// IsTriggerReaction reports whether adding or removing reaction starts any inquiry work
+func (s *InquiryService) IsTriggerReaction(reaction, eventType string) bool {
+	if s.isFeedbackReaction(reaction) {
+		return true
+	}
+	if eventType != "added" {
+		return false
+	}
+	if reaction == s.cfg().AnswerRefreshEmoji && s.cfg().AnswerTTLDays > 0 {
+		return true
+	}
+	_, isTrigger := s.modelForReaction(reaction)
+	return isTrigger || s.isForceReaction(reaction)
+}
+
+// Reload switches the service to cfg. The queue depth, worker count and
+// follow-up watcher limit are fixed at startup and only change on restart.
+func (s *InquiryService) Reload(cfg *config.Config) {
+	if cfg.MaxQueueDepth != s.cfg().MaxQueueDepth || cfg.MaxConcurrentInquiries != s.cfg().MaxConcurrentInquiries {
+		logrus.Warn("MAX_QUEUE_DEPTH and MAX_CONCURRENT_INQUIRIES changes take effect after a restart")
+	}
+	if cfg.MaxFollowUpWatchers != s.cfg().MaxFollowUpWatchers {
+		logrus.Warn("MAX_FOLLOW_UP_WATCHERS changes take effect after a restart")
+	}
+
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config = cfg
+}
+
+// cfg returns the current configuration
+func (s *InquiryService) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// Stats returns the in-memory processing counters since startup
+func (s *InquiryService) Stats() StatsSnapshot {
+	return s.stats.Snapshot()
+}
+
+// ProcessInquiry processes an inquiry from start to finish. An empty model uses
+// the configured default.
+func (s *InquiryService) ProcessInquiry(ctx context.Context, messageID, channelID, userID, messageText, timestamp, model string) error {
+	return s.processInquiry(ctx, InquirySourceSlack, messageID, channelID, userID, messageText, timestamp, model)
+}
+
+// processInquiry records an inquiry from source and runs it through the pipeline
+func (s *InquiryService) processInquiry(ctx context.Context, source, messageID, channelID, userID, messageText, timestamp, model string) error {
+	ctx = NewInquiryContext(ctx)
+	loggerFrom(ctx).WithFields(logrus.Fields{
 		"message_id": messageID,
 		"channel_id": channelID,
 		"user_id":    userID,
+		"model":      model,
+		"source":     source,
 	}).Info("Starting inquiry processing")
 
+	requestedBy := requesterFrom(ctx, userID)
+	if err := s.checkUserRateLimit(ctx, channelID, requestedBy); err != nil {
+		return err
+	}
+
 	// Create inquiry record
 	inquiry := &storage.Inquiry{
-		MessageID:   messageID,
-		ChannelID:   channelID,
-		UserID:      userID,
-		MessageText: messageText,
-		Timestamp:   timestamp,
-		Status:      "pending",
+		MessageID:      messageID,
+		ChannelID:      channelID,
+		UserID:         userID,
+		RequestedBy:    requestedBy,
+		MessageText:    messageText,
+		Timestamp:      timestamp,
+		Status:         "pending",
+		Source:         source,
+		RequestedModel: model,
 	}
 
 	if err := s.db.Create(inquiry).Error; err != nil {
-		logrus.WithError(err).Error("Failed to create inquiry record")
+		loggerFrom(ctx).WithError(err).Error("Failed to create inquiry record")
 		return fmt.Errorf("failed to create inquiry: %w", err)
 	}
+	s.broadcastStatus(inquiry)
+
+	if handled, err := s.applyOfficeHours(ctx, inquiry, time.Now()); handled {
+		s.broadcastStatus(inquiry)
+		return err
+	}
+
+	return s.runPipeline(ctx, inquiry)
+}
+
+// ReprocessInquiry re-runs search, response generation and posting for an existing inquiry
+func (s *InquiryService) ReprocessInquiry(ctx context.Context, inquiryID uint) error {
+	ctx = NewInquiryContext(ctx)
+	var inquiry storage.Inquiry
+	if err := s.db.First(&inquiry, inquiryID).Error; err != nil {
+		return fmt.Errorf("failed to load inquiry: %w", err)
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"status":     inquiry.Status,
+	}).Info("Reprocessing inquiry")
+
+	if inquiry.Status == "failed" {
+		inquiry.RetryCount++
+	}
+
+	// Drop results from the previous run so the new search starts from a clean slate
+	if err := s.db.Where("inquiry_id = ?", inquiry.ID).Delete(&storage.SearchResult{}).Error; err != nil {
+		return fmt.Errorf("failed to clear previous search results: %w", err)
+	}
+	if err := s.search.InvalidateCachedResults(inquiry.MessageText); err != nil {
+		return fmt.Errorf("failed to clear cached search results: %w", err)
+	}
+
+	return s.runPipeline(ctx, &inquiry)
+}
+
+// BatchReprocessFailed reprocesses every inquiry currently in the failed state
+func (s *InquiryService) BatchReprocessFailed(ctx context.Context) error {
+	if s.cfg().BotStatusEnabled {
+		if err := s.slack.SetStatus("", botBusyStatusText, botBusyStatusEmoji); err != nil {
+			logrus.WithError(err).Warn("Failed to set bot status")
+		}
+		defer func() {
+			if err := s.slack.SetStatus("", "", ""); err != nil {
+				logrus.WithError(err).Warn("Failed to clear bot status")
+			}
+		}()
+	}
+
+	var failed []storage.Inquiry
+	if err := s.db.Where("status = ?", "failed").Order("created_at ASC").Find(&failed).Error; err != nil {
+		return fmt.Errorf("failed to list failed inquiries: %w", err)
+	}
+
+	logrus.WithField("count", len(failed)).Info("Reprocessing failed inquiries")
+
+	var errCount int
+	for _, inquiry := range failed {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.ReprocessInquiry(ctx, inquiry.ID); err != nil {
+			logrus.WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to reprocess inquiry")
+			errCount++
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("failed to reprocess %d of %d inquiries", errCount, len(failed))
+	}
+
+	return nil
+}
+
+// ReprocessStale reprocesses every inquiry with at least one search result marked stale
+func (s *InquiryService) ReprocessStale(ctx context.Context) error {
+	var inquiryIDs []uint
+	if err := s.db.Model(&storage.SearchResult{}).
+		Where("stale = ?", true).
+		Distinct().
+		Order("inquiry_id ASC").
+		Pluck("inquiry_id", &inquiryIDs).Error; err != nil {
+		return fmt.Errorf("failed to list inquiries with stale results: %w", err)
+	}
+
+	if len(inquiryIDs) == 0 {
+		return nil
+	}
+
+	logrus.WithField("count", len(inquiryIDs)).Info("Reprocessing inquiries with stale search results")
+
+	var errCount int
+	for _, inquiryID := range inquiryIDs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if err := s.db.Model(&storage.SearchResult{}).
+			Where("inquiry_id = ? AND stale = ?", inquiryID, true).
+			Update("stale", false).Error; err != nil {
+			logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to clear stale flag")
+			errCount++
+			continue
+		}
+
+		if err := s.ReprocessInquiry(ctx, inquiryID); err != nil {
+			logrus.WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to reprocess stale inquiry")
+			errCount++
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("failed to reprocess %d of %d stale inquiries", errCount, len(inquiryIDs))
+	}
+
+	return nil
+}
+
+// RunStaleReprocessLoop periodically reprocesses inquiries with stale results until ctx is cancelled
+func (s *InquiryService) RunStaleReprocessLoop(ctx context.Context) {
+	runPeriodically(ctx, "stale_reprocess", s.cfg().StaleReprocessInterval, s.ReprocessStale)
+}
+
+// runPipeline searches, generates and posts a response for a persisted inquiry
+func (s *InquiryService) runPipeline(ctx context.Context, inquiry *storage.Inquiry) (err error) {
+	ctx = NewInquiryContext(ctx)
+	start := time.Now()
+	defer func() {
+		s.stats.RecordOutcome(err == nil, time.Since(start))
+		tags := map[string]string{"status": outcomeTag(err)}
+		s.metrics.Incr("inquiry.processed", tags)
+		s.metrics.Timing("inquiry.duration", time.Since(start), tags)
+		if err != nil && inquiry.Status == "failed" {
+			s.recordFailure(ctx, inquiry, err)
+		}
+		s.broadcastStatus(inquiry)
+	}()
 
 	// Update status to processing
 	inquiry.Status = "processing"
+	inquiry.ProcessingNode = s.node
+	inquiry.Model = s.llm.ModelFor(inquiry)
+	inquiry.ConfidenceScore, inquiry.ConfidenceScored = 0, false
+	inquiry.PromptTokens, inquiry.CompletionTokens, inquiry.ContextRatio = 0, 0, 0
+	if inquiry.Category == "" {
+		inquiry.Category = s.Categorize(ctx, inquiry.MessageText)
+	}
+	inquiry.ContentHash = ContentHash(inquiry.MessageText)
 	s.db.Save(inquiry)
+	s.broadcastStatus(inquiry)
+
+	// Reuse the answer to the same question from another channel instead of searching again
+	if s.cfg().CrossChannelDedup {
+		original, err := s.findAnsweredDuplicate(inquiry, time.Now())
+		if err != nil {
+			loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Warn("Failed to look up duplicate inquiries")
+		} else if original != nil {
+			return s.reuseAnswer(ctx, inquiry, original)
+		}
+	}
+
+	// Acknowledge the inquiry right away; the answer replaces the placeholder
+	ctx = s.postPlaceholder(ctx, inquiry)
+	defer s.removePlaceholder(ctx)
 
 	// Search for relevant information
-	searchResults, err := s.search.SearchAll(ctx, messageText, inquiry.ID)
+	searchResults, explanation, err := s.search.SearchAllExplained(ctx, inquiry.MessageText, inquiry.ID, inquiry.ChannelID)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to search for relevant information")
+		loggerFrom(ctx).WithError(err).Error("Failed to search for relevant information")
 		inquiry.Status = "failed"
 		s.db.Save(inquiry)
 		return fmt.Errorf("search failed: %w", err)
 	}
-
-	// Generate AI response
-	response, err := s.llm.GenerateResponse(ctx, inquiry, searchResults)
-	if err != nil {
-		logrus.WithError(err).Error("Failed to generate AI response")
+	// Keep what was searched for, to explain the results later
+	inquiry.SearchQuery = explanation.Query
+	inquiry.SearchKeywords = strings.Join(explanation.Keywords, ",")
+	ctx = withSourceStatus(ctx, explanation.Sources)
+	s.recordResultDiversity(ctx, inquiry, searchResults)
+	s.updatePlaceholder(ctx, placeholderGenerating)
+
+	// Post a page that answers the question on its own, only links when the
+	// sources match too poorly for an answer, or generate an AI response
+	var response, model string
+	confidence := bestResultScore(searchResults)
+	tier := s.confidenceTier(confidence)
+	if doc := s.directDocResult(searchResults); doc != nil {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": inquiry.ID,
+			"page_id":    doc.SourceID,
+			"score":      doc.Score,
+		}).Info("Posting top Confluence page instead of generating an answer")
+		response = directDocAnswer(*doc)
+	} else if tier == confidenceLinksOnly {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": inquiry.ID,
+			"confidence": confidence,
+		}).Info("Confidence too low to answer, posting links only")
+		response = s.generateFallbackResponse(inquiry.MessageText, searchResults)
+	} else if response, model, err = s.generateCoalescedAnswer(ctx, inquiry, searchResults); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to generate AI response")
 
 		// Send fallback response
-		fallbackResponse := s.generateFallbackResponse(searchResults)
-		if err := s.sendResponse(ctx, inquiry, fallbackResponse); err != nil {
-			logrus.WithError(err).Error("Failed to send fallback response")
+		fallbackResponse := s.generateFallbackResponse(inquiry.MessageText, searchResults)
+		if err := s.sendResponse(ctx, inquiry, fallbackResponse, "", searchResults, true); err != nil {
+			loggerFrom(ctx).WithError(err).Error("Failed to send fallback response")
 		}
 
 		inquiry.Status = "failed"
@@ -84,9 +458,18 @@ func (s *InquiryService) ProcessInquiry(ctx context.Context, messageID, channelI
 		return fmt.Errorf("AI response generation failed: %w", err)
 	}
 
+	if model != "" {
+		s.shadowAnswer(ctx, inquiry, searchResults)
+		response = s.stripAnswerBoilerplate(ctx, response)
+		s.scoreAnswerConfidence(ctx, inquiry, response)
+	}
+	if tier == confidenceFlagged && model != "" {
+		response = lowConfidenceNote + "\n\n" + response
+	}
+
 	// Send response to Slack
-	if err := s.sendResponse(ctx, inquiry, response); err != nil {
-		logrus.WithError(err).Error("Failed to send response to Slack")
+	if err := s.sendResponse(ctx, inquiry, response, model, searchResults, tier == confidenceLinksOnly); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to send response to Slack")
 		inquiry.Status = "failed"
 		inquiry.ResponseText = response
 		s.db.Save(inquiry)
@@ -99,9 +482,20 @@ func (s *InquiryService) ProcessInquiry(ctx context.Context, messageID, channelI
 	inquiry.ProcessedAt = &now
 	inquiry.ResponseSent = true
 	inquiry.ResponseText = response
+	inquiry.RefreshOfferedAt = nil
+	if s.cfg().AnswerTTLDays > 0 && inquiry.Source != InquirySourceAPI {
+		refreshAfter := now.AddDate(0, 0, s.cfg().AnswerTTLDays)
+		inquiry.RefreshAfter = &refreshAfter
+	}
 	s.db.Save(inquiry)
 
-	logrus.WithFields(logrus.Fields{
+	if _, err := s.publishCanvas(ctx, inquiry, response, searchResults); err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to publish answer as canvas")
+	}
+	s.watchFollowUps(ctx, inquiry)
+	s.routeToExpert(ctx, inquiry, tier)
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
 		"inquiry_id":      inquiry.ID,
 		"search_results":  len(searchResults),
 		"response_length": len(response),
@@ -110,33 +504,164 @@ func (s *InquiryService) ProcessInquiry(ctx context.Context, messageID, channelI
 	return nil
 }
 
-// sendResponse sends the response to Slack as a thread reply
-func (s *InquiryService) sendResponse(ctx context.Context, inquiry *storage.Inquiry, response string) error {
+// sendResponse sends the response to Slack as a thread reply. fallback marks
+// responses sent because no answer could be generated.
+func (s *InquiryService) sendResponse(ctx context.Context, inquiry *storage.Inquiry, response, model string, searchResults []storage.SearchResult, fallback bool) error {
+	// API callers poll for the answer instead
+	if inquiry.Source == InquirySourceAPI {
+		return nil
+	}
+
 	_, cancelFn := context.WithTimeout(ctx, 500*time.Millisecond)
 	defer cancelFn()
 	// Format the response with a header
 	formattedResponse := fmt.Sprintf("🤖 *AI Assistant Response*\n\n%s", response)
 
-	// Send as a thread reply to the original message
-	threadTS, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, formattedResponse)
-	if err != nil {
-		return err
+	// Say which sources were down, so an answer built without them doesn't look complete
+	if s.cfg().PartialResultsNote {
+		if note := partialResultsNote(sourceStatusFrom(ctx), "this answer is"); note != "" {
+			formattedResponse += "\n\n_" + note + "_"
+		}
+	}
+
+	// List the sources under generated answers whose profile cites them that way
+	if model != "" {
+		if profile := s.llm.AnswerProfile(ctx, inquiry); profile != nil && profile.Citations == "list" {
+			if sources := sourceList(searchResults); sources != "" {
+				formattedResponse += "\n\n" + sources
+			}
+		}
+	}
+
+	// Footer the model and sources behind generated answers
+	if s.cfg().AnswerAttribution && model != "" {
+		formattedResponse += "\n\n_" + answerAttribution(model, s.llm.selectContextResults(ctx, searchResults)) + "_"
+	}
+	if s.cfg().ShowConfidence && model != "" {
+		formattedResponse += "\n\n_Confidence: " + confidenceLabel(searchResults) + "_"
+	}
+
+	// Send as a thread reply to the original message, the first reply carrying the header
+	var threadTS string
+	var err error
+	switch {
+	case utf8.RuneCountInString(formattedResponse) <= slackMessageLimit:
+		threadTS, err = s.postReply(ctx, inquiry, formattedResponse)
+	case s.cfg().LongAnswerStrategy == "snippet":
+		threadTS, err = s.sendSnippetResponse(ctx, inquiry, response, model, searchResults)
+	default:
+		threadTS, err = s.sendSplitResponse(ctx, inquiry, formattedResponse)
 	}
 
 	// Update inquiry with thread timestamp
-	inquiry.ThreadTimestamp = threadTS
-	s.db.Save(inquiry)
+	if threadTS != "" {
+		inquiry.ThreadTimestamp = threadTS
+		s.db.Save(inquiry)
+	}
 
-	return nil
+	if err == nil && s.cfg().OutcomeReactions {
+		emoji := outcomeAnswered
+		if fallback {
+			emoji = outcomeFallback
+		}
+		s.addOutcomeReaction(ctx, inquiry, emoji)
+	}
+
+	return err
+}
+
+// sendSplitResponse posts an oversized response as consecutive thread replies
+// split at paragraph boundaries and returns the timestamp of the first one
+func (s *InquiryService) sendSplitResponse(ctx context.Context, inquiry *storage.Inquiry, formattedResponse string) (string, error) {
+	var firstTS string
+	for i, chunk := range splitMessage(formattedResponse, slackMessageLimit) {
+		ts, err := s.postReply(ctx, inquiry, chunk)
+		if err != nil {
+			return firstTS, fmt.Errorf("failed to post response part %d: %w", i+1, err)
+		}
+		if firstTS == "" {
+			firstTS = ts
+		}
+	}
+	return firstTS, nil
+}
+
+// sendSnippetResponse posts the header with a short note and attaches the full
+// response as a snippet, returning the timestamp of the header reply
+func (s *InquiryService) sendSnippetResponse(ctx context.Context, inquiry *storage.Inquiry, response, model string, searchResults []storage.SearchResult) (string, error) {
+	note := "🤖 *AI Assistant Response*\n\nThe answer is too long for a single message, so it's attached as a snippet below."
+	if s.cfg().AnswerAttribution && model != "" {
+		note += "\n\n_" + answerAttribution(model, s.llm.selectContextResults(ctx, searchResults)) + "_"
+	}
+	if s.cfg().ShowConfidence && model != "" {
+		note += "\n\n_Confidence: " + confidenceLabel(searchResults) + "_"
+	}
+
+	threadTS, err := s.postReply(ctx, inquiry, note)
+	if err != nil {
+		return "", err
+	}
+
+	if err := s.slack.UploadSnippet(inquiry.ChannelID, inquiry.Timestamp, "AI Assistant Response", response); err != nil {
+		return threadTS, err
+	}
+	return threadTS, nil
 }
 
-// generateFallbackResponse generates a fallback response when AI fails
-func (s *InquiryService) generateFallbackResponse(searchResults []storage.SearchResult) string {
+// answerAttribution describes the model and the number of results per source behind an answer,
+// e.g. "Generated by gpt-4o-mini using 2 Slack threads and 1 Confluence page". Pass only the
+// results the answer's context was built from.
+func answerAttribution(model string, searchResults []storage.SearchResult) string {
+	counts := make(map[string]int)
+	for _, result := range searchResults {
+		counts[result.Source]++
+	}
+
+	var parts []string
+	for _, source := range []struct{ name, singular, plural string }{
+		{"slack", "Slack thread", "Slack threads"},
+		{"confluence", "Confluence page", "Confluence pages"},
+		{GitHubSource, "GitHub file", "GitHub files"},
+	} {
+		switch count := counts[source.name]; count {
+		case 0:
+		case 1:
+			parts = append(parts, "1 "+source.singular)
+		default:
+			parts = append(parts, fmt.Sprintf("%d %s", count, source.plural))
+		}
+		delete(counts, source.name)
+	}
+
+	other := 0
+	for _, count := range counts {
+		other += count
+	}
+	if other == 1 {
+		parts = append(parts, "1 other source")
+	} else if other > 1 {
+		parts = append(parts, fmt.Sprintf("%d other sources", other))
+	}
+
+	switch len(parts) {
+	case 0:
+		return fmt.Sprintf("Generated by %s without matching sources", model)
+	case 1:
+		return fmt.Sprintf("Generated by %s using %s", model, parts[0])
+	default:
+		return fmt.Sprintf("Generated by %s using %s and %s", model, strings.Join(parts[:len(parts)-1], ", "), parts[len(parts)-1])
+	}
+}
+
+// generateFallbackResponse generates a fallback response when AI fails, with
+// snippets highlighting where each result matched the query
+func (s *InquiryService) generateFallbackResponse(query string, searchResults []storage.SearchResult) string {
 	if len(searchResults) == 0 {
 		return "I couldn't find relevant information to answer your inquiry. You might want to check our documentation or reach out to the relevant team directly."
 	}
 
 	response := "I found some potentially relevant information:\n\n"
+	keywords := mergeSearchTerms(s.search.extractKeywords(query), s.search.ExtractNamedEntities(query))
 
 	for i, result := range searchResults {
 		if i >= 3 { // Limit to top 3 results
@@ -145,12 +670,7 @@ func (s *InquiryService) generateFallbackResponse(searchResults []storage.Search
 
 		response += fmt.Sprintf("• **%s** (%s)\n", result.Title, result.Source)
 		if result.Content != "" {
-			// Truncate content
-			content := result.Content
-			if len(content) > 100 {
-				content = content[:100] + "..."
-			}
-			response += fmt.Sprintf("  %s\n", content)
+			response += fmt.Sprintf("  %s\n", highlightSnippet(result.Content, keywords, s.cfg().SnippetWindow))
 		}
 		if result.URL != "" {
 			response += fmt.Sprintf("  %s\n", result.URL)
@@ -180,6 +700,93 @@ func (s *InquiryService) GetInquiryByMessageID(messageID string) (*storage.Inqui
 	return &inquiry, nil
 }
 
+// CountByCategory counts the inquiries created since the given time per category
+func (s *InquiryService) CountByCategory(since time.Time) (map[string]int64, error) {
+	var rows []struct {
+		Category string
+		Count    int64
+	}
+	if err := s.db.Model(&storage.Inquiry{}).
+		Select("category, COUNT(*) AS count").
+		Where("created_at >= ?", since).
+		Group("category").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		category := row.Category
+		if category == "" {
+			category = CategoryOther
+		}
+		counts[category] += row.Count
+	}
+	return counts, nil
+}
+
+// GetInquiryCount counts the inquiries created in channelID since the given
+// time. An empty channelID counts across all channels.
+func (s *InquiryService) GetInquiryCount(channelID string, since time.Time) (int64, error) {
+	return countInquiries(s.inquiriesSince(channelID, since))
+}
+
+// GetUserInquiryCount counts the inquiries userID asked for since the given
+// time, whether by posting or by reacting to someone else's message
+func (s *InquiryService) GetUserInquiryCount(userID string, since time.Time) (int64, error) {
+	return countInquiries(s.inquiriesSince("", since).Where("requested_by = ?", userID))
+}
+
+// inquiriesSince queries the inquiries created in channelID, or any channel
+// when empty, since the given time
+func (s *InquiryService) inquiriesSince(channelID string, since time.Time) *gorm.DB {
+	query := s.db.Model(&storage.Inquiry{}).Where("created_at >= ?", since)
+	if channelID != "" {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	return query
+}
+
+// countInquiries counts the inquiries matched by query
+func countInquiries(query *gorm.DB) (int64, error) {
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// checkUserRateLimit rejects the inquiry with ErrRateLimited and an ephemeral
+// note when userID already started MaxInquiriesPerHour inquiries in the last hour
+func (s *InquiryService) checkUserRateLimit(ctx context.Context, channelID, userID string) error {
+	if s.cfg().MaxInquiriesPerHour <= 0 || userID == "" {
+		return nil
+	}
+
+	count, err := s.GetUserInquiryCount(userID, time.Now().Add(-time.Hour))
+	if err != nil {
+		// Don't block inquiries on a failed lookup
+		loggerFrom(ctx).WithError(err).WithField("user_id", userID).Warn("Failed to count recent inquiries")
+		return nil
+	}
+	if count < int64(s.cfg().MaxInquiriesPerHour) {
+		return nil
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"user_id":    userID,
+		"count":      count,
+	}).Warn("User exceeded hourly inquiry limit, rejecting inquiry")
+
+	note := fmt.Sprintf("⏳ You've asked %d questions in the last hour, which is my limit. Please try again a little later.", count)
+	if err := s.slack.PostEphemeral(channelID, userID, note); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to post rate limit note")
+	}
+
+	return ErrRateLimited
+}
+
 // ListRecentInquiries lists recent inquiries
 func (s *InquiryService) ListRecentInquiries(limit int) ([]storage.Inquiry, error) {
 	var inquiries []storage.Inquiry
@@ -189,17 +796,47 @@ func (s *InquiryService) ListRecentInquiries(limit int) ([]storage.Inquiry, erro
 	return inquiries, nil
 }
 
-// ProcessReactionEvent processes a reaction event from Slack
+// requesterKey is the context key of the user who asked for an inquiry to be answered
+type requesterKey struct{}
+
+// withRequester returns a context recording that userID asked for the
+// inquiry to be answered, such as by reacting to another user's message
+func withRequester(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, requesterKey{}, userID)
+}
+
+// requesterFrom returns the user set by withRequester, or author when none was
+func requesterFrom(ctx context.Context, author string) string {
+	if userID, _ := ctx.Value(requesterKey{}).(string); userID != "" {
+		return userID
+	}
+	return author
+}
+
+// ProcessReactionEvent processes a reaction event from Slack. The reacting
+// user, not the message author, is held to MAX_INQUIRIES_PER_HOUR.
 func (s *InquiryService) ProcessReactionEvent(ctx context.Context, messageID, channelID, userID, reaction, eventType, timestamp string) error {
-	// Only process if it's the trigger emoji being added
-	if reaction != s.config.TriggerEmoji || eventType != "added" {
+	ctx = withRequester(NewInquiryContext(ctx), userID)
+	if s.isFeedbackReaction(reaction) {
+		return s.recordFeedback(channelID, messageID, userID, reaction, eventType)
+	}
+
+	if reaction == s.cfg().AnswerRefreshEmoji && eventType == "added" && s.cfg().AnswerTTLDays > 0 {
+		return s.refreshAnswer(ctx, messageID)
+	}
+
+	// Only process if a trigger emoji is being added; the force emoji also answers noisy threads
+	model, isTrigger := s.modelForReaction(reaction)
+	forced := s.isForceReaction(reaction)
+	if !(isTrigger || forced) || eventType != "added" {
 		return nil
 	}
 
-	logrus.WithFields(logrus.Fields{
+	loggerFrom(ctx).WithFields(logrus.Fields{
 		"message_id": messageID,
 		"channel_id": channelID,
 		"reaction":   reaction,
+		"forced":     forced,
 	}).Info("Processing trigger emoji reaction")
 
 	// Record the reaction event
@@ -214,35 +851,49 @@ func (s *InquiryService) ProcessReactionEvent(ctx context.Context, messageID, ch
 	}
 
 	if err := s.db.Create(reactionEvent).Error; err != nil {
-		logrus.WithError(err).Error("Failed to create reaction event record")
+		loggerFrom(ctx).WithError(err).Error("Failed to create reaction event record")
 		return err
 	}
 
 	// Check if we've already processed this message
 	var existingInquiry storage.Inquiry
 	if err := s.db.Where("message_id = ?", messageID).First(&existingInquiry).Error; err == nil {
-		logrus.Info("Message already processed, skipping")
+		loggerFrom(ctx).Info("Message already processed, skipping")
 		reactionEvent.Processed = true
 		reactionEvent.InquiryID = &existingInquiry.ID
 		s.db.Save(reactionEvent)
 		return nil
 	}
 
+	if !forced && s.skipOldMessage(ctx, channelID, userID, messageID, time.Now()) {
+		return nil
+	}
+
 	// Get the original message
 	slackMessage, err := s.slack.GetMessage(channelID, messageID)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to get original message")
+		loggerFrom(ctx).WithError(err).Error("Failed to get original message")
 		return err
 	}
 
+	if slackMessage.Text == "" && len(slackMessage.Files) > 0 {
+		slackMessage.Text = s.attachmentText(ctx, slackMessage.Files)
+	}
+	if s.cfg().LLMVisionEnabled {
+		ctx = WithInquiryImages(ctx, s.attachmentImages(ctx, slackMessage.Files))
+	}
 	if slackMessage.Text == "" {
-		logrus.Info("Slack message is empty")
+		loggerFrom(ctx).Info("Slack message is empty")
 		return fmt.Errorf("empty Slack message")
 	}
 
+	if !forced && s.skipNoisyThread(ctx, channelID, messageID) {
+		return nil
+	}
+
 	// Process the inquiry
-	if err := s.ProcessInquiry(ctx, messageID, channelID, slackMessage.User, slackMessage.Text, slackMessage.Timestamp); err != nil {
-		logrus.WithError(err).Error("Failed to process inquiry")
+	if err := s.ProcessInquiry(ctx, messageID, channelID, slackMessage.User, slackMessage.Text, slackMessage.Timestamp, model); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to process inquiry")
 		return err
 	}
 
@@ -255,3 +906,97 @@ func (s *InquiryService) ProcessReactionEvent(ctx context.Context, messageID, ch
 
 	return nil
 }
+
+// ProcessFileReactionEvent processes a reaction on a file or file comment. The
+// file title and comment text become the inquiry, answered where the file was shared.
+func (s *InquiryService) ProcessFileReactionEvent(ctx context.Context, fileID, commentID, userID, reaction, eventType, timestamp string) error {
+	ctx = withRequester(NewInquiryContext(ctx), userID)
+	if !s.cfg().ProcessFileReactions {
+		loggerFrom(ctx).WithField("file_id", fileID).Debug("File reaction processing disabled, skipping")
+		return nil
+	}
+
+	model, isTrigger := s.modelForReaction(reaction)
+	if !isTrigger || eventType != "added" {
+		return nil
+	}
+
+	fileMessage, err := s.slack.GetFileMessage(fileID, commentID)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("file_id", fileID).Error("Failed to get file")
+		return err
+	}
+
+	if fileMessage.Text == "" {
+		loggerFrom(ctx).Info("File has no title or comment text")
+		return fmt.Errorf("empty file text")
+	}
+
+	reactionEvent := &storage.ReactionEvent{
+		MessageID: fileMessage.ID,
+		ChannelID: fileMessage.Channel,
+		UserID:    userID,
+		Reaction:  reaction,
+		EventType: eventType,
+		Timestamp: timestamp,
+	}
+	if err := s.db.Create(reactionEvent).Error; err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to create reaction event record")
+		return err
+	}
+
+	// Check if we've already processed this file or comment
+	var existingInquiry storage.Inquiry
+	if err := s.db.Where("message_id = ?", fileMessage.ID).First(&existingInquiry).Error; err == nil {
+		loggerFrom(ctx).Info("File already processed, skipping")
+		reactionEvent.Processed = true
+		reactionEvent.InquiryID = &existingInquiry.ID
+		s.db.Save(reactionEvent)
+		return nil
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"file_id":    fileID,
+		"comment_id": commentID,
+		"channel_id": fileMessage.Channel,
+		"reaction":   reaction,
+	}).Info("Processing trigger emoji reaction on file")
+
+	if err := s.ProcessInquiry(ctx, fileMessage.ID, fileMessage.Channel, fileMessage.User, fileMessage.Text, fileMessage.Timestamp, model); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to process file inquiry")
+		return err
+	}
+
+	if inquiry, err := s.GetInquiryByMessageID(fileMessage.ID); err == nil {
+		reactionEvent.Processed = true
+		reactionEvent.InquiryID = &inquiry.ID
+		s.db.Save(reactionEvent)
+	}
+
+	return nil
+}
+
+// isForceReaction reports whether reaction is FORCE_ANSWER_EMOJI, which answers
+// messages the trigger emoji skips as too old or in noisy threads
+func (s *InquiryService) isForceReaction(reaction string) bool {
+	return s.cfg().ForceAnswerEmoji != "" && reaction == s.cfg().ForceAnswerEmoji
+}
+
+// modelForReaction reports whether reaction triggers an inquiry and which model
+// it selects. The default trigger emoji and disallowed overrides use the default model.
+func (s *InquiryService) modelForReaction(reaction string) (string, bool) {
+	model, mapped := s.cfg().EmojiModels[reaction]
+	if !mapped {
+		return "", reaction == s.cfg().TriggerEmoji
+	}
+
+	if !s.llm.IsModelAllowed(model) {
+		logrus.WithFields(logrus.Fields{
+			"reaction": reaction,
+			"model":    model,
+		}).Warn("Emoji model override is not in the allowed models list, using default model")
+		return "", true
+	}
+
+	return model, true
+}
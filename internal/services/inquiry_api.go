@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateAPIInquiry records a pending inquiry submitted through the API. It has
+// no Slack message, so the answer is only stored for the caller to fetch.
+func (s *InquiryService) CreateAPIInquiry(channelID, userID, text string) (*storage.Inquiry, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return nil, fmt.Errorf("failed to generate inquiry ID: %w", err)
+	}
+
+	inquiry := &storage.Inquiry{
+		MessageID:   "api-" + hex.EncodeToString(id),
+		ChannelID:   channelID,
+		UserID:      userID,
+		MessageText: text,
+		Status:      "pending",
+		Source:      InquirySourceAPI,
+	}
+	if err := s.db.Create(inquiry).Error; err != nil {
+		return nil, fmt.Errorf("failed to create inquiry: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"channel_id": channelID,
+		"user_id":    userID,
+	}).Info("Created API inquiry")
+
+	return inquiry, nil
+}
+
+// ProcessAPIInquiry runs the pipeline for an inquiry created with CreateAPIInquiry
+func (s *InquiryService) ProcessAPIInquiry(ctx context.Context, inquiryID uint) error {
+	ctx = NewInquiryContext(ctx)
+	var inquiry storage.Inquiry
+	if err := s.db.First(&inquiry, inquiryID).Error; err != nil {
+		return fmt.Errorf("failed to load inquiry: %w", err)
+	}
+	if inquiry.Source != InquirySourceAPI {
+		return fmt.Errorf("inquiry %d was not created through the API", inquiryID)
+	}
+
+	return s.runPipeline(ctx, &inquiry)
+}
+
+// MarkInquiryFailed sets an inquiry's status to failed, e.g. when it could not be queued
+func (s *InquiryService) MarkInquiryFailed(inquiryID uint) error {
+	return s.db.Model(&storage.Inquiry{}).Where("id = ?", inquiryID).Update("status", "failed").Error
+}
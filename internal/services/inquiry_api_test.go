@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestProcessAPIInquiry(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerTTLDays = 30
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run the deploy script.")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	inquiry, err := service.CreateAPIInquiry("C1", "U1", "How do I deploy?")
+	if err != nil {
+		t.Fatalf("CreateAPIInquiry returned error: %v", err)
+	}
+	if err := service.ProcessAPIInquiry(context.Background(), inquiry.ID); err != nil {
+		t.Fatalf("ProcessAPIInquiry returned error: %v", err)
+	}
+
+	stored, err := service.GetInquiry(inquiry.ID)
+	if err != nil {
+		t.Fatalf("GetInquiry returned error: %v", err)
+	}
+	if stored.Status != "completed" || stored.ResponseText != "Run the deploy script." {
+		t.Errorf("Expected completed inquiry with answer, got status %q answer %q", stored.Status, stored.ResponseText)
+	}
+	if stored.RefreshAfter != nil {
+		t.Errorf("Expected API inquiries not to be offered refreshes")
+	}
+	if replies := fake.callsTo("chat.postMessage"); len(replies) != 0 {
+		t.Errorf("Expected no Slack replies for API inquiries, got %d", len(replies))
+	}
+}
+
+func TestProcessAPIInquiry_RejectsSlackInquiries(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	inquiry, _ := service.CreateAPIInquiry("C1", "U1", "How do I deploy?")
+	db.Model(inquiry).Update("source", InquirySourceSlack)
+
+	if err := service.ProcessAPIInquiry(context.Background(), inquiry.ID); err == nil {
+		t.Error("Expected error processing a Slack inquiry through the API path")
+	}
+}
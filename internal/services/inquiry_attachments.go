@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/slack-go/slack"
+)
+
+// maxInquiryImages caps the images of a message sent to the vision model
+const maxInquiryImages = 4
+
+// attachmentImages downloads the JPEG and PNG images among files as data URIs
+// for the vision model, skipping images that can't be downloaded
+func (s *InquiryService) attachmentImages(ctx context.Context, files []slack.File) []string {
+	var images []string
+	for _, file := range files {
+		if !isImageFile(file) {
+			continue
+		}
+		if len(images) == maxInquiryImages {
+			loggerFrom(ctx).WithField("limit", maxInquiryImages).Info("Skipping images over the limit")
+			break
+		}
+
+		image, err := s.slack.GetImageDataURI(ctx, file)
+		if err != nil {
+			loggerFrom(ctx).WithError(err).WithField("file_id", file.ID).Warn("Failed to download image")
+			continue
+		}
+		images = append(images, image)
+	}
+	return images
+}
+
+// attachmentText builds the question of a message that only shares files: the
+// title and content of each file, or just the title of files whose content
+// can't be downloaded
+func (s *InquiryService) attachmentText(ctx context.Context, files []slack.File) string {
+	var parts []string
+	for _, file := range files {
+		title := file.Title
+		if title == "" {
+			title = file.Name
+		}
+
+		content, err := s.slack.GetFileContent(ctx, file)
+		if err != nil {
+			loggerFrom(ctx).WithError(err).WithFields(logrus.Fields{
+				"file_id":  file.ID,
+				"mimetype": file.Mimetype,
+			}).Debug("Using the file title instead of its content")
+		}
+		if part := strings.TrimSpace(title + "\n" + strings.TrimSpace(content)); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
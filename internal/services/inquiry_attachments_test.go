@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestProcessReactionEvent_FileOnlyMessage(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", fmt.Sprintf(`{"ok": true, "messages": [{"type": "message", "subtype": "file_share", "user": "U2", "text": "", "ts": "1.1", "files": [
+		{"id": "F1", "name": "error.log", "title": "Deploy error", "mimetype": "text/plain", "size": 40, "url_private_download": "%[1]sfiles/error.log"},
+		{"id": "F2", "name": "screenshot.png", "title": "Screenshot", "mimetype": "image/png", "size": 2048, "url_private_download": "%[1]sfiles/screenshot.png"}
+	]}]}`, cfg.SlackAPIURL))
+	fake.respond("files/error.log", "helm upgrade failed: timed out waiting")
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessReactionEvent(context.Background(), "1.1", "C1", "U1", "eyes", "added", "2.2"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+
+	inquiry, err := service.GetInquiryByMessageID("1.1")
+	if err != nil {
+		t.Fatalf("Expected the file-only message to be recorded: %v", err)
+	}
+	want := "Deploy error\nhelm upgrade failed: timed out waiting\n\nScreenshot"
+	if inquiry.MessageText != want {
+		t.Errorf("Expected message text %q, got %q", want, inquiry.MessageText)
+	}
+	if llm.requestCount() == 0 {
+		t.Error("Expected the file-only message to be answered")
+	}
+	if calls := fake.callsTo("files/screenshot.png"); len(calls) != 0 {
+		t.Errorf("Expected the image not to be downloaded, got %d downloads", len(calls))
+	}
+}
+
+func TestProcessReactionEvent_ImageForVisionModel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMVisionEnabled = true
+	cfg.LLMVisionModel = "gpt-4o"
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", fmt.Sprintf(`{"ok": true, "messages": [{"type": "message", "user": "U2", "text": "Why does the deploy fail like this?", "ts": "1.1", "files": [
+		{"id": "F1", "name": "error.png", "mimetype": "image/png", "size": 70, "url_private_download": "%[1]sfiles/error.png"},
+		{"id": "F2", "name": "notes.txt", "mimetype": "text/plain", "size": 10, "url_private_download": "%[1]sfiles/notes.txt"}
+	]}]}`, cfg.SlackAPIURL))
+	image, err := os.ReadFile("testdata/pixel.png")
+	if err != nil {
+		t.Fatalf("Failed to read fixture image: %v", err)
+	}
+	fake.respond("files/error.png", string(image))
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessReactionEvent(context.Background(), "1.1", "C1", "U1", "eyes", "added", "2.2"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+
+	if llm.requestCount() == 0 {
+		t.Fatal("Expected the message to be answered")
+	}
+	request := llm.requests[0]
+	if request["model"] != "gpt-4o" {
+		t.Errorf("Expected the vision model, got %v", request["model"])
+	}
+	parts, ok := request["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{})
+	if !ok || len(parts) != 2 || parts[1].(map[string]interface{})["image_url"].(map[string]interface{})["url"] != pixelDataURI(t) {
+		t.Errorf("Expected only the image to be sent with the prompt, got %v", request["messages"])
+	}
+}
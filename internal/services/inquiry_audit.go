@@ -0,0 +1,31 @@
+package services
+
+import (
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// RecordAudit appends an entry to the audit log. A failure is logged rather
+// than returned since the change it describes has already been made.
+func (s *InquiryService) RecordAudit(userID, action, resourceType, resourceID string) {
+	event := storage.AuditLog{
+		UserID:       userID,
+		Action:       action,
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}
+	if err := storage.AppendAuditLog(s.db, event); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"action":        action,
+			"resource_type": resourceType,
+			"resource_id":   resourceID,
+		}).Error("Failed to record audit log entry")
+	}
+}
+
+// ListAuditLog returns up to limit audit log entries since the given time, newest first
+func (s *InquiryService) ListAuditLog(since time.Time, limit int) ([]storage.AuditLog, error) {
+	return storage.ListAuditLog(s.db, since, limit)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// canvasTitleLength caps the inquiry text used as a canvas title
+const canvasTitleLength = 80
+
+// publishCanvas publishes a completed answer as a channel canvas when its best
+// search result scores above CanvasPublishThreshold, records the canvas ID on
+// the inquiry and links it in the answer thread. It reports whether a canvas
+// was created.
+func (s *InquiryService) publishCanvas(ctx context.Context, inquiry *storage.Inquiry, response string, searchResults []storage.SearchResult) (bool, error) {
+	if !s.cfg().CanvasPublishEnabled || inquiry.ExternalDocumentID != "" || inquiry.Source == InquirySourceAPI {
+		return false, nil
+	}
+
+	bestScore := bestResultScore(searchResults)
+	if bestScore <= s.cfg().CanvasPublishThreshold {
+		return false, nil
+	}
+
+	title := []rune(inquiry.MessageText)
+	if len(title) > canvasTitleLength {
+		title = append(title[:canvasTitleLength], '…')
+	}
+
+	content := fmt.Sprintf("# %s\n\n%s", inquiry.MessageText, response)
+	canvasID, err := s.slack.CreateCanvas(inquiry.ChannelID, string(title), content)
+	if err != nil {
+		return false, err
+	}
+
+	inquiry.ExternalDocumentID = canvasID
+	if err := s.db.Model(inquiry).Update("external_document_id", canvasID).Error; err != nil {
+		return true, fmt.Errorf("failed to record canvas ID: %w", err)
+	}
+
+	link := canvasID
+	if permalink, err := s.slack.GetFilePermalink(canvasID); err != nil {
+		loggerFrom(ctx).WithError(err).WithField("canvas_id", canvasID).Warn("Failed to get canvas permalink")
+	} else if permalink != "" {
+		link = permalink
+	}
+
+	note := fmt.Sprintf("📌 This answer has been saved as a canvas for future reference: %s", link)
+	if _, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, note); err != nil {
+		return true, fmt.Errorf("failed to post canvas link: %w", err)
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"canvas_id":  canvasID,
+		"score":      bestScore,
+	}).Info("Published answer as canvas")
+
+	return true, nil
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestPublishCanvas(t *testing.T) {
+	tests := []struct {
+		name      string
+		enabled   bool
+		score     float64
+		published bool
+	}{
+		{name: "above threshold", enabled: true, score: 0.95, published: true},
+		{name: "at threshold", enabled: true, score: 0.9, published: false},
+		{name: "disabled", enabled: false, score: 0.95, published: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.CanvasPublishEnabled = tt.enabled
+			fake := newFakeSlack(t, cfg)
+			fake.respond("canvases.create", `{"ok": true, "canvas_id": "F0CANVAS"}`)
+			fake.respond("files.info", `{"ok": true, "file": {"id": "F0CANVAS", "permalink": "https://example.slack.com/docs/T1/F0CANVAS"}}`)
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+
+			inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", MessageText: "How do I deploy?", Timestamp: "1.1", Status: "completed"}
+			db.Create(inquiry)
+			results := []storage.SearchResult{{Source: "confluence", Score: 0.5}, {Source: "slack", Score: tt.score}}
+
+			published, err := service.publishCanvas(context.Background(), inquiry, "Run the deploy script", results)
+			if err != nil {
+				t.Fatalf("publishCanvas returned error: %v", err)
+			}
+			if published != tt.published {
+				t.Fatalf("Expected published=%v, got %v", tt.published, published)
+			}
+
+			var stored storage.Inquiry
+			db.First(&stored, inquiry.ID)
+			replies := fake.callsTo("chat.postMessage")
+			if !tt.published {
+				if stored.ExternalDocumentID != "" || len(fake.callsTo("canvases.create")) != 0 || len(replies) != 0 {
+					t.Errorf("Expected no canvas to be created")
+				}
+				return
+			}
+
+			if stored.ExternalDocumentID != "F0CANVAS" {
+				t.Errorf("Expected stored canvas ID 'F0CANVAS', got '%s'", stored.ExternalDocumentID)
+			}
+			if len(replies) != 1 || replies[0].Get("thread_ts") != "1.1" ||
+				!strings.Contains(replies[0].Get("text"), "https://example.slack.com/docs/T1/F0CANVAS") {
+				t.Errorf("Expected canvas link in the thread, got %v", replies)
+			}
+			if content := fake.callsTo("canvases.create")[0].Get("document_content"); !strings.Contains(content, "Run the deploy script") {
+				t.Errorf("Expected answer in canvas content, got %s", content)
+			}
+		})
+	}
+}
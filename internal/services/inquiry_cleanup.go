@@ -0,0 +1,40 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// interruptedNote replaces the response of inquiries interrupted mid-processing
+const interruptedNote = "Processing was interrupted, e.g. by a restart, before an answer was sent."
+
+// CleanupProcessingInquiries marks inquiries that have been processing for
+// longer than ProcessingTimeoutMinutes as failed. Such inquiries were
+// interrupted by a crash or restart and would otherwise stay processing
+// forever; as failures they are picked up by reprocessing like any other.
+func (s *InquiryService) CleanupProcessingInquiries(ctx context.Context) error {
+	cutoff := time.Now().Add(-time.Duration(s.cfg().ProcessingTimeoutMinutes) * time.Minute)
+
+	var stuck []storage.Inquiry
+	if err := s.db.WithContext(ctx).Where("status = ? AND updated_at < ?", "processing", cutoff).Find(&stuck).Error; err != nil {
+		return fmt.Errorf("failed to list processing inquiries: %w", err)
+	}
+
+	for i := range stuck {
+		inquiry := &stuck[i]
+		inquiry.Status = "failed"
+		inquiry.ResponseText = interruptedNote
+		s.recordFailure(ctx, inquiry, errors.New("processing interrupted"))
+	}
+
+	if len(stuck) > 0 {
+		logrus.WithField("count", len(stuck)).Warn("Marked interrupted inquiries as failed")
+	}
+
+	return nil
+}
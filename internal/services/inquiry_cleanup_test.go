@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestCleanupProcessingInquiries(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestInquiryService(config.LoadTestConfig(), db)
+
+	stuck := &storage.Inquiry{MessageID: "1.1", Status: "processing"}
+	running := &storage.Inquiry{MessageID: "2.2", Status: "processing"}
+	done := &storage.Inquiry{MessageID: "3.3", Status: "completed"}
+	for _, inquiry := range []*storage.Inquiry{stuck, running, done} {
+		db.Create(inquiry)
+	}
+	old := time.Now().Add(-time.Hour)
+	db.Model(&storage.Inquiry{}).Where("id IN ?", []uint{stuck.ID, done.ID}).UpdateColumn("updated_at", old)
+
+	if err := service.CleanupProcessingInquiries(context.Background()); err != nil {
+		t.Fatalf("CleanupProcessingInquiries returned error: %v", err)
+	}
+
+	statuses := make(map[string]storage.Inquiry)
+	var inquiries []storage.Inquiry
+	db.Find(&inquiries)
+	for _, inquiry := range inquiries {
+		statuses[inquiry.MessageID] = inquiry
+	}
+	if got := statuses["1.1"]; got.Status != "failed" || got.ResponseText != interruptedNote || got.FailureReason == "" {
+		t.Errorf("Expected the stuck inquiry to be failed with a note, got %+v", got)
+	}
+	if got := statuses["2.2"].Status; got != "processing" {
+		t.Errorf("Expected the recently started inquiry to keep processing, got %q", got)
+	}
+	if got := statuses["3.3"].Status; got != "completed" {
+		t.Errorf("Expected the completed inquiry to be left alone, got %q", got)
+	}
+}
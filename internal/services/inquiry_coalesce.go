@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// coalescedAnswer is an answer generated once for identical in-flight inquiries
+type coalescedAnswer struct {
+	response         string
+	model            string
+	confidenceScore  float64
+	confidenceScored bool
+}
+
+// generateCoalescedAnswer is generateAnswer, except that with COALESCE_INQUIRIES
+// an inquiry asking the same question as one already being answered, from the
+// same search results with the same model and answer profile, waits for that
+// answer and reuses it instead of asking the model again
+func (s *InquiryService) generateCoalescedAnswer(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) (string, string, error) {
+	if !s.cfg().CoalesceInquiries {
+		return s.generateAnswer(ctx, inquiry, searchResults)
+	}
+
+	// shared is also true for the inquiry whose answer was shared, which ran it
+	ran := false
+	value, err, shared := s.answerFlight.Do(s.coalesceKey(ctx, inquiry, searchResults), func() (interface{}, error) {
+		ran = true
+		response, model, err := s.generateAnswer(ctx, inquiry, searchResults)
+		if err != nil {
+			return nil, err
+		}
+		return &coalescedAnswer{response: response, model: model, confidenceScore: inquiry.ConfidenceScore, confidenceScored: inquiry.ConfidenceScored}, nil
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	answer := value.(*coalescedAnswer)
+	// The tokens are only recorded on the inquiry that spent them
+	if shared && !ran {
+		inquiry.ConfidenceScore, inquiry.ConfidenceScored = answer.confidenceScore, answer.confidenceScored
+		s.metrics.Incr("inquiry.coalesced", nil)
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": inquiry.ID,
+			"model":      answer.model,
+		}).Info("Shared the answer to an identical in-flight inquiry")
+	}
+	return answer.response, answer.model, nil
+}
+
+// coalesceKey identifies the answers generateCoalescedAnswer may share: the
+// normalized question, the model, the answer profile's instructions, the
+// search results the answer is built from and the images shared with it
+func (s *InquiryService) coalesceKey(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) string {
+	sources := make([]string, 0, len(searchResults))
+	for _, result := range searchResults {
+		sources = append(sources, result.Source+":"+result.SourceID)
+	}
+	sort.Strings(sources)
+
+	var instructions string
+	if profile := s.llm.AnswerProfile(ctx, inquiry); profile != nil {
+		instructions = profileInstructions(profile)
+	}
+
+	var images []string
+	for _, image := range s.llm.answerImages(ctx) {
+		sum := sha256.Sum256([]byte(image))
+		images = append(images, hex.EncodeToString(sum[:]))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		ContentHash(inquiry.MessageText),
+		inquiry.Model,
+		instructions,
+		strings.Join(sources, ","),
+		strings.Join(images, ","),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// processConcurrently processes one inquiry per question at the same time, each
+// from its own user in its own channel
+func processConcurrently(t *testing.T, service *InquiryService, questions ...string) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	for i, question := range questions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ts := string(rune('1'+i)) + ".1"
+			if err := service.ProcessInquiry(context.Background(), ts, "C"+ts, "U"+ts, question, ts, ""); err != nil {
+				t.Errorf("ProcessInquiry(%q) returned error: %v", question, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestProcessInquiry_CoalescesIdenticalInquiries(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.CoalesceInquiries = true
+	cfg.LLMTimeout = 5 * time.Second
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "Run the deploy script.")
+	llm.answerAfter(200 * time.Millisecond)
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	processConcurrently(t, service, "How do I deploy?", "how do I deploy")
+
+	if llm.requestCount() != 1 {
+		t.Errorf("Expected the identical inquiries to share one LLM call, got %d", llm.requestCount())
+	}
+	var inquiries []storage.Inquiry
+	db.Find(&inquiries)
+	if len(inquiries) != 2 {
+		t.Fatalf("Expected 2 inquiries, got %d", len(inquiries))
+	}
+	for _, inquiry := range inquiries {
+		if inquiry.Status != "completed" || inquiry.ResponseText != "Run the deploy script." {
+			t.Errorf("Expected inquiry %d to be answered with the shared answer, got %s %q", inquiry.ID, inquiry.Status, inquiry.ResponseText)
+		}
+	}
+	if posts := fake.callsTo("chat.postMessage"); len(posts) != 2 {
+		t.Errorf("Expected each inquiry to get its own reply, got %d", len(posts))
+	}
+}
+
+func TestProcessInquiry_CoalescingOnlySharesIdenticalInquiries(t *testing.T) {
+	tests := []struct {
+		name      string
+		coalesce  bool
+		questions []string
+	}{
+		{name: "disabled", questions: []string{"How do I deploy?", "How do I deploy?"}},
+		{name: "different questions", coalesce: true, questions: []string{"How do I deploy?", "How do I roll back?"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.CoalesceInquiries = tt.coalesce
+			cfg.LLMTimeout = 5 * time.Second
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			llm := newFakeLLM(t, cfg, "answer")
+			llm.answerAfter(100 * time.Millisecond)
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			processConcurrently(t, service, tt.questions...)
+
+			if llm.requestCount() != 2 {
+				t.Errorf("Expected each inquiry to be answered separately, got %d LLM calls", llm.requestCount())
+			}
+		})
+	}
+}
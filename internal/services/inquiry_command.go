@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// commandExcerptLimit caps the excerpt shown for Slack results of /inquiry-search, in characters
+const commandExcerptLimit = 80
+
+// AskFromCommand posts question to the channel on behalf of userID, as asked
+// with /inquiry-ask, and answers it in the post's thread. Searches are
+// restricted to the sources set on ctx with WithSourceFilter.
+func (s *InquiryService) AskFromCommand(ctx context.Context, channelID, userID, question string) error {
+	ts, err := s.slack.PostMessage(channelID, fmt.Sprintf("❓ <@%s> asked: %s", userID, question))
+	if err != nil {
+		return fmt.Errorf("failed to post question: %w", err)
+	}
+
+	return s.ProcessInquiry(ctx, ts, channelID, userID, question, ts, "")
+}
+
+// SearchFromCommand searches for query, as asked with /inquiry-search, and
+// shows userID the ranked results in an ephemeral message. Searches are
+// restricted to the sources set on ctx with WithSourceFilter.
+func (s *InquiryService) SearchFromCommand(ctx context.Context, channelID, userID, query string) error {
+	results, status, err := s.search.SearchAll(ctx, query, 0, channelID)
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	response := searchCommandResponse(query, results)
+	if note := partialResultsNote(status, "these results are"); note != "" {
+		response += "\n_" + note + "_"
+	}
+	return s.slack.PostEphemeral(channelID, userID, response)
+}
+
+// searchCommandResponse lists results found for query, one per line
+func searchCommandResponse(query string, results []storage.SearchResult) string {
+	if len(results) == 0 {
+		return fmt.Sprintf("🔍 No results found for \"%s\".", query)
+	}
+
+	lines := []string{fmt.Sprintf("🔍 Results for \"%s\":", query)}
+	for _, result := range results {
+		title := result.Title
+		if result.Source == "slack" {
+			title = strings.Join(strings.Fields(result.Content), " ")
+			if utf8.RuneCountInString(title) > commandExcerptLimit {
+				title = string([]rune(title)[:commandExcerptLimit]) + "…"
+			}
+		}
+		if result.URL != "" {
+			title = fmt.Sprintf("<%s|%s>", result.URL, title)
+		}
+		lines = append(lines, fmt.Sprintf("• [%s] %s (%.2f)", result.Source, title, result.Score))
+	}
+	return strings.Join(lines, "\n")
+}
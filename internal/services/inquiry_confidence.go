@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// confidenceTier is how an answer is delivered given how well its sources match
+type confidenceTier int
+
+const (
+	// confidenceAutoPost posts the generated answer as it is
+	confidenceAutoPost confidenceTier = iota
+	// confidenceFlagged posts the generated answer under lowConfidenceNote
+	confidenceFlagged
+	// confidenceLinksOnly posts the matching sources without generating an answer
+	confidenceLinksOnly
+)
+
+// lowConfidenceNote heads answers in the flagged confidence tier
+const lowConfidenceNote = "⚠️ _Low confidence, please verify this answer against the sources._"
+
+// Confidence labels shown under answers with SHOW_CONFIDENCE
+const (
+	confidenceHigh   = "High"
+	confidenceMedium = "Medium"
+	confidenceLow    = "Low"
+)
+
+const (
+	// confidenceLabelResults is how many of the best-scoring results the label's
+	// aggregate score averages
+	confidenceLabelResults = 3
+	// highConfidenceScore is the aggregate score a High answer needs, along with
+	// documentation among its sources
+	highConfidenceScore = 0.75
+	// mediumConfidenceScore is the aggregate score a Medium answer needs
+	mediumConfidenceScore = 0.5
+)
+
+// confidenceLabel labels how sure an answer built from searchResults can be:
+// High when its best results average at least highConfidenceScore and include
+// documentation, Medium when they average at least mediumConfidenceScore, and
+// Low otherwise. Chat alone tops out at Medium, since a discussion may not have
+// reached an answer.
+func confidenceLabel(searchResults []storage.SearchResult) string {
+	score := topResultsScore(searchResults, confidenceLabelResults)
+	switch {
+	case score >= highConfidenceScore && hasDocumentation(searchResults):
+		return confidenceHigh
+	case score >= mediumConfidenceScore:
+		return confidenceMedium
+	default:
+		return confidenceLow
+	}
+}
+
+// topResultsScore averages the scores of the n best-scoring results, 0 without results
+func topResultsScore(searchResults []storage.SearchResult, n int) float64 {
+	scores := make([]float64, 0, len(searchResults))
+	for _, result := range searchResults {
+		scores = append(scores, result.Score)
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(scores)))
+	if len(scores) > n {
+		scores = scores[:n]
+	}
+	if len(scores) == 0 {
+		return 0
+	}
+
+	var sum float64
+	for _, score := range scores {
+		sum += score
+	}
+	return sum / float64(len(scores))
+}
+
+// hasDocumentation reports whether searchResults include a Confluence page or
+// a Markdown document from GitHub
+func hasDocumentation(searchResults []storage.SearchResult) bool {
+	for _, result := range searchResults {
+		if result.Source == "confluence" || (result.Source == GitHubSource && isMarkdownPath(result.SourceID)) {
+			return true
+		}
+	}
+	return false
+}
+
+// bestResultScore returns the highest search result score, 0 without results
+func bestResultScore(searchResults []storage.SearchResult) float64 {
+	var best float64
+	for _, result := range searchResults {
+		if result.Score > best {
+			best = result.Score
+		}
+	}
+	return best
+}
+
+// scoreAnswerConfidence records how confident the model is in a generated
+// answer when ANSWER_CONFIDENCE_SCORING is enabled. Answers already scored from
+// log-probabilities keep that score; the others are scored by asking the model.
+func (s *InquiryService) scoreAnswerConfidence(ctx context.Context, inquiry *storage.Inquiry, answer string) {
+	if !s.cfg().AnswerConfidenceScoring || inquiry.ConfidenceScored {
+		return
+	}
+
+	confidence, err := s.llm.EvaluateConfidence(ctx, inquiry, answer)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Warn("Failed to score answer confidence")
+		return
+	}
+	inquiry.ConfidenceScore, inquiry.ConfidenceScored = confidence, true
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"confidence": confidence,
+	}).Debug("Scored answer confidence")
+}
+
+// lowAnswerConfidence reports whether the model scored inquiry's answer below
+// LOW_ANSWER_CONFIDENCE. Unscored answers aren't low.
+func (s *InquiryService) lowAnswerConfidence(inquiry *storage.Inquiry) bool {
+	return inquiry.ConfidenceScored && inquiry.ConfidenceScore < s.cfg().LowAnswerConfidence
+}
+
+// confidenceTier places an answer whose best source scores confidence in a
+// tier: at or above AutoPostConfidence it is posted, at or above
+// SuggestConfidence it is posted flagged, and below that only links are posted.
+// With both thresholds at 0 every answer is posted.
+func (s *InquiryService) confidenceTier(confidence float64) confidenceTier {
+	switch {
+	case confidence >= s.cfg().AutoPostConfidence:
+		return confidenceAutoPost
+	case confidence >= s.cfg().SuggestConfidence:
+		return confidenceFlagged
+	default:
+		return confidenceLinksOnly
+	}
+}
@@ -0,0 +1,225 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestConfidenceTier(t *testing.T) {
+	tests := []struct {
+		name       string
+		autoPost   float64
+		suggest    float64
+		confidence float64
+		expected   confidenceTier
+	}{
+		{name: "disabled", confidence: 0, expected: confidenceAutoPost},
+		{name: "above auto-post", autoPost: 0.8, suggest: 0.5, confidence: 0.9, expected: confidenceAutoPost},
+		{name: "at auto-post", autoPost: 0.8, suggest: 0.5, confidence: 0.8, expected: confidenceAutoPost},
+		{name: "between thresholds", autoPost: 0.8, suggest: 0.5, confidence: 0.6, expected: confidenceFlagged},
+		{name: "at suggest", autoPost: 0.8, suggest: 0.5, confidence: 0.5, expected: confidenceFlagged},
+		{name: "below suggest", autoPost: 0.8, suggest: 0.5, confidence: 0.4, expected: confidenceLinksOnly},
+		{name: "flagging only", autoPost: 0.8, confidence: 0.1, expected: confidenceFlagged},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.AutoPostConfidence = tt.autoPost
+			cfg.SuggestConfidence = tt.suggest
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			if tier := service.confidenceTier(tt.confidence); tier != tt.expected {
+				t.Errorf("Expected tier %d, got %d", tt.expected, tier)
+			}
+		})
+	}
+}
+
+func TestBestResultScore(t *testing.T) {
+	results := []storage.SearchResult{{Score: 0.4}, {Score: 0.9}, {Score: 0.7}}
+	if score := bestResultScore(results); score != 0.9 {
+		t.Errorf("Expected best score 0.9, got %v", score)
+	}
+	if score := bestResultScore(nil); score != 0 {
+		t.Errorf("Expected 0 without results, got %v", score)
+	}
+}
+
+func TestConfidenceLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		results  []storage.SearchResult
+		expected string
+	}{
+		{name: "no results", expected: confidenceLow},
+		{
+			name:     "strong docs",
+			results:  []storage.SearchResult{{Source: "confluence", Score: 0.9}, {Source: "slack", Score: 0.8}, {Source: "slack", Score: 0.6}},
+			expected: confidenceHigh,
+		},
+		{
+			name:     "at the high threshold",
+			results:  []storage.SearchResult{{Source: "confluence", Score: 0.75}},
+			expected: confidenceHigh,
+		},
+		{
+			name:     "GitHub runbook counts as docs",
+			results:  []storage.SearchResult{{Source: GitHubSource, SourceID: "docs/RUNBOOK.md", Score: 0.8}},
+			expected: confidenceHigh,
+		},
+		{
+			name:     "strong chat without docs",
+			results:  []storage.SearchResult{{Source: "slack", Score: 0.95}, {Source: GitHubSource, SourceID: "main.go", Score: 0.9}},
+			expected: confidenceMedium,
+		},
+		{
+			name:     "one strong result among weak ones",
+			results:  []storage.SearchResult{{Source: "confluence", Score: 0.95}, {Source: "slack", Score: 0.5}, {Source: "slack", Score: 0.4}},
+			expected: confidenceMedium,
+		},
+		{
+			name:     "only the best three count",
+			results:  []storage.SearchResult{{Source: "slack", Score: 0.1}, {Source: "confluence", Score: 0.8}, {Source: "slack", Score: 0.8}, {Source: "slack", Score: 0.8}},
+			expected: confidenceHigh,
+		},
+		{
+			name:     "at the medium threshold",
+			results:  []storage.SearchResult{{Source: "slack", Score: 0.5}},
+			expected: confidenceMedium,
+		},
+		{
+			name:     "weak matches",
+			results:  []storage.SearchResult{{Source: "confluence", Score: 0.49}, {Source: "slack", Score: 0.3}},
+			expected: confidenceLow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := confidenceLabel(tt.results); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_ShowConfidence(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ShowConfidence = enabled
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			newFakeLLM(t, cfg, "answer")
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "deploy", "1.1", ""); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			text := fake.callsTo("chat.postMessage")[0].Get("text")
+			if strings.Contains(text, "Confidence: Low") != enabled {
+				t.Errorf("Expected confidence label present=%v, got %q", enabled, text)
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_ConfidenceTiers(t *testing.T) {
+	tests := []struct {
+		name        string
+		suggest     float64
+		wantLLM     bool
+		wantFlagged bool
+	}{
+		{name: "flagged", wantLLM: true, wantFlagged: true},
+		{name: "links only", suggest: 0.5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.AutoPostConfidence = 0.8
+			cfg.SuggestConfidence = tt.suggest
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			llm := newFakeLLM(t, cfg, "Run make deploy")
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			if called := llm.requestCount() > 0; called != tt.wantLLM {
+				t.Errorf("Expected LLM called %v, got %v", tt.wantLLM, called)
+			}
+			posts := fake.callsTo("chat.postMessage")
+			if len(posts) == 0 {
+				t.Fatal("Expected a reply to be posted")
+			}
+			text := posts[0].Get("text")
+			if flagged := strings.Contains(text, lowConfidenceNote); flagged != tt.wantFlagged {
+				t.Errorf("Expected flagged %v, got %q", tt.wantFlagged, text)
+			}
+			if strings.Contains(text, "Run make deploy") != tt.wantLLM {
+				t.Errorf("Unexpected reply %q", text)
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_ScoresAnswerConfidence(t *testing.T) {
+	tests := []struct {
+		name           string
+		answer         string
+		logprobs       []float64
+		wantConfidence float64
+		wantRequests   int
+		wantForwarded  bool
+	}{
+		// The fake answers the self-evaluation with the answer itself
+		{name: "self-evaluated", answer: "0.3", wantConfidence: 0.3, wantRequests: 2, wantForwarded: true},
+		{name: "self-evaluated as not confident at all", answer: "0", wantConfidence: 0, wantRequests: 2, wantForwarded: true},
+		{name: "from log-probabilities", answer: "0.3", logprobs: []float64{0}, wantConfidence: 1, wantRequests: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.AnswerConfidenceScoring = true
+			cfg.ExpertRoutingEnabled = true
+			cfg.DefaultExpertUserID = "UEXPERT"
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			llm := newFakeLLM(t, cfg, tt.answer)
+			if tt.logprobs != nil {
+				llm.returnLogprobs(tt.logprobs...)
+			}
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+
+			if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			var inquiry storage.Inquiry
+			db.Where("message_id = ?", "1.1").First(&inquiry)
+			if !inquiry.ConfidenceScored || inquiry.ConfidenceScore != tt.wantConfidence {
+				t.Errorf("Expected confidence %v, got %v (scored %v)", tt.wantConfidence, inquiry.ConfidenceScore, inquiry.ConfidenceScored)
+			}
+			if llm.requestCount() != tt.wantRequests {
+				t.Errorf("Expected %d LLM requests, got %d", tt.wantRequests, llm.requestCount())
+			}
+			forwarded := len(postsTo(fake.callsTo("chat.postMessage"), "UEXPERT")) > 0
+			if forwarded != tt.wantForwarded {
+				t.Errorf("Expected forwarded %v, got %v", tt.wantForwarded, forwarded)
+			}
+		})
+	}
+}
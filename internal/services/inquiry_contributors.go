@@ -0,0 +1,74 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ContributorStats describes how many questions a user asked and how their answers were received
+type ContributorStats struct {
+	UserID       string `json:"user_id"`
+	UserName     string `json:"user_name"`
+	InquiryCount int    `json:"inquiry_count"`
+	// AvgResponseQuality is the share of feedback on the user's answers that
+	// was helpful, from 0 to 1; it is 0 when FeedbackCount is 0
+	AvgResponseQuality float64 `json:"avg_response_quality"`
+	FeedbackCount      int     `json:"feedback_count"`
+}
+
+// GetTopContributors returns the limit users who asked the most questions since
+// the given time, most active first
+func (s *InquiryService) GetTopContributors(since time.Time, limit int) ([]ContributorStats, error) {
+	return s.topContributors("", since, limit)
+}
+
+// topContributors is GetTopContributors restricted to questions asked in
+// channelID; an empty channelID counts across all channels
+func (s *InquiryService) topContributors(channelID string, since time.Time, limit int) ([]ContributorStats, error) {
+	var rows []struct {
+		UserID        string
+		InquiryCount  int
+		AvgHelpful    *float64
+		FeedbackCount int
+	}
+	query := s.db.Table("inquiries").
+		Select("inquiries.user_id, "+
+			"COUNT(DISTINCT inquiries.id) AS inquiry_count, "+
+			"AVG(CASE WHEN feedbacks.id IS NULL THEN NULL WHEN feedbacks.helpful THEN 1.0 ELSE 0.0 END) AS avg_helpful, "+
+			"COUNT(feedbacks.id) AS feedback_count").
+		Joins("LEFT JOIN feedbacks ON feedbacks.inquiry_id = inquiries.id").
+		Where("inquiries.deleted_at IS NULL AND inquiries.user_id <> '' AND inquiries.created_at >= ?", since)
+	if channelID != "" {
+		query = query.Where("inquiries.channel_id = ?", channelID)
+	}
+	if err := query.
+		Group("inquiries.user_id").
+		Order("inquiry_count DESC, inquiries.user_id").
+		Limit(limit).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to count inquiries per user: %w", err)
+	}
+
+	contributors := make([]ContributorStats, 0, len(rows))
+	for _, row := range rows {
+		contributor := ContributorStats{
+			UserID:        row.UserID,
+			UserName:      row.UserID,
+			InquiryCount:  row.InquiryCount,
+			FeedbackCount: row.FeedbackCount,
+		}
+		if row.AvgHelpful != nil {
+			contributor.AvgResponseQuality = *row.AvgHelpful
+		}
+		if user, err := s.slack.GetUserInfo(row.UserID); err == nil && user.RealName != "" {
+			contributor.UserName = user.RealName
+		} else if err != nil {
+			logrus.WithError(err).WithField("user_id", row.UserID).Debug("Failed to look up contributor name")
+		}
+		contributors = append(contributors, contributor)
+	}
+
+	return contributors, nil
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestGetTopContributors(t *testing.T) {
+	db := setupTestDB(t)
+	service := newTestInquiryService(config.LoadTestConfig(), db)
+
+	create := func(userID string, createdAt time.Time) *storage.Inquiry {
+		inquiry := &storage.Inquiry{MessageID: fmt.Sprintf("%s-%d", userID, createdAt.UnixNano()), ChannelID: "C1", UserID: userID, Status: "completed"}
+		db.Create(inquiry)
+		db.Model(inquiry).Update("created_at", createdAt)
+		return inquiry
+	}
+
+	now := time.Now()
+	alice1 := create("U-alice", now.Add(-time.Hour))
+	alice2 := create("U-alice", now.Add(-2*time.Hour))
+	create("U-alice", now.Add(-3*time.Hour))
+	create("U-bob", now.Add(-time.Hour))
+	// Too old to count
+	create("U-bob", now.AddDate(0, 0, -10))
+	create("U-bob", now.AddDate(0, 0, -10).Add(time.Minute))
+	create("U-bob", now.AddDate(0, 0, -10).Add(2*time.Minute))
+
+	db.Create(&storage.Feedback{InquiryID: alice1.ID, UserID: "U1", Helpful: true})
+	db.Create(&storage.Feedback{InquiryID: alice1.ID, UserID: "U2", Helpful: true})
+	db.Create(&storage.Feedback{InquiryID: alice2.ID, UserID: "U1", Helpful: false})
+	db.Create(&storage.Feedback{InquiryID: alice2.ID, UserID: "U2", Helpful: true})
+
+	contributors, err := service.GetTopContributors(now.AddDate(0, 0, -7), 10)
+	if err != nil {
+		t.Fatalf("GetTopContributors returned error: %v", err)
+	}
+	if len(contributors) != 2 {
+		t.Fatalf("Expected 2 contributors, got %+v", contributors)
+	}
+
+	alice := contributors[0]
+	if alice.UserID != "U-alice" || alice.InquiryCount != 3 || alice.FeedbackCount != 4 || alice.AvgResponseQuality != 0.75 {
+		t.Errorf("Unexpected top contributor: %+v", alice)
+	}
+	if alice.UserName != "U-alice" {
+		t.Errorf("Expected the user ID as name when Slack is unavailable, got %q", alice.UserName)
+	}
+	bob := contributors[1]
+	if bob.UserID != "U-bob" || bob.InquiryCount != 1 || bob.FeedbackCount != 0 || bob.AvgResponseQuality != 0 {
+		t.Errorf("Unexpected second contributor: %+v", bob)
+	}
+
+	if limited, _ := service.GetTopContributors(now.AddDate(0, 0, -7), 1); len(limited) != 1 || limited[0].UserID != "U-alice" {
+		t.Errorf("Expected only the top contributor with limit 1, got %+v", limited)
+	}
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotDeadLettered is returned when requeueing an inquiry that isn't dead-lettered
+var ErrNotDeadLettered = errors.New("inquiry is not dead-lettered")
+
+// recordFailure stores why a failed inquiry failed and dead-letters it once
+// MaxInquiryRetries reprocessing attempts have failed
+func (s *InquiryService) recordFailure(ctx context.Context, inquiry *storage.Inquiry, cause error) {
+	inquiry.FailureReason = cause.Error()
+	if s.cfg().MaxInquiryRetries > 0 && inquiry.RetryCount >= s.cfg().MaxInquiryRetries {
+		inquiry.Status = "dead_letter"
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id":  inquiry.ID,
+			"retry_count": inquiry.RetryCount,
+		}).Warn("Inquiry failed too many times, dead-lettering")
+	}
+
+	if err := s.db.Save(inquiry).Error; err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to record inquiry failure")
+	}
+}
+
+// ListDeadLetter lists up to limit dead-lettered inquiries, most recently failed first
+func (s *InquiryService) ListDeadLetter(limit int) ([]storage.Inquiry, error) {
+	var inquiries []storage.Inquiry
+	if err := s.db.Where("status = ?", "dead_letter").Order("updated_at DESC").Limit(limit).Find(&inquiries).Error; err != nil {
+		return nil, err
+	}
+	return inquiries, nil
+}
+
+// Requeue moves a dead-lettered inquiry back to failed with a fresh retry
+// budget, so the next reprocessing run picks it up again
+func (s *InquiryService) Requeue(inquiryID uint) (*storage.Inquiry, error) {
+	var inquiry storage.Inquiry
+	if err := s.db.First(&inquiry, inquiryID).Error; err != nil {
+		return nil, err
+	}
+	if inquiry.Status != "dead_letter" {
+		return nil, ErrNotDeadLettered
+	}
+
+	inquiry.Status = "failed"
+	inquiry.RetryCount = 0
+	if err := s.db.Save(&inquiry).Error; err != nil {
+		return nil, fmt.Errorf("failed to requeue inquiry: %w", err)
+	}
+
+	logrus.WithField("inquiry_id", inquiry.ID).Info("Requeued dead-lettered inquiry")
+	return &inquiry, nil
+}
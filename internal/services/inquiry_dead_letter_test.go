@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestReprocessInquiry_DeadLettersAfterMaxRetries(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxInquiryRetries = 2
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	// LiteLLM is left unconfigured so every attempt fails
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	inquiry, err := service.CreateAPIInquiry("C1", "U1", "How do I deploy?")
+	if err != nil {
+		t.Fatalf("CreateAPIInquiry returned error: %v", err)
+	}
+	if err := service.ProcessAPIInquiry(context.Background(), inquiry.ID); err == nil {
+		t.Fatal("Expected the first attempt to fail")
+	}
+
+	expected := []string{"failed", "dead_letter"}
+	for attempt, status := range expected {
+		if err := service.ReprocessInquiry(context.Background(), inquiry.ID); err == nil {
+			t.Fatalf("Expected retry %d to fail", attempt+1)
+		}
+		stored, _ := service.GetInquiry(inquiry.ID)
+		if stored.Status != status || stored.RetryCount != attempt+1 {
+			t.Errorf("After retry %d expected status %q with %d retries, got %q with %d",
+				attempt+1, status, attempt+1, stored.Status, stored.RetryCount)
+		}
+		if stored.FailureReason == "" {
+			t.Errorf("Expected a failure reason after retry %d", attempt+1)
+		}
+	}
+
+	deadLettered, err := service.ListDeadLetter(10)
+	if err != nil {
+		t.Fatalf("ListDeadLetter returned error: %v", err)
+	}
+	if len(deadLettered) != 1 || deadLettered[0].ID != inquiry.ID {
+		t.Errorf("Expected the inquiry to be listed as dead-lettered, got %+v", deadLettered)
+	}
+}
+
+func TestReprocessInquiry_RetriesForeverWhenDisabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxInquiryRetries = 0
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	inquiry, _ := service.CreateAPIInquiry("C1", "U1", "How do I deploy?")
+	_ = service.ProcessAPIInquiry(context.Background(), inquiry.ID)
+	for i := 0; i < 5; i++ {
+		_ = service.ReprocessInquiry(context.Background(), inquiry.ID)
+	}
+
+	if stored, _ := service.GetInquiry(inquiry.ID); stored.Status != "failed" {
+		t.Errorf("Expected inquiry to stay failed, got %q", stored.Status)
+	}
+}
+
+func TestRequeue(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	deadLettered := &storage.Inquiry{MessageID: "1", ChannelID: "C1", Status: "dead_letter", RetryCount: 3, FailureReason: "boom"}
+	completed := &storage.Inquiry{MessageID: "2", ChannelID: "C1", Status: "completed"}
+	db.Create(deadLettered)
+	db.Create(completed)
+
+	requeued, err := service.Requeue(deadLettered.ID)
+	if err != nil {
+		t.Fatalf("Requeue returned error: %v", err)
+	}
+	if requeued.Status != "failed" || requeued.RetryCount != 0 {
+		t.Errorf("Expected failed inquiry with a fresh retry budget, got %q with %d retries", requeued.Status, requeued.RetryCount)
+	}
+	if listed, _ := service.ListDeadLetter(10); len(listed) != 0 {
+		t.Errorf("Expected no dead-lettered inquiries after requeueing, got %d", len(listed))
+	}
+
+	if _, err := service.Requeue(completed.ID); !errors.Is(err, ErrNotDeadLettered) {
+		t.Errorf("Expected ErrNotDeadLettered for a completed inquiry, got %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// ContentHash identifies inquiries asking the same question: the text is
+// lowercased and reduced to its words, so case, punctuation and spacing
+// don't matter but word order does
+func ContentHash(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	sum := sha256.Sum256([]byte(strings.Join(words, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// findAnsweredDuplicate returns the most recent inquiry with the same content
+// hash answered within CrossChannelDedupWindow of now, or nil if there is none
+func (s *InquiryService) findAnsweredDuplicate(inquiry *storage.Inquiry, now time.Time) (*storage.Inquiry, error) {
+	var original storage.Inquiry
+	err := s.db.
+		Where("content_hash = ? AND id <> ? AND status = ? AND response_sent = ? AND processed_at >= ?",
+			inquiry.ContentHash, inquiry.ID, "completed", true, now.Add(-s.cfg().CrossChannelDedupWindow)).
+		Order("processed_at DESC").
+		First(&original).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &original, nil
+}
+
+// reuseAnswer answers inquiry with original's answer, noting where it was first
+// answered, instead of running search and generation again
+func (s *InquiryService) reuseAnswer(ctx context.Context, inquiry, original *storage.Inquiry) error {
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id":  inquiry.ID,
+		"original_id": original.ID,
+		"channel_id":  original.ChannelID,
+	}).Info("Reusing answer to the same question from another inquiry")
+
+	response := s.answeredElsewhereNote(ctx, original) + "\n\n" + original.ResponseText
+	if err := s.sendResponse(ctx, inquiry, response, "", nil, false); err != nil {
+		inquiry.Status = "failed"
+		s.db.Save(inquiry)
+		return fmt.Errorf("failed to send reused response: %w", err)
+	}
+
+	now := time.Now()
+	inquiry.Status = "completed"
+	inquiry.ProcessedAt = &now
+	inquiry.ResponseSent = true
+	inquiry.ResponseText = original.ResponseText
+	inquiry.Model = original.Model
+	inquiry.DuplicateOfID = &original.ID
+	return s.db.Save(inquiry).Error
+}
+
+// answeredElsewhereNote links the original question, falling back to its
+// channel when the permalink can't be fetched
+func (s *InquiryService) answeredElsewhereNote(ctx context.Context, original *storage.Inquiry) string {
+	if original.ChannelID == "" || original.Timestamp == "" {
+		return "_This question was answered before:_"
+	}
+
+	permalink, err := s.slack.GetMessagePermalink(original.ChannelID, original.Timestamp)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", original.ID).Warn("Failed to get permalink of original question")
+		return fmt.Sprintf("_This question was answered in <#%s> before:_", original.ChannelID)
+	}
+	return fmt.Sprintf("_This question was answered in <#%s> before (<%s|original thread>):_", original.ChannelID, permalink)
+}
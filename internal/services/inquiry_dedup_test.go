@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestContentHash(t *testing.T) {
+	base := ContentHash("How do I deploy the payment-api?")
+
+	if ContentHash("how do i deploy  the Payment API") != base {
+		t.Error("Expected case, punctuation and spacing to be ignored")
+	}
+	if ContentHash("How do I roll back the payment-api?") == base {
+		t.Error("Expected a different question to hash differently")
+	}
+	if ContentHash("the payment-api How do I deploy?") == base {
+		t.Error("Expected word order to matter")
+	}
+}
+
+func TestFindAnsweredDuplicate(t *testing.T) {
+	now := time.Now()
+	recent := now.Add(-time.Hour)
+	old := now.Add(-48 * time.Hour)
+	hash := ContentHash("How do I deploy?")
+
+	tests := []struct {
+		name     string
+		existing storage.Inquiry
+		reuse    bool
+	}{
+		{name: "answered in another channel", existing: storage.Inquiry{ChannelID: "C2", Status: "completed", ResponseSent: true, ProcessedAt: &recent}, reuse: true},
+		{name: "answered outside the window", existing: storage.Inquiry{ChannelID: "C2", Status: "completed", ResponseSent: true, ProcessedAt: &old}},
+		{name: "failed", existing: storage.Inquiry{ChannelID: "C2", Status: "failed", ProcessedAt: &recent}},
+		{name: "different question", existing: storage.Inquiry{ChannelID: "C2", Status: "completed", ResponseSent: true, ProcessedAt: &recent, ContentHash: ContentHash("How do I roll back?")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB(t)
+			service := newTestInquiryService(config.LoadTestConfig(), db)
+
+			existing := tt.existing
+			existing.MessageID = "1.1"
+			if existing.ContentHash == "" {
+				existing.ContentHash = hash
+			}
+			db.Create(&existing)
+			inquiry := &storage.Inquiry{MessageID: "2.2", ChannelID: "C1", ContentHash: hash}
+			db.Create(inquiry)
+
+			original, err := service.findAnsweredDuplicate(inquiry, now)
+			if err != nil {
+				t.Fatalf("findAnsweredDuplicate returned error: %v", err)
+			}
+			if tt.reuse && (original == nil || original.ID != existing.ID) {
+				t.Errorf("Expected to reuse inquiry %d, got %+v", existing.ID, original)
+			}
+			if !tt.reuse && original != nil {
+				t.Errorf("Expected a new answer, got duplicate of %d", original.ID)
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_CrossChannelDedup(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.CrossChannelDedup = true
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	fakeSlack.respond("chat.getPermalink", `{"ok": true, "permalink": "https://example.slack.com/archives/C1/p11"}`)
+	llm := newFakeLLM(t, cfg, "Use the deploy script.")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+	if err := service.ProcessInquiry(context.Background(), "2.2", "C2", "U2", "how do I deploy", "2.2", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	if llm.requestCount() != 1 {
+		t.Errorf("Expected the second inquiry to reuse the first answer, got %d LLM requests", llm.requestCount())
+	}
+
+	original, _ := service.GetInquiryByMessageID("1.1")
+	duplicate, _ := service.GetInquiryByMessageID("2.2")
+	if duplicate.Status != "completed" || duplicate.DuplicateOfID == nil || *duplicate.DuplicateOfID != original.ID {
+		t.Errorf("Expected a completed duplicate of %d, got %+v", original.ID, duplicate)
+	}
+
+	posts := fakeSlack.callsTo("chat.postMessage")
+	reply := posts[len(posts)-1]
+	if reply.Get("channel") != "C2" || !strings.Contains(reply.Get("text"), "https://example.slack.com/archives/C1/p11") || !strings.Contains(reply.Get("text"), "Use the deploy script.") {
+		t.Errorf("Expected the reused answer linking the original in C2, got %v", reply)
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// directDocSummaryLimit caps the summary line of a direct doc answer, in characters
+const directDocSummaryLimit = 200
+
+// directDocResult returns the Confluence page to post instead of a generated
+// answer: with PreferDirectDocs set, the best-scoring page when it scores at
+// least DirectDocThreshold. It returns nil when an answer should be generated.
+func (s *InquiryService) directDocResult(searchResults []storage.SearchResult) *storage.SearchResult {
+	if !s.cfg().PreferDirectDocs {
+		return nil
+	}
+
+	var best *storage.SearchResult
+	for i := range searchResults {
+		result := &searchResults[i]
+		if result.Source != "confluence" || result.URL == "" {
+			continue
+		}
+		if best == nil || result.Score > best.Score {
+			best = result
+		}
+	}
+	if best == nil || best.Score < s.cfg().DirectDocThreshold {
+		return nil
+	}
+	return best
+}
+
+// directDocAnswer links the page prominently, followed by the first sentence
+// of its content as a summary
+func directDocAnswer(result storage.SearchResult) string {
+	answer := fmt.Sprintf("📄 This is covered in *<%s|%s>*", result.URL, result.Title)
+
+	summary := strings.TrimSpace(result.Content)
+	if loc := sentenceBoundaryPattern.FindStringIndex(summary); loc != nil {
+		summary = strings.TrimSpace(summary[:loc[1]])
+	}
+	if utf8.RuneCountInString(summary) > directDocSummaryLimit {
+		summary = string([]rune(summary)[:directDocSummaryLimit]) + "…"
+	}
+	if summary != "" {
+		answer += "\n> " + summary
+	}
+	return answer
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestDirectDocResult(t *testing.T) {
+	page := func(id string, score float64) storage.SearchResult {
+		return storage.SearchResult{Source: "confluence", SourceID: id, URL: "https://wiki/" + id, Score: score}
+	}
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		results  []storage.SearchResult
+		expected string // SourceID of the page posted directly, "" to generate an answer
+	}{
+		{name: "disabled", results: []storage.SearchResult{page("P1", 0.99)}},
+		{name: "best page above threshold", enabled: true, results: []storage.SearchResult{page("P1", 0.96), page("P2", 0.98)}, expected: "P2"},
+		{name: "at threshold", enabled: true, results: []storage.SearchResult{page("P1", 0.95)}, expected: "P1"},
+		{name: "below threshold", enabled: true, results: []storage.SearchResult{page("P1", 0.94)}},
+		{name: "only Slack above threshold", enabled: true, results: []storage.SearchResult{
+			{Source: "slack", SourceID: "1.1", Score: 0.99}, page("P1", 0.8),
+		}},
+		{name: "page without link", enabled: true, results: []storage.SearchResult{{Source: "confluence", SourceID: "P1", Score: 0.99}}},
+		{name: "no results", enabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.PreferDirectDocs = tt.enabled
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			doc := service.directDocResult(tt.results)
+			switch {
+			case tt.expected == "" && doc != nil:
+				t.Errorf("Expected an answer to be generated, got page %s", doc.SourceID)
+			case tt.expected != "" && (doc == nil || doc.SourceID != tt.expected):
+				t.Errorf("Expected page %s to be posted directly, got %+v", tt.expected, doc)
+			}
+		})
+	}
+}
+
+func TestDirectDocAnswer(t *testing.T) {
+	answer := directDocAnswer(storage.SearchResult{
+		Title:   "Deploying",
+		URL:     "https://wiki/P1",
+		Content: "Run make deploy from the repository root. It takes a few minutes.",
+	})
+
+	if !strings.Contains(answer, "*<https://wiki/P1|Deploying>*") {
+		t.Errorf("Expected a prominent page link, got %q", answer)
+	}
+	if !strings.HasSuffix(answer, "\n> Run make deploy from the repository root.") {
+		t.Errorf("Expected the first sentence as summary, got %q", answer)
+	}
+
+	long := directDocAnswer(storage.SearchResult{Title: "Deploying", URL: "https://wiki/P1", Content: strings.Repeat("a", 300)})
+	if !strings.HasSuffix(long, strings.Repeat("a", directDocSummaryLimit)+"…") {
+		t.Errorf("Expected the summary to be truncated, got %q", long)
+	}
+}
@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+	"strings"
+)
+
+// ProcessMessageEvent answers a direct message to the bot in a thread on the
+// message, without requiring a trigger emoji. Messages outside DM channels,
+// the bot's own messages and empty messages are ignored.
+func (s *InquiryService) ProcessMessageEvent(ctx context.Context, message SlackMessage) error {
+	ctx = NewInquiryContext(ctx)
+	if !s.cfg().DMEnabled || !strings.HasPrefix(message.Channel, "D") {
+		return nil
+	}
+	if strings.TrimSpace(message.Text) == "" || message.User == "" {
+		return nil
+	}
+
+	// Never answer our own replies
+	botUserID, err := s.slack.BotUserID()
+	if err != nil {
+		return err
+	}
+	if message.User == botUserID {
+		return nil
+	}
+
+	// Follow-ups in an existing thread aren't new questions
+	if message.ThreadTS != "" && message.ThreadTS != message.Timestamp {
+		loggerFrom(ctx).WithField("channel_id", message.Channel).Debug("Ignoring threaded direct message")
+		return nil
+	}
+
+	messageID := message.ID
+	if messageID == "" {
+		messageID = message.Timestamp
+	}
+
+	return s.processInquiry(ctx, InquirySourceDM, messageID, message.Channel, message.User, message.Text, message.Timestamp, "")
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestProcessMessageEvent(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.DMEnabled = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("auth.test", `{"ok": true, "user_id": "UBOT"}`)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run the deploy script.")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	message := SlackMessage{ID: "1.1", Channel: "D1", User: "U1", Text: "How do I deploy?", Timestamp: "1.1"}
+	if err := service.ProcessMessageEvent(context.Background(), message); err != nil {
+		t.Fatalf("ProcessMessageEvent returned error: %v", err)
+	}
+
+	inquiry, err := service.GetInquiryByMessageID("1.1")
+	if err != nil {
+		t.Fatalf("Expected inquiry to be stored: %v", err)
+	}
+	if inquiry.Source != InquirySourceDM || inquiry.Status != "completed" {
+		t.Errorf("Expected completed DM inquiry, got source %q status %q", inquiry.Source, inquiry.Status)
+	}
+
+	replies := fake.callsTo("chat.postMessage")
+	if len(replies) != 1 || replies[0].Get("channel") != "D1" || replies[0].Get("thread_ts") != "1.1" {
+		t.Errorf("Expected one threaded reply in the DM, got %v", replies)
+	}
+}
+
+func TestProcessMessageEvent_Ignored(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		message SlackMessage
+	}{
+		{name: "disabled", enabled: false, message: SlackMessage{Channel: "D1", User: "U1", Text: "deploy?", Timestamp: "1.1"}},
+		{name: "public channel", enabled: true, message: SlackMessage{Channel: "C1", User: "U1", Text: "deploy?", Timestamp: "1.1"}},
+		{name: "own message", enabled: true, message: SlackMessage{Channel: "D1", User: "UBOT", Text: "deploy?", Timestamp: "1.1"}},
+		{name: "thread reply", enabled: true, message: SlackMessage{Channel: "D1", User: "U1", Text: "thanks", Timestamp: "2.2", ThreadTS: "1.1"}},
+		{name: "empty text", enabled: true, message: SlackMessage{Channel: "D1", User: "U1", Text: " ", Timestamp: "1.1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.DMEnabled = tt.enabled
+			fake := newFakeSlack(t, cfg)
+			fake.respond("auth.test", `{"ok": true, "user_id": "UBOT"}`)
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+
+			if err := service.ProcessMessageEvent(context.Background(), tt.message); err != nil {
+				t.Fatalf("ProcessMessageEvent returned error: %v", err)
+			}
+
+			var count int64
+			db.Model(&storage.Inquiry{}).Count(&count)
+			if count != 0 {
+				t.Errorf("Expected message to be ignored, got %d inquiries", count)
+			}
+			if replies := fake.callsTo("chat.postMessage"); len(replies) != 0 {
+				t.Errorf("Expected no replies, got %d", len(replies))
+			}
+		})
+	}
+}
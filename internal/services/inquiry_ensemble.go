@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// generateAnswer answers inquiry with its model, or with an ensemble of models
+// when it selected EnsembleModel. It returns the answer and the model that wrote it.
+func (s *InquiryService) generateAnswer(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) (string, string, error) {
+	if inquiry.Model != EnsembleModel {
+		response, err := s.llm.GenerateResponse(ctx, inquiry, searchResults)
+		return response, inquiry.Model, err
+	}
+
+	result, err := s.llm.GenerateEnsemble(ctx, inquiry, searchResults)
+	if err != nil {
+		return "", "", err
+	}
+	s.recordAnswerVersions(ctx, inquiry.ID, result)
+
+	return result.Answer, result.Model, nil
+}
+
+// recordAnswerVersions stores every candidate of an ensemble answer, plus the
+// judge's merge if there was one, marking the version that was posted
+func (s *InquiryService) recordAnswerVersions(ctx context.Context, inquiryID uint, result *EnsembleResult) {
+	versions := make([]storage.AnswerVersion, 0, len(result.Candidates)+1)
+	for i, candidate := range result.Candidates {
+		version := storage.AnswerVersion{
+			InquiryID: inquiryID,
+			Kind:      "candidate",
+			Model:     candidate.Model,
+			Content:   candidate.Answer,
+			Selected:  i == result.Winner,
+		}
+		if candidate.Err != nil {
+			version.Error = candidate.Err.Error()
+		}
+		versions = append(versions, version)
+	}
+	if result.Merged {
+		versions = append(versions, storage.AnswerVersion{
+			InquiryID: inquiryID,
+			Kind:      "merged",
+			Model:     result.Model,
+			Content:   result.Answer,
+			Selected:  true,
+		})
+	}
+
+	if err := s.db.Create(&versions).Error; err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiryID).Error("Failed to record answer versions")
+	}
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// expertSourceLimit caps the sources listed when forwarding an inquiry to an expert
+const expertSourceLimit = 3
+
+// ForwardToExpert sends an inquiry, with the sources found for it, to
+// expertUserID in a direct message and tells the user who triggered it that
+// it was forwarded
+func (s *InquiryService) ForwardToExpert(ctx context.Context, inquiryID uint, expertUserID string) error {
+	if expertUserID == "" {
+		return fmt.Errorf("no expert to forward inquiry %d to", inquiryID)
+	}
+
+	inquiry, err := s.GetInquiry(inquiryID)
+	if err != nil {
+		return err
+	}
+
+	// Posting to a user ID delivers the message in the bot's DM with them
+	if _, err := s.slack.PostMessage(expertUserID, s.expertMessage(ctx, inquiry)); err != nil {
+		return fmt.Errorf("failed to message expert: %w", err)
+	}
+
+	note := fmt.Sprintf("🙋 I've forwarded this to <@%s>, who should be able to help.", expertUserID)
+	if err := s.slack.PostEphemeral(inquiry.ChannelID, s.triggeringUser(inquiry), note); err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Warn("Failed to tell the user the inquiry was forwarded")
+	}
+
+	s.metrics.Incr("inquiry.forwarded", nil)
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"expert":     expertUserID,
+	}).Info("Forwarded inquiry to expert")
+	return nil
+}
+
+// routeToExpert forwards a low-confidence answer's inquiry to the default
+// expert when expert routing is enabled: one whose sources matched poorly, or
+// that the model itself had little confidence in. Inquiries from the API
+// aren't forwarded, their callers having no Slack thread to be helped in.
+func (s *InquiryService) routeToExpert(ctx context.Context, inquiry *storage.Inquiry, tier confidenceTier) {
+	if !s.cfg().ExpertRoutingEnabled || inquiry.Source == InquirySourceAPI {
+		return
+	}
+	if tier == confidenceAutoPost && !s.lowAnswerConfidence(inquiry) {
+		return
+	}
+	if err := s.ForwardToExpert(ctx, inquiry.ID, s.cfg().DefaultExpertUserID); err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to forward inquiry to expert")
+	}
+}
+
+// triggeringUser returns who asked for the inquiry to be answered: the latest
+// user to react to the question, or its author
+func (s *InquiryService) triggeringUser(inquiry *storage.Inquiry) string {
+	var event storage.ReactionEvent
+	err := s.db.Where("message_id = ? AND event_type = ?", inquiry.MessageID, "added").
+		Order("id DESC").First(&event).Error
+	if err == nil && event.UserID != "" {
+		return event.UserID
+	}
+	return inquiry.UserID
+}
+
+// expertMessage describes an inquiry for an expert: who asked where, the
+// question, and the best sources found for it
+func (s *InquiryService) expertMessage(ctx context.Context, inquiry *storage.Inquiry) string {
+	var b strings.Builder
+	if inquiry.ChannelID != "" {
+		fmt.Fprintf(&b, "🙋 <@%s> asked in <#%s> and I couldn't answer confidently:\n", inquiry.UserID, inquiry.ChannelID)
+	} else {
+		fmt.Fprintf(&b, "🙋 <@%s> asked and I couldn't answer confidently:\n", inquiry.UserID)
+	}
+	for _, line := range strings.Split(inquiry.MessageText, "\n") {
+		b.WriteString("> " + line + "\n")
+	}
+
+	if inquiry.Source == InquirySourceSlack {
+		if permalink, err := s.slack.GetMessagePermalink(inquiry.ChannelID, inquiry.Timestamp); err == nil {
+			fmt.Fprintf(&b, "<%s|View the question>\n", permalink)
+		} else {
+			loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Warn("Failed to link the question for the expert")
+		}
+	}
+
+	results := append([]storage.SearchResult(nil), inquiry.SearchResults...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	if len(results) > expertSourceLimit {
+		results = results[:expertSourceLimit]
+	}
+	if len(results) > 0 {
+		b.WriteString("\nClosest sources I found:\n")
+		for _, result := range results {
+			fmt.Fprintf(&b, "• [%s] <%s|%s> (%.2f)\n", result.Source, result.URL, result.Title, result.Score)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
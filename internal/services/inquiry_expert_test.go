@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// postsTo returns the text of the messages posted to channel
+func postsTo(calls []url.Values, channel string) []string {
+	var texts []string
+	for _, call := range calls {
+		if call.Get("channel") == channel {
+			texts = append(texts, call.Get("text"))
+		}
+	}
+	return texts
+}
+
+func TestForwardToExpert(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("chat.getPermalink", `{"ok": true, "permalink": "https://example.slack.com/archives/C1/p11"}`)
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	inquiry := &storage.Inquiry{
+		MessageID:   "1.1",
+		ChannelID:   "C1",
+		UserID:      "U1",
+		MessageText: "How do I rotate the payment API key?",
+		Timestamp:   "1.1",
+		Source:      InquirySourceSlack,
+		SearchResults: []storage.SearchResult{
+			{Source: "slack", Title: "Slack Message", URL: "https://slack/1", Score: 0.3},
+			{Source: "confluence", Title: "Key rotation", URL: "https://wiki/rotation", Score: 0.5},
+		},
+	}
+	if err := db.Create(inquiry).Error; err != nil {
+		t.Fatalf("Failed to create inquiry: %v", err)
+	}
+	if err := db.Create(&storage.ReactionEvent{MessageID: "1.1", ChannelID: "C1", UserID: "U2", Reaction: "eyes", EventType: "added"}).Error; err != nil {
+		t.Fatalf("Failed to create reaction event: %v", err)
+	}
+
+	if err := service.ForwardToExpert(context.Background(), inquiry.ID, "UEXPERT"); err != nil {
+		t.Fatalf("ForwardToExpert returned error: %v", err)
+	}
+
+	dms := postsTo(fake.callsTo("chat.postMessage"), "UEXPERT")
+	if len(dms) != 1 {
+		t.Fatalf("Expected one message to the expert, got %d", len(dms))
+	}
+	for _, want := range []string{"<@U1>", "<#C1>", "> How do I rotate the payment API key?", "p11|View the question", "<https://wiki/rotation|Key rotation>"} {
+		if !strings.Contains(dms[0], want) {
+			t.Errorf("Expected the expert message to contain %q, got %q", want, dms[0])
+		}
+	}
+	if strings.Index(dms[0], "Key rotation") > strings.Index(dms[0], "Slack Message") {
+		t.Errorf("Expected sources ordered by score, got %q", dms[0])
+	}
+
+	ephemerals := fake.callsTo("chat.postEphemeral")
+	if len(ephemerals) != 1 {
+		t.Fatalf("Expected one ephemeral note, got %d", len(ephemerals))
+	}
+	if ephemerals[0].Get("user") != "U2" || !strings.Contains(ephemerals[0].Get("text"), "<@UEXPERT>") {
+		t.Errorf("Expected the reactor to be told about the expert, got %v", ephemerals[0])
+	}
+}
+
+func TestForwardToExpert_RequiresExpert(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ForwardToExpert(context.Background(), 1, ""); err == nil {
+		t.Error("Expected an error without an expert")
+	}
+	if calls := fake.callsTo("chat.postMessage"); len(calls) != 0 {
+		t.Errorf("Expected nothing posted, got %d messages", len(calls))
+	}
+}
+
+func TestProcessInquiry_RoutesLowConfidenceToExpert(t *testing.T) {
+	tests := []struct {
+		name          string
+		routing       bool
+		autoPost      float64
+		wantForwarded bool
+	}{
+		{name: "low confidence", routing: true, autoPost: 0.8, wantForwarded: true},
+		{name: "confident", routing: true},
+		{name: "routing disabled", autoPost: 0.8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.AutoPostConfidence = tt.autoPost
+			cfg.ExpertRoutingEnabled = tt.routing
+			cfg.DefaultExpertUserID = "UEXPERT"
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			newFakeLLM(t, cfg, "Run make deploy")
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			forwarded := len(postsTo(fake.callsTo("chat.postMessage"), "UEXPERT")) > 0
+			if forwarded != tt.wantForwarded {
+				t.Errorf("Expected forwarded %v, got %v", tt.wantForwarded, forwarded)
+			}
+		})
+	}
+}
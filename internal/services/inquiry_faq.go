@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// faqTitleLimit bounds the question part of an FAQ page title, in bytes
+const faqTitleLimit = 120
+
+var (
+	// ErrFAQAlreadyPublished is returned when publishing an inquiry that already has an FAQ page
+	ErrFAQAlreadyPublished = errors.New("inquiry is already published to the FAQ")
+	// ErrNotFAQWorthy is returned when publishing an inquiry that wasn't answered
+	// or that users didn't find helpful
+	ErrNotFAQWorthy = errors.New("inquiry has no answer users found helpful")
+)
+
+// PublishFAQ publishes an answered inquiry users found helpful as a page in
+// FAQ_SPACE_KEY holding the question, the answer and its sources. Each inquiry
+// is published once; publishing it again returns ErrFAQAlreadyPublished.
+func (s *InquiryService) PublishFAQ(ctx context.Context, inquiryID uint) (*storage.Inquiry, error) {
+	// Serialises publishing so two admins can't create the same page twice
+	s.faqMu.Lock()
+	defer s.faqMu.Unlock()
+
+	var inquiry storage.Inquiry
+	if err := s.db.Preload("SearchResults").First(&inquiry, inquiryID).Error; err != nil {
+		return nil, err
+	}
+	if inquiry.FAQPageID != "" {
+		return nil, ErrFAQAlreadyPublished
+	}
+	worthy, err := s.faqWorthy(&inquiry)
+	if err != nil {
+		return nil, err
+	}
+	if !worthy {
+		return nil, ErrNotFAQWorthy
+	}
+	if s.cfg().FAQSpaceKey == "" {
+		return nil, fmt.Errorf("FAQ_SPACE_KEY is not set")
+	}
+
+	title := fmt.Sprintf("%s (#%d)", truncateAtWord(strings.Join(strings.Fields(inquiry.MessageText), " "), faqTitleLimit), inquiry.ID)
+	page, err := s.search.confluence.CreatePage(ctx, s.cfg().FAQSpaceKey, s.cfg().FAQParentPageID, title, faqPageBody(&inquiry))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create FAQ page: %w", err)
+	}
+
+	inquiry.FAQPageID = page.ID
+	if err := s.db.Model(&inquiry).Update("faq_page_id", page.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to record FAQ page: %w", err)
+	}
+
+	s.metrics.Incr("inquiry.faq_published", nil)
+	logrus.WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"page_id":    page.ID,
+	}).Info("Published inquiry to the FAQ")
+	return &inquiry, nil
+}
+
+// faqWorthy reports whether inquiry was answered and more users voted its
+// answer helpful than unhelpful
+func (s *InquiryService) faqWorthy(inquiry *storage.Inquiry) (bool, error) {
+	if inquiry.Status != "completed" || !inquiry.ResponseSent || inquiry.ResponseText == "" {
+		return false, nil
+	}
+
+	var helpful, unhelpful int64
+	if err := s.db.Model(&storage.Feedback{}).Where("inquiry_id = ? AND helpful = ?", inquiry.ID, true).Count(&helpful).Error; err != nil {
+		return false, fmt.Errorf("failed to count helpful votes: %w", err)
+	}
+	if err := s.db.Model(&storage.Feedback{}).Where("inquiry_id = ? AND helpful = ?", inquiry.ID, false).Count(&unhelpful).Error; err != nil {
+		return false, fmt.Errorf("failed to count unhelpful votes: %w", err)
+	}
+	return helpful > unhelpful, nil
+}
+
+// faqPageBody renders an FAQ page in Confluence storage format: the question,
+// the answer one paragraph per block of text, and the best-scoring sources
+func faqPageBody(inquiry *storage.Inquiry) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<h2>Question</h2><p>%s</p>", html.EscapeString(inquiry.MessageText))
+
+	b.WriteString("<h2>Answer</h2>")
+	for _, paragraph := range strings.Split(inquiry.ResponseText, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(paragraph, "\n")
+		for i, line := range lines {
+			lines[i] = html.EscapeString(line)
+		}
+		fmt.Fprintf(&b, "<p>%s</p>", strings.Join(lines, "<br/>"))
+	}
+
+	results := make([]storage.SearchResult, len(inquiry.SearchResults))
+	copy(results, inquiry.SearchResults)
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	var sources []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result.URL == "" || seen[result.URL] {
+			continue
+		}
+		if len(sources) == sourceListLimit {
+			break
+		}
+		seen[result.URL] = true
+		title := result.Title
+		if title == "" {
+			title = result.URL
+		}
+		sources = append(sources, fmt.Sprintf(`<li><a href="%s">%s</a></li>`, html.EscapeString(result.URL), html.EscapeString(title)))
+	}
+	if len(sources) > 0 {
+		fmt.Fprintf(&b, "<h2>Sources</h2><ul>%s</ul>", strings.Join(sources, ""))
+	}
+	return b.String()
+}
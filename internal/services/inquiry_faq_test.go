@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+func createFAQInquiry(t *testing.T, db *gorm.DB, helpful, unhelpful int) storage.Inquiry {
+	t.Helper()
+
+	inquiry := storage.Inquiry{
+		MessageID:    "1.1",
+		ChannelID:    "C1",
+		MessageText:  "How do I rotate <my> credentials?",
+		Status:       "completed",
+		ResponseSent: true,
+		ResponseText: "Run the rotate job.\n\nThen restart the service.",
+		Source:       InquirySourceSlack,
+		SearchResults: []storage.SearchResult{
+			{Source: "confluence", SourceID: "1", Title: "Rotation guide", URL: "https://wiki.example.com/rotate", Score: 0.9},
+		},
+	}
+	if err := db.Create(&inquiry).Error; err != nil {
+		t.Fatalf("Failed to create inquiry: %v", err)
+	}
+	for i := 0; i < helpful+unhelpful; i++ {
+		vote := storage.Feedback{InquiryID: inquiry.ID, UserID: "U" + string(rune('A'+i)), Helpful: i < helpful}
+		if err := db.Create(&vote).Error; err != nil {
+			t.Fatalf("Failed to create feedback: %v", err)
+		}
+	}
+	return inquiry
+}
+
+func TestFAQPageBody(t *testing.T) {
+	inquiry := &storage.Inquiry{
+		MessageText:  "How do I rotate <my> credentials?",
+		ResponseText: "Run the rotate job:\n`rotate --all`\n\n\n\nThen restart & check.",
+		SearchResults: []storage.SearchResult{
+			{Title: "Low", URL: "https://wiki.example.com/low", Score: 0.2},
+			{Title: "No link", Score: 0.95},
+			{Title: "Best", URL: "https://wiki.example.com/best?a=1&b=2", Score: 0.9},
+			{Title: "Best again", URL: "https://wiki.example.com/best?a=1&b=2", Score: 0.5},
+		},
+	}
+
+	want := "<h2>Question</h2><p>How do I rotate &lt;my&gt; credentials?</p>" +
+		"<h2>Answer</h2><p>Run the rotate job:<br/>`rotate --all`</p><p>Then restart &amp; check.</p>" +
+		`<h2>Sources</h2><ul><li><a href="https://wiki.example.com/best?a=1&amp;b=2">Best</a></li>` +
+		`<li><a href="https://wiki.example.com/low">Low</a></li></ul>`
+	if got := faqPageBody(inquiry); got != want {
+		t.Errorf("faqPageBody() =\n%s\nwant\n%s", got, want)
+	}
+
+	inquiry.SearchResults = nil
+	if got := faqPageBody(inquiry); strings.Contains(got, "Sources") {
+		t.Errorf("Expected no sources section without linked results, got %q", got)
+	}
+}
+
+func TestPublishFAQ(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	pages := newFakeConfluencePages(t, cfg)
+	cfg.FAQSpaceKey = "FAQ"
+	cfg.FAQParentPageID = "42"
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+	inquiry := createFAQInquiry(t, db, 2, 1)
+
+	published, err := service.PublishFAQ(context.Background(), inquiry.ID)
+	if err != nil {
+		t.Fatalf("PublishFAQ returned error: %v", err)
+	}
+	if published.FAQPageID != "12345" {
+		t.Errorf("Expected FAQ page 12345, got %q", published.FAQPageID)
+	}
+
+	if len(*pages) != 1 {
+		t.Fatalf("Expected one page to be created, got %d", len(*pages))
+	}
+	page := (*pages)[0]
+	if page.Space.Key != "FAQ" || len(page.Ancestors) != 1 || page.Ancestors[0].ID != "42" {
+		t.Errorf("Expected the page under 42 in FAQ, got %+v", page)
+	}
+	if page.Title != "How do I rotate <my> credentials? (#1)" {
+		t.Errorf("Unexpected title %q", page.Title)
+	}
+	if !strings.Contains(page.Body.Storage.Value, "Rotation guide") {
+		t.Errorf("Expected the page to link its sources, got %q", page.Body.Storage.Value)
+	}
+
+	var stored storage.Inquiry
+	db.First(&stored, inquiry.ID)
+	if stored.FAQPageID != "12345" {
+		t.Errorf("Expected the FAQ page to be recorded, got %q", stored.FAQPageID)
+	}
+
+	if _, err := service.PublishFAQ(context.Background(), inquiry.ID); !errors.Is(err, ErrFAQAlreadyPublished) {
+		t.Errorf("Expected ErrFAQAlreadyPublished when publishing again, got %v", err)
+	}
+	if len(*pages) != 1 {
+		t.Errorf("Expected publishing again not to create a page, got %d pages", len(*pages))
+	}
+}
+
+func TestPublishFAQ_NotWorthy(t *testing.T) {
+	tests := []struct {
+		name      string
+		helpful   int
+		unhelpful int
+		update    map[string]interface{}
+	}{
+		{name: "no votes"},
+		{name: "tied votes", helpful: 1, unhelpful: 1},
+		{name: "not answered", helpful: 1, update: map[string]interface{}{"status": "failed"}},
+		{name: "answer not sent", helpful: 1, update: map[string]interface{}{"response_sent": false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			pages := newFakeConfluencePages(t, cfg)
+			cfg.FAQSpaceKey = "FAQ"
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+			inquiry := createFAQInquiry(t, db, tt.helpful, tt.unhelpful)
+			if tt.update != nil {
+				db.Model(&inquiry).Updates(tt.update)
+			}
+
+			if _, err := service.PublishFAQ(context.Background(), inquiry.ID); !errors.Is(err, ErrNotFAQWorthy) {
+				t.Errorf("Expected ErrNotFAQWorthy, got %v", err)
+			}
+			if len(*pages) != 0 {
+				t.Errorf("Expected no page to be created, got %d", len(*pages))
+			}
+		})
+	}
+}
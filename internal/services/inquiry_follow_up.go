@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// followUpNote offers more help when the asker's follow-up suggests the answer didn't resolve their question
+const followUpNote = "🤔 It looks like that didn't fully answer your question. " +
+	"Reply here with more details, like what you tried and what happened, and someone can pick it up."
+
+// negativeFollowUpPhrases mark a follow-up as saying the answer didn't help
+var negativeFollowUpPhrases = []string{
+	"didn't work", "didnt work", "doesn't work", "doesnt work", "not working", "still failing",
+	"still broken", "still not", "still getting", "not helpful", "doesn't help", "didn't help",
+	"wrong", "no luck", "not what i", "that's not", "not quite",
+}
+
+// isUnresolvedFollowUp reports whether text, posted by the asker after the
+// answer, asks another question or says the answer didn't help
+func isUnresolvedFollowUp(text string) bool {
+	lower := strings.ToLower(text)
+	if strings.Contains(lower, "?") {
+		return true
+	}
+	for _, phrase := range negativeFollowUpPhrases {
+		if strings.Contains(lower, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// watchFollowUps starts watching the answered inquiry's thread for
+// FollowUpWindow, unless follow-up watching is disabled or
+// MaxFollowUpWatchers threads are already watched. The watcher stops early
+// when ctx is cancelled, e.g. on shutdown.
+func (s *InquiryService) watchFollowUps(ctx context.Context, inquiry *storage.Inquiry) {
+	if s.cfg().FollowUpWindow <= 0 || inquiry.Source == InquirySourceAPI || inquiry.ThreadTimestamp == "" {
+		return
+	}
+
+	select {
+	case s.followUpSlots <- struct{}{}:
+	default:
+		loggerFrom(ctx).WithField("inquiry_id", inquiry.ID).Debug("Too many follow-up watchers, not watching thread")
+		return
+	}
+
+	answered := *inquiry
+	go func() {
+		defer func() { <-s.followUpSlots }()
+		s.followUp(ctx, &answered, time.Now().Add(s.cfg().FollowUpWindow))
+	}()
+}
+
+// followUp checks the inquiry's thread every FollowUpPollInterval until
+// deadline, offering more help the first time an unresolved follow-up is found
+func (s *InquiryService) followUp(ctx context.Context, inquiry *storage.Inquiry, deadline time.Time) {
+	ticker := time.NewTicker(s.cfg().FollowUpPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			followUp, err := s.findFollowUp(ctx, inquiry)
+			if err != nil {
+				loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Warn("Failed to check thread for follow-ups")
+			} else if followUp != nil {
+				s.offerFollowUpHelp(ctx, inquiry, followUp)
+				return
+			}
+			if !now.Before(deadline) {
+				return
+			}
+		}
+	}
+}
+
+// findFollowUp returns the asker's first unresolved follow-up posted after
+// the answer, or nil if there is none
+func (s *InquiryService) findFollowUp(ctx context.Context, inquiry *storage.Inquiry) (*SlackMessage, error) {
+	replies, err := s.slack.threadReplies(ctx, inquiry.ChannelID, inquiry.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	answeredAt := s.search.timestampToTime(inquiry.ThreadTimestamp)
+	for i := range replies {
+		reply := &replies[i]
+		if reply.User != inquiry.UserID || !s.search.timestampToTime(reply.Timestamp).After(answeredAt) {
+			continue
+		}
+		if isUnresolvedFollowUp(reply.Text) {
+			return reply, nil
+		}
+	}
+	return nil, nil
+}
+
+// offerFollowUpHelp replies to the unresolved follow-up, mentioning
+// FollowUpEscalationContact if one is configured
+func (s *InquiryService) offerFollowUpHelp(ctx context.Context, inquiry *storage.Inquiry, followUp *SlackMessage) {
+	note := followUpNote
+	if s.cfg().FollowUpEscalationContact != "" {
+		note += "\ncc " + s.cfg().FollowUpEscalationContact
+	}
+
+	if _, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, note); err != nil {
+		loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to offer follow-up help")
+		return
+	}
+
+	s.metrics.Incr("inquiry.follow_up", nil)
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id":   inquiry.ID,
+		"follow_up_ts": followUp.Timestamp,
+	}).Info("Offered more help after an unresolved follow-up")
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// answeredInquiry is U1's question at 1.1, answered by the bot at 2.1
+func answeredInquiry() *storage.Inquiry {
+	return &storage.Inquiry{ChannelID: "C1", UserID: "U1", Timestamp: "1.1", ThreadTimestamp: "2.1", Status: "completed"}
+}
+
+// waitFor polls condition until it holds or a second has passed
+func waitFor(t *testing.T, condition func() bool) bool {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if condition() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func TestIsUnresolvedFollowUp(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected bool
+	}{
+		{text: "Thanks, that worked!", expected: false},
+		{text: "Perfect 🙏", expected: false},
+		{text: "That didn't work, I still get a 403", expected: true},
+		{text: "Still not working for me", expected: true},
+		{text: "What about staging?", expected: true},
+		{text: "That's Not what I asked", expected: true},
+	}
+
+	for _, tt := range tests {
+		if got := isUnresolvedFollowUp(tt.text); got != tt.expected {
+			t.Errorf("isUnresolvedFollowUp(%q) = %v, expected %v", tt.text, got, tt.expected)
+		}
+	}
+}
+
+func TestFindFollowUp(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  string
+		expected string // timestamp of the follow-up found, "" for none
+	}{
+		{
+			name: "asker reports failure",
+			replies: `{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+				{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"},
+				{"user": "U1", "text": "That didn't work for me", "ts": "3.1"}`,
+			expected: "3.1",
+		},
+		{
+			name: "asker asks another question",
+			replies: `{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+				{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"},
+				{"user": "U1", "text": "Thanks", "ts": "3.1"},
+				{"user": "U1", "text": "And to staging?", "ts": "4.1"}`,
+			expected: "4.1",
+		},
+		{
+			name: "asker is satisfied",
+			replies: `{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+				{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"},
+				{"user": "U1", "text": "Thanks, that worked!", "ts": "3.1"}`,
+		},
+		{
+			name: "someone else replies",
+			replies: `{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+				{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"},
+				{"user": "U2", "text": "Doesn't work for me either?", "ts": "3.1"}`,
+		},
+		{
+			name: "asker's question before the answer",
+			replies: `{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+				{"user": "U1", "text": "Anyone?", "ts": "1.5"},
+				{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			fake := newFakeSlack(t, cfg)
+			fake.respond("conversations.replies", `{"ok": true, "has_more": false, "messages": [`+tt.replies+`]}`)
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			followUp, err := service.findFollowUp(context.Background(), answeredInquiry())
+			if err != nil {
+				t.Fatalf("findFollowUp returned error: %v", err)
+			}
+
+			switch {
+			case tt.expected == "" && followUp != nil:
+				t.Errorf("Expected no follow-up, got %+v", followUp)
+			case tt.expected != "" && (followUp == nil || followUp.Timestamp != tt.expected):
+				t.Errorf("Expected follow-up %s, got %+v", tt.expected, followUp)
+			}
+		})
+	}
+}
+
+func TestWatchFollowUps_OffersHelp(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.FollowUpWindow = time.Second
+	cfg.FollowUpPollInterval = 10 * time.Millisecond
+	cfg.FollowUpEscalationContact = "<!subteam^S1>"
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.replies", `{"ok": true, "has_more": false, "messages": [
+		{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+		{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"},
+		{"user": "U1", "text": "Still getting a 403", "ts": "3.1"}
+	]}`)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	service.watchFollowUps(context.Background(), answeredInquiry())
+
+	if !waitFor(t, func() bool { return len(service.followUpSlots) == 0 }) {
+		t.Fatal("Expected the watcher to stop after offering help")
+	}
+	posts := fake.callsTo("chat.postMessage")
+	if len(posts) != 1 {
+		t.Fatalf("Expected one offer of help, got %v", posts)
+	}
+	if text := posts[0].Get("text"); !strings.HasPrefix(text, followUpNote) || !strings.HasSuffix(text, "cc <!subteam^S1>") {
+		t.Errorf("Expected an offer of help escalated to the contact, got %q", text)
+	}
+	if posts[0].Get("thread_ts") != "1.1" {
+		t.Errorf("Expected the offer in the inquiry's thread, got %v", posts[0])
+	}
+}
+
+func TestWatchFollowUps_StopsOnCancel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.FollowUpWindow = time.Hour
+	cfg.FollowUpPollInterval = 10 * time.Millisecond
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.replies", `{"ok": true, "has_more": false, "messages": [
+		{"user": "U1", "text": "How do I deploy?", "ts": "1.1"},
+		{"user": "UBOT", "text": "Run make deploy", "ts": "2.1"}
+	]}`)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	service.watchFollowUps(ctx, answeredInquiry())
+	if !waitFor(t, func() bool { return len(fake.callsTo("conversations.replies")) > 0 }) {
+		t.Fatal("Expected the watcher to check the thread")
+	}
+	cancel()
+
+	if !waitFor(t, func() bool { return len(service.followUpSlots) == 0 }) {
+		t.Fatal("Expected the watcher to stop when cancelled")
+	}
+	if posts := fake.callsTo("chat.postMessage"); len(posts) != 0 {
+		t.Errorf("Expected no offer without a follow-up, got %v", posts)
+	}
+}
+
+func TestWatchFollowUps_Bounded(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.FollowUpWindow = time.Hour
+	cfg.MaxFollowUpWatchers = 1
+	newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	service.watchFollowUps(ctx, answeredInquiry())
+	service.watchFollowUps(ctx, answeredInquiry())
+
+	if watchers := len(service.followUpSlots); watchers != 1 {
+		t.Errorf("Expected 1 watcher, got %d", watchers)
+	}
+}
+
+func TestWatchFollowUps_Disabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	service.watchFollowUps(context.Background(), answeredInquiry())
+
+	if watchers := len(service.followUpSlots); watchers != 0 {
+		t.Errorf("Expected no watcher with FOLLOW_UP_WINDOW unset, got %d", watchers)
+	}
+}
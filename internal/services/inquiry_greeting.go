@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// GreetChannel posts introduction in channelID when the joining user is the bot
+// itself and the channel has not been greeted before. It reports whether a
+// greeting was posted.
+func (s *InquiryService) GreetChannel(channelID, joinedUserID, introduction string) (bool, error) {
+	if !s.cfg().GreetOnJoin {
+		return false, nil
+	}
+
+	botUserID, err := s.slack.BotUserID()
+	if err != nil {
+		return false, err
+	}
+	if joinedUserID != botUserID {
+		return false, nil
+	}
+
+	// Claim the channel first so concurrent join events can't both greet
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&storage.GreetedChannel{ChannelID: channelID})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record greeted channel: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		logrus.WithField("channel_id", channelID).Debug("Channel already greeted, skipping")
+		return false, nil
+	}
+
+	if _, err := s.slack.PostMessage(channelID, introduction); err != nil {
+		// Release the claim so the next join can try again
+		s.db.Where("channel_id = ?", channelID).Delete(&storage.GreetedChannel{})
+		return false, err
+	}
+
+	logrus.WithField("channel_id", channelID).Info("Posted introduction in new channel")
+	return true, nil
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// skipOldMessage reports whether the message at ts should go unanswered
+// because it was posted more than MaxReactedMessageAgeDays before now, in
+// which case reacting to it was likely a mistake. userID, who reacted, is
+// told why in an ephemeral note.
+func (s *InquiryService) skipOldMessage(ctx context.Context, channelID, userID, ts string, now time.Time) bool {
+	if s.cfg().MaxReactedMessageAgeDays <= 0 {
+		return false
+	}
+
+	posted := s.search.timestampToTime(ts)
+	if !posted.Before(now.AddDate(0, 0, -s.cfg().MaxReactedMessageAgeDays)) {
+		return false
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"message_id": ts,
+		"posted_at":  posted,
+	}).Info("Reacted message is too old, skipping answer")
+
+	note := fmt.Sprintf("🕰️ That message is more than %d days old, so I won't answer it; "+
+		"things have likely changed since. Please ask again in a new message.", s.cfg().MaxReactedMessageAgeDays)
+	if s.cfg().ForceAnswerEmoji != "" {
+		note += fmt.Sprintf(" To answer it anyway, react with :%s:.", s.cfg().ForceAnswerEmoji)
+	}
+	if userID != "" {
+		if err := s.slack.PostEphemeral(channelID, userID, note); err != nil {
+			loggerFrom(ctx).WithError(err).Warn("Failed to post old message note")
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// slackTS is the Slack timestamp of a message posted at t
+func slackTS(t time.Time) string {
+	return fmt.Sprintf("%d.000100", t.Unix())
+}
+
+func TestProcessReactionEvent_MaxReactedMessageAge(t *testing.T) {
+	tests := []struct {
+		name     string
+		age      time.Duration
+		reaction string
+		answered bool
+	}{
+		{name: "within the limit", age: 29 * 24 * time.Hour, reaction: "eyes", answered: true},
+		{name: "beyond the limit", age: 31 * 24 * time.Hour, reaction: "eyes", answered: false},
+		{name: "beyond the limit, forced", age: 31 * 24 * time.Hour, reaction: "rotating_light", answered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.MaxReactedMessageAgeDays = 30
+			cfg.ForceAnswerEmoji = "rotating_light"
+			ts := slackTS(time.Now().Add(-tt.age))
+			fake := newFakeSlack(t, cfg)
+			fake.respond("conversations.history", fmt.Sprintf(`{"ok": true, "messages": [{"type": "message", "user": "U2", "text": "How do I deploy?", "ts": %q}]}`, ts))
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			llm := newFakeLLM(t, cfg, "answer")
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			if err := service.ProcessReactionEvent(context.Background(), ts, "C1", "U1", tt.reaction, "added", "2.2"); err != nil {
+				t.Fatalf("ProcessReactionEvent returned error: %v", err)
+			}
+
+			if answered := llm.requestCount() > 0; answered != tt.answered {
+				t.Errorf("Expected answered=%v for a message %v old", tt.answered, tt.age)
+			}
+			notes := fake.callsTo("chat.postEphemeral")
+			if tt.answered && len(notes) != 0 {
+				t.Errorf("Expected no note on an answered message, got %v", notes)
+			}
+			if !tt.answered {
+				if len(notes) != 1 || notes[0].Get("user") != "U1" {
+					t.Fatalf("Expected an ephemeral note to the reactor, got %v", notes)
+				}
+				if text := notes[0].Get("text"); !strings.Contains(text, "30 days") || !strings.Contains(text, ":rotating_light:") {
+					t.Errorf("Expected the note to explain the limit and override, got %q", text)
+				}
+			}
+		})
+	}
+}
+
+func TestSkipOldMessage_Disabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	twoYearsAgo := slackTS(time.Now().AddDate(-2, 0, 0))
+	if service.skipOldMessage(context.Background(), "C1", "U1", twoYearsAgo, time.Now()) {
+		t.Error("Expected messages of any age to be answered with MAX_REACTED_MESSAGE_AGE_DAYS unset")
+	}
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// PollSlackForUnprocessedReactions recovers trigger reactions whose events
+// were missed, e.g. while the bot was offline: messages posted in the
+// monitored channels within MissedReactionLookback that carry a trigger emoji
+// but have neither an inquiry nor a recorded event for that reaction are
+// queued for answering as if the reaction had just been added. A recorded
+// event means the reaction was seen, even if it was skipped, e.g. as too old.
+func (s *InquiryService) PollSlackForUnprocessedReactions(ctx context.Context) error {
+	channels := s.cfg().MonitoredChannels
+	if len(channels) == 0 && s.cfg().SlackChannelID != "" {
+		channels = []string{s.cfg().SlackChannelID}
+	}
+	since := time.Now().Add(-s.cfg().MissedReactionLookback)
+
+	var errCount, queued int
+	for _, channelID := range channels {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		messages, err := s.slack.ListRecentMessages(channelID, since)
+		if err != nil {
+			logrus.WithError(err).WithField("channel_id", channelID).Error("Failed to list messages for missed reactions")
+			errCount++
+			continue
+		}
+
+		for _, msg := range messages {
+			reaction, userID := s.answerTriggerReaction(msg)
+			if reaction == "" {
+				continue
+			}
+
+			handled, err := s.reactionHandled(channelID, msg.Timestamp, reaction)
+			if err != nil {
+				return err
+			}
+			if handled {
+				continue
+			}
+
+			messageID, channel := msg.Timestamp, channelID
+			eventTS := strconv.FormatInt(time.Now().Unix(), 10)
+			job := func(ctx context.Context) error {
+				return s.ProcessReactionEvent(ctx, messageID, channel, userID, reaction, "added", eventTS)
+			}
+			if err := s.Submit(channelID, "", job); err != nil {
+				return fmt.Errorf("failed to queue missed reaction: %w", err)
+			}
+			queued++
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"channels": len(channels),
+		"queued":   queued,
+	}).Info("Polled Slack for missed trigger reactions")
+
+	if errCount > 0 {
+		return fmt.Errorf("failed to poll %d of %d channels", errCount, len(channels))
+	}
+
+	return nil
+}
+
+// reactionHandled reports whether the message already has an inquiry, or an
+// event for reaction was recorded, answered or not
+func (s *InquiryService) reactionHandled(channelID, messageID, reaction string) (bool, error) {
+	err := s.db.Where("message_id = ?", messageID).First(&storage.Inquiry{}).Error
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, fmt.Errorf("failed to look up inquiry: %w", err)
+	}
+
+	var events int64
+	if err := s.db.Model(&storage.ReactionEvent{}).
+		Where("channel_id = ? AND message_id = ? AND reaction = ? AND event_type = ?", channelID, messageID, reaction, "added").
+		Count(&events).Error; err != nil {
+		return false, fmt.Errorf("failed to look up reaction events: %w", err)
+	}
+	return events > 0, nil
+}
+
+// answerTriggerReaction returns the first reaction on msg that asks for an
+// answer, and the first user who added it, or "" if there is none
+func (s *InquiryService) answerTriggerReaction(msg SlackMessage) (string, string) {
+	for _, reaction := range msg.Reactions {
+		_, isTrigger := s.modelForReaction(reaction.Name)
+		if !(isTrigger || s.isForceReaction(reaction.Name)) {
+			continue
+		}
+
+		var userID string
+		if len(reaction.Users) > 0 {
+			userID = reaction.Users[0]
+		}
+		return reaction.Name, userID
+	}
+	return "", ""
+}
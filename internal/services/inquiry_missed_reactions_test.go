@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestPollSlackForUnprocessedReactions(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MonitoredChannels = []string{"C1"}
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": [
+		{"type": "message", "user": "U2", "text": "How do I deploy?", "ts": "3.3", "reactions": [{"name": "eyes", "count": 1, "users": ["U1"]}]},
+		{"type": "message", "user": "U2", "text": "Already answered", "ts": "2.2", "reactions": [{"name": "eyes", "count": 1, "users": ["U1"]}]},
+		{"type": "message", "user": "U2", "text": "Skipped as too old", "ts": "1.5", "reactions": [{"name": "eyes", "count": 1, "users": ["U1"]}]},
+		{"type": "message", "user": "U2", "text": "Thanks all", "ts": "1.1", "reactions": [{"name": "+1", "count": 1, "users": ["U1"]}]}
+	]}`)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "Run make deploy")
+	db := setupTestDB(t)
+	db.Create(&storage.Inquiry{MessageID: "2.2", ChannelID: "C1", Status: "completed"})
+	db.Create(&storage.ReactionEvent{MessageID: "1.5", ChannelID: "C1", UserID: "U1", Reaction: "eyes", EventType: "added"})
+	service := newTestInquiryService(cfg, db)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go service.RunWorkers(ctx)
+
+	if err := service.PollSlackForUnprocessedReactions(ctx); err != nil {
+		t.Fatalf("PollSlackForUnprocessedReactions returned error: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		inquiry, err := service.GetInquiryByMessageID("3.3")
+		if err == nil && inquiry.Status == "completed" {
+			if inquiry.UserID != "U2" || inquiry.MessageText != "How do I deploy?" {
+				t.Errorf("Expected the missed message to be answered, got %+v", inquiry)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the missed reaction to be processed, got %+v, %v", inquiry, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var count int64
+	db.Model(&storage.Inquiry{}).Count(&count)
+	if count != 2 || llm.requestCount() != 1 {
+		t.Errorf("Expected only the missed reaction to be answered, got %d inquiries and %d LLM requests", count, llm.requestCount())
+	}
+	history := fake.callsTo("conversations.history")
+	if len(history) == 0 || history[0].Get("channel") != "C1" {
+		t.Errorf("Expected the monitored channel to be polled, got %v", history)
+	}
+}
+
+func TestPollSlackForUnprocessedReactions_DefaultChannel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackChannelID = "C9"
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": []}`)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.PollSlackForUnprocessedReactions(context.Background()); err != nil {
+		t.Fatalf("PollSlackForUnprocessedReactions returned error: %v", err)
+	}
+
+	history := fake.callsTo("conversations.history")
+	if len(history) != 1 || history[0].Get("channel") != "C9" {
+		t.Errorf("Expected SLACK_CHANNEL_ID to be polled, got %v", history)
+	}
+}
+
+func TestPollSlackForUnprocessedReactions_ListFails(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MonitoredChannels = []string{"C1"}
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": false, "error": "channel_not_found"}`)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.PollSlackForUnprocessedReactions(context.Background()); err == nil {
+		t.Error("Expected an error when a channel can't be listed")
+	}
+}
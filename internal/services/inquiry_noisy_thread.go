@@ -0,0 +1,41 @@
+package services
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// skipNoisyThread reports whether the message at ts should go unanswered
+// because its thread already has more than MaxThreadRepliesForAnswer replies,
+// in which case the question has likely been answered there. Skipped messages
+// get the NoisyThreadReaction, if one is configured. When the reply count
+// can't be fetched the message is answered.
+func (s *InquiryService) skipNoisyThread(ctx context.Context, channelID, ts string) bool {
+	if s.cfg().MaxThreadRepliesForAnswer <= 0 {
+		return false
+	}
+
+	replies, err := s.slack.GetReplyCount(ctx, channelID, ts)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to count thread replies, answering anyway")
+		return false
+	}
+	if replies <= s.cfg().MaxThreadRepliesForAnswer {
+		return false
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"channel_id":  channelID,
+		"message_id":  ts,
+		"reply_count": replies,
+	}).Info("Thread already has many replies, skipping answer")
+
+	if s.cfg().NoisyThreadReaction != "" {
+		if err := s.slack.AddReaction(channelID, ts, s.cfg().NoisyThreadReaction); err != nil {
+			loggerFrom(ctx).WithError(err).Warn("Failed to react to noisy thread")
+		}
+	}
+
+	return true
+}
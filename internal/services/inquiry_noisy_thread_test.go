@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newNoisyThreadService answers reactions on message 1.1, whose thread has replies replies
+func newNoisyThreadService(t *testing.T, cfg *config.Config, replies int) (*InquiryService, *fakeSlack, *fakeLLM) {
+	t.Helper()
+
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": [{"type": "message", "user": "U2", "text": "How do I deploy?", "ts": "1.1"}]}`)
+	fake.respond("conversations.replies", fmt.Sprintf(`{"ok": true, "messages": [{"type": "message", "user": "U2", "text": "How do I deploy?", "ts": "1.1", "reply_count": %d}]}`, replies))
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "answer")
+
+	return newTestInquiryService(cfg, setupTestDB(t)), fake, llm
+}
+
+func TestProcessReactionEvent_NoisyThreadBoundary(t *testing.T) {
+	tests := []struct {
+		name     string
+		replies  int
+		answered bool
+	}{
+		{name: "below the limit", replies: 4, answered: true},
+		{name: "at the limit", replies: 5, answered: true},
+		{name: "above the limit", replies: 6, answered: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.MaxThreadRepliesForAnswer = 5
+			cfg.NoisyThreadReaction = "thread"
+			service, fake, llm := newNoisyThreadService(t, cfg, tt.replies)
+
+			if err := service.ProcessReactionEvent(context.Background(), "1.1", "C1", "U1", "eyes", "added", "2.2"); err != nil {
+				t.Fatalf("ProcessReactionEvent returned error: %v", err)
+			}
+
+			if answered := llm.requestCount() > 0; answered != tt.answered {
+				t.Errorf("Expected answered=%v with %d replies", tt.answered, tt.replies)
+			}
+			reactions := fake.callsTo("reactions.add")
+			if tt.answered && len(reactions) != 0 {
+				t.Errorf("Expected no pointer reaction on an answered message, got %v", reactions)
+			}
+			if !tt.answered && (len(reactions) != 1 || reactions[0].Get("name") != "thread") {
+				t.Errorf("Expected a 'thread' reaction on the skipped message, got %v", reactions)
+			}
+		})
+	}
+}
+
+func TestProcessReactionEvent_ForceAnswersNoisyThread(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxThreadRepliesForAnswer = 5
+	cfg.ForceAnswerEmoji = "rotating_light"
+	service, fake, llm := newNoisyThreadService(t, cfg, 20)
+
+	if err := service.ProcessReactionEvent(context.Background(), "1.1", "C1", "U1", "rotating_light", "added", "2.2"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+
+	if llm.requestCount() != 1 {
+		t.Errorf("Expected the forced reaction to be answered, got %d LLM requests", llm.requestCount())
+	}
+	if calls := fake.callsTo("conversations.replies"); len(calls) != 0 {
+		t.Errorf("Expected the reply count not to be checked when forced, got %d calls", len(calls))
+	}
+}
+
+func TestProcessReactionEvent_NoisyThreadDisabled(t *testing.T) {
+	service, fake, llm := newNoisyThreadService(t, config.LoadTestConfig(), 50)
+
+	if err := service.ProcessReactionEvent(context.Background(), "1.1", "C1", "U1", "eyes", "added", "2.2"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+
+	if llm.requestCount() != 1 || len(fake.callsTo("conversations.replies")) != 0 {
+		t.Error("Expected the message to be answered without counting replies")
+	}
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// deferredCheckInterval is how often deferred inquiries are checked against office hours
+const deferredCheckInterval = 5 * time.Minute
+
+// applyOfficeHours handles an inquiry arriving outside office hours according to
+// OfficeHoursMode and reports whether it did. Inquiries inside office hours, or
+// with office hours disabled, are left for the normal pipeline.
+func (s *InquiryService) applyOfficeHours(ctx context.Context, inquiry *storage.Inquiry, now time.Time) (bool, error) {
+	schedule, err := s.cfg().OfficeHoursSchedule()
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Error("Invalid office hours, answering immediately")
+		return false, nil
+	}
+	if schedule == nil || schedule.IsOpen(now) {
+		return false, nil
+	}
+
+	hours := fmt.Sprintf("%s %s", s.cfg().OfficeHours, schedule.Location)
+
+	if s.cfg().OfficeHoursMode == "links" {
+		return true, s.postOutOfHoursLinks(ctx, inquiry, hours)
+	}
+
+	opensAt := schedule.NextOpen(now).In(schedule.Location)
+	note := fmt.Sprintf("🌙 It's outside office hours (%s). I'll answer this when they start, %s.",
+		hours, opensAt.Format("Mon Jan 2 15:04 MST"))
+	if _, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, note); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to post office hours note")
+	}
+
+	inquiry.Status = "deferred"
+	s.db.Save(inquiry)
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"opens_at":   opensAt,
+	}).Info("Deferred inquiry until office hours")
+
+	return true, nil
+}
+
+// postOutOfHoursLinks answers with search result links only, without calling the LLM
+func (s *InquiryService) postOutOfHoursLinks(ctx context.Context, inquiry *storage.Inquiry, hours string) error {
+	searchResults, _, err := s.search.SearchAll(ctx, inquiry.MessageText, inquiry.ID, inquiry.ChannelID)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to search for out-of-hours links")
+	}
+
+	var note strings.Builder
+	fmt.Fprintf(&note, "🌙 The bot answers questions during office hours (%s).", hours)
+	var links []string
+	for _, result := range searchResults {
+		if result.URL == "" {
+			continue
+		}
+		links = append(links, fmt.Sprintf("• <%s|%s>", result.URL, result.Title))
+	}
+	if len(links) > 0 {
+		note.WriteString(" In the meantime, these might help:\n")
+		note.WriteString(strings.Join(links, "\n"))
+	}
+
+	threadTS, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, note.String())
+	if err != nil {
+		inquiry.Status = "failed"
+		s.db.Save(inquiry)
+		return fmt.Errorf("failed to post out-of-hours links: %w", err)
+	}
+
+	inquiry.Status = "out_of_hours"
+	inquiry.ThreadTimestamp = threadTS
+	inquiry.ResponseSent = true
+	inquiry.ResponseText = note.String()
+	s.db.Save(inquiry)
+
+	return nil
+}
+
+// ProcessDeferred answers inquiries deferred until office hours once office hours are open
+func (s *InquiryService) ProcessDeferred(ctx context.Context) error {
+	schedule, err := s.cfg().OfficeHoursSchedule()
+	if err != nil {
+		return fmt.Errorf("invalid office hours: %w", err)
+	}
+	if schedule != nil && !schedule.IsOpen(time.Now()) {
+		return nil
+	}
+
+	var deferred []storage.Inquiry
+	if err := s.db.Where("status = ?", "deferred").Order("created_at ASC").Find(&deferred).Error; err != nil {
+		return fmt.Errorf("failed to list deferred inquiries: %w", err)
+	}
+
+	var errCount int
+	for i := range deferred {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := s.runPipeline(ctx, &deferred[i]); err != nil {
+			logrus.WithError(err).WithField("inquiry_id", deferred[i].ID).Error("Failed to process deferred inquiry")
+			errCount++
+		}
+	}
+
+	if errCount > 0 {
+		return fmt.Errorf("failed to process %d of %d deferred inquiries", errCount, len(deferred))
+	}
+
+	return nil
+}
+
+// RunDeferredLoop periodically answers deferred inquiries until ctx is cancelled
+func (s *InquiryService) RunDeferredLoop(ctx context.Context) {
+	runPeriodically(ctx, "office_hours", deferredCheckInterval, s.ProcessDeferred)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// Outcome reactions added to answered questions
+const (
+	outcomeAnswered = "white_check_mark"
+	outcomeFallback = "x"
+)
+
+// addOutcomeReaction reacts to the inquiry's question with emoji unless it
+// already carries that reaction, e.g. from before the inquiry was reprocessed.
+// Failures are logged; the answer has been sent regardless.
+func (s *InquiryService) addOutcomeReaction(ctx context.Context, inquiry *storage.Inquiry, emoji string) {
+	reactions, err := s.slack.GetMessageReactions(inquiry.ChannelID, inquiry.Timestamp)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to get reactions, skipping outcome reaction")
+		return
+	}
+	for _, reaction := range reactions {
+		if reaction.Name == emoji {
+			return
+		}
+	}
+
+	if err := s.slack.AddReaction(inquiry.ChannelID, inquiry.Timestamp, emoji); err != nil {
+		loggerFrom(ctx).WithError(err).WithFields(logrus.Fields{
+			"inquiry_id": inquiry.ID,
+			"reaction":   emoji,
+		}).Warn("Failed to add outcome reaction")
+	}
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestGetMessageReactions(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("reactions.get", `{"ok": true, "type": "message", "message": {"reactions": [{"name": "eyes", "count": 2, "users": ["U1", "U2"]}]}}`)
+
+	reactions, err := NewSlackService(cfg).GetMessageReactions("C1", "1.1")
+	if err != nil {
+		t.Fatalf("GetMessageReactions returned error: %v", err)
+	}
+
+	if len(reactions) != 1 || reactions[0].Name != "eyes" || reactions[0].Count != 2 {
+		t.Errorf("Expected the eyes reaction, got %+v", reactions)
+	}
+	calls := fake.callsTo("reactions.get")
+	if len(calls) != 1 || calls[0].Get("channel") != "C1" || calls[0].Get("timestamp") != "1.1" {
+		t.Errorf("Expected reactions of message 1.1 to be requested, got %v", calls)
+	}
+}
+
+func TestSendResponse_OutcomeReaction(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		fallback bool
+		added    string
+	}{
+		{name: "answered", existing: `[]`, added: "white_check_mark"},
+		{name: "fallback", existing: `[]`, fallback: true, added: "x"},
+		{name: "already acknowledged", existing: `[{"name": "white_check_mark", "count": 1, "users": ["UBOT"]}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.OutcomeReactions = true
+			fake := newFakeSlack(t, cfg)
+			fake.respond("reactions.get", `{"ok": true, "type": "message", "message": {"reactions": `+tt.existing+`}}`)
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1"}
+			if err := service.sendResponse(context.Background(), inquiry, "answer", "", nil, tt.fallback); err != nil {
+				t.Fatalf("sendResponse returned error: %v", err)
+			}
+
+			added := fake.callsTo("reactions.add")
+			if tt.added == "" && len(added) != 0 {
+				t.Errorf("Expected no reaction to be re-added, got %v", added)
+			}
+			if tt.added != "" && (len(added) != 1 || added[0].Get("name") != tt.added || added[0].Get("timestamp") != "1.1") {
+				t.Errorf("Expected a %q reaction on the question, got %v", tt.added, added)
+			}
+		})
+	}
+}
+
+func TestSendResponse_OutcomeReactionsDisabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1"}
+	if err := service.sendResponse(context.Background(), inquiry, "answer", "", nil, false); err != nil {
+		t.Fatalf("sendResponse returned error: %v", err)
+	}
+
+	if len(fake.callsTo("reactions.get")) != 0 || len(fake.callsTo("reactions.add")) != 0 {
+		t.Error("Expected no reaction calls when outcome reactions are disabled")
+	}
+}
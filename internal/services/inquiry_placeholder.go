@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// Placeholder texts shown while an answer is being prepared
+const (
+	placeholderSearching  = "🔍 Searching Slack and Confluence…"
+	placeholderGenerating = "🤖 Generating answer…"
+)
+
+// placeholderKey is the context key holding an inquiry's placeholder reply
+type placeholderKey struct{}
+
+// placeholder is the thread reply edited through the stages of an answer
+// until the answer itself replaces it
+type placeholder struct {
+	channelID string
+	ts        string
+	used      bool
+}
+
+// placeholderFrom returns the unused placeholder set by postPlaceholder, or nil
+func placeholderFrom(ctx context.Context) *placeholder {
+	p, _ := ctx.Value(placeholderKey{}).(*placeholder)
+	if p == nil || p.used {
+		return nil
+	}
+	return p
+}
+
+// postPlaceholder acknowledges the inquiry with a "searching" thread reply
+// when ProgressPlaceholder is enabled, returning ctx carrying the reply for
+// the later stages. A placeholder that can't be posted is skipped.
+func (s *InquiryService) postPlaceholder(ctx context.Context, inquiry *storage.Inquiry) context.Context {
+	if !s.cfg().ProgressPlaceholder || inquiry.Source == InquirySourceAPI {
+		return ctx
+	}
+
+	ts, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, placeholderSearching)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to post placeholder reply")
+		return ctx
+	}
+	return context.WithValue(ctx, placeholderKey{}, &placeholder{channelID: inquiry.ChannelID, ts: ts})
+}
+
+// updatePlaceholder replaces the text of ctx's placeholder, if it has one
+func (s *InquiryService) updatePlaceholder(ctx context.Context, text string) {
+	p := placeholderFrom(ctx)
+	if p == nil {
+		return
+	}
+	if err := s.slack.UpdateMessage(p.channelID, p.ts, text); err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to update placeholder reply")
+	}
+}
+
+// postReply posts text as a reply in the inquiry's thread. The first reply
+// of an inquiry with a placeholder edits the placeholder instead.
+func (s *InquiryService) postReply(ctx context.Context, inquiry *storage.Inquiry, text string) (string, error) {
+	if p := placeholderFrom(ctx); p != nil {
+		if err := s.slack.UpdateMessage(p.channelID, p.ts, text); err != nil {
+			return "", err
+		}
+		p.used = true
+		return p.ts, nil
+	}
+	return s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, text)
+}
+
+// removePlaceholder deletes ctx's placeholder when no answer replaced it,
+// e.g. because searching failed
+func (s *InquiryService) removePlaceholder(ctx context.Context) {
+	p := placeholderFrom(ctx)
+	if p == nil {
+		return
+	}
+	if err := s.slack.DeleteMessage(p.channelID, p.ts); err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to delete placeholder reply")
+	}
+	p.used = true
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestProcessInquiry_PlaceholderSequence(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ProgressPlaceholder = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("chat.postMessage", `{"ok": true, "channel": "C1", "ts": "1.2"}`)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run make deploy")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	posts := fake.callsTo("chat.postMessage")
+	if len(posts) != 1 || posts[0].Get("text") != placeholderSearching {
+		t.Fatalf("Expected only the placeholder to be posted, got %v", posts)
+	}
+	updates := fake.callsTo("chat.update")
+	if len(updates) != 2 {
+		t.Fatalf("Expected the placeholder to be edited twice, got %v", updates)
+	}
+	if updates[0].Get("text") != placeholderGenerating || updates[0].Get("ts") != "1.2" {
+		t.Errorf("Expected the placeholder to announce generation, got %v", updates[0])
+	}
+	if !strings.Contains(updates[1].Get("text"), "Run make deploy") || updates[1].Get("ts") != "1.2" {
+		t.Errorf("Expected the placeholder to become the answer, got %v", updates[1])
+	}
+	if len(fake.callsTo("chat.delete")) != 0 {
+		t.Error("Expected the answered placeholder to be kept")
+	}
+
+	inquiry, err := service.GetInquiryByMessageID("1.1")
+	if err != nil || inquiry.ThreadTimestamp != "1.2" {
+		t.Errorf("Expected the placeholder to be recorded as the answer, got %+v, %v", inquiry, err)
+	}
+}
+
+func TestProcessInquiry_PlaceholderSplitAnswer(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ProgressPlaceholder = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("chat.postMessage", `{"ok": true, "channel": "C1", "ts": "1.2"}`)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, strings.Repeat("word ", 500)+"\n\n"+strings.Repeat("more ", 500))
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	// The placeholder becomes the first part and the rest follow as new replies
+	if updates := fake.callsTo("chat.update"); len(updates) != 2 {
+		t.Errorf("Expected the placeholder to be edited twice, got %d edits", len(updates))
+	}
+	if posts := fake.callsTo("chat.postMessage"); len(posts) != 2 {
+		t.Errorf("Expected the placeholder and the second part to be posted, got %d posts", len(posts))
+	}
+}
+
+func TestProcessInquiry_PlaceholderDisabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run make deploy")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	posts := fake.callsTo("chat.postMessage")
+	if len(posts) != 1 || !strings.Contains(posts[0].Get("text"), "Run make deploy") {
+		t.Errorf("Expected the answer to be posted directly, got %v", posts)
+	}
+	if updates := fake.callsTo("chat.update"); len(updates) != 0 {
+		t.Errorf("Expected no edits, got %v", updates)
+	}
+}
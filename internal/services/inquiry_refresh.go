@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// ListRefreshEligible lists completed inquiries whose answer is past its TTL
+// and has not yet been offered a refresh
+func (s *InquiryService) ListRefreshEligible(now time.Time) ([]storage.Inquiry, error) {
+	var inquiries []storage.Inquiry
+	err := s.db.Where("status = ? AND refresh_after IS NOT NULL AND refresh_after <= ? AND refresh_offered_at IS NULL", "completed", now).
+		Order("refresh_after ASC").
+		Find(&inquiries).Error
+	if err != nil {
+		return nil, err
+	}
+	return inquiries, nil
+}
+
+// OfferAnswerRefreshes posts a refresh offer in the thread of every expired
+// answer whose thread has seen activity within the TTL window
+func (s *InquiryService) OfferAnswerRefreshes(ctx context.Context) error {
+	now := time.Now()
+	inquiries, err := s.ListRefreshEligible(now)
+	if err != nil {
+		return fmt.Errorf("failed to list refresh-eligible inquiries: %w", err)
+	}
+
+	activeSince := now.AddDate(0, 0, -s.cfg().AnswerTTLDays)
+	for i := range inquiries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		inquiry := &inquiries[i]
+		active, err := s.threadActiveSince(inquiry, activeSince)
+		if err != nil {
+			logrus.WithError(err).WithField("inquiry_id", inquiry.ID).Warn("Failed to check thread activity")
+			continue
+		}
+		if !active {
+			continue
+		}
+
+		note := fmt.Sprintf("📚 This answer is more than %d days old and the sources it was based on may have changed. "+
+			"React to the original message with :%s: to refresh it.", s.cfg().AnswerTTLDays, s.cfg().AnswerRefreshEmoji)
+		if _, err := s.slack.PostThreadReply(inquiry.ChannelID, inquiry.Timestamp, note); err != nil {
+			logrus.WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to post refresh offer")
+			continue
+		}
+
+		inquiry.RefreshOfferedAt = &now
+		s.db.Save(inquiry)
+	}
+
+	return nil
+}
+
+// RunAnswerRefreshLoop periodically offers refreshes for expired answers until ctx is cancelled
+func (s *InquiryService) RunAnswerRefreshLoop(ctx context.Context) {
+	if s.cfg().AnswerTTLDays <= 0 {
+		return
+	}
+
+	runPeriodically(ctx, "answer_refresh", s.cfg().AnswerRefreshCheckInterval, s.OfferAnswerRefreshes)
+}
+
+// threadActiveSince reports whether the inquiry's thread has a message newer than since
+func (s *InquiryService) threadActiveSince(inquiry *storage.Inquiry, since time.Time) (bool, error) {
+	replies, err := s.slack.GetThreadReplies(inquiry.ChannelID, inquiry.Timestamp)
+	if err != nil {
+		return false, err
+	}
+
+	for _, reply := range replies {
+		if s.search.timestampToTime(reply.Timestamp).After(since) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// refreshAnswer reprocesses the answered inquiry for messageID against current search results
+func (s *InquiryService) refreshAnswer(ctx context.Context, messageID string) error {
+	var inquiry storage.Inquiry
+	if err := s.db.Where("message_id = ?", messageID).First(&inquiry).Error; err != nil {
+		logrus.WithField("message_id", messageID).Debug("Refresh requested for unknown inquiry, ignoring")
+		return nil
+	}
+
+	if inquiry.Status != "completed" || inquiry.RefreshOfferedAt == nil {
+		return nil
+	}
+
+	logrus.WithField("inquiry_id", inquiry.ID).Info("Refreshing expired answer")
+	return s.ReprocessInquiry(ctx, inquiry.ID)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// RescoreInquiry reranks the stored search results of an inquiry with the
+// current scoring configuration. With persist set, the recomputed base scores
+// replace the stored ones.
+func (s *InquiryService) RescoreInquiry(ctx context.Context, inquiryID uint, persist bool) ([]storage.SearchResult, *SearchExplanation, error) {
+	var inquiry storage.Inquiry
+	if err := s.db.First(&inquiry, inquiryID).Error; err != nil {
+		return nil, nil, err
+	}
+
+	var results []storage.SearchResult
+	if err := s.db.Where("inquiry_id = ?", inquiry.ID).Find(&results).Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to load search results: %w", err)
+	}
+
+	ranked, explanation := s.search.RescoreStored(ctx, &inquiry, results)
+
+	if persist {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			for _, result := range results {
+				if err := tx.Model(&storage.SearchResult{}).Where("id = ?", result.ID).Update("score", result.Score).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to persist rescored results: %w", err)
+		}
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"results":    len(results),
+		"selected":   len(ranked),
+		"persisted":  persist,
+	}).Info("Rescored stored search results")
+
+	return ranked, explanation, nil
+}
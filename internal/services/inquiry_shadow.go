@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// shadowSampled reports whether the inquiry with the given ID falls in the
+// SHADOW_SAMPLING_RATE share of inquiries answered by the shadow model. The
+// choice hashes the ID, so reprocessing an inquiry samples it the same way.
+func shadowSampled(inquiryID uint, rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	h := fnv.New32a()
+	h.Write([]byte(strconv.FormatUint(uint64(inquiryID), 10)))
+	return float64(h.Sum32()%10000) < rate*10000
+}
+
+// shadowAnswer, for a sampled inquiry, has SHADOW_LLM_MODEL answer it from the
+// same search results in the background and stores the answer as a shadow
+// version, without ever posting it. The live answer doesn't wait for it.
+func (s *InquiryService) shadowAnswer(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) {
+	model := s.cfg().ShadowLLMModel
+	if model == "" || model == inquiry.Model || !shadowSampled(inquiry.ID, s.cfg().ShadowSamplingRate) {
+		return
+	}
+
+	select {
+	case s.shadowSlots <- struct{}{}:
+	default:
+		loggerFrom(ctx).WithField("inquiry_id", inquiry.ID).Debug("Too many shadow answers in flight, skipping")
+		return
+	}
+
+	// The pipeline goes on changing the inquiry and its results
+	shadowed := *inquiry
+	results := append([]storage.SearchResult(nil), searchResults...)
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		defer func() { <-s.shadowSlots }()
+
+		answer, err := s.llm.chat(ctx, s.llm.answerRequest(ctx, &shadowed, results, model))
+		s.metrics.Incr("inquiry.shadow_answer", map[string]string{"model": model, "status": outcomeTag(err)})
+
+		version := storage.AnswerVersion{
+			InquiryID: shadowed.ID,
+			Kind:      "shadow",
+			Model:     model,
+			Content:   answer,
+		}
+		if err != nil {
+			version.Error = err.Error()
+		}
+		if err := s.db.Create(&version).Error; err != nil {
+			loggerFrom(ctx).WithError(err).WithField("inquiry_id", shadowed.ID).Error("Failed to record shadow answer")
+			return
+		}
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": shadowed.ID,
+			"model":      model,
+		}).Debug("Recorded shadow answer")
+	}()
+}
@@ -0,0 +1,89 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestShadowSampled(t *testing.T) {
+	for _, rate := range []float64{0, 0.1, 0.5, 1} {
+		sampled := 0
+		for id := uint(1); id <= 10000; id++ {
+			if shadowSampled(id, rate) {
+				sampled++
+			}
+		}
+		if got := float64(sampled) / 10000; got < rate-0.02 || got > rate+0.02 {
+			t.Errorf("Expected about %v of inquiries to be sampled, got %v", rate, got)
+		}
+	}
+
+	if shadowSampled(42, 0.5) != shadowSampled(42, 0.5) {
+		t.Error("Expected the same inquiry to be sampled the same way every time")
+	}
+}
+
+func TestProcessInquiry_ShadowAnswer(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64
+		shadows int
+	}{
+		{name: "sampled", rate: 1, shadows: 1},
+		{name: "not sampled", rate: 0, shadows: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ShadowLLMModel = "shadow-model"
+			cfg.ShadowSamplingRate = tt.rate
+			fakeSlack := newFakeSlack(t, cfg)
+			fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			fake := newFakeLLM(t, cfg, "Live answer")
+			fake.answerModel("shadow-model", "Shadow answer")
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+
+			if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			inquiry, _ := service.GetInquiryByMessageID("1.1")
+			if inquiry.ResponseText != "Live answer" {
+				t.Errorf("Expected the live answer to be the response, got %q", inquiry.ResponseText)
+			}
+
+			var versions []storage.AnswerVersion
+			recorded := waitFor(t, func() bool {
+				db.Where("inquiry_id = ? AND kind = ?", inquiry.ID, "shadow").Find(&versions)
+				return len(versions) == tt.shadows
+			})
+			if tt.shadows == 0 {
+				// Give a shadow answer that shouldn't exist the time to show up
+				time.Sleep(50 * time.Millisecond)
+				db.Where("inquiry_id = ? AND kind = ?", inquiry.ID, "shadow").Find(&versions)
+			}
+			if !recorded || len(versions) != tt.shadows {
+				t.Fatalf("Expected %d shadow answers, got %d", tt.shadows, len(versions))
+			}
+			if tt.shadows == 1 {
+				version := versions[0]
+				if version.Model != "shadow-model" || version.Content != "Shadow answer" || version.Selected {
+					t.Errorf("Expected the shadow model's unselected answer, got %+v", version)
+				}
+			}
+
+			for _, call := range fakeSlack.callsTo("chat.postMessage") {
+				if strings.Contains(call.Encode(), "Shadow") {
+					t.Errorf("Expected the shadow answer not to be posted, got %v", call)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// deletedSourceNote heads answers annotated after their question was deleted
+const deletedSourceNote = "The question this answered has been deleted."
+
+// HandleSourceDeleted records that the message at ts in channelID was deleted
+// and, per DeletedSourceAction, annotates or deletes the answer posted to its
+// thread. Messages that never became inquiries are ignored.
+func (s *InquiryService) HandleSourceDeleted(ctx context.Context, channelID, ts string) error {
+	var inquiry storage.Inquiry
+	err := s.db.Where("channel_id = ? AND timestamp = ?", channelID, ts).First(&inquiry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up inquiry: %w", err)
+	}
+	if inquiry.SourceDeletedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	inquiry.SourceDeletedAt = &now
+	if err := s.db.Model(&inquiry).Update("source_deleted_at", now).Error; err != nil {
+		return fmt.Errorf("failed to mark inquiry source deleted: %w", err)
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"inquiry_id": inquiry.ID,
+		"channel_id": channelID,
+		"action":     s.cfg().DeletedSourceAction,
+	}).Info("Inquiry source message deleted")
+
+	// Only the first reply is tracked; the rest of a split answer stays
+	if inquiry.ThreadTimestamp == "" {
+		return nil
+	}
+	switch s.cfg().DeletedSourceAction {
+	case "annotate":
+		text := fmt.Sprintf("🤖 *AI Assistant Response*\n_%s_\n\n%s", deletedSourceNote, inquiry.ResponseText)
+		if err := s.slack.UpdateMessage(channelID, inquiry.ThreadTimestamp, splitMessage(text, slackMessageLimit)[0]); err != nil {
+			return fmt.Errorf("failed to annotate answer: %w", err)
+		}
+	case "delete":
+		if err := s.slack.DeleteMessage(channelID, inquiry.ThreadTimestamp); err != nil {
+			return fmt.Errorf("failed to delete answer: %w", err)
+		}
+	}
+
+	return nil
+}
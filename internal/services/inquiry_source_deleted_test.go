@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestHandleSourceDeleted(t *testing.T) {
+	tests := []struct {
+		action  string
+		updates int
+		deletes int
+	}{
+		{action: "none"},
+		{action: "annotate", updates: 1},
+		{action: "delete", deletes: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.action, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.DeletedSourceAction = tt.action
+			fake := newFakeSlack(t, cfg)
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+
+			inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1", Status: "completed",
+				ResponseSent: true, ResponseText: "Run make deploy", ThreadTimestamp: "1.2"}
+			db.Create(inquiry)
+
+			if err := service.HandleSourceDeleted(context.Background(), "C1", "1.1"); err != nil {
+				t.Fatalf("HandleSourceDeleted returned error: %v", err)
+			}
+
+			var stored storage.Inquiry
+			db.First(&stored, inquiry.ID)
+			if stored.SourceDeletedAt == nil {
+				t.Error("Expected the deletion time to be stored")
+			}
+
+			updates, deletes := fake.callsTo("chat.update"), fake.callsTo("chat.delete")
+			if len(updates) != tt.updates || len(deletes) != tt.deletes {
+				t.Fatalf("Expected %d updates and %d deletes, got %d and %d", tt.updates, tt.deletes, len(updates), len(deletes))
+			}
+			if tt.updates > 0 {
+				text := updates[0].Get("text")
+				if updates[0].Get("ts") != "1.2" || !strings.Contains(text, deletedSourceNote) || !strings.Contains(text, "Run make deploy") {
+					t.Errorf("Expected the answer to be annotated, got %v", updates[0])
+				}
+			}
+			if tt.deletes > 0 && deletes[0].Get("ts") != "1.2" {
+				t.Errorf("Expected the answer to be deleted, got %v", deletes[0])
+			}
+		})
+	}
+}
+
+func TestHandleSourceDeleted_UnknownMessage(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.DeletedSourceAction = "delete"
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.HandleSourceDeleted(context.Background(), "C1", "9.9"); err != nil {
+		t.Fatalf("HandleSourceDeleted returned error: %v", err)
+	}
+	if calls := fake.callsTo("chat.delete"); len(calls) != 0 {
+		t.Errorf("Expected nothing to be deleted, got %v", calls)
+	}
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// channelSummaryPrompt is the instruction given to the LLM in place of an inquiry
+const channelSummaryPrompt = "Summarise the knowledge shared in this channel since %s. " +
+	"Group related discussions into topics, list the questions that were answered with their answers, " +
+	"and call out any questions that are still open. Use the Slack discussions in the context as the only source."
+
+// partialSummaryPrompt summarises one chunk of a channel history too long to
+// fit LLM_MAX_CONTEXT_CHARS in one request
+const partialSummaryPrompt = "Summarise part %d of %d of the messages posted in this channel since %s. " +
+	"List the topics discussed, the questions that were answered with their answers, " +
+	"and any questions that are still open. Use the Slack discussions in the context as the only source."
+
+// combinedSummaryPrompt merges the partial summaries of a long channel history
+const combinedSummaryPrompt = "Combine the partial summaries in the context into one summary of the knowledge " +
+	"shared in this channel since %s. Group related discussions into topics, list the questions that were " +
+	"answered with their answers, and call out any questions that are still open."
+
+// maxSummaryStages caps how many times partial summaries are summarised again
+// before the context budget is allowed to drop some of them
+const maxSummaryStages = 3
+
+// summaryTruncatedNote ends a summary whose history did not fit the context budget
+const summaryTruncatedNote = "\n\n_The channel history was too long to summarise in full, so part of it was left out._"
+
+// SummariseChannel generates a knowledge summary of the messages posted in a channel since the given time
+func (s *InquiryService) SummariseChannel(ctx context.Context, channelID string, since time.Time) (string, error) {
+	messages, err := s.slack.ListRecentMessages(channelID, since)
+	if err != nil {
+		return "", fmt.Errorf("failed to list channel messages: %w", err)
+	}
+	if len(messages) == 0 {
+		return "", fmt.Errorf("no messages in channel since %s", since.Format("2006-01-02"))
+	}
+
+	results := make([]storage.SearchResult, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Text == "" {
+			continue
+		}
+		results = append(results, storage.SearchResult{
+			Source:    "slack",
+			SourceID:  msg.Timestamp,
+			ChannelID: channelID,
+			Content:   msg.Text,
+			Author:    msg.User,
+		})
+	}
+
+	// The LLM context budget would silently drop whatever does not fit, so a
+	// history over budget is summarised in chunks that each fit, and the
+	// partial summaries are then combined
+	sinceDate := since.Format("2006-01-02")
+	budget := s.cfg().LLMMaxContextChars
+	prompt := channelSummaryPrompt
+	history := results
+	stages := 0
+	for budget > 0 && contextChars(history) > budget && stages < maxSummaryStages {
+		stages++
+		chunks := chunkByContextChars(history, budget)
+		partials := make([]storage.SearchResult, 0, len(chunks))
+		for i, chunk := range chunks {
+			partialRequest := &storage.Inquiry{
+				ChannelID:   channelID,
+				MessageText: fmt.Sprintf(partialSummaryPrompt, i+1, len(chunks), sinceDate),
+			}
+			partial, err := s.llm.GenerateResponse(ctx, partialRequest, chunk)
+			if err != nil {
+				return "", fmt.Errorf("failed to summarise part %d of %d: %w", i+1, len(chunks), err)
+			}
+			partials = append(partials, storage.SearchResult{
+				Source:    "slack",
+				SourceID:  fmt.Sprintf("summary-%d-%d", stages, i+1),
+				ChannelID: channelID,
+				Title:     fmt.Sprintf("Part %d of %d", i+1, len(chunks)),
+				Content:   partial,
+			})
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"channel_id": channelID,
+			"stage":      stages,
+			"chunks":     len(chunks),
+		}).Info("Summarised channel history in chunks")
+
+		history = partials
+		prompt = combinedSummaryPrompt
+	}
+
+	truncated := budget > 0 && contextChars(history) > budget
+	if truncated {
+		logrus.WithFields(logrus.Fields{
+			"channel_id": channelID,
+			"stages":     stages,
+			"chars":      contextChars(history),
+			"budget":     budget,
+		}).Warn("Channel summary exceeds the LLM context budget, part of it will be left out")
+	}
+
+	summaryRequest := &storage.Inquiry{
+		ChannelID:   channelID,
+		MessageText: fmt.Sprintf(prompt, sinceDate),
+	}
+
+	summary, err := s.llm.GenerateResponse(ctx, summaryRequest, history)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate summary: %w", err)
+	}
+	if truncated {
+		summary += summaryTruncatedNote
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"messages":   len(results),
+		"stages":     stages,
+	}).Info("Generated channel summary")
+
+	return summary, nil
+}
+
+// contextChars is how much of the LLM context budget results take up
+func contextChars(results []storage.SearchResult) int {
+	chars := 0
+	for _, result := range results {
+		chars += len(result.Title) + len(result.Content)
+	}
+	return chars
+}
+
+// chunkByContextChars splits results, in order, into chunks that each fit
+// budget. A result too large to fit on its own is cut to the budget.
+func chunkByContextChars(results []storage.SearchResult, budget int) [][]storage.SearchResult {
+	var chunks [][]storage.SearchResult
+	var chunk []storage.SearchResult
+	used := 0
+	for _, result := range results {
+		size := len(result.Title) + len(result.Content)
+		if size > budget {
+			result.Content = truncateAtWord(result.Content, max(budget-len(result.Title), 1))
+			size = len(result.Title) + len(result.Content)
+		}
+		if len(chunk) > 0 && used+size > budget {
+			chunks = append(chunks, chunk)
+			chunk, used = nil, 0
+		}
+		chunk = append(chunk, result)
+		used += size
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
@@ -0,0 +1,898 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+// setupTestDB creates a migrated SQLite database in a temporary directory
+func setupTestDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := storage.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to initialize test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			_ = sqlDB.Close()
+		}
+	})
+
+	return db
+}
+
+// newTestInquiryService wires an InquiryService with real services backed by cfg and db
+func newTestInquiryService(cfg *config.Config, db *gorm.DB) *InquiryService {
+	slackService := NewSlackService(cfg)
+	confluenceService := NewConfluenceService(cfg)
+	llmService := NewLLMService(cfg)
+	searchService := NewSearchService(slackService, confluenceService, db, cfg)
+	return NewInquiryService(searchService, slackService, llmService, db, cfg)
+}
+
+// statusTexts decodes the status text of each recorded users.profile.set call
+func statusTexts(t *testing.T, fake *fakeSlack) []string {
+	t.Helper()
+
+	var texts []string
+	for _, call := range fake.callsTo("users.profile.set") {
+		var profile struct {
+			StatusText string `json:"status_text"`
+		}
+		if err := json.Unmarshal([]byte(call.Get("profile")), &profile); err != nil {
+			t.Fatalf("Failed to decode profile: %v", err)
+		}
+		texts = append(texts, profile.StatusText)
+	}
+	return texts
+}
+
+func TestBatchReprocessFailed_SetsAndClearsStatus(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.BotStatusEnabled = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	db.Create(&storage.Inquiry{MessageID: "1.1", ChannelID: "C1", MessageText: "deploy", Timestamp: "1.1", Status: "failed"})
+
+	if err := service.BatchReprocessFailed(context.Background()); err != nil {
+		t.Fatalf("BatchReprocessFailed returned error: %v", err)
+	}
+
+	texts := statusTexts(t, fake)
+	if len(texts) != 2 {
+		t.Fatalf("Expected status to be set and cleared, got %d calls", len(texts))
+	}
+	if texts[0] != botBusyStatusText {
+		t.Errorf("Expected busy status '%s', got '%s'", botBusyStatusText, texts[0])
+	}
+	if texts[1] != "" {
+		t.Errorf("Expected cleared status, got '%s'", texts[1])
+	}
+}
+
+func TestBatchReprocessFailed_ClearsStatusOnError(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.BotStatusEnabled = true
+	fake := newFakeSlack(t, cfg)
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	// Closing the connection makes the failed-inquiry query error out
+	sqlDB, _ := db.DB()
+	_ = sqlDB.Close()
+
+	if err := service.BatchReprocessFailed(context.Background()); err == nil {
+		t.Fatal("Expected error when the database is unavailable")
+	}
+
+	texts := statusTexts(t, fake)
+	if len(texts) != 2 || texts[0] != botBusyStatusText || texts[1] != "" {
+		t.Errorf("Expected status to be set then cleared, got %q", texts)
+	}
+}
+
+func TestBatchReprocessFailed_StatusDisabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.BatchReprocessFailed(context.Background()); err != nil {
+		t.Fatalf("BatchReprocessFailed returned error: %v", err)
+	}
+
+	if calls := fake.callsTo("users.profile.set"); len(calls) != 0 {
+		t.Errorf("Expected no status updates when disabled, got %d", len(calls))
+	}
+}
+
+func TestModelForReaction(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMAllowedModels = []string{"gpt-4o-mini", "gpt-4o"}
+	cfg.EmojiModels = map[string]string{
+		"brain":  "gpt-4o",
+		"rocket": "o1-preview",
+	}
+	service := newTestInquiryService(cfg, nil)
+
+	tests := []struct {
+		name            string
+		reaction        string
+		expectedModel   string
+		expectedTrigger bool
+	}{
+		{name: "default trigger", reaction: "eyes", expectedModel: "", expectedTrigger: true},
+		{name: "mapped emoji", reaction: "brain", expectedModel: "gpt-4o", expectedTrigger: true},
+		{name: "disallowed model falls back", reaction: "rocket", expectedModel: "", expectedTrigger: true},
+		{name: "unrelated emoji", reaction: "thumbsup", expectedModel: "", expectedTrigger: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model, isTrigger := service.modelForReaction(tt.reaction)
+			if model != tt.expectedModel || isTrigger != tt.expectedTrigger {
+				t.Errorf("modelForReaction(%q) = (%q, %v), expected (%q, %v)",
+					tt.reaction, model, isTrigger, tt.expectedModel, tt.expectedTrigger)
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_ModelOverride(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "answer")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "deploy", "1.1", "gpt-4o"); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+	if err := service.ProcessInquiry(context.Background(), "2.2", "C1", "U1", "deploy", "2.2", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	if llm.requests[0]["model"] != "gpt-4o" {
+		t.Errorf("Expected override model 'gpt-4o' to be requested, got %v", llm.requests[0]["model"])
+	}
+	if llm.requests[1]["model"] != "gpt-4o-mini" {
+		t.Errorf("Expected default model 'gpt-4o-mini' to be requested, got %v", llm.requests[1]["model"])
+	}
+
+	overridden, _ := service.GetInquiryByMessageID("1.1")
+	if overridden.Model != "gpt-4o" {
+		t.Errorf("Expected recorded model 'gpt-4o', got '%s'", overridden.Model)
+	}
+	defaulted, _ := service.GetInquiryByMessageID("2.2")
+	if defaulted.Model != "gpt-4o-mini" {
+		t.Errorf("Expected recorded model 'gpt-4o-mini', got '%s'", defaulted.Model)
+	}
+}
+
+func TestReprocessInquiry_KeepsRequestedModel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMAllowedModels = []string{"gpt-4o-mini", "gpt-4o"}
+	cfg.ChannelModels = map[string]string{"C1": "gpt-4o-mini"}
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "deploy", "1.1", "gpt-4o"); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if inquiry.Model != "gpt-4o-mini" || inquiry.RequestedModel != "gpt-4o" {
+		t.Errorf("Expected gpt-4o requested and the channel's gpt-4o-mini used, got %q requested and %q used", inquiry.RequestedModel, inquiry.Model)
+	}
+
+	// Without the channel override the emoji's model answers again
+	reloaded := *cfg
+	reloaded.ChannelModels = nil
+	service.llm.Reload(&reloaded)
+	service.Reload(&reloaded)
+	if err := service.ReprocessInquiry(context.Background(), inquiry.ID); err != nil {
+		t.Fatalf("ReprocessInquiry returned error: %v", err)
+	}
+	if model := llm.requests[len(llm.requests)-1]["model"]; model != "gpt-4o" {
+		t.Errorf("Expected the requested model on reprocessing, got %v", model)
+	}
+}
+
+func TestProcessInquiry_SetsRefreshAfter(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerTTLDays = 30
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	before := time.Now()
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "deploy", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if inquiry.RefreshAfter == nil {
+		t.Fatal("Expected RefreshAfter to be set")
+	}
+	if inquiry.RefreshAfter.Before(before.AddDate(0, 0, 30)) {
+		t.Errorf("Expected RefreshAfter at least 30 days out, got %v", inquiry.RefreshAfter)
+	}
+}
+
+func TestProcessInquiry_RecordsProcessingNode(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "deploy", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if inquiry.ProcessingNode == "" {
+		t.Error("Expected ProcessingNode to be recorded")
+	}
+}
+
+func TestListRefreshEligible(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	db.Create(&storage.Inquiry{MessageID: "due", Status: "completed", RefreshAfter: &past})
+	db.Create(&storage.Inquiry{MessageID: "not-due", Status: "completed", RefreshAfter: &future})
+	db.Create(&storage.Inquiry{MessageID: "offered", Status: "completed", RefreshAfter: &past, RefreshOfferedAt: &past})
+	db.Create(&storage.Inquiry{MessageID: "failed", Status: "failed", RefreshAfter: &past})
+	db.Create(&storage.Inquiry{MessageID: "no-ttl", Status: "completed"})
+
+	inquiries, err := service.ListRefreshEligible(now)
+	if err != nil {
+		t.Fatalf("ListRefreshEligible returned error: %v", err)
+	}
+	if len(inquiries) != 1 || inquiries[0].MessageID != "due" {
+		t.Errorf("Expected only the due inquiry, got %+v", inquiries)
+	}
+}
+
+func TestOfferAnswerRefreshes_ActiveThreadOnly(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerTTLDays = 30
+	fake := newFakeSlack(t, cfg)
+	recent := fmt.Sprintf("%d.000100", time.Now().Add(-24*time.Hour).Unix())
+	fake.respond("conversations.replies", fmt.Sprintf(
+		`{"ok": true, "messages": [{"ts": "1.1", "text": "question"}, {"ts": "%s", "text": "still broken?"}]}`, recent))
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	past := time.Now().Add(-time.Hour)
+	db.Create(&storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1", Status: "completed", RefreshAfter: &past})
+
+	if err := service.OfferAnswerRefreshes(context.Background()); err != nil {
+		t.Fatalf("OfferAnswerRefreshes returned error: %v", err)
+	}
+
+	posts := fake.callsTo("chat.postMessage")
+	if len(posts) != 1 {
+		t.Fatalf("Expected 1 refresh offer, got %d", len(posts))
+	}
+	if posts[0].Get("thread_ts") != "1.1" {
+		t.Errorf("Expected offer in thread '1.1', got '%s'", posts[0].Get("thread_ts"))
+	}
+
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if inquiry.RefreshOfferedAt == nil {
+		t.Error("Expected RefreshOfferedAt to be recorded")
+	}
+
+	// A second pass must not offer again
+	if err := service.OfferAnswerRefreshes(context.Background()); err != nil {
+		t.Fatalf("OfferAnswerRefreshes returned error: %v", err)
+	}
+	if posts := fake.callsTo("chat.postMessage"); len(posts) != 1 {
+		t.Errorf("Expected no repeat offer, got %d posts", len(posts))
+	}
+}
+
+func TestSummariseChannel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": [
+		{"ts": "200.000000", "user": "U2", "text": "Use the rollout dashboard."},
+		{"ts": "100.000000", "user": "U1", "text": "How do I check a rollout?"}
+	]}`)
+	llm := newFakeLLM(t, cfg, "Weekly summary")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	summary, err := service.SummariseChannel(context.Background(), "C1", time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("SummariseChannel returned error: %v", err)
+	}
+	if summary != "Weekly summary" {
+		t.Errorf("Expected LLM summary, got %q", summary)
+	}
+
+	payload, _ := json.Marshal(llm.requests[0]["messages"])
+	for _, expected := range []string{"Summarise the knowledge", "How do I check a rollout?", "Use the rollout dashboard."} {
+		if !strings.Contains(string(payload), expected) {
+			t.Errorf("Expected LLM prompt to contain %q", expected)
+		}
+	}
+}
+
+func TestSummariseChannel_OverContextBudget(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMMaxContextChars = 70
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": [
+		{"ts": "400.000000", "user": "U4", "text": "Rollbacks go through the release channel."},
+		{"ts": "300.000000", "user": "U3", "text": "How do I roll back a release?"},
+		{"ts": "200.000000", "user": "U2", "text": "Use the rollout dashboard."},
+		{"ts": "100.000000", "user": "U1", "text": "How do I check a rollout?"}
+	]}`)
+	llm := newFakeLLM(t, cfg, "Part summary")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	summary, err := service.SummariseChannel(context.Background(), "C1", time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatalf("SummariseChannel returned error: %v", err)
+	}
+	if summary != "Part summary" {
+		t.Errorf("Expected the combined summary without a truncation note, got %q", summary)
+	}
+
+	if llm.requestCount() < 3 {
+		t.Fatalf("Expected the history summarised in chunks and then combined, got %d LLM requests", llm.requestCount())
+	}
+	payload, _ := json.Marshal(llm.requests)
+	for _, expected := range []string{"How do I check a rollout?", "Use the rollout dashboard.", "How do I roll back a release?", "Rollbacks go through the release channel.", "Combine the partial summaries"} {
+		if !strings.Contains(string(payload), expected) {
+			t.Errorf("Expected an LLM prompt to contain %q", expected)
+		}
+	}
+}
+
+func TestSummariseChannel_NoMessages(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": []}`)
+	llm := newFakeLLM(t, cfg, "unused")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if _, err := service.SummariseChannel(context.Background(), "C1", time.Now().AddDate(0, 0, -7)); err == nil {
+		t.Error("Expected error for a channel without messages")
+	}
+	if llm.requestCount() != 0 {
+		t.Errorf("Expected no LLM request, got %d", llm.requestCount())
+	}
+}
+
+func TestProcessFileReactionEvent(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ProcessFileReactions = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("files.info", `{"ok": true,
+		"file": {"id": "F1", "user": "U1", "title": "Deploy runbook",
+			"shares": {"public": {"C1": [{"ts": "10.0001"}]}}},
+		"comments": [{"id": "Fc1", "user": "U2", "comment": "Is step 3 still required?"}]}`)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessFileReactionEvent(context.Background(), "F1", "Fc1", "U3", "eyes", "added", "11.0"); err != nil {
+		t.Fatalf("ProcessFileReactionEvent returned error: %v", err)
+	}
+
+	inquiry, err := service.GetInquiryByMessageID("Fc1")
+	if err != nil {
+		t.Fatalf("Expected inquiry for file comment: %v", err)
+	}
+	if inquiry.ChannelID != "C1" || inquiry.Timestamp != "10.0001" {
+		t.Errorf("Expected inquiry anchored at the file share, got channel '%s' ts '%s'", inquiry.ChannelID, inquiry.Timestamp)
+	}
+	if !strings.Contains(inquiry.MessageText, "Deploy runbook") || !strings.Contains(inquiry.MessageText, "step 3") {
+		t.Errorf("Expected file title and comment in inquiry text, got %q", inquiry.MessageText)
+	}
+
+	posts := fake.callsTo("chat.postMessage")
+	if len(posts) != 1 || posts[0].Get("thread_ts") != "10.0001" {
+		t.Errorf("Expected a reply in the file share thread, got %v", posts)
+	}
+}
+
+func TestProcessFileReactionEvent_Disabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	if err := service.ProcessFileReactionEvent(context.Background(), "F1", "", "U3", "eyes", "added", "11.0"); err != nil {
+		t.Fatalf("Expected disabled file reactions to be skipped without error, got %v", err)
+	}
+
+	if calls := fake.callsTo("files.info"); len(calls) != 0 {
+		t.Errorf("Expected no file lookups when disabled, got %d", len(calls))
+	}
+	var count int64
+	db.Model(&storage.Inquiry{}).Count(&count)
+	if count != 0 {
+		t.Errorf("Expected no inquiries when disabled, got %d", count)
+	}
+}
+
+func TestAnswerAttribution(t *testing.T) {
+	tests := []struct {
+		name     string
+		model    string
+		sources  []string
+		expected string
+	}{
+		{
+			name:     "mixed sources",
+			model:    "gpt-4o-mini",
+			sources:  []string{"slack", "confluence", "slack"},
+			expected: "Generated by gpt-4o-mini using 2 Slack threads and 1 Confluence page",
+		},
+		{
+			name:     "single source",
+			model:    "gpt-4o",
+			sources:  []string{"confluence", "confluence"},
+			expected: "Generated by gpt-4o using 2 Confluence pages",
+		},
+		{
+			name:     "unknown sources",
+			model:    "gpt-4o",
+			sources:  []string{"slack", "pagerduty", "jira", "confluence"},
+			expected: "Generated by gpt-4o using 1 Slack thread, 1 Confluence page and 2 other sources",
+		},
+		{
+			name:     "code",
+			model:    "gpt-4o",
+			sources:  []string{"github", "confluence", "github"},
+			expected: "Generated by gpt-4o using 1 Confluence page and 2 GitHub files",
+		},
+		{
+			name:     "no sources",
+			model:    "gpt-4o-mini",
+			expected: "Generated by gpt-4o-mini without matching sources",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []storage.SearchResult
+			for _, source := range tt.sources {
+				results = append(results, storage.SearchResult{Source: source})
+			}
+
+			if got := answerAttribution(tt.model, results); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_AnswerAttribution(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.AnswerAttribution = enabled
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+			newFakeLLM(t, cfg, "answer")
+			service := newTestInquiryService(cfg, setupTestDB(t))
+
+			if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "deploy", "1.1", "gpt-4o"); err != nil {
+				t.Fatalf("ProcessInquiry returned error: %v", err)
+			}
+
+			text := fake.callsTo("chat.postMessage")[0].Get("text")
+			footer := "Generated by gpt-4o without matching sources"
+			if strings.Contains(text, footer) != enabled {
+				t.Errorf("Expected footer present=%v, got %q", enabled, text)
+			}
+		})
+	}
+}
+
+func TestSendResponse_AttributesContextResultsOnly(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerAttribution = true
+	cfg.LLMMustHaveThreshold = 0.9
+	cfg.LLMMaxContextChars = 20
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	// The page doesn't fit the context budget, so the answer wasn't built from it
+	results := []storage.SearchResult{
+		{Source: "slack", Content: "make deploy", Score: 0.5},
+		{Source: "confluence", Title: "Deploy guide", Content: strings.Repeat("deploy ", 20), Score: 0.5},
+	}
+	inquiry := &storage.Inquiry{ChannelID: "C1", Timestamp: "1.1"}
+	if err := service.sendResponse(context.Background(), inquiry, "answer", "gpt-4o", results, false); err != nil {
+		t.Fatalf("sendResponse returned error: %v", err)
+	}
+
+	text := fake.callsTo("chat.postMessage")[0].Get("text")
+	if !strings.Contains(text, "Generated by gpt-4o using 1 Slack thread_") {
+		t.Errorf("Expected only the result in the context to be attributed, got %q", text)
+	}
+}
+
+func TestSubmit_QueueFull(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxQueueDepth = 1
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+	noop := func(ctx context.Context) error { return nil }
+
+	if err := service.Submit("C1", "U1", noop); err != nil {
+		t.Fatalf("Expected first submission to be queued, got %v", err)
+	}
+	if err := service.Submit("C1", "U2", noop); !errors.Is(err, ErrQueueFull) {
+		t.Fatalf("Expected ErrQueueFull, got %v", err)
+	}
+
+	notes := fake.callsTo("chat.postEphemeral")
+	if len(notes) != 1 || notes[0].Get("user") != "U2" {
+		t.Errorf("Expected one ephemeral note to the rejected user, got %v", notes)
+	}
+	if rejected := service.Stats().Rejected; rejected != 1 {
+		t.Errorf("Expected 1 rejected inquiry, got %d", rejected)
+	}
+}
+
+func TestGetInquiryCount(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	now := time.Now()
+	db.Create(&storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Status: "completed"})
+	db.Create(&storage.Inquiry{MessageID: "2.2", ChannelID: "C1", Status: "completed"})
+	db.Create(&storage.Inquiry{MessageID: "3.3", ChannelID: "C2", Status: "completed"})
+	db.Create(&storage.Inquiry{MessageID: "4.4", ChannelID: "C1", Status: "completed", CreatedAt: now.Add(-2 * time.Hour)})
+
+	tests := []struct {
+		channelID string
+		expected  int64
+	}{
+		{channelID: "C1", expected: 2},
+		{channelID: "C2", expected: 1},
+		{channelID: "", expected: 3},
+	}
+	for _, tt := range tests {
+		count, err := service.GetInquiryCount(tt.channelID, now.Add(-time.Hour))
+		if err != nil {
+			t.Fatalf("GetInquiryCount(%q) returned error: %v", tt.channelID, err)
+		}
+		if count != tt.expected {
+			t.Errorf("GetInquiryCount(%q) = %d, expected %d", tt.channelID, count, tt.expected)
+		}
+	}
+}
+
+func TestProcessInquiry_UserRateLimit(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxInquiriesPerHour = 2
+	fake := newFakeSlack(t, cfg)
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	db.Create(&storage.Inquiry{MessageID: "1.1", ChannelID: "C1", UserID: "U1", RequestedBy: "U1", Status: "completed"})
+	db.Create(&storage.Inquiry{MessageID: "2.2", ChannelID: "C2", UserID: "U1", RequestedBy: "U1", Status: "completed"})
+	db.Create(&storage.Inquiry{MessageID: "3.3", ChannelID: "C1", UserID: "U1", RequestedBy: "U1", Status: "completed", CreatedAt: time.Now().Add(-2 * time.Hour)})
+
+	err := service.ProcessInquiry(context.Background(), "4.4", "C1", "U1", "deploy", "4.4", "")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected ErrRateLimited, got %v", err)
+	}
+
+	var count int64
+	db.Model(&storage.Inquiry{}).Where("message_id = ?", "4.4").Count(&count)
+	if count != 0 {
+		t.Errorf("Expected rate limited inquiry not to be stored, got %d", count)
+	}
+
+	notes := fake.callsTo("chat.postEphemeral")
+	if len(notes) != 1 || notes[0].Get("user") != "U1" || notes[0].Get("channel") != "C1" {
+		t.Errorf("Expected one ephemeral note to the limited user, got %v", notes)
+	}
+}
+
+func TestProcessReactionEvent_RateLimitsReactingUser(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxInquiriesPerHour = 1
+	fake := newFakeSlack(t, cfg)
+	fake.respond("conversations.history", `{"ok": true, "messages": [{"ts": "5.5", "user": "U1", "text": "How do I deploy?"}]}`)
+	fake.respond("conversations.replies", `{"ok": true, "messages": [{"ts": "5.5", "user": "U1", "text": "How do I deploy?"}]}`)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	// U2 reached the limit by reacting to someone else's message, and the author U1 by asking
+	db.Create(&storage.Inquiry{MessageID: "1.1", ChannelID: "C1", UserID: "U3", RequestedBy: "U2", Status: "completed"})
+	db.Create(&storage.Inquiry{MessageID: "2.2", ChannelID: "C1", UserID: "U1", RequestedBy: "U1", Status: "completed"})
+
+	err := service.ProcessReactionEvent(context.Background(), "5.5", "C1", "U2", cfg.TriggerEmoji, "added", "6.0")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("Expected the reacting user to be rate limited, got %v", err)
+	}
+	notes := fake.callsTo("chat.postEphemeral")
+	if len(notes) != 1 || notes[0].Get("user") != "U2" {
+		t.Errorf("Expected the ephemeral note to go to the reacting user, got %v", notes)
+	}
+
+	// The author's own count doesn't stop U4 from asking on their behalf
+	if err := service.ProcessReactionEvent(context.Background(), "5.5", "C1", "U4", cfg.TriggerEmoji, "added", "7.0"); err != nil {
+		t.Fatalf("ProcessReactionEvent returned error: %v", err)
+	}
+	inquiry, err := service.GetInquiryByMessageID("5.5")
+	if err != nil {
+		t.Fatalf("Expected inquiry to be stored: %v", err)
+	}
+	if inquiry.UserID != "U1" || inquiry.RequestedBy != "U4" {
+		t.Errorf("Expected author U1 requested by U4, got %s requested by %s", inquiry.UserID, inquiry.RequestedBy)
+	}
+}
+
+func TestReprocessStale(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	llm := newFakeLLM(t, cfg, "fresh answer")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	stale := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", MessageText: "deploy", Timestamp: "1.1", Status: "completed", ResponseText: "old"}
+	fresh := &storage.Inquiry{MessageID: "2.2", ChannelID: "C1", MessageText: "rollback", Timestamp: "2.2", Status: "completed", ResponseText: "old"}
+	db.Create(stale)
+	db.Create(fresh)
+	db.Create(&storage.SearchResult{InquiryID: stale.ID, Source: "confluence", SourceID: "P1"})
+	db.Create(&storage.SearchResult{InquiryID: stale.ID, Source: "confluence", SourceID: "P2"})
+	db.Create(&storage.SearchResult{InquiryID: fresh.ID, Source: "confluence", SourceID: "P3"})
+
+	if marked, err := storage.MarkSearchResultsStale(db, "confluence", "P1"); err != nil || marked != 1 {
+		t.Fatalf("Expected 1 result marked stale, got %d (%v)", marked, err)
+	}
+
+	if err := service.ReprocessStale(context.Background()); err != nil {
+		t.Fatalf("ReprocessStale returned error: %v", err)
+	}
+
+	if llm.requestCount() != 1 {
+		t.Errorf("Expected only the stale inquiry to be reprocessed, got %d LLM requests", llm.requestCount())
+	}
+
+	reprocessed, _ := service.GetInquiry(stale.ID)
+	if reprocessed.ResponseText != "fresh answer" {
+		t.Errorf("Expected stale inquiry to get a new answer, got %q", reprocessed.ResponseText)
+	}
+	untouched, _ := service.GetInquiry(fresh.ID)
+	if untouched.ResponseText != "old" || len(untouched.SearchResults) != 1 {
+		t.Errorf("Expected inquiry without stale results to be untouched, got %+v", untouched)
+	}
+
+	var remaining int64
+	db.Model(&storage.SearchResult{}).Where("stale = ?", true).Count(&remaining)
+	if remaining != 0 {
+		t.Errorf("Expected no stale results to remain, got %d", remaining)
+	}
+}
+
+func TestApplyOfficeHours(t *testing.T) {
+	// Sunday 2024-01-14 12:00 in Tokyo
+	sunday := time.Date(2024, 1, 14, 3, 0, 0, 0, time.UTC)
+	// Monday 2024-01-15 10:00 in Tokyo
+	monday := time.Date(2024, 1, 15, 1, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		mode           string
+		now            time.Time
+		expectHandled  bool
+		expectedStatus string
+		expectedNote   string
+	}{
+		{name: "in hours", mode: "defer", now: monday, expectHandled: false, expectedStatus: "pending"},
+		{name: "deferred", mode: "defer", now: sunday, expectHandled: true, expectedStatus: "deferred", expectedNote: "Mon Jan 15 09:00 JST"},
+		{name: "links only", mode: "links", now: sunday, expectHandled: true, expectedStatus: "out_of_hours", expectedNote: "<https://slack.com/archives/C9/p55|Slack Message>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.OfficeHours = "Mon-Fri 09:00-18:00"
+			cfg.OfficeHoursTimezone = "Asia/Tokyo"
+			cfg.OfficeHoursMode = tt.mode
+			cfg.SimilarityThreshold = 0
+			fake := newFakeSlack(t, cfg)
+			fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+				{"ts": "5.5", "text": "See the deploy runbook", "channel": {"id": "C9"}}
+			]}}`)
+			llm := newFakeLLM(t, cfg, "answer")
+			db := setupTestDB(t)
+			service := newTestInquiryService(cfg, db)
+
+			inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", MessageText: "deploy runbook", Timestamp: "1.1", Status: "pending"}
+			db.Create(inquiry)
+
+			handled, err := service.applyOfficeHours(context.Background(), inquiry, tt.now)
+			if err != nil {
+				t.Fatalf("applyOfficeHours returned error: %v", err)
+			}
+			if handled != tt.expectHandled {
+				t.Fatalf("Expected handled=%v, got %v", tt.expectHandled, handled)
+			}
+			if inquiry.Status != tt.expectedStatus {
+				t.Errorf("Expected status '%s', got '%s'", tt.expectedStatus, inquiry.Status)
+			}
+			if llm.requestCount() != 0 {
+				t.Errorf("Expected no LLM requests, got %d", llm.requestCount())
+			}
+
+			posts := fake.callsTo("chat.postMessage")
+			if tt.expectedNote == "" {
+				if len(posts) != 0 {
+					t.Errorf("Expected no note in office hours, got %d posts", len(posts))
+				}
+				return
+			}
+			if len(posts) != 1 || !strings.Contains(posts[0].Get("text"), tt.expectedNote) {
+				t.Errorf("Expected note containing %q, got %v", tt.expectedNote, posts)
+			}
+		})
+	}
+}
+
+func TestProcessDeferred(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	db.Create(&storage.Inquiry{MessageID: "1.1", ChannelID: "C1", MessageText: "deploy", Timestamp: "1.1", Status: "deferred"})
+
+	if err := service.ProcessDeferred(context.Background()); err != nil {
+		t.Fatalf("ProcessDeferred returned error: %v", err)
+	}
+
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if inquiry.Status != "completed" {
+		t.Errorf("Expected deferred inquiry to be answered, got status '%s'", inquiry.Status)
+	}
+}
+
+func TestGreetChannel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.GreetOnJoin = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("auth.test", `{"ok": true, "user_id": "UBOT"}`)
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	greeted, err := service.GreetChannel("C1", "U1", "hello")
+	if err != nil || greeted {
+		t.Fatalf("Expected no greeting when another user joins, got %v (%v)", greeted, err)
+	}
+
+	greeted, err = service.GreetChannel("C1", "UBOT", "hello")
+	if err != nil || !greeted {
+		t.Fatalf("Expected greeting when the bot joins, got %v (%v)", greeted, err)
+	}
+
+	// Re-joining the same channel must not greet again
+	greeted, err = service.GreetChannel("C1", "UBOT", "hello")
+	if err != nil || greeted {
+		t.Fatalf("Expected no repeat greeting, got %v (%v)", greeted, err)
+	}
+
+	posts := fake.callsTo("chat.postMessage")
+	if len(posts) != 1 || posts[0].Get("channel") != "C1" || posts[0].Get("text") != "hello" {
+		t.Errorf("Expected exactly one introduction in C1, got %v", posts)
+	}
+	if calls := fake.callsTo("auth.test"); len(calls) != 1 {
+		t.Errorf("Expected bot user ID to be cached, got %d auth.test calls", len(calls))
+	}
+
+	var count int64
+	db.Model(&storage.GreetedChannel{}).Count(&count)
+	if count != 1 {
+		t.Errorf("Expected 1 greeted channel record, got %d", count)
+	}
+}
+
+func TestGreetChannel_Disabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if greeted, err := service.GreetChannel("C1", "UBOT", "hello"); err != nil || greeted {
+		t.Fatalf("Expected no greeting when disabled, got %v (%v)", greeted, err)
+	}
+	if posts := fake.callsTo("chat.postMessage"); len(posts) != 0 {
+		t.Errorf("Expected no posts when disabled, got %d", len(posts))
+	}
+}
+
+func TestGreetChannel_RetriesAfterPostFailure(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.GreetOnJoin = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("auth.test", `{"ok": true, "user_id": "UBOT"}`)
+	fake.respond("chat.postMessage", `{"ok": false, "error": "not_in_channel"}`)
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if _, err := service.GreetChannel("C1", "UBOT", "hello"); err == nil {
+		t.Fatal("Expected error when posting fails")
+	}
+
+	fake.respond("chat.postMessage", `{"ok": true, "ts": "1.1"}`)
+	if greeted, err := service.GreetChannel("C1", "UBOT", "hello"); err != nil || !greeted {
+		t.Errorf("Expected greeting to be retried, got %v (%v)", greeted, err)
+	}
+}
+
+// TestReload_DuringInquiries reloads every service while inquiries are being
+// searched and answered; run with -race to catch unguarded configuration reads
+func TestReload_DuringInquiries(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run the deploy script.")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	reloading := make(chan struct{})
+	go func() {
+		defer close(reloading)
+		for i := 0; i < 20; i++ {
+			next := *cfg
+			next.LLMMaxTokens = 500 + i
+			service.slack.Reload(&next)
+			service.search.confluence.Reload(&next)
+			service.llm.Reload(&next)
+			service.search.Reload(&next)
+			service.Reload(&next)
+			time.Sleep(time.Millisecond)
+		}
+	}()
+
+	errs := make(chan error, 5)
+	for i := 0; i < 5; i++ {
+		go func(i int) {
+			id := fmt.Sprintf("%d.1", i)
+			errs <- service.ProcessInquiry(context.Background(), id, "C1", fmt.Sprintf("U%d", i), "How do I deploy?", id, "")
+		}(i)
+	}
+	for i := 0; i < 5; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("ProcessInquiry returned error: %v", err)
+		}
+	}
+	<-reloading
+}
@@ -0,0 +1,260 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// webhookTimeout bounds each webhook delivery attempt
+	webhookTimeout = 10 * time.Second
+	// webhookRetries is how many times a failed delivery is retried
+	webhookRetries = 3
+	// webhookSignatureHeader carries the HMAC-SHA256 of the payload, keyed by the webhook's secret
+	webhookSignatureHeader = "X-Webhook-Signature"
+	// webhookEventHeader carries the event the payload is for
+	webhookEventHeader = "X-Webhook-Event"
+)
+
+// WebhookEvents are the inquiry lifecycle events webhooks can subscribe to,
+// one per status an inquiry can move to
+var WebhookEvents = []string{
+	"inquiry.pending",
+	"inquiry.processing",
+	"inquiry.completed",
+	"inquiry.failed",
+	"inquiry.dead_letter",
+	"inquiry.deferred",
+	"inquiry.out_of_hours",
+}
+
+// ErrInvalidWebhook is returned when creating or updating a webhook with an
+// unusable URL, secret or events
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// WebhookPayload is the JSON body POSTed to webhooks when an inquiry changes status
+type WebhookPayload struct {
+	Event         string    `json:"event"`
+	InquiryID     uint      `json:"inquiry_id"`
+	Status        string    `json:"status"`
+	ChannelID     string    `json:"channel_id"`
+	Source        string    `json:"source"`
+	Category      string    `json:"category,omitempty"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// CreateWebhook subscribes url to events, signing its payloads with secret
+func (s *InquiryService) CreateWebhook(webhookURL, secret string, events []string) (*storage.Webhook, error) {
+	webhook := &storage.Webhook{URL: webhookURL, Secret: secret, Events: events}
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if err := s.db.Create(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks returns every webhook, oldest first
+func (s *InquiryService) ListWebhooks() ([]storage.Webhook, error) {
+	var webhooks []storage.Webhook
+	if err := s.db.Order("id").Find(&webhooks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook returns a webhook by ID
+func (s *InquiryService) GetWebhook(webhookID uint) (*storage.Webhook, error) {
+	var webhook storage.Webhook
+	if err := s.db.First(&webhook, webhookID).Error; err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+// UpdateWebhook replaces a webhook's URL and events, and its secret unless
+// secret is empty
+func (s *InquiryService) UpdateWebhook(webhookID uint, webhookURL, secret string, events []string) (*storage.Webhook, error) {
+	webhook, err := s.GetWebhook(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	webhook.URL = webhookURL
+	webhook.Events = events
+	if secret != "" {
+		webhook.Secret = secret
+	}
+	if err := validateWebhook(webhook); err != nil {
+		return nil, err
+	}
+	if err := s.db.Save(webhook).Error; err != nil {
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook removes a webhook, returning gorm.ErrRecordNotFound when there is none
+func (s *InquiryService) DeleteWebhook(webhookID uint) error {
+	webhook, err := s.GetWebhook(webhookID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(webhook).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	return nil
+}
+
+// validateWebhook checks a webhook has an http(s) URL, a secret and known events
+func validateWebhook(webhook *storage.Webhook) error {
+	parsed, err := url.Parse(webhook.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: url must be an http or https URL", ErrInvalidWebhook)
+	}
+	if webhook.Secret == "" {
+		return fmt.Errorf("%w: secret is required", ErrInvalidWebhook)
+	}
+	if len(webhook.Events) == 0 {
+		return fmt.Errorf("%w: events must not be empty", ErrInvalidWebhook)
+	}
+	for _, event := range webhook.Events {
+		if !isWebhookEvent(event) {
+			return fmt.Errorf("%w: unknown event %q, must be one of %s", ErrInvalidWebhook, event, strings.Join(WebhookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// isWebhookEvent reports whether event is one of WebhookEvents
+func isWebhookEvent(event string) bool {
+	for _, known := range WebhookEvents {
+		if event == known {
+			return true
+		}
+	}
+	return false
+}
+
+// notifyWebhooks POSTs the inquiry's new status to every webhook subscribed to
+// it, in the background so a slow endpoint doesn't hold up the inquiry
+func (s *InquiryService) notifyWebhooks(inquiry *storage.Inquiry) {
+	event := "inquiry." + inquiry.Status
+
+	var webhooks []storage.Webhook
+	if err := s.db.Find(&webhooks).Error; err != nil {
+		logrus.WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to load webhooks")
+		return
+	}
+
+	var body []byte
+	for _, webhook := range webhooks {
+		if !subscribed(webhook, event) {
+			continue
+		}
+		if body == nil {
+			var err error
+			body, err = json.Marshal(WebhookPayload{
+				Event:         event,
+				InquiryID:     inquiry.ID,
+				Status:        inquiry.Status,
+				ChannelID:     inquiry.ChannelID,
+				Source:        inquiry.Source,
+				Category:      inquiry.Category,
+				FailureReason: inquiry.FailureReason,
+				UpdatedAt:     inquiry.UpdatedAt,
+			})
+			if err != nil {
+				logrus.WithError(err).WithField("inquiry_id", inquiry.ID).Error("Failed to encode webhook payload")
+				return
+			}
+		}
+
+		go s.deliverWebhook(context.Background(), webhook, event, body)
+	}
+}
+
+// subscribed reports whether webhook subscribed to event
+func subscribed(webhook storage.Webhook, event string) bool {
+	for _, subscribed := range webhook.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
+
+// deliverWebhook POSTs body to webhook, retrying failed attempts up to
+// webhookRetries times with exponential backoff
+func (s *InquiryService) deliverWebhook(ctx context.Context, webhook storage.Webhook, event string, body []byte) {
+	var err error
+	for attempt := 0; attempt <= webhookRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(s.webhookRetryDelay << (attempt - 1)):
+			case <-ctx.Done():
+				return
+			}
+		}
+		if err = s.postWebhook(ctx, webhook, event, body); err == nil {
+			break
+		}
+	}
+
+	s.metrics.Incr("webhook.delivery", map[string]string{"event": event, "status": outcomeTag(err)})
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"webhook_id": webhook.ID,
+			"event":      event,
+		}).Error("Failed to deliver webhook")
+	}
+}
+
+// postWebhook makes one delivery attempt, signing body with the webhook's secret
+func (s *InquiryService) postWebhook(ctx context.Context, webhook storage.Webhook, event string, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(webhookEventHeader, event)
+	req.Header.Set(webhookSignatureHeader, "sha256="+webhookSignature(webhook.Secret, body))
+
+	resp, err := s.webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// webhookSignature is the hex-encoded HMAC-SHA256 of body keyed by secret
+func webhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// webhookDelivery is a request received by a fakeWebhook
+type webhookDelivery struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+// fakeWebhook records the deliveries it receives, failing the first failures
+type fakeWebhook struct {
+	mu         sync.Mutex
+	deliveries []webhookDelivery
+	failures   int
+}
+
+func newFakeWebhook(t *testing.T, failures int) (*fakeWebhook, *httptest.Server) {
+	t.Helper()
+
+	fake := &fakeWebhook{failures: failures}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		fake.deliveries = append(fake.deliveries, webhookDelivery{
+			event:     r.Header.Get(webhookEventHeader),
+			signature: r.Header.Get(webhookSignatureHeader),
+			body:      body,
+		})
+		if len(fake.deliveries) <= fake.failures {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return fake, server
+}
+
+func (f *fakeWebhook) received() []webhookDelivery {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]webhookDelivery(nil), f.deliveries...)
+}
+
+func TestWebhookValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		url    string
+		secret string
+		events []string
+	}{
+		{name: "not http", url: "ftp://example.com/hook", secret: "s", events: []string{"inquiry.completed"}},
+		{name: "relative url", url: "/hook", secret: "s", events: []string{"inquiry.completed"}},
+		{name: "no secret", url: "https://example.com/hook", events: []string{"inquiry.completed"}},
+		{name: "no events", url: "https://example.com/hook", secret: "s"},
+		{name: "unknown event", url: "https://example.com/hook", secret: "s", events: []string{"inquiry.exploded"}},
+	}
+
+	service := newTestInquiryService(config.LoadTestConfig(), setupTestDB(t))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := service.CreateWebhook(tt.url, tt.secret, tt.events); !errors.Is(err, ErrInvalidWebhook) {
+				t.Errorf("Expected ErrInvalidWebhook, got %v", err)
+			}
+		})
+	}
+}
+
+func TestUpdateWebhook_KeepsSecret(t *testing.T) {
+	service := newTestInquiryService(config.LoadTestConfig(), setupTestDB(t))
+	created, err := service.CreateWebhook("https://example.com/hook", "s3cret", []string{"inquiry.completed"})
+	if err != nil {
+		t.Fatalf("CreateWebhook returned error: %v", err)
+	}
+
+	if _, err := service.UpdateWebhook(created.ID, "https://example.com/other", "", []string{"inquiry.failed"}); err != nil {
+		t.Fatalf("UpdateWebhook returned error: %v", err)
+	}
+
+	updated, err := service.GetWebhook(created.ID)
+	if err != nil {
+		t.Fatalf("GetWebhook returned error: %v", err)
+	}
+	if updated.URL != "https://example.com/other" || len(updated.Events) != 1 || updated.Events[0] != "inquiry.failed" || updated.Secret != "s3cret" {
+		t.Errorf("Expected the new URL and events with the old secret, got %+v", updated)
+	}
+}
+
+func TestProcessInquiry_NotifiesWebhooks(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run the deploy script.")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+	fake, server := newFakeWebhook(t, 0)
+
+	if _, err := service.CreateWebhook(server.URL, "s3cret", []string{"inquiry.completed", "inquiry.failed"}); err != nil {
+		t.Fatalf("CreateWebhook returned error: %v", err)
+	}
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	if !waitFor(t, func() bool { return len(fake.received()) > 0 }) {
+		t.Fatal("Expected the completed inquiry to be delivered")
+	}
+	// Give deliveries for the unsubscribed pending and processing events the time to show up
+	time.Sleep(50 * time.Millisecond)
+
+	deliveries := fake.received()
+	if len(deliveries) != 1 {
+		t.Fatalf("Expected only the completed event to be delivered, got %d deliveries", len(deliveries))
+	}
+	delivery := deliveries[0]
+	if delivery.event != "inquiry.completed" {
+		t.Errorf("Expected the inquiry.completed event, got %q", delivery.event)
+	}
+	if want := "sha256=" + webhookSignature("s3cret", delivery.body); delivery.signature != want {
+		t.Errorf("Expected signature %q, got %q", want, delivery.signature)
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(delivery.body, &payload); err != nil {
+		t.Fatalf("Failed to decode payload: %v", err)
+	}
+	if payload.Event != "inquiry.completed" || payload.Status != "completed" || payload.InquiryID == 0 || payload.ChannelID != "C1" {
+		t.Errorf("Unexpected payload %+v", payload)
+	}
+}
+
+func TestDeliverWebhook_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		attempts int
+	}{
+		{name: "succeeds first time", failures: 0, attempts: 1},
+		{name: "succeeds on retry", failures: 2, attempts: 3},
+		{name: "gives up after 3 retries", failures: 10, attempts: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := newTestInquiryService(config.LoadTestConfig(), setupTestDB(t))
+			service.webhookRetryDelay = time.Millisecond
+			fake, server := newFakeWebhook(t, tt.failures)
+
+			webhook := storage.Webhook{URL: server.URL, Secret: "s3cret", Events: []string{"inquiry.failed"}}
+			service.deliverWebhook(context.Background(), webhook, "inquiry.failed", []byte(`{}`))
+
+			if got := len(fake.received()); got != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// weeklyReportListLimit caps the topics, askers and questions listed in each section of a weekly report
+	weeklyReportListLimit = 10
+	// weeklyReportQuestionBytes caps the length of questions quoted in a weekly report
+	weeklyReportQuestionBytes = 200
+	// weeklyReportSummaryQuestions caps the questions sent to the LLM for the report summary
+	weeklyReportSummaryQuestions = 50
+)
+
+// weeklyReportSystemPrompt asks the LLM to summarise a week of questions
+const weeklyReportSystemPrompt = `You summarise a week of questions that team members asked an internal help bot.
+In two or three sentences of plain text, describe the main themes, anything that came up repeatedly, and any gaps the unanswered questions point to.`
+
+// RunWeeklyReportLoop generates the weekly reports at WEEKLY_REPORT_SCHEDULE
+// until ctx is cancelled. It returns immediately when weekly reports are disabled.
+func (s *InquiryService) RunWeeklyReportLoop(ctx context.Context) {
+	schedule, err := s.cfg().WeeklyReport()
+	if err != nil {
+		logrus.WithError(err).Error("Invalid weekly report schedule, not generating weekly reports")
+		return
+	}
+	if schedule == nil {
+		return
+	}
+
+	for {
+		timer := time.NewTimer(time.Until(schedule.Next(time.Now())))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.GenerateWeeklyReports(ctx)
+		}
+	}
+}
+
+// GenerateWeeklyReports generates the report of every channel in
+// WEEKLY_REPORT_CHANNELS, or a single report across channels when none are listed
+func (s *InquiryService) GenerateWeeklyReports(ctx context.Context) {
+	channels := s.cfg().WeeklyReportChannels
+	if len(channels) == 0 {
+		channels = []string{""}
+	}
+	for _, channelID := range channels {
+		if err := s.GenerateWeeklyReport(ctx, channelID); err != nil {
+			logrus.WithError(err).WithField("channel_id", channelID).Error("Failed to generate weekly report")
+		}
+	}
+}
+
+// GenerateWeeklyReport creates a Confluence page in WEEKLY_REPORT_SPACE_KEY
+// summarising the last week of inquiries in channelID, or in every channel when
+// channelID is empty: how many were asked, the most common topics and askers,
+// the answered questions with links to their threads, and the unanswered ones
+func (s *InquiryService) GenerateWeeklyReport(ctx context.Context, channelID string) error {
+	if s.cfg().WeeklyReportSpaceKey == "" {
+		return fmt.Errorf("WEEKLY_REPORT_SPACE_KEY is not set")
+	}
+
+	end := time.Now()
+	start := end.AddDate(0, 0, -7)
+
+	query := s.db.Where("created_at >= ?", start).Order("created_at")
+	if channelID != "" {
+		query = query.Where("channel_id = ?", channelID)
+	}
+	var inquiries []storage.Inquiry
+	if err := query.Find(&inquiries).Error; err != nil {
+		return fmt.Errorf("failed to load the week's inquiries: %w", err)
+	}
+
+	contributors, err := s.topContributors(channelID, start, weeklyReportListLimit)
+	if err != nil {
+		return err
+	}
+
+	title := fmt.Sprintf("Inquiry report %s to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+	if channelID != "" {
+		title += " (" + channelID + ")"
+	}
+	content := s.weeklyReportContent(ctx, inquiries, contributors, start, end)
+
+	page, err := s.search.confluence.CreatePage(ctx, s.cfg().WeeklyReportSpaceKey, s.cfg().WeeklyReportParentPageID, title, content)
+	if err != nil {
+		return fmt.Errorf("failed to create weekly report page: %w", err)
+	}
+
+	s.metrics.Incr("inquiry.weekly_report", nil)
+	logrus.WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"inquiries":  len(inquiries),
+		"page_id":    page.ID,
+	}).Info("Created weekly report")
+	return nil
+}
+
+// weeklyReportContent renders a weekly report in Confluence storage format
+func (s *InquiryService) weeklyReportContent(ctx context.Context, inquiries []storage.Inquiry, contributors []ContributorStats, start, end time.Time) string {
+	var answered, unanswered []storage.Inquiry
+	topics := make(map[string]int)
+	for _, inquiry := range inquiries {
+		category := inquiry.Category
+		if category == "" {
+			category = CategoryOther
+		}
+		topics[category]++
+		if inquiry.Status == "completed" {
+			answered = append(answered, inquiry)
+		} else {
+			unanswered = append(unanswered, inquiry)
+		}
+	}
+
+	var b strings.Builder
+	if summary := s.weeklyReportSummary(ctx, answered, unanswered); summary != "" {
+		fmt.Fprintf(&b, "<h2>Summary</h2><p>%s</p>", html.EscapeString(summary))
+	}
+	fmt.Fprintf(&b, "<p><strong>%d</strong> questions were asked between %s and %s: %d answered, %d unanswered.</p>",
+		len(inquiries), start.Format("2006-01-02"), end.Format("2006-01-02"), len(answered), len(unanswered))
+
+	topicNames := make([]string, 0, len(topics))
+	for topic := range topics {
+		topicNames = append(topicNames, topic)
+	}
+	sort.Slice(topicNames, func(i, j int) bool {
+		if topics[topicNames[i]] != topics[topicNames[j]] {
+			return topics[topicNames[i]] > topics[topicNames[j]]
+		}
+		return topicNames[i] < topicNames[j]
+	})
+	var topicItems []string
+	for _, topic := range topicNames {
+		topicItems = append(topicItems, fmt.Sprintf("%s: %d", topic, topics[topic]))
+	}
+	writeReportList(&b, "Most common topics", topicItems, false)
+
+	var askerItems []string
+	for _, contributor := range contributors {
+		askerItems = append(askerItems, fmt.Sprintf("%s: %d", contributor.UserName, contributor.InquiryCount))
+	}
+	writeReportList(&b, "Most active askers", askerItems, false)
+
+	writeReportList(&b, "Answered questions", s.reportQuestions(ctx, answered), true)
+	writeReportList(&b, "Unanswered questions", s.reportQuestions(ctx, unanswered), true)
+
+	return b.String()
+}
+
+// weeklyReportSummary asks the LLM to summarise the week's questions. It
+// returns an empty summary when there were none or the LLM fails.
+func (s *InquiryService) weeklyReportSummary(ctx context.Context, answered, unanswered []storage.Inquiry) string {
+	if len(answered)+len(unanswered) == 0 {
+		return ""
+	}
+
+	var prompt strings.Builder
+	for _, section := range []struct {
+		heading   string
+		inquiries []storage.Inquiry
+	}{{"Answered questions:", answered}, {"Unanswered questions:", unanswered}} {
+		prompt.WriteString(section.heading + "\n")
+		for i, inquiry := range section.inquiries {
+			if i == weeklyReportSummaryQuestions {
+				break
+			}
+			prompt.WriteString("- " + truncateAtWord(strings.Join(strings.Fields(inquiry.MessageText), " "), weeklyReportQuestionBytes) + "\n")
+		}
+	}
+
+	summary, err := s.llm.Complete(ctx, weeklyReportSystemPrompt, prompt.String())
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to summarise the week's inquiries, reporting without a summary")
+		return ""
+	}
+	return strings.TrimSpace(summary)
+}
+
+// reportQuestions renders inquiries as storage-format list items, linking
+// questions asked in channels to their Slack threads
+func (s *InquiryService) reportQuestions(ctx context.Context, inquiries []storage.Inquiry) []string {
+	var items []string
+	for i, inquiry := range inquiries {
+		if i == weeklyReportListLimit {
+			break
+		}
+
+		question := html.EscapeString(truncateAtWord(strings.Join(strings.Fields(inquiry.MessageText), " "), weeklyReportQuestionBytes))
+		if inquiry.Source == InquirySourceSlack {
+			if permalink, err := s.slack.GetMessagePermalink(inquiry.ChannelID, inquiry.Timestamp); err != nil {
+				loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiry.ID).Debug("Failed to link question in weekly report")
+			} else if permalink != "" {
+				question = fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(permalink), question)
+			}
+		}
+		items = append(items, question)
+	}
+	return items
+}
+
+// writeReportList writes a headed list to a storage-format report. Items are
+// escaped unless escaped already holds; an empty list is shown as "None".
+func writeReportList(b *strings.Builder, heading string, items []string, escaped bool) {
+	fmt.Fprintf(b, "<h2>%s</h2>", html.EscapeString(heading))
+	if len(items) == 0 {
+		b.WriteString("<p>None</p>")
+		return
+	}
+	b.WriteString("<ul>")
+	for _, item := range items {
+		if !escaped {
+			item = html.EscapeString(item)
+		}
+		b.WriteString("<li>" + item + "</li>")
+	}
+	b.WriteString("</ul>")
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+// newFakeConfluencePages records the pages created through a fake Confluence
+func newFakeConfluencePages(t *testing.T, cfg *config.Config) *[]confluenceNewPage {
+	t.Helper()
+
+	var (
+		mu    sync.Mutex
+		pages []confluenceNewPage
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/rest/api/content" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var page confluenceNewPage
+		if err := json.NewDecoder(r.Body).Decode(&page); err != nil {
+			t.Errorf("Failed to decode page: %v", err)
+		}
+		mu.Lock()
+		pages = append(pages, page)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id": "12345", "title": "report"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	cfg.WeeklyReportSpaceKey = "REPORTS"
+	cfg.WeeklyReportParentPageID = "999"
+	return &pages
+}
+
+func createReportInquiry(t *testing.T, db *gorm.DB, inquiry storage.Inquiry) {
+	t.Helper()
+
+	if inquiry.Source == "" {
+		inquiry.Source = InquirySourceSlack
+	}
+	if err := db.Create(&inquiry).Error; err != nil {
+		t.Fatalf("Failed to create inquiry: %v", err)
+	}
+}
+
+func TestGenerateWeeklyReport(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	pages := newFakeConfluencePages(t, cfg)
+	fake := newFakeSlack(t, cfg)
+	fake.respond("chat.getPermalink", `{"ok": true, "permalink": "https://example.slack.com/archives/C1/p11"}`)
+	llm := newFakeLLM(t, cfg, "Mostly deployment questions.")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	createReportInquiry(t, db, storage.Inquiry{MessageID: "1.1", ChannelID: "C1", UserID: "U1", Timestamp: "1.1", MessageText: "How do I deploy <service>?", Category: CategoryDeployment, Status: "completed"})
+	createReportInquiry(t, db, storage.Inquiry{MessageID: "2.2", ChannelID: "C1", UserID: "U1", Timestamp: "2.2", MessageText: "Why did the deploy fail?", Category: CategoryDeployment, Status: "failed"})
+	createReportInquiry(t, db, storage.Inquiry{MessageID: "3.3", ChannelID: "C2", UserID: "U2", Timestamp: "3.3", MessageText: "Where is the on-call rota?", Status: "completed"})
+	old := storage.Inquiry{MessageID: "4.4", ChannelID: "C1", UserID: "U3", MessageText: "An old question", Status: "completed", Source: InquirySourceSlack}
+	db.Create(&old)
+	db.Model(&old).Update("created_at", time.Now().AddDate(0, 0, -10))
+
+	if err := service.GenerateWeeklyReport(context.Background(), ""); err != nil {
+		t.Fatalf("GenerateWeeklyReport returned error: %v", err)
+	}
+
+	if len(*pages) != 1 {
+		t.Fatalf("Expected one page to be created, got %d", len(*pages))
+	}
+	page := (*pages)[0]
+	if page.Space.Key != "REPORTS" || len(page.Ancestors) != 1 || page.Ancestors[0].ID != "999" {
+		t.Errorf("Expected the page under 999 in REPORTS, got %+v", page)
+	}
+	if !strings.HasPrefix(page.Title, "Inquiry report ") || strings.Contains(page.Title, "(") {
+		t.Errorf("Unexpected title %q", page.Title)
+	}
+	if page.Body.Storage.Representation != "storage" {
+		t.Errorf("Expected storage representation, got %q", page.Body.Storage.Representation)
+	}
+
+	content := page.Body.Storage.Value
+	for _, want := range []string{
+		"<h2>Summary</h2><p>Mostly deployment questions.</p>",
+		"<strong>3</strong> questions",
+		"2 answered, 1 unanswered",
+		"<li>" + CategoryDeployment + ": 2</li>",
+		"<li>U1: 2</li>",
+		`<a href="https://example.slack.com/archives/C1/p11">How do I deploy &lt;service&gt;?</a>`,
+		"<h2>Unanswered questions</h2><ul><li>",
+		"Why did the deploy fail?",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Expected the report to contain %q, got %q", want, content)
+		}
+	}
+	if strings.Contains(content, "An old question") {
+		t.Error("Expected questions older than a week to be left out")
+	}
+
+	if llm.requestCount() != 1 {
+		t.Fatalf("Expected one summary request, got %d", llm.requestCount())
+	}
+}
+
+func TestGenerateWeeklyReport_ScopesToChannel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	pages := newFakeConfluencePages(t, cfg)
+	newFakeSlack(t, cfg)
+	newFakeLLM(t, cfg, "summary")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	createReportInquiry(t, db, storage.Inquiry{MessageID: "1.1", ChannelID: "C1", UserID: "U1", MessageText: "Question in C1", Status: "completed"})
+	createReportInquiry(t, db, storage.Inquiry{MessageID: "2.2", ChannelID: "C2", UserID: "U2", MessageText: "Question in C2", Status: "completed"})
+
+	if err := service.GenerateWeeklyReport(context.Background(), "C1"); err != nil {
+		t.Fatalf("GenerateWeeklyReport returned error: %v", err)
+	}
+
+	if len(*pages) != 1 {
+		t.Fatalf("Expected one page to be created, got %d", len(*pages))
+	}
+	page := (*pages)[0]
+	if !strings.HasSuffix(page.Title, " (C1)") {
+		t.Errorf("Expected the channel in the title, got %q", page.Title)
+	}
+	if !strings.Contains(page.Body.Storage.Value, "Question in C1") || strings.Contains(page.Body.Storage.Value, "Question in C2") {
+		t.Errorf("Expected only C1's questions, got %q", page.Body.Storage.Value)
+	}
+	if strings.Contains(page.Body.Storage.Value, "U2") {
+		t.Errorf("Expected only C1's askers, got %q", page.Body.Storage.Value)
+	}
+}
+
+func TestGenerateWeeklyReport_RequiresSpace(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.GenerateWeeklyReport(context.Background(), ""); err == nil {
+		t.Error("Expected an error without WEEKLY_REPORT_SPACE_KEY")
+	}
+}
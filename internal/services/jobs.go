@@ -0,0 +1,30 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runPeriodically calls job every interval until ctx is cancelled. A
+// non-positive interval disables the job.
+func runPeriodically(ctx context.Context, name string, interval time.Duration, job func(ctx context.Context) error) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := job(ctx); err != nil {
+				logrus.WithError(err).WithField("job", name).Error("Background job failed")
+			}
+		}
+	}
+}
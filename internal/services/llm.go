@@ -6,109 +6,297 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/metrics"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
 	"github.com/sirupsen/logrus"
 )
 
 // LLMService handles AI-powered response generation
 type LLMService struct {
-	client *http.Client
-	config *config.Config
+	// configMu guards client and config, which Reload replaces
+	configMu sync.RWMutex
+	client   *http.Client
+	config   *config.Config
+
+	metrics metrics.Metrics
+	auditMu sync.Mutex // serialises writes to the audit log
+
+	// Answer profiles loaded from ANSWER_PROFILES_FILE on first use
+	profilesMu   sync.Mutex
+	profiles     map[string]config.AnswerProfile
+	profilesPath string
 }
 
 // LiteLLMRequest represents a request to LiteLLM API
 type LiteLLMRequest struct {
 	Model       string           `json:"model"`
+	System      string           `json:"system,omitempty"` // top-level system prompt for non-OpenAI schemas
 	Messages    []LiteLLMMessage `json:"messages"`
 	Temperature float64          `json:"temperature"`
 	MaxTokens   int              `json:"max_tokens"`
+	Logprobs    bool             `json:"logprobs,omitempty"` // OpenAI schema only
+
+	// tags are sent as the x-litellm-tags header, not in the body
+	tags []string
+	// contextRatio is the share of the search result context included in the prompt
+	contextRatio float64
 }
 
 // LiteLLMMessage represents a message in the conversation
 type LiteLLMMessage struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// Images are data URIs sent after Content as image_url parts
+	Images []string `json:"-"`
 }
 
 // LiteLLMResponse represents a response from LiteLLM API
 type LiteLLMResponse struct {
 	Choices []LiteLLMChoice `json:"choices"`
+	Usage   LiteLLMUsage    `json:"usage"`
+}
+
+// LiteLLMUsage reports the tokens a request consumed
+type LiteLLMUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
 }
 
 // LiteLLMChoice represents a choice in the response
 type LiteLLMChoice struct {
-	Message LiteLLMMessage `json:"message"`
+	Message  LiteLLMMessage   `json:"message"`
+	Logprobs *LiteLLMLogprobs `json:"logprobs,omitempty"`
+}
+
+// LiteLLMLogprobs holds the log-probability of each token of a choice, returned
+// when the request asked for logprobs and the provider supports them
+type LiteLLMLogprobs struct {
+	Content []LiteLLMTokenLogprob `json:"content"`
+}
+
+// LiteLLMTokenLogprob is the log-probability of a single generated token
+type LiteLLMTokenLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
 }
 
 // NewLLMService creates a new LLM service instance
 func NewLLMService(cfg *config.Config) *LLMService {
 	return &LLMService{
 		client: &http.Client{
-			Timeout: 30 * time.Second, // 30 second timeout for LLM API calls
+			Timeout: cfg.LLMTimeout,
 		},
-		config: cfg,
+		config:  cfg,
+		metrics: metrics.Nop{},
 	}
 }
 
-// GenerateResponse generates an AI response based on the inquiry and search results
+// SetMetrics makes the service report LLM request timings to m
+func (s *LLMService) SetMetrics(m metrics.Metrics) {
+	s.metrics = m
+}
+
+// Reload switches the service to cfg, picking up the new request timeout
+func (s *LLMService) Reload(cfg *config.Config) {
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.client = &http.Client{Timeout: cfg.LLMTimeout}
+	s.config = cfg
+}
+
+// httpClient returns the client for LiteLLM requests
+func (s *LLMService) httpClient() *http.Client {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.client
+}
+
+// cfg returns the current configuration
+func (s *LLMService) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// GenerateResponse generates an AI response based on the inquiry and search
+// results. With ANSWER_CONFIDENCE_SCORING it also asks for token
+// log-probabilities and, when the provider returns them, records the answer's
+// confidence in inquiry.ConfidenceScore.
 func (s *LLMService) GenerateResponse(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) (string, error) {
-	if s.config.LiteLLMAPIKey == "" || s.config.LiteLLMBaseURL == "" {
+	if s.cfg().LiteLLMAPIKey == "" || s.cfg().LiteLLMBaseURL == "" {
 		return "", fmt.Errorf("LiteLLM not configured")
 	}
 
-	// Build the context from search results
-	contextStr := s.buildContext(inquiry, searchResults)
+	model := s.ModelFor(inquiry)
+	if model == EnsembleModel {
+		model = s.cfg().LLMModel
+	}
+	model = s.visionModel(ctx, model)
+
+	request := s.answerRequest(ctx, inquiry, searchResults, model)
+	request.tags = s.metadataTags(inquiry)
+	request.Logprobs = s.cfg().AnswerConfidenceScoring && s.cfg().LLMProvider != "anthropic"
+	start := time.Now()
+	response, err := s.chatCompletion(ctx, request)
+	var answer string
+	var tokens int
+	if err == nil {
+		answer, tokens = response.Choices[0].Message.Content, response.Usage.TotalTokens
+		if confidence, ok := logprobConfidence(response.Choices[0].Logprobs); ok {
+			inquiry.ConfidenceScore, inquiry.ConfidenceScored = confidence, true
+		}
+		s.recordEfficiency(inquiry, request, response.Usage, answer)
+	}
+	s.audit(ctx, inquiry.ID, request, answer, tokens, time.Since(start), err)
+
+	return answer, err
+}
+
+// answerRequest builds the request asking model to answer inquiry from searchResults
+func (s *LLMService) answerRequest(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult, model string) LiteLLMRequest {
+	// Build the context from the search results that fit the context budget
+	selected := s.selectContextResults(ctx, searchResults)
+	contextStr := s.buildContext(ctx, inquiry, selected)
 
 	// Create the prompt
-	prompt := s.buildPrompt(inquiry.MessageText, contextStr)
+	prompt := s.buildPrompt(inquiry.MessageText, contextStr, s.AnswerProfile(ctx, inquiry))
 
 	// Prepare the request payload
-	request := LiteLLMRequest{
-		Model:       s.config.LLMModel,
-		Temperature: s.config.LLMTemperature,
-		MaxTokens:   s.config.LLMMaxTokens,
-		Messages: []LiteLLMMessage{
-			{
-				Role:    "system",
-				Content: s.getSystemPrompt(),
-			},
-			{
-				Role:    "user",
-				Content: prompt,
-			},
+	request := FormatMessages(s.cfg().LLMProvider, []LiteLLMMessage{
+		{
+			Role:    "system",
+			Content: s.getSystemPrompt(),
 		},
+		{
+			Role:    "user",
+			Content: prompt,
+			Images:  s.answerImages(ctx),
+		},
+	})
+	request.Model = model
+	request.Temperature = s.cfg().LLMTemperature
+	request.MaxTokens = s.cfg().LLMMaxTokens
+	request.contextRatio = contextRatio(searchResults, selected)
+
+	return request
+}
+
+// Complete sends a single system and user prompt to the default model and returns the reply
+func (s *LLMService) Complete(ctx context.Context, systemPrompt, prompt string) (string, error) {
+	if s.cfg().LiteLLMAPIKey == "" || s.cfg().LiteLLMBaseURL == "" {
+		return "", fmt.Errorf("LiteLLM not configured")
+	}
+
+	request := FormatMessages(s.cfg().LLMProvider, []LiteLLMMessage{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: prompt},
+	})
+	request.Model = s.cfg().LLMModel
+	request.Temperature = s.cfg().LLMTemperature
+	request.MaxTokens = s.cfg().LLMMaxTokens
+
+	return s.chat(ctx, request)
+}
+
+// ValidateAPIKey checks the LiteLLM API key with a lightweight GET /models
+// request. Rejected credentials and unreachable endpoints are errors; other
+// statuses are tolerated since not every proxy serves /models.
+func (s *LLMService) ValidateAPIKey() error {
+	if s.cfg().LiteLLMAPIKey == "" || s.cfg().LiteLLMBaseURL == "" {
+		return fmt.Errorf("LiteLLM not configured")
+	}
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("%s/models", s.cfg().LiteLLMBaseURL), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("x-litellm-api-key", s.cfg().LiteLLMAPIKey)
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach LiteLLM API: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithError(err).Error("Failed to close response body")
+		}
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		return fmt.Errorf("LiteLLM API authentication failed (401): check API key")
+	case http.StatusForbidden:
+		return fmt.Errorf("LiteLLM API access forbidden (403): insufficient permissions")
+	case http.StatusOK:
+		return nil
+	default:
+		logrus.WithField("status_code", resp.StatusCode).Warn("Could not verify LiteLLM API key")
+		return nil
+	}
+}
+
+// chat sends a chat completion request to LiteLLM and returns the first choice
+func (s *LLMService) chat(ctx context.Context, request LiteLLMRequest) (string, error) {
+	answer, _, err := s.chatWithUsage(ctx, request)
+	return answer, err
+}
+
+// chatWithUsage is chat that also returns the total tokens the request used
+func (s *LLMService) chatWithUsage(ctx context.Context, request LiteLLMRequest) (string, int, error) {
+	response, err := s.chatCompletion(ctx, request)
+	if err != nil {
+		return "", 0, err
 	}
+	return response.Choices[0].Message.Content, response.Usage.TotalTokens, nil
+}
+
+// chatCompletion sends a chat completion request to LiteLLM and returns the
+// whole response, which has at least one choice
+func (s *LLMService) chatCompletion(ctx context.Context, request LiteLLMRequest) (_ *LiteLLMResponse, err error) {
+	start := time.Now()
+	defer func() {
+		s.metrics.Timing("llm.request", time.Since(start), map[string]string{
+			"model":  request.Model,
+			"status": outcomeTag(err),
+		})
+	}()
 
 	// Convert to JSON
 	jsonData, err := json.Marshal(request)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
 	// Create HTTP request
-	url := fmt.Sprintf("%s/chat/completions", s.config.LiteLLMBaseURL)
+	url := fmt.Sprintf("%s/chat/completions", s.cfg().LiteLLMBaseURL)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Set headers
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("x-litellm-api-key", s.config.LiteLLMAPIKey)
+	req.Header.Set("x-litellm-api-key", s.cfg().LiteLLMAPIKey)
+	if len(request.tags) > 0 {
+		req.Header.Set("x-litellm-tags", strings.Join(request.tags, ","))
+	}
 
 	// Execute request
-	resp, err := s.client.Do(req)
+	resp, err := s.httpClient().Do(req)
 	if err != nil {
-		logrus.WithError(err).Error("Failed to call LiteLLM API")
-		return "", fmt.Errorf("failed to call LiteLLM API: %w", err)
+		loggerFrom(ctx).WithError(err).Error("Failed to call LiteLLM API")
+		return nil, fmt.Errorf("failed to call LiteLLM API: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			logrus.WithError(err).Error("Failed to close response body")
+			loggerFrom(ctx).WithError(err).Error("Failed to close response body")
 		}
 	}()
 
@@ -117,48 +305,110 @@ func (s *LLMService) GenerateResponse(ctx context.Context, inquiry *storage.Inqu
 		var body map[string]interface{}
 		err = json.NewDecoder(resp.Body).Decode(&body)
 		if err != nil {
-			logrus.WithError(err).Error("Failed to call LiteLLM API")
+			loggerFrom(ctx).WithError(err).Error("Failed to call LiteLLM API")
 		}
 
 		switch resp.StatusCode {
 		case http.StatusUnauthorized:
-			return "", fmt.Errorf("LiteLLM API authentication failed (401): check API key")
+			return nil, fmt.Errorf("LiteLLM API authentication failed (401): check API key")
 		case http.StatusForbidden:
-			return "", fmt.Errorf("LiteLLM API access forbidden (403): insufficient permissions")
+			return nil, fmt.Errorf("LiteLLM API access forbidden (403): insufficient permissions")
 		case http.StatusTooManyRequests:
-			return "", fmt.Errorf("LiteLLM API rate limit exceeded (429): try again later")
+			return nil, fmt.Errorf("LiteLLM API rate limit exceeded (429): try again later")
 		case http.StatusInternalServerError:
-			return "", fmt.Errorf("LiteLLM API internal error (500): service unavailable")
+			return nil, fmt.Errorf("LiteLLM API internal error (500): service unavailable")
 		case http.StatusBadRequest:
-			return "", fmt.Errorf("LiteLLM API bad request (400): invalid request format")
+			return nil, fmt.Errorf("LiteLLM API bad request (400): invalid request format")
 		default:
 			// Log only status code to avoid exposing sensitive information in response body
-			logrus.WithFields(logrus.Fields{
+			loggerFrom(ctx).WithFields(logrus.Fields{
 				"status_code": resp.StatusCode,
 			}).Error("LiteLLM API returned non-200 status")
-			return "", fmt.Errorf("LiteLLM API returned status %d", resp.StatusCode)
+			return nil, fmt.Errorf("LiteLLM API returned status %d", resp.StatusCode)
 		}
 	}
 
 	// Parse response
 	var response LiteLLMResponse
 	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
+		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	if len(response.Choices) == 0 {
-		return "", fmt.Errorf("no response generated")
+		return nil, fmt.Errorf("no response generated")
 	}
 
-	return response.Choices[0].Message.Content, nil
+	return &response, nil
+}
+
+// FormatMessages shapes the conversation for the provider's request schema.
+// OpenAI-compatible providers take system prompts as messages; Anthropic
+// expects them in a top-level system field.
+func FormatMessages(provider string, messages []LiteLLMMessage) LiteLLMRequest {
+	if provider != "anthropic" {
+		return LiteLLMRequest{Messages: messages}
+	}
+
+	var request LiteLLMRequest
+	var systemParts []string
+	for _, message := range messages {
+		if message.Role == "system" {
+			systemParts = append(systemParts, message.Content)
+			continue
+		}
+		request.Messages = append(request.Messages, message)
+	}
+	request.System = strings.Join(systemParts, "\n\n")
+
+	return request
+}
+
+// IsModelAllowed reports whether model may be requested. An empty allow-list
+// permits any model; EnsembleModel is allowed whenever ensembles are configured.
+func (s *LLMService) IsModelAllowed(model string) bool {
+	if model == EnsembleModel {
+		return len(s.cfg().EnsembleModels) >= 2
+	}
+	if len(s.cfg().LLMAllowedModels) == 0 {
+		return true
+	}
+	for _, allowed := range s.cfg().LLMAllowedModels {
+		if allowed == model {
+			return true
+		}
+	}
+	return false
+}
+
+// ModelFor picks the model that answers inquiry: its channel's override, then
+// the model its trigger emoji selected, then the default model. Channel
+// overrides outside the allowed models list are ignored.
+func (s *LLMService) ModelFor(inquiry *storage.Inquiry) string {
+	if model, ok := s.cfg().ChannelModels[inquiry.ChannelID]; ok {
+		if s.IsModelAllowed(model) {
+			return model
+		}
+		logrus.WithFields(logrus.Fields{
+			"channel_id": inquiry.ChannelID,
+			"model":      model,
+		}).Warn("Channel model override is not in the allowed models list, ignoring it")
+	}
+
+	if inquiry.RequestedModel != "" {
+		return inquiry.RequestedModel
+	}
+	return s.cfg().LLMModel
 }
 
 // buildContext creates a context string from search results
-func (s *LLMService) buildContext(inquiry *storage.Inquiry, searchResults []storage.SearchResult) string {
+func (s *LLMService) buildContext(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) string {
 	var contextParts []string
 
 	// Add inquiry details
 	contextParts = append(contextParts, fmt.Sprintf("Original inquiry: %s", inquiry.MessageText))
+	if images := s.answerImages(ctx); len(images) > 0 {
+		contextParts = append(contextParts, imagesNote(len(images)))
+	}
 	contextParts = append(contextParts, "")
 
 	if len(searchResults) == 0 {
@@ -166,52 +416,170 @@ func (s *LLMService) buildContext(inquiry *storage.Inquiry, searchResults []stor
 		return strings.Join(contextParts, "\n")
 	}
 
+	searchResults = s.selectContextResults(ctx, searchResults)
+	if note := lowDiversityNote(searchResults); note != "" {
+		contextParts = append(contextParts, note, "")
+	}
+
+	if s.cfg().ContextSourceOrder == "by_score" {
+		contextParts = append(contextParts, contextByScore(searchResults)...)
+		return strings.Join(contextParts, "\n")
+	}
+
 	// Group results by source
 	slackResults := []storage.SearchResult{}
 	confluenceResults := []storage.SearchResult{}
+	githubDocResults := []storage.SearchResult{}
+	codeResults := []storage.SearchResult{}
 
 	for _, result := range searchResults {
-		switch result.Source {
-		case "slack":
+		switch {
+		case result.Source == "slack":
 			slackResults = append(slackResults, result)
-		case "confluence":
+		case result.Source == "confluence":
 			confluenceResults = append(confluenceResults, result)
+		case result.Source == GitHubSource && isMarkdownPath(result.SourceID):
+			githubDocResults = append(githubDocResults, result)
+		case result.Source == GitHubSource:
+			codeResults = append(codeResults, result)
 		}
 	}
 
-	// Add Slack context
-	if len(slackResults) > 0 {
-		contextParts = append(contextParts, "Similar past Slack discussions:")
-		for i, result := range slackResults {
-			contextParts = append(contextParts, fmt.Sprintf("%d. %s", i+1, result.Content))
-			if result.Author != "" {
-				contextParts = append(contextParts, fmt.Sprintf("   (by %s)", result.Author))
-			}
-			contextParts = append(contextParts, "")
+	slackSection := contextSection("Similar past Slack discussions:", slackResults)
+	docsSection := contextSection("Relevant documentation:", confluenceResults)
+	if s.cfg().ContextSourceOrder == "docs_first" {
+		contextParts = append(contextParts, docsSection...)
+		contextParts = append(contextParts, slackSection...)
+	} else {
+		contextParts = append(contextParts, slackSection...)
+		contextParts = append(contextParts, docsSection...)
+	}
+	contextParts = append(contextParts, contextSection("Relevant runbooks and READMEs from GitHub:", githubDocResults)...)
+	contextParts = append(contextParts, contextSection("Relevant source code:", codeResults)...)
+
+	return strings.Join(contextParts, "\n")
+}
+
+// isMarkdownPath reports whether path is a Markdown file, such as a README or
+// runbook, rather than code
+func isMarkdownPath(path string) bool {
+	path = strings.ToLower(path)
+	return strings.HasSuffix(path, ".md") || strings.HasSuffix(path, ".markdown")
+}
+
+// contextSection lists results under heading, or nothing when there are none
+func contextSection(heading string, results []storage.SearchResult) []string {
+	if len(results) == 0 {
+		return nil
+	}
+
+	lines := []string{heading}
+	for i, result := range results {
+		lines = append(lines, contextEntry(fmt.Sprintf("%d. ", i+1), result)...)
+	}
+	return lines
+}
+
+// contextByScore lists Slack, Confluence and code results together, most relevant first
+func contextByScore(results []storage.SearchResult) []string {
+	sorted := make([]storage.SearchResult, 0, len(results))
+	for _, result := range results {
+		if result.Source == "slack" || result.Source == "confluence" || result.Source == GitHubSource {
+			sorted = append(sorted, result)
 		}
 	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score > sorted[j].Score })
+	if len(sorted) == 0 {
+		return nil
+	}
 
-	// Add Confluence context
-	if len(confluenceResults) > 0 {
-		contextParts = append(contextParts, "Relevant documentation:")
-		for i, result := range confluenceResults {
-			contextParts = append(contextParts, fmt.Sprintf("%d. %s", i+1, result.Title))
-			if result.Content != "" {
-				contextParts = append(contextParts, fmt.Sprintf("   %s", result.Content))
-			}
-			if result.URL != "" {
-				contextParts = append(contextParts, fmt.Sprintf("   Link: %s", result.URL))
-			}
-			contextParts = append(contextParts, "")
+	lines := []string{"Relevant Slack discussions and documentation, most relevant first:"}
+	for i, result := range sorted {
+		label := "[Slack] "
+		switch {
+		case result.Source == "confluence":
+			label = "[Docs] "
+		case result.Source == GitHubSource && isMarkdownPath(result.SourceID):
+			label = "[GitHub docs] "
+		case result.Source == GitHubSource:
+			label = "[Code] "
 		}
+		lines = append(lines, contextEntry(fmt.Sprintf("%d. %s", i+1, label), result)...)
 	}
+	return lines
+}
 
-	return strings.Join(contextParts, "\n")
+// contextEntry formats one result, its first line starting with prefix:
+// Slack discussions with their author, documentation with its title and link
+func contextEntry(prefix string, result storage.SearchResult) []string {
+	if result.Source == "slack" {
+		lines := []string{prefix + result.Content}
+		if result.Author != "" {
+			lines = append(lines, fmt.Sprintf("   (by %s)", result.Author))
+		}
+		return append(lines, "")
+	}
+
+	lines := []string{prefix + result.Title}
+	if result.Content != "" {
+		lines = append(lines, fmt.Sprintf("   %s", result.Content))
+	}
+	if result.URL != "" {
+		lines = append(lines, fmt.Sprintf("   Link: %s", result.URL))
+	}
+	return append(lines, "")
 }
 
-// buildPrompt creates the final prompt for the LLM
-func (s *LLMService) buildPrompt(inquiry, context string) string {
-	return fmt.Sprintf(`Based on the following context and inquiry, please provide a helpful and accurate response.
+// SplitResultsByTier separates manual overrides and results scoring at least
+// mustHaveThreshold from the rest, preserving the original order within each group
+func SplitResultsByTier(results []storage.SearchResult, mustHaveThreshold float64) (mustHave, niceToHave []storage.SearchResult) {
+	for _, result := range results {
+		if result.ManualOverride || result.Score >= mustHaveThreshold {
+			mustHave = append(mustHave, result)
+		} else {
+			niceToHave = append(niceToHave, result)
+		}
+	}
+	return mustHave, niceToHave
+}
+
+// selectContextResults keeps every must-have result and fills the remaining
+// context budget with nice-to-have results in ranking order
+func (s *LLMService) selectContextResults(ctx context.Context, results []storage.SearchResult) []storage.SearchResult {
+	mustHave, niceToHave := SplitResultsByTier(results, s.cfg().LLMMustHaveThreshold)
+	if s.cfg().LLMMaxContextChars <= 0 {
+		return append(mustHave, niceToHave...)
+	}
+
+	used := 0
+	for _, result := range mustHave {
+		used += len(result.Title) + len(result.Content)
+	}
+
+	selected := mustHave
+	for _, result := range niceToHave {
+		size := len(result.Title) + len(result.Content)
+		if used+size > s.cfg().LLMMaxContextChars {
+			continue
+		}
+		used += size
+		selected = append(selected, result)
+	}
+
+	if dropped := len(results) - len(selected); dropped > 0 {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"must_have": len(mustHave),
+			"dropped":   dropped,
+		}).Debug("Dropped nice-to-have results exceeding context budget")
+	}
+
+	return selected
+}
+
+// buildPrompt creates the final prompt for the LLM, formatted for profile
+// when one applies
+func (s *LLMService) buildPrompt(inquiry, context string, profile *config.AnswerProfile) string {
+	prompt := fmt.Sprintf(`Based on the following context and inquiry, please provide a helpful and accurate response.
 
 Inquiry: %s
 
@@ -226,6 +594,13 @@ Please provide a comprehensive answer that:
 5. Suggests next steps if appropriate
 
 Keep the response concise but thorough.`, inquiry, context)
+
+	if profile != nil {
+		if instructions := profileInstructions(profile); instructions != "" {
+			prompt += "\n\nFormat the response for its audience, these instructions taking precedence over the ones above:\n" + instructions
+		}
+	}
+	return prompt
 }
 
 // getSystemPrompt returns the system prompt for the LLM
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// llmAuditEntry is one line of the LLM audit log. Prompts and responses are
+// hashed so the log proves what was exchanged without storing it.
+type llmAuditEntry struct {
+	Time         time.Time `json:"time"`
+	InquiryID    uint      `json:"inquiry_id"`
+	Model        string    `json:"model"`
+	PromptHash   string    `json:"prompt_hash"`
+	ResponseHash string    `json:"response_hash,omitempty"`
+	TokensUsed   int       `json:"tokens_used"`
+	DurationMs   int64     `json:"duration_ms"`
+	Success      bool      `json:"success"`
+}
+
+// audit appends a JSON line describing an answer request to AuditLogPath,
+// if one is configured. Failing to write it is logged but doesn't fail the request.
+func (s *LLMService) audit(ctx context.Context, inquiryID uint, request LiteLLMRequest, answer string, tokens int, duration time.Duration, err error) {
+	path := s.cfg().AuditLogPath
+	if path == "" {
+		return
+	}
+
+	prompt, marshalErr := json.Marshal(request)
+	if marshalErr != nil {
+		loggerFrom(ctx).WithError(marshalErr).Error("Failed to marshal LLM request for audit log")
+		return
+	}
+	entry := llmAuditEntry{
+		Time:       time.Now().UTC(),
+		InquiryID:  inquiryID,
+		Model:      request.Model,
+		PromptHash: sha256Hex(prompt),
+		TokensUsed: tokens,
+		DurationMs: duration.Milliseconds(),
+		Success:    err == nil,
+	}
+	if err == nil {
+		entry.ResponseHash = sha256Hex([]byte(answer))
+	}
+
+	if writeErr := s.appendAuditEntry(path, entry); writeErr != nil {
+		loggerFrom(ctx).WithError(writeErr).Error("Failed to write LLM audit log")
+	}
+}
+
+// appendAuditEntry writes entry as a single line, serialised with other writers
+func (s *LLMService) appendAuditEntry(path string, entry llmAuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	s.auditMu.Lock()
+	defer s.auditMu.Unlock()
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to append to audit log: %w", err)
+	}
+	return file.Close()
+}
+
+// sha256Hex returns the hex-encoded SHA-256 digest of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// readAuditLog decodes every line of the audit log at path
+func readAuditLog(t *testing.T, path string) []llmAuditEntry {
+	t.Helper()
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open audit log: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	var entries []llmAuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry llmAuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("Failed to decode audit line %q: %v", scanner.Text(), err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestGenerateResponse_AuditLog(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AuditLogPath = filepath.Join(t.TempDir(), "audit.jsonl")
+	newFakeLLM(t, cfg, "Use the deploy script.")
+	service := NewLLMService(cfg)
+
+	for _, inquiry := range []*storage.Inquiry{
+		{ID: 1, MessageText: "How do I deploy?"},
+		{ID: 2, MessageText: "How do I roll back?"},
+	} {
+		if _, err := service.GenerateResponse(context.Background(), inquiry, nil); err != nil {
+			t.Fatalf("GenerateResponse returned error: %v", err)
+		}
+	}
+
+	entries := readAuditLog(t, cfg.AuditLogPath)
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 audit lines, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if entry.InquiryID != uint(i+1) || entry.Model != "gpt-4o-mini" || !entry.Success || entry.TokensUsed != 42 {
+			t.Errorf("Unexpected audit entry %d: %+v", i, entry)
+		}
+		if entry.ResponseHash != sha256Hex([]byte("Use the deploy script.")) {
+			t.Errorf("Expected the response hash, got %q", entry.ResponseHash)
+		}
+	}
+	if entries[0].PromptHash == "" || entries[0].PromptHash == entries[1].PromptHash {
+		t.Errorf("Expected distinct prompt hashes, got %q and %q", entries[0].PromptHash, entries[1].PromptHash)
+	}
+}
+
+func TestGenerateResponse_AuditLogFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := config.LoadTestConfig()
+	cfg.AuditLogPath = filepath.Join(t.TempDir(), "audit.jsonl")
+	cfg.LiteLLMAPIKey = "test-key"
+	cfg.LiteLLMBaseURL = server.URL
+
+	if _, err := NewLLMService(cfg).GenerateResponse(context.Background(), &storage.Inquiry{ID: 7, MessageText: "How do I deploy?"}, nil); err == nil {
+		t.Fatal("Expected GenerateResponse to fail")
+	}
+
+	entries := readAuditLog(t, cfg.AuditLogPath)
+	if len(entries) != 1 || entries[0].Success || entries[0].InquiryID != 7 || entries[0].ResponseHash != "" {
+		t.Errorf("Expected one failed audit entry, got %+v", entries)
+	}
+}
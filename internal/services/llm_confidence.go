@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// confidenceSystemPrompt asks the model to rate an answer for EvaluateConfidence
+const confidenceSystemPrompt = `You rate answers given by an internal help bot.
+Reply with a single number between 0 and 1 and nothing else.`
+
+// confidenceNumber matches the score in a self-evaluation reply
+var confidenceNumber = regexp.MustCompile(`\d*\.?\d+`)
+
+// logprobConfidence turns the token log-probabilities of an answer into a 0-1
+// confidence: the exponent of their average, i.e. the geometric mean token
+// probability. It reports false when there are none.
+func logprobConfidence(logprobs *LiteLLMLogprobs) (float64, bool) {
+	if logprobs == nil || len(logprobs.Content) == 0 {
+		return 0, false
+	}
+
+	var sum float64
+	for _, token := range logprobs.Content {
+		sum += token.Logprob
+	}
+	return math.Exp(sum / float64(len(logprobs.Content))), true
+}
+
+// EvaluateConfidence asks the model how confident it is in answer to inquiry,
+// for providers that don't return log-probabilities
+func (s *LLMService) EvaluateConfidence(ctx context.Context, inquiry *storage.Inquiry, answer string) (float64, error) {
+	prompt := fmt.Sprintf("Question:\n%s\n\nAnswer:\n%s\n\nHow confident are you in this response on a scale of 0-1?",
+		inquiry.MessageText, answer)
+
+	reply, err := s.Complete(ctx, confidenceSystemPrompt, prompt)
+	if err != nil {
+		return 0, err
+	}
+	return parseConfidence(reply)
+}
+
+// parseConfidence reads the 0-1 score from a self-evaluation reply
+func parseConfidence(reply string) (float64, error) {
+	match := confidenceNumber.FindString(reply)
+	if match == "" {
+		return 0, fmt.Errorf("no confidence score in %q", strings.TrimSpace(reply))
+	}
+	confidence, err := strconv.ParseFloat(match, 64)
+	if err != nil || confidence > 1 {
+		return 0, fmt.Errorf("invalid confidence score %q", match)
+	}
+	return confidence, nil
+}
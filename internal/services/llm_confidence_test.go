@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestLogprobConfidence(t *testing.T) {
+	if _, ok := logprobConfidence(nil); ok {
+		t.Error("Expected no confidence without log-probabilities")
+	}
+	if _, ok := logprobConfidence(&LiteLLMLogprobs{}); ok {
+		t.Error("Expected no confidence without tokens")
+	}
+
+	logprobs := &LiteLLMLogprobs{Content: []LiteLLMTokenLogprob{{Logprob: 0}, {Logprob: math.Log(0.25)}}}
+	confidence, ok := logprobConfidence(logprobs)
+	if !ok || math.Abs(confidence-0.5) > 1e-9 {
+		t.Errorf("Expected the geometric mean probability 0.5, got %v (%v)", confidence, ok)
+	}
+}
+
+func TestParseConfidence(t *testing.T) {
+	tests := []struct {
+		reply   string
+		want    float64
+		wantErr bool
+	}{
+		{reply: "0.8", want: 0.8},
+		{reply: "Confidence: .35\n", want: 0.35},
+		{reply: "1", want: 1},
+		{reply: "I am fairly sure", wantErr: true},
+		{reply: "85", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			got, err := parseConfidence(tt.reply)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestGenerateResponse_ConfidenceFromLogprobs(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.AnswerConfidenceScoring = true
+	fake := newFakeLLM(t, cfg, "Use the deploy script.")
+	fake.returnLogprobs(0, math.Log(0.25))
+	service := NewLLMService(cfg)
+
+	inquiry := &storage.Inquiry{MessageText: "How do I deploy?"}
+	if _, err := service.GenerateResponse(context.Background(), inquiry, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	if fake.requests[0]["logprobs"] != true {
+		t.Errorf("Expected log-probabilities to be requested, got %v", fake.requests[0]["logprobs"])
+	}
+	if math.Abs(inquiry.ConfidenceScore-0.5) > 1e-9 {
+		t.Errorf("Expected confidence 0.5, got %v", inquiry.ConfidenceScore)
+	}
+}
+
+func TestGenerateResponse_NoLogprobsWithoutScoring(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeLLM(t, cfg, "Use the deploy script.")
+	fake.returnLogprobs(0)
+	service := NewLLMService(cfg)
+
+	inquiry := &storage.Inquiry{MessageText: "How do I deploy?"}
+	if _, err := service.GenerateResponse(context.Background(), inquiry, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	if _, ok := fake.requests[0]["logprobs"]; ok {
+		t.Error("Expected log-probabilities not to be requested")
+	}
+}
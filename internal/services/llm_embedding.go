@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// LiteLLMEmbeddingRequest represents a request to the LiteLLM embeddings API
+type LiteLLMEmbeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// LiteLLMEmbeddingResponse represents a response from the LiteLLM embeddings API
+type LiteLLMEmbeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+// GenerateEmbedding embeds text with EmbeddingModel through LiteLLM's
+// /embeddings endpoint. Vectors whose length isn't EmbeddingDimensions are
+// rejected, so a model change can't silently mix incomparable vectors.
+func (s *LLMService) GenerateEmbedding(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := s.GenerateEmbeddings(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GenerateEmbeddings embeds texts in a single /embeddings request, returning
+// their vectors in the same order, with the checks of GenerateEmbedding
+func (s *LLMService) GenerateEmbeddings(ctx context.Context, texts []string) (embeddings [][]float64, err error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+	if s.cfg().LiteLLMAPIKey == "" || s.cfg().LiteLLMBaseURL == "" {
+		return nil, fmt.Errorf("LiteLLM not configured")
+	}
+
+	start := time.Now()
+	defer func() {
+		s.metrics.Timing("llm.embedding", time.Since(start), map[string]string{
+			"model":  s.cfg().EmbeddingModel,
+			"status": outcomeTag(err),
+		})
+	}()
+
+	jsonData, err := json.Marshal(LiteLLMEmbeddingRequest{Model: s.cfg().EmbeddingModel, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/embeddings", s.cfg().LiteLLMBaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-litellm-api-key", s.cfg().LiteLLMAPIKey)
+
+	resp, err := s.httpClient().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call LiteLLM embeddings API: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			loggerFrom(ctx).WithError(err).Error("Failed to close response body")
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("LiteLLM embeddings API returned status %d", resp.StatusCode)
+	}
+
+	var response LiteLLMEmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(response.Data) != len(texts) {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(response.Data), len(texts))
+	}
+
+	embeddings = make([][]float64, len(texts))
+	for _, data := range response.Data {
+		if data.Index < 0 || data.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding index %d out of range", data.Index)
+		}
+		if s.cfg().EmbeddingDimensions > 0 && len(data.Embedding) != s.cfg().EmbeddingDimensions {
+			return nil, fmt.Errorf("embedding has %d dimensions, expected %d", len(data.Embedding), s.cfg().EmbeddingDimensions)
+		}
+		embeddings[data.Index] = data.Embedding
+	}
+	for i, embedding := range embeddings {
+		if embedding == nil {
+			return nil, fmt.Errorf("no embedding generated for input %d", i)
+		}
+	}
+
+	return embeddings, nil
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newFakeEmbeddings starts a fake LiteLLM embeddings API returning vector and points cfg at it
+func newFakeEmbeddings(t *testing.T, cfg *config.Config, vector []float64) *LiteLLMEmbeddingRequest {
+	t.Helper()
+
+	received := &LiteLLMEmbeddingRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/embeddings" {
+			t.Errorf("Expected POST /embeddings, got %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(received); err != nil {
+			t.Errorf("Failed to decode embeddings request: %v", err)
+		}
+
+		// Listed last input first, as the API doesn't promise their order
+		data := []map[string]interface{}{}
+		for i := len(received.Input) - 1; i >= 0; i-- {
+			data = append(data, map[string]interface{}{"index": i, "embedding": append([]float64{float64(i)}, vector[1:]...)})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.LiteLLMAPIKey = "test-key"
+	cfg.LiteLLMBaseURL = server.URL
+
+	return received
+}
+
+func TestGenerateEmbedding(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.EmbeddingDimensions = 3
+	received := newFakeEmbeddings(t, cfg, []float64{0.1, 0.2, 0.3})
+
+	embedding, err := NewLLMService(cfg).GenerateEmbedding(context.Background(), "How do I deploy?")
+	if err != nil {
+		t.Fatalf("GenerateEmbedding returned error: %v", err)
+	}
+
+	if len(embedding) != 3 || embedding[1] != 0.2 {
+		t.Errorf("Expected the canned vector, got %v", embedding)
+	}
+	if received.Model != "text-embedding-3-small" || len(received.Input) != 1 || received.Input[0] != "How do I deploy?" {
+		t.Errorf("Unexpected embeddings request %+v", received)
+	}
+}
+
+func TestGenerateEmbeddings_Batch(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.EmbeddingDimensions = 2
+	received := newFakeEmbeddings(t, cfg, []float64{0, 0.5})
+
+	embeddings, err := NewLLMService(cfg).GenerateEmbeddings(context.Background(), []string{"deploy", "rollback", "oncall"})
+	if err != nil {
+		t.Fatalf("GenerateEmbeddings returned error: %v", err)
+	}
+
+	if len(received.Input) != 3 {
+		t.Errorf("Expected all inputs in one request, got %v", received.Input)
+	}
+	if len(embeddings) != 3 {
+		t.Fatalf("Expected 3 embeddings, got %d", len(embeddings))
+	}
+	for i, embedding := range embeddings {
+		if embedding[0] != float64(i) {
+			t.Errorf("Embedding %d: expected it in input order, got %v", i, embedding)
+		}
+	}
+}
+
+func TestGenerateEmbedding_Dimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		dimensions int
+		wantErr    bool
+	}{
+		{name: "matching", dimensions: 2},
+		{name: "mismatched", dimensions: 1536, wantErr: true},
+		{name: "unchecked", dimensions: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.EmbeddingDimensions = tt.dimensions
+			newFakeEmbeddings(t, cfg, []float64{0.5, 0.5})
+
+			_, err := NewLLMService(cfg).GenerateEmbedding(context.Background(), "deploy")
+			if tt.wantErr && (err == nil || !strings.Contains(err.Error(), "expected 1536")) {
+				t.Errorf("Expected dimension mismatch error, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestGenerateEmbedding_NotConfigured(t *testing.T) {
+	if _, err := NewLLMService(config.LoadTestConfig()).GenerateEmbedding(context.Background(), "deploy"); err == nil {
+		t.Error("Expected error when LiteLLM is not configured")
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// EnsembleModel is the model name that selects ensemble answering in
+// CHANNEL_MODELS or EMOJI_MODELS
+const EnsembleModel = "ensemble"
+
+// judgeMergedPrefix starts a judge reply that merges the candidates instead of picking one
+const judgeMergedPrefix = "MERGED:"
+
+// EnsembleCandidate is one model's answer to an inquiry
+type EnsembleCandidate struct {
+	Model  string
+	Answer string
+	Err    error
+}
+
+// EnsembleResult is the answer chosen by the judge and the candidates it chose from
+type EnsembleResult struct {
+	Answer     string
+	Model      string // model that wrote Answer; the judge model when merged
+	Merged     bool
+	Winner     int // index of the picked candidate, -1 when merged
+	Candidates []EnsembleCandidate
+}
+
+// GenerateEnsemble asks every ENSEMBLE_MODELS model to answer inquiry in
+// parallel, then has the judge model pick the best answer or merge them.
+// Failed candidates are left out of judging; a single surviving candidate
+// wins without a judge.
+func (s *LLMService) GenerateEnsemble(ctx context.Context, inquiry *storage.Inquiry, searchResults []storage.SearchResult) (*EnsembleResult, error) {
+	if s.cfg().LiteLLMAPIKey == "" || s.cfg().LiteLLMBaseURL == "" {
+		return nil, fmt.Errorf("LiteLLM not configured")
+	}
+
+	candidates := make([]EnsembleCandidate, len(s.cfg().EnsembleModels))
+	var wg sync.WaitGroup
+	for i, model := range s.cfg().EnsembleModels {
+		wg.Add(1)
+		go func(i int, model string) {
+			defer wg.Done()
+			answer, err := s.chat(ctx, s.answerRequest(ctx, inquiry, searchResults, model))
+			candidates[i] = EnsembleCandidate{Model: model, Answer: answer, Err: err}
+		}(i, model)
+	}
+	wg.Wait()
+
+	var answered []int
+	for i, candidate := range candidates {
+		if candidate.Err != nil {
+			loggerFrom(ctx).WithError(candidate.Err).WithField("model", candidate.Model).Warn("Ensemble candidate failed")
+			continue
+		}
+		answered = append(answered, i)
+	}
+
+	result := &EnsembleResult{Candidates: candidates, Winner: -1}
+	switch len(answered) {
+	case 0:
+		return nil, fmt.Errorf("all %d ensemble models failed: %w", len(candidates), candidates[0].Err)
+	case 1:
+		result.pick(answered[0])
+		return result, nil
+	}
+
+	judgeModel := s.judgeModel()
+	verdict, err := s.chat(ctx, s.judgeRequest(inquiry, candidates, answered, judgeModel))
+	if err != nil {
+		// Better an unjudged answer than none
+		loggerFrom(ctx).WithError(err).Warn("Ensemble judge failed, using the first answer")
+		result.pick(answered[0])
+		return result, nil
+	}
+
+	verdict = strings.TrimSpace(verdict)
+	if merged, ok := strings.CutPrefix(verdict, judgeMergedPrefix); ok {
+		result.Answer = strings.TrimSpace(merged)
+		result.Model = judgeModel
+		result.Merged = true
+	} else if choice, err := strconv.Atoi(verdict); err == nil && choice >= 1 && choice <= len(answered) {
+		result.pick(answered[choice-1])
+	} else {
+		loggerFrom(ctx).WithField("verdict", verdict).Warn("Unrecognised ensemble judge verdict, using the first answer")
+		result.pick(answered[0])
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"candidates": len(answered),
+		"model":      result.Model,
+		"merged":     result.Merged,
+	}).Info("Ensemble answer selected")
+
+	return result, nil
+}
+
+// pick makes candidate i the ensemble's answer
+func (r *EnsembleResult) pick(i int) {
+	r.Winner = i
+	r.Answer = r.Candidates[i].Answer
+	r.Model = r.Candidates[i].Model
+}
+
+// judgeModel returns the model that judges ensemble candidates
+func (s *LLMService) judgeModel() string {
+	if s.cfg().EnsembleJudgeModel != "" {
+		return s.cfg().EnsembleJudgeModel
+	}
+	return s.cfg().LLMModel
+}
+
+// judgeRequest asks model to pick the best of the answered candidates, or to merge them
+func (s *LLMService) judgeRequest(inquiry *storage.Inquiry, candidates []EnsembleCandidate, answered []int, model string) LiteLLMRequest {
+	var prompt strings.Builder
+	fmt.Fprintf(&prompt, "Question: %s\n\n", inquiry.MessageText)
+	for n, i := range answered {
+		fmt.Fprintf(&prompt, "Answer %d:\n%s\n\n", n+1, candidates[i].Answer)
+	}
+	fmt.Fprintf(&prompt, "Reply with only the number of the most accurate and helpful answer. "+
+		"If combining them gives a clearly better answer, reply with %q followed by the combined answer instead.", judgeMergedPrefix)
+
+	request := FormatMessages(s.cfg().LLMProvider, []LiteLLMMessage{
+		{Role: "system", Content: "You judge answers to questions from an internal inquiry system, preferring accurate, specific and actionable answers."},
+		{Role: "user", Content: prompt.String()},
+	})
+	request.Model = model
+	request.Temperature = 0
+	request.MaxTokens = s.cfg().LLMMaxTokens
+
+	return request
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// newEnsembleConfig returns a test config ensembling model-a and model-b, judged by judge
+func newEnsembleConfig() *config.Config {
+	cfg := config.LoadTestConfig()
+	cfg.EnsembleModels = []string{"model-a", "model-b"}
+	cfg.EnsembleJudgeModel = "judge"
+	return cfg
+}
+
+func TestGenerateEnsemble(t *testing.T) {
+	tests := []struct {
+		name          string
+		verdict       string
+		expected      string
+		expectedModel string
+		merged        bool
+		winner        int
+	}{
+		{name: "judge picks an answer", verdict: "2", expected: "Answer B", expectedModel: "model-b", winner: 1},
+		{name: "judge merges answers", verdict: "MERGED: Answer A and B", expected: "Answer A and B", expectedModel: "judge", merged: true, winner: -1},
+		{name: "unrecognised verdict", verdict: "Both are fine", expected: "Answer A", expectedModel: "model-a", winner: 0},
+		{name: "out of range choice", verdict: "3", expected: "Answer A", expectedModel: "model-a", winner: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := newEnsembleConfig()
+			fake := newFakeLLM(t, cfg, "")
+			fake.answerModel("model-a", "Answer A")
+			fake.answerModel("model-b", "Answer B")
+			fake.answerModel("judge", tt.verdict)
+
+			result, err := NewLLMService(cfg).GenerateEnsemble(context.Background(), &storage.Inquiry{MessageText: "How do I deploy?"}, nil)
+			if err != nil {
+				t.Fatalf("GenerateEnsemble returned error: %v", err)
+			}
+
+			if result.Answer != tt.expected || result.Model != tt.expectedModel || result.Merged != tt.merged || result.Winner != tt.winner {
+				t.Errorf("Expected %q by %s (merged %v, winner %d), got %+v", tt.expected, tt.expectedModel, tt.merged, tt.winner, result)
+			}
+			if len(result.Candidates) != 2 {
+				t.Errorf("Expected 2 candidates, got %d", len(result.Candidates))
+			}
+		})
+	}
+}
+
+func TestGenerateEnsemble_QueriesEveryModelBeforeJudging(t *testing.T) {
+	cfg := newEnsembleConfig()
+	fake := newFakeLLM(t, cfg, "1")
+	fake.answerModel("model-a", "Answer A")
+	fake.answerModel("model-b", "Answer B")
+
+	if _, err := NewLLMService(cfg).GenerateEnsemble(context.Background(), &storage.Inquiry{MessageText: "How do I deploy?"}, nil); err != nil {
+		t.Fatalf("GenerateEnsemble returned error: %v", err)
+	}
+
+	if fake.requestCount() != 3 {
+		t.Fatalf("Expected 2 candidate requests and 1 judge request, got %d", fake.requestCount())
+	}
+	models := map[interface{}]bool{fake.requests[0]["model"]: true, fake.requests[1]["model"]: true}
+	if !models["model-a"] || !models["model-b"] {
+		t.Errorf("Expected both ensemble models to be queried first, got %v", models)
+	}
+
+	judge := fake.requests[2]
+	if judge["model"] != "judge" {
+		t.Fatalf("Expected the judge to be queried last, got %v", judge["model"])
+	}
+	messages := judge["messages"].([]interface{})
+	prompt := messages[len(messages)-1].(map[string]interface{})["content"].(string)
+	if !strings.Contains(prompt, "Answer A") || !strings.Contains(prompt, "Answer B") {
+		t.Errorf("Expected the judge prompt to contain both answers, got %q", prompt)
+	}
+}
+
+func TestIsModelAllowed_Ensemble(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMAllowedModels = []string{"gpt-4o-mini"}
+	if NewLLMService(cfg).IsModelAllowed(EnsembleModel) {
+		t.Error("Expected ensemble to be disallowed without ENSEMBLE_MODELS")
+	}
+
+	cfg.EnsembleModels = []string{"gpt-4o-mini", "gpt-4o"}
+	if !NewLLMService(cfg).IsModelAllowed(EnsembleModel) {
+		t.Error("Expected ensemble to be allowed with ENSEMBLE_MODELS")
+	}
+}
+
+func TestProcessInquiry_EnsembleRecordsVersions(t *testing.T) {
+	cfg := newEnsembleConfig()
+	cfg.ChannelModels = map[string]string{"C1": EnsembleModel}
+	fakeSlack := newFakeSlack(t, cfg)
+	fakeSlack.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	fake := newFakeLLM(t, cfg, "2")
+	fake.answerModel("model-a", "Answer A")
+	fake.answerModel("model-b", "Answer B")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if inquiry.Model != EnsembleModel || inquiry.ResponseText != "Answer B" {
+		t.Errorf("Expected the judged ensemble answer, got model %q and %q", inquiry.Model, inquiry.ResponseText)
+	}
+
+	var versions []storage.AnswerVersion
+	db.Where("inquiry_id = ?", inquiry.ID).Order("id").Find(&versions)
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 answer versions, got %d", len(versions))
+	}
+	for _, version := range versions {
+		if version.Selected != (version.Model == "model-b") {
+			t.Errorf("Expected only model-b's answer to be selected, got %+v", version)
+		}
+	}
+}
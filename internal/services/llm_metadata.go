@@ -0,0 +1,28 @@
+package services
+
+import "github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+
+// metadataTags returns the LiteLLM tags attributing a request for inquiry to
+// its channel, user and category, as selected by LLMMetadataHeaders. Fields
+// the inquiry doesn't have are left out.
+func (s *LLMService) metadataTags(inquiry *storage.Inquiry) []string {
+	var tags []string
+	for _, field := range s.cfg().LLMMetadataHeaders {
+		var value string
+		switch field {
+		case "channel":
+			value = inquiry.ChannelID
+		case "user":
+			value = inquiry.UserID
+			if value != "" && s.cfg().LLMMetadataHashUsers {
+				value = sha256Hex([]byte(value))
+			}
+		case "category":
+			value = inquiry.Category
+		}
+		if value != "" {
+			tags = append(tags, field+":"+value)
+		}
+	}
+	return tags
+}
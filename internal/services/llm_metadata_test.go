@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestGenerateResponse_MetadataHeaders(t *testing.T) {
+	inquiry := &storage.Inquiry{ChannelID: "C1", UserID: "U1", Category: "deployment", MessageText: "How do I deploy?"}
+
+	tests := []struct {
+		name     string
+		fields   []string
+		hash     bool
+		expected string
+	}{
+		{name: "disabled"},
+		{name: "hashed user", fields: []string{"channel", "user", "category"}, hash: true,
+			expected: "channel:C1,user:" + sha256Hex([]byte("U1")) + ",category:deployment"},
+		{name: "plain user", fields: []string{"user"}, expected: "user:U1"},
+		{name: "selected fields", fields: []string{"category", "channel"}, hash: true, expected: "category:deployment,channel:C1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.LLMMetadataHeaders = tt.fields
+			cfg.LLMMetadataHashUsers = tt.hash
+			fake := newFakeLLM(t, cfg, "Use the deploy script.")
+
+			if _, err := NewLLMService(cfg).GenerateResponse(context.Background(), inquiry, nil); err != nil {
+				t.Fatalf("GenerateResponse returned error: %v", err)
+			}
+
+			if tags := fake.headers[0].Get("x-litellm-tags"); tags != tt.expected {
+				t.Errorf("Expected tags %q, got %q", tt.expected, tags)
+			}
+		})
+	}
+}
+
+func TestMetadataTags_SkipsMissingFields(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMMetadataHeaders = []string{"channel", "user", "category"}
+	service := NewLLMService(cfg)
+
+	tags := service.metadataTags(&storage.Inquiry{ChannelID: "C1"})
+	if len(tags) != 1 || tags[0] != "channel:C1" {
+		t.Errorf("Expected only the channel tag, got %v", tags)
+	}
+}
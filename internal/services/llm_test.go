@@ -0,0 +1,384 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// fakeLLM is a minimal stand-in for the LiteLLM chat completions API
+type fakeLLM struct {
+	mu       sync.Mutex
+	answer   string
+	answers  map[string]string // per-model answers overriding answer
+	logprobs *LiteLLMLogprobs  // returned with every answer when set
+	delay    time.Duration     // how long every answer takes
+	requests []map[string]interface{}
+	headers  []http.Header
+}
+
+// newFakeLLM starts a fake LiteLLM server answering every request with answer and points cfg at it
+func newFakeLLM(t *testing.T, cfg *config.Config, answer string) *fakeLLM {
+	t.Helper()
+
+	fake := &fakeLLM{answer: answer, answers: make(map[string]string)}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Failed to decode LLM request: %v", err)
+		}
+
+		fake.mu.Lock()
+		fake.requests = append(fake.requests, body)
+		fake.headers = append(fake.headers, r.Header.Clone())
+		answer, ok := fake.answers[fmt.Sprint(body["model"])]
+		if !ok {
+			answer = fake.answer
+		}
+		logprobs := fake.logprobs
+		delay := fake.delay
+		fake.mu.Unlock()
+
+		time.Sleep(delay)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(LiteLLMResponse{
+			Choices: []LiteLLMChoice{{Message: LiteLLMMessage{Role: "assistant", Content: answer}, Logprobs: logprobs}},
+			Usage:   LiteLLMUsage{PromptTokens: 30, CompletionTokens: 12, TotalTokens: 42},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.LiteLLMAPIKey = "test-key"
+	cfg.LiteLLMBaseURL = server.URL
+
+	return fake
+}
+
+// answerModel makes the fake answer requests for model with answer
+func (f *fakeLLM) answerModel(model, answer string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.answers[model] = answer
+}
+
+// returnLogprobs makes the fake return a token with each of the given log-probabilities
+func (f *fakeLLM) returnLogprobs(logprobs ...float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.logprobs = &LiteLLMLogprobs{}
+	for _, logprob := range logprobs {
+		f.logprobs.Content = append(f.logprobs.Content, LiteLLMTokenLogprob{Token: "t", Logprob: logprob})
+	}
+}
+
+// answerAfter makes the fake take d to answer each request
+func (f *fakeLLM) answerAfter(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.delay = d
+}
+
+// requestCount returns the number of requests the fake has received
+func (f *fakeLLM) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.requests)
+}
+
+func TestGenerateResponse(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeLLM(t, cfg, "Use the deploy script.")
+	service := NewLLMService(cfg)
+
+	inquiry := &storage.Inquiry{MessageText: "How do I deploy?"}
+	response, err := service.GenerateResponse(context.Background(), inquiry, nil)
+	if err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	if response != "Use the deploy script." {
+		t.Errorf("Expected canned answer, got %q", response)
+	}
+	if fake.requestCount() != 1 {
+		t.Fatalf("Expected 1 LLM request, got %d", fake.requestCount())
+	}
+	if fake.headers[0].Get("x-litellm-api-key") != "test-key" {
+		t.Errorf("Expected API key header to be set")
+	}
+	if fake.requests[0]["model"] != "gpt-4o-mini" {
+		t.Errorf("Expected model 'gpt-4o-mini', got %v", fake.requests[0]["model"])
+	}
+}
+
+func TestGenerateResponse_NotConfigured(t *testing.T) {
+	service := NewLLMService(config.LoadTestConfig())
+
+	if _, err := service.GenerateResponse(context.Background(), &storage.Inquiry{}, nil); err == nil {
+		t.Error("Expected error when LiteLLM is not configured")
+	}
+}
+
+func TestSplitResultsByTier(t *testing.T) {
+	tests := []struct {
+		name         string
+		scores       []float64
+		expectedMust int
+		expectedNice int
+	}{
+		{name: "only must-have", scores: []float64{0.9, 0.8, 1.0}, expectedMust: 3, expectedNice: 0},
+		{name: "mixed", scores: []float64{0.95, 0.5, 0.8, 0.3}, expectedMust: 2, expectedNice: 2},
+		{name: "only nice-to-have", scores: []float64{0.7, 0.2}, expectedMust: 0, expectedNice: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []storage.SearchResult
+			for _, score := range tt.scores {
+				results = append(results, storage.SearchResult{Score: score})
+			}
+
+			mustHave, niceToHave := SplitResultsByTier(results, 0.8)
+			if len(mustHave) != tt.expectedMust {
+				t.Errorf("Expected %d must-have results, got %d", tt.expectedMust, len(mustHave))
+			}
+			if len(niceToHave) != tt.expectedNice {
+				t.Errorf("Expected %d nice-to-have results, got %d", tt.expectedNice, len(niceToHave))
+			}
+			for _, result := range mustHave {
+				if result.Score < 0.8 {
+					t.Errorf("Result with score %f should not be must-have", result.Score)
+				}
+			}
+		})
+	}
+}
+
+func TestSelectContextResults_KeepsMustHaveOverBudget(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMMaxContextChars = 20
+	service := NewLLMService(cfg)
+
+	results := []storage.SearchResult{
+		{Score: 0.9, Content: strings.Repeat("a", 30)},
+		{Score: 0.5, Content: "short"},
+	}
+
+	selected := service.selectContextResults(context.Background(), results)
+	if len(selected) != 1 || selected[0].Score != 0.9 {
+		t.Errorf("Expected only the must-have result to survive the budget, got %+v", selected)
+	}
+}
+
+func TestFormatMessages(t *testing.T) {
+	messages := []LiteLLMMessage{
+		{Role: "system", Content: "You are helpful."},
+		{Role: "user", Content: "How do I deploy?"},
+	}
+
+	t.Run("openai", func(t *testing.T) {
+		request := FormatMessages("openai", messages)
+
+		if len(request.Messages) != 2 || request.Messages[0].Role != "system" {
+			t.Errorf("Expected system prompt to stay in messages, got %+v", request.Messages)
+		}
+
+		payload, _ := json.Marshal(request)
+		if strings.Contains(string(payload), `"system":`) {
+			t.Errorf("Expected no top-level system field, got %s", payload)
+		}
+	})
+
+	t.Run("anthropic", func(t *testing.T) {
+		request := FormatMessages("anthropic", messages)
+
+		if request.System != "You are helpful." {
+			t.Errorf("Expected top-level system prompt, got %q", request.System)
+		}
+		if len(request.Messages) != 1 || request.Messages[0].Role != "user" {
+			t.Errorf("Expected only the user message to remain, got %+v", request.Messages)
+		}
+	})
+}
+
+func TestGenerateResponse_AnthropicSchema(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMProvider = "anthropic"
+	fake := newFakeLLM(t, cfg, "answer")
+	service := NewLLMService(cfg)
+
+	if _, err := service.GenerateResponse(context.Background(), &storage.Inquiry{MessageText: "deploy"}, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	request := fake.requests[0]
+	if _, ok := request["system"].(string); !ok {
+		t.Errorf("Expected top-level system field, got %v", request["system"])
+	}
+	for _, message := range request["messages"].([]interface{}) {
+		if message.(map[string]interface{})["role"] == "system" {
+			t.Error("Expected no system message in messages array")
+		}
+	}
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr string
+	}{
+		{name: "valid key", status: http.StatusOK},
+		{name: "unauthorized", status: http.StatusUnauthorized, wantErr: "401"},
+		{name: "forbidden", status: http.StatusForbidden, wantErr: "403"},
+		{name: "models endpoint not served", status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var path, key string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.Method + " " + r.URL.Path
+				key = r.Header.Get("x-litellm-api-key")
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			cfg := config.LoadTestConfig()
+			cfg.LiteLLMAPIKey = "test-key"
+			cfg.LiteLLMBaseURL = server.URL
+			err := NewLLMService(cfg).ValidateAPIKey()
+
+			if path != "GET /models" || key != "test-key" {
+				t.Errorf("Expected authenticated GET /models, got %q with key %q", path, key)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error mentioning %s, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestValidateAPIKey_Unreachable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	cfg := config.LoadTestConfig()
+	cfg.LiteLLMAPIKey = "test-key"
+	cfg.LiteLLMBaseURL = server.URL
+	if err := NewLLMService(cfg).ValidateAPIKey(); err == nil {
+		t.Error("Expected error for unreachable LiteLLM API")
+	}
+}
+
+func TestSplitResultsByTier_ManualOverride(t *testing.T) {
+	results := []storage.SearchResult{{Score: 0.9}, {Score: 0, ManualOverride: true}, {Score: 0.3}}
+
+	mustHave, niceToHave := SplitResultsByTier(results, 0.8)
+	if len(mustHave) != 2 || !mustHave[1].ManualOverride {
+		t.Errorf("Expected the override to be must-have, got %+v", mustHave)
+	}
+	if len(niceToHave) != 1 {
+		t.Errorf("Expected 1 nice-to-have result, got %d", len(niceToHave))
+	}
+}
+
+func TestModelFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		channelModels map[string]string
+		emojiModel    string
+		expected      string
+	}{
+		{name: "global default", expected: "gpt-4o-mini"},
+		{name: "emoji override", emojiModel: "gpt-4o", expected: "gpt-4o"},
+		{name: "channel override", channelModels: map[string]string{"C1": "gpt-4o"}, expected: "gpt-4o"},
+		{name: "channel override wins over emoji", channelModels: map[string]string{"C1": "gpt-4o-mini"}, emojiModel: "gpt-4o", expected: "gpt-4o-mini"},
+		{name: "other channel's override", channelModels: map[string]string{"C2": "gpt-4o"}, expected: "gpt-4o-mini"},
+		{name: "disallowed channel override", channelModels: map[string]string{"C1": "o1"}, emojiModel: "gpt-4o", expected: "gpt-4o"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.LLMAllowedModels = []string{"gpt-4o-mini", "gpt-4o"}
+			if tt.channelModels != nil {
+				cfg.ChannelModels = tt.channelModels
+			}
+
+			model := NewLLMService(cfg).ModelFor(&storage.Inquiry{ChannelID: "C1", RequestedModel: tt.emojiModel})
+			if model != tt.expected {
+				t.Errorf("Expected model %q, got %q", tt.expected, model)
+			}
+		})
+	}
+}
+
+func TestGenerateResponse_ChannelModel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ChannelModels = map[string]string{"C1": "gpt-4o"}
+	fake := newFakeLLM(t, cfg, "answer")
+	service := NewLLMService(cfg)
+
+	if _, err := service.GenerateResponse(context.Background(), &storage.Inquiry{ChannelID: "C1", MessageText: "deploy"}, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if fake.requests[0]["model"] != "gpt-4o" {
+		t.Errorf("Expected the channel's model, got %v", fake.requests[0]["model"])
+	}
+}
+
+func TestBuildContext_SourceOrder(t *testing.T) {
+	results := []storage.SearchResult{
+		{Source: "slack", Content: "Ask in #deploys", Score: 0.75},
+		{Source: "confluence", Title: "Deploy guide", Content: "Run make deploy", Score: 0.9},
+		{Source: "slack", Content: "Use the pipeline", Score: 0.95},
+		{Source: "github", SourceID: "scripts/deploy.sh", Title: "scripts/deploy.sh", Content: "make deploy ENV=prod", Score: 0.8},
+		{Source: "github", SourceID: "docs/RUNBOOK.md", Title: "docs/RUNBOOK.md", Content: "Roll back with make rollback", Score: 0.7},
+	}
+
+	tests := []struct {
+		order    string
+		expected []string // substrings in the order they must appear
+	}{
+		{order: "chat_first", expected: []string{"Similar past Slack discussions:", "Use the pipeline", "Ask in #deploys", "Relevant documentation:", "Deploy guide", "Relevant runbooks and READMEs from GitHub:", "docs/RUNBOOK.md", "Relevant source code:", "scripts/deploy.sh"}},
+		{order: "docs_first", expected: []string{"Relevant documentation:", "Deploy guide", "Similar past Slack discussions:", "Use the pipeline", "Ask in #deploys", "Relevant runbooks and READMEs from GitHub:", "docs/RUNBOOK.md", "Relevant source code:", "scripts/deploy.sh"}},
+		{order: "by_score", expected: []string{"1. [Slack] Use the pipeline", "2. [Docs] Deploy guide", "3. [Code] scripts/deploy.sh", "4. [Slack] Ask in #deploys", "5. [GitHub docs] docs/RUNBOOK.md"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ContextSourceOrder = tt.order
+			prompt := NewLLMService(cfg).buildContext(context.Background(), &storage.Inquiry{MessageText: "How do I deploy?"}, results)
+
+			last := -1
+			for _, part := range tt.expected {
+				index := strings.Index(prompt, part)
+				if index <= last {
+					t.Fatalf("Expected %q after the previous section in:\n%s", part, prompt)
+				}
+				last = index
+			}
+			if tt.order == "by_score" && strings.Contains(prompt, "Relevant documentation:") {
+				t.Errorf("Expected no per-source sections when ordering by score:\n%s", prompt)
+			}
+		})
+	}
+}
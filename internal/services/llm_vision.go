@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// LiteLLMContentPart is one item of a message whose content is an array, as
+// sent for messages with images
+type LiteLLMContentPart struct {
+	Type     string           `json:"type"` // text or image_url
+	Text     string           `json:"text,omitempty"`
+	ImageURL *LiteLLMImageURL `json:"image_url,omitempty"`
+}
+
+// LiteLLMImageURL is the image of an image_url content part, a URL or data URI
+type LiteLLMImageURL struct {
+	URL string `json:"url"`
+}
+
+// MarshalJSON sends the content of a message with images as an array of a
+// text part followed by an image_url part per image, and as a string otherwise
+func (m LiteLLMMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Images) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+
+	parts := []LiteLLMContentPart{{Type: "text", Text: m.Content}}
+	for _, image := range m.Images {
+		parts = append(parts, LiteLLMContentPart{Type: "image_url", ImageURL: &LiteLLMImageURL{URL: image}})
+	}
+	return json.Marshal(struct {
+		Role    string               `json:"role"`
+		Content []LiteLLMContentPart `json:"content"`
+	}{m.Role, parts})
+}
+
+// inquiryImagesKey is the context key of the images shared with an inquiry
+type inquiryImagesKey struct{}
+
+// WithInquiryImages returns a context carrying the data URIs of the images
+// shared with the inquiry being answered, sent to the vision model
+func WithInquiryImages(ctx context.Context, images []string) context.Context {
+	if len(images) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, inquiryImagesKey{}, images)
+}
+
+// answerImages returns the images of ctx the answer is generated from, none
+// unless LLM_VISION_ENABLED is set
+func (s *LLMService) answerImages(ctx context.Context) []string {
+	if !s.cfg().LLMVisionEnabled {
+		return nil
+	}
+	images, _ := ctx.Value(inquiryImagesKey{}).([]string)
+	return images
+}
+
+// visionModel returns LLM_VISION_MODEL in place of model when the answer is
+// generated from images, since the selected model may not read them
+func (s *LLMService) visionModel(ctx context.Context, model string) string {
+	if len(s.answerImages(ctx)) == 0 {
+		return model
+	}
+	return s.cfg().LLMVisionModel
+}
+
+// imagesNote tells the model about the images following the prompt
+func imagesNote(count int) string {
+	if count == 1 {
+		return "The inquiry includes an attached image, shown after this prompt."
+	}
+	return fmt.Sprintf("The inquiry includes %d attached images, shown after this prompt.", count)
+}
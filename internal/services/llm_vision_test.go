@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/slack-go/slack"
+)
+
+// pixelDataURI returns the fixture image as a data URI
+func pixelDataURI(t *testing.T) string {
+	t.Helper()
+
+	image, err := os.ReadFile("testdata/pixel.png")
+	if err != nil {
+		t.Fatalf("Failed to read fixture image: %v", err)
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(image)
+}
+
+func TestGetImageDataURI(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	image, err := os.ReadFile("testdata/pixel.png")
+	if err != nil {
+		t.Fatalf("Failed to read fixture image: %v", err)
+	}
+	fake.respond("files/pixel.png", string(image))
+	service := NewSlackService(cfg)
+
+	file := slack.File{ID: "F1", Mimetype: "image/png", Size: len(image), URLPrivateDownload: cfg.SlackAPIURL + "files/pixel.png"}
+	uri, err := service.GetImageDataURI(context.Background(), file)
+	if err != nil {
+		t.Fatalf("GetImageDataURI returned error: %v", err)
+	}
+	if uri != pixelDataURI(t) {
+		t.Errorf("Expected the image as a PNG data URI, got %q", uri)
+	}
+
+	if _, err := service.GetImageDataURI(context.Background(), slack.File{ID: "F2", Mimetype: "image/gif"}); err == nil {
+		t.Error("Expected an error for an image vision models don't accept")
+	}
+	if _, err := service.GetImageDataURI(context.Background(), slack.File{ID: "F3", Mimetype: "image/jpeg", Size: maxImageBytes + 1}); err == nil {
+		t.Error("Expected an error for an image over the size limit")
+	}
+	if calls := fake.callsTo("files/pixel.png"); len(calls) != 1 {
+		t.Errorf("Expected only the accepted image to be downloaded, got %d downloads", len(calls))
+	}
+}
+
+func TestGenerateResponse_Vision(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMModel = "gpt-4o-mini"
+	cfg.LLMVisionEnabled = true
+	cfg.LLMVisionModel = "gpt-4o"
+	fake := newFakeLLM(t, cfg, "The pod is crash looping.")
+	service := NewLLMService(cfg)
+	image := pixelDataURI(t)
+
+	ctx := WithInquiryImages(context.Background(), []string{image})
+	if _, err := service.GenerateResponse(ctx, &storage.Inquiry{MessageText: "What does this error mean?"}, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+	if _, err := service.GenerateResponse(context.Background(), &storage.Inquiry{MessageText: "What does this error mean?"}, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	withImage := fake.requests[0]
+	if withImage["model"] != "gpt-4o" {
+		t.Errorf("Expected the vision model for a question with an image, got %v", withImage["model"])
+	}
+	parts, ok := withImage["messages"].([]interface{})[1].(map[string]interface{})["content"].([]interface{})
+	if !ok || len(parts) != 2 {
+		t.Fatalf("Expected a text and an image content part, got %v", withImage["messages"])
+	}
+	text := parts[0].(map[string]interface{})
+	if text["type"] != "text" || !strings.Contains(text["text"].(string), "includes an attached image") {
+		t.Errorf("Expected the prompt to mention the image, got %v", text)
+	}
+	imagePart := parts[1].(map[string]interface{})
+	if imagePart["type"] != "image_url" || imagePart["image_url"].(map[string]interface{})["url"] != image {
+		t.Errorf("Expected the image as a data URI, got %v", imagePart)
+	}
+
+	withoutImage := fake.requests[1]
+	if withoutImage["model"] != "gpt-4o-mini" {
+		t.Errorf("Expected the default model without images, got %v", withoutImage["model"])
+	}
+	if _, ok := withoutImage["messages"].([]interface{})[1].(map[string]interface{})["content"].(string); !ok {
+		t.Errorf("Expected string content without images, got %v", withoutImage["messages"])
+	}
+}
+
+func TestGenerateResponse_VisionDisabled(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.LLMVisionModel = "gpt-4o"
+	fake := newFakeLLM(t, cfg, "answer")
+	service := NewLLMService(cfg)
+
+	ctx := WithInquiryImages(context.Background(), []string{pixelDataURI(t)})
+	if _, err := service.GenerateResponse(ctx, &storage.Inquiry{MessageText: "What does this error mean?"}, nil); err != nil {
+		t.Fatalf("GenerateResponse returned error: %v", err)
+	}
+
+	request := fake.requests[0]
+	if request["model"] != cfg.LLMModel {
+		t.Errorf("Expected the default model with vision disabled, got %v", request["model"])
+	}
+	if _, ok := request["messages"].([]interface{})[1].(map[string]interface{})["content"].(string); !ok {
+		t.Errorf("Expected images to be left out with vision disabled, got %v", request["messages"])
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// slackMessageLimit is the longest text, in characters, posted as a single
+// Slack message; Slack truncates section text beyond 3000 characters
+const slackMessageLimit = 3000
+
+// splitSeparators are tried in order when a message is too long: paragraphs,
+// then lines, then words
+var splitSeparators = []string{"\n\n", "\n", " "}
+
+// splitMessage splits text into chunks of at most limit characters, breaking
+// at paragraph boundaries where possible and falling back to lines, words and
+// finally hard cuts. Chunks are returned in their original order.
+func splitMessage(text string, limit int) []string {
+	return splitOn(text, limit, splitSeparators)
+}
+
+// splitOn splits text on the first separator, packing as many consecutive
+// parts into each chunk as fit and splitting oversized parts further
+func splitOn(text string, limit int, separators []string) []string {
+	if utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+	if len(separators) == 0 {
+		return hardSplit(text, limit)
+	}
+
+	sep := separators[0]
+	var chunks []string
+	var current string
+	for _, part := range strings.Split(text, sep) {
+		if utf8.RuneCountInString(part) > limit {
+			// An oversized part starts its own chunks
+			if current != "" {
+				chunks = append(chunks, current)
+			}
+			pieces := splitOn(part, limit, separators[1:])
+			chunks = append(chunks, pieces[:len(pieces)-1]...)
+			current = pieces[len(pieces)-1]
+			continue
+		}
+
+		if current == "" {
+			current = part
+		} else if utf8.RuneCountInString(current)+len(sep)+utf8.RuneCountInString(part) <= limit {
+			current += sep + part
+		} else {
+			chunks = append(chunks, current)
+			current = part
+		}
+	}
+	if current != "" {
+		chunks = append(chunks, current)
+	}
+
+	return chunks
+}
+
+// hardSplit cuts text into chunks of exactly limit characters, the last one possibly shorter
+func hardSplit(text string, limit int) []string {
+	runes := []rune(text)
+	chunks := make([]string, 0, len(runes)/limit+1)
+	for len(runes) > limit {
+		chunks = append(chunks, string(runes[:limit]))
+		runes = runes[limit:]
+	}
+	return append(chunks, string(runes))
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestSplitMessage(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected []string
+	}{
+		{
+			name:     "fits in one message",
+			text:     "short answer",
+			limit:    20,
+			expected: []string{"short answer"},
+		},
+		{
+			name:     "exactly at the limit",
+			text:     "aaaa\n\nbbbb",
+			limit:    10,
+			expected: []string{"aaaa\n\nbbbb"},
+		},
+		{
+			name:     "one over the limit splits at the paragraph",
+			text:     "aaaa\n\nbbbbb",
+			limit:    10,
+			expected: []string{"aaaa", "bbbbb"},
+		},
+		{
+			name:     "paragraphs are packed together",
+			text:     "aaa\n\nbbb\n\nccc\n\nddd",
+			limit:    10,
+			expected: []string{"aaa\n\nbbb", "ccc\n\nddd"},
+		},
+		{
+			name:     "long paragraph falls back to lines",
+			text:     "intro\n\nline one\nline two\nline three",
+			limit:    20,
+			expected: []string{"intro", "line one\nline two", "line three"},
+		},
+		{
+			name:     "long line falls back to words",
+			text:     "alpha beta gamma delta",
+			limit:    11,
+			expected: []string{"alpha beta", "gamma delta"},
+		},
+		{
+			name:     "long word is cut",
+			text:     "abcdefghij",
+			limit:    4,
+			expected: []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name:     "limit counts characters not bytes",
+			text:     "ééééé\n\nüüüüü",
+			limit:    5,
+			expected: []string{"ééééé", "üüüüü"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitMessage(tt.text, tt.limit)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("splitMessage() = %q, expected %q", result, tt.expected)
+			}
+			for _, chunk := range result {
+				if utf8.RuneCountInString(chunk) > tt.limit {
+					t.Errorf("Chunk %q exceeds limit %d", chunk, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestSendResponse_LongAnswer(t *testing.T) {
+	paragraph := strings.Repeat("word ", 300) // 1500 characters
+	response := strings.Join([]string{"first " + paragraph, "second " + paragraph, "third " + paragraph}, "\n\n")
+
+	t.Run("split", func(t *testing.T) {
+		cfg := config.LoadTestConfig()
+		fake := newFakeSlack(t, cfg)
+		service := newTestInquiryService(cfg, setupTestDB(t))
+		inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1"}
+
+		if err := service.sendResponse(context.Background(), inquiry, response, "", nil, false); err != nil {
+			t.Fatalf("sendResponse returned error: %v", err)
+		}
+
+		replies := fake.callsTo("chat.postMessage")
+		if len(replies) != 3 {
+			t.Fatalf("Expected 3 replies, got %d", len(replies))
+		}
+		for i, prefix := range []string{"🤖 *AI Assistant Response*\n\nfirst", "second", "third"} {
+			if !strings.HasPrefix(replies[i].Get("text"), prefix) {
+				t.Errorf("Expected reply %d to start with %q, got %q", i, prefix, replies[i].Get("text"))
+			}
+			if replies[i].Get("thread_ts") != "1.1" {
+				t.Errorf("Expected reply %d in thread 1.1, got %q", i, replies[i].Get("thread_ts"))
+			}
+		}
+		if len(fake.callsTo("files.upload")) != 0 {
+			t.Errorf("Expected no snippet upload with the split strategy")
+		}
+	})
+
+	t.Run("snippet", func(t *testing.T) {
+		cfg := config.LoadTestConfig()
+		cfg.LongAnswerStrategy = "snippet"
+		fake := newFakeSlack(t, cfg)
+		service := newTestInquiryService(cfg, setupTestDB(t))
+		inquiry := &storage.Inquiry{MessageID: "1.1", ChannelID: "C1", Timestamp: "1.1"}
+
+		if err := service.sendResponse(context.Background(), inquiry, response, "", nil, false); err != nil {
+			t.Fatalf("sendResponse returned error: %v", err)
+		}
+
+		replies := fake.callsTo("chat.postMessage")
+		if len(replies) != 1 || !strings.HasPrefix(replies[0].Get("text"), "🤖 *AI Assistant Response*") {
+			t.Fatalf("Expected a single header reply, got %v", replies)
+		}
+		uploads := fake.callsTo("files.upload")
+		if len(uploads) != 1 {
+			t.Fatalf("Expected 1 snippet upload, got %d", len(uploads))
+		}
+		if uploads[0].Get("content") != response || uploads[0].Get("thread_ts") != "1.1" || uploads[0].Get("channels") != "C1" {
+			t.Errorf("Unexpected snippet upload parameters")
+		}
+	})
+}
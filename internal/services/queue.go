@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrQueueFull is returned when a job is submitted to a queue at capacity
+var ErrQueueFull = errors.New("inquiry queue is full")
+
+// QueueJob is a unit of work run by the queue's workers
+type QueueJob func(ctx context.Context)
+
+// InquiryQueue is a bounded FIFO work queue drained by a fixed pool of workers
+type InquiryQueue struct {
+	jobs chan QueueJob
+}
+
+// NewInquiryQueue creates a queue holding at most depth pending jobs
+func NewInquiryQueue(depth int) *InquiryQueue {
+	return &InquiryQueue{jobs: make(chan QueueJob, depth)}
+}
+
+// Enqueue adds a job to the back of the queue without blocking, returning
+// ErrQueueFull when the queue is at capacity
+func (q *InquiryQueue) Enqueue(job QueueJob) error {
+	select {
+	case q.jobs <- job:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// Len returns the number of jobs waiting to be picked up
+func (q *InquiryQueue) Len() int {
+	return len(q.jobs)
+}
+
+// Run processes jobs in FIFO order with the given number of workers until ctx
+// is cancelled, then waits for in-flight jobs to finish
+func (q *InquiryQueue) Run(ctx context.Context, workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case job := <-q.jobs:
+					job(ctx)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
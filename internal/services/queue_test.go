@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestInquiryQueue_FIFO(t *testing.T) {
+	queue := NewInquiryQueue(5)
+
+	var mu sync.Mutex
+	var order []int
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		i := i
+		wg.Add(1)
+		if err := queue.Enqueue(func(ctx context.Context) {
+			defer wg.Done()
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+		}); err != nil {
+			t.Fatalf("Enqueue %d returned error: %v", i, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		queue.Run(ctx, 1)
+		close(done)
+	}()
+
+	wg.Wait()
+	cancel()
+	<-done
+
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("Expected jobs in FIFO order, got %v", order)
+		}
+	}
+}
+
+func TestInquiryQueue_RejectsAtCapacity(t *testing.T) {
+	queue := NewInquiryQueue(2)
+	noop := func(ctx context.Context) {}
+
+	for i := 0; i < 2; i++ {
+		if err := queue.Enqueue(noop); err != nil {
+			t.Fatalf("Enqueue %d returned error: %v", i, err)
+		}
+	}
+
+	if err := queue.Enqueue(noop); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull at capacity, got %v", err)
+	}
+	if queue.Len() != 2 {
+		t.Errorf("Expected 2 queued jobs, got %d", queue.Len())
+	}
+}
+
+func TestInquiryQueue_BoundsConcurrency(t *testing.T) {
+	queue := NewInquiryQueue(10)
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		_ = queue.Enqueue(func(ctx context.Context) {
+			defer wg.Done()
+			mu.Lock()
+			running++
+			if running > peak {
+				peak = running
+			}
+			mu.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go queue.Run(ctx, 3)
+	wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent jobs, got %d", peak)
+	}
+}
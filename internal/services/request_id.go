@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// requestIDKey is the context key holding an inquiry's request ID
+type requestIDKey struct{}
+
+// NewInquiryContext returns ctx carrying a new random request ID, shared by
+// every log line of one inquiry processing run. A ctx that already carries an
+// ID is returned as it is, so nested calls keep the run's ID.
+func NewInquiryContext(ctx context.Context) context.Context {
+	if RequestIDFromContext(ctx) != "" {
+		return ctx
+	}
+	return context.WithValue(ctx, requestIDKey{}, newRequestID())
+}
+
+// RequestIDFromContext returns the request ID set by NewInquiryContext, or "" if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID generates a random version 4 UUID
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand doesn't fail on supported platforms
+		panic(fmt.Sprintf("failed to generate request ID: %v", err))
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// loggerFrom returns a log entry tagged with ctx's request ID, if it has one
+func loggerFrom(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if id := RequestIDFromContext(ctx); id != "" {
+		entry = entry.WithField("inquiry_request_id", id)
+	}
+	return entry
+}
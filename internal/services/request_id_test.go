@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+)
+
+func TestNewInquiryContext(t *testing.T) {
+	if id := RequestIDFromContext(context.Background()); id != "" {
+		t.Errorf("Expected no request ID on a plain context, got %q", id)
+	}
+
+	ctx := NewInquiryContext(context.Background())
+	id := RequestIDFromContext(ctx)
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(id) {
+		t.Errorf("Expected a version 4 UUID, got %q", id)
+	}
+	if RequestIDFromContext(NewInquiryContext(ctx)) != id {
+		t.Error("Expected a context with a request ID to keep it")
+	}
+	if RequestIDFromContext(NewInquiryContext(context.Background())) == id {
+		t.Error("Expected every new inquiry context to get its own ID")
+	}
+}
+
+func TestProcessInquiry_LogsShareRequestID(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "text": "Deploy with the CLI", "channel": {"id": "C1"}}
+	]}}`)
+	newFakeLLM(t, cfg, "Use the CLI.")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.DebugLevel)
+	defer logrus.SetLevel(level)
+	hook := test.NewGlobal()
+	defer logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+
+	for _, messageID := range []string{"1.1", "2.2"} {
+		hook.Reset()
+		if err := service.ProcessInquiry(context.Background(), messageID, "C1", "U1", "How do I deploy?", messageID, ""); err != nil {
+			t.Fatalf("ProcessInquiry returned error: %v", err)
+		}
+
+		entries := hook.AllEntries()
+		if len(entries) < 3 {
+			t.Fatalf("Expected several log entries, got %d", len(entries))
+		}
+		id, _ := entries[0].Data["inquiry_request_id"].(string)
+		if id == "" {
+			t.Fatalf("Expected a request ID on %q", entries[0].Message)
+		}
+		for _, entry := range entries {
+			if entry.Data["inquiry_request_id"] != id {
+				t.Errorf("Expected request ID %s on %q, got %v", id, entry.Message, entry.Data["inquiry_request_id"])
+			}
+		}
+	}
+}
@@ -2,22 +2,70 @@ package services
 
 import (
 	"context"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/metrics"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
 
+var (
+	// slackMarkupPattern matches user/channel mentions, links and emoji codes
+	slackMarkupPattern = regexp.MustCompile(`<[^>]*>|:[a-z0-9_+-]+:`)
+	// sentenceBoundaryPattern splits text into sentences
+	sentenceBoundaryPattern = regexp.MustCompile(`[.!?]+(\s+|$)|\n+`)
+	// acronymPattern matches all-caps acronyms such as API or S3
+	acronymPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]+$`)
+	// hyphenatedNamePattern matches service-style names such as payment-api
+	hyphenatedNamePattern = regexp.MustCompile(`^[A-Za-z0-9]+(-[A-Za-z0-9]+)+$`)
+	// properNounPattern matches capitalised and CamelCase words such as Kubernetes or GitHub
+	properNounPattern = regexp.MustCompile(`^[A-Z][a-z0-9]+([A-Z][a-z0-9]*)*$`)
+	// camelCasePattern matches words with an inner capital, which are names even at sentence start
+	camelCasePattern = regexp.MustCompile(`^[A-Z][a-z0-9]+[A-Z]`)
+)
+
+// commonCapitalisedWords are capitalised or all-caps tokens that are not names
+var commonCapitalisedWords = map[string]bool{
+	"i": true, "ok": true, "fyi": true, "asap": true, "tldr": true, "imo": true,
+	"afaik": true, "ptal": true, "lgtm": true, "eod": true, "tbd": true,
+}
+
 // SearchService handles searching across multiple sources
 type SearchService struct {
 	slack      *SlackService
 	confluence *ConfluenceService
 	db         *gorm.DB
-	config     *config.Config
+	metrics    metrics.Metrics
+	kv         *storage.KVStore
+
+	// configMu guards config and noise, which Reload replaces; noise
+	// filters Slack messages before they are scored
+	configMu sync.RWMutex
+	config   *config.Config
+	noise    slackNoise
+
+	// Synonym dictionary loaded from SYNONYM_DICT_FILE on first use
+	synonymsMu   sync.Mutex
+	synonyms     SynonymDictionary
+	synonymsPath string
+
+	// Sources searched besides Slack and Confluence
+	plugins []SearchPlugin
+
+	// Embeds results to drop semantic duplicates, nil to keep them
+	embedder Embedder
+
+	// Result embeddings by source and ID, reused across searches
+	embeddingMu sync.Mutex
+	embeddings  map[string]cachedEmbedding
 }
 
 // NewSearchService creates a new search service instance
@@ -27,126 +75,367 @@ func NewSearchService(slack *SlackService, confluence *ConfluenceService, db *go
 		confluence: confluence,
 		db:         db,
 		config:     cfg,
+		metrics:    metrics.Nop{},
+		kv:         storage.NewKVStore(db),
+		noise:      newSlackNoise(cfg),
 	}
 }
 
-// SearchAll searches across all available sources (Slack and Confluence)
-func (s *SearchService) SearchAll(ctx context.Context, query string, inquiryID uint) ([]storage.SearchResult, error) {
+// SetMetrics makes the service report search timings and cache hits to m
+func (s *SearchService) SetMetrics(m metrics.Metrics) {
+	s.metrics = m
+}
+
+// Reload switches the service to cfg
+func (s *SearchService) Reload(cfg *config.Config) {
+	noise := newSlackNoise(cfg)
+	s.configMu.Lock()
+	defer s.configMu.Unlock()
+	s.config = cfg
+	s.noise = noise
+}
+
+// cfg returns the current configuration
+func (s *SearchService) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// noiseFilter returns the filters for the current configuration
+func (s *SearchService) noiseFilter() slackNoise {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.noise
+}
+
+// searchQuery reduces an inquiry to the keywords and named entities searched
+// for, expanded with their synonyms
+func (s *SearchService) searchQuery(query string) string {
+	expanded, err := s.SearchWithSynonyms(query)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load synonym dictionary, searching without synonyms")
+	}
+	return expanded
+}
+
+// SearchAll searches across all available sources (Slack and Confluence).
+// Slack results posted in channelID are boosted over results from other channels.
+// A source failing doesn't fail the search; the returned SourceStatus reports it.
+func (s *SearchService) SearchAll(ctx context.Context, query string, inquiryID uint, channelID string) ([]storage.SearchResult, SourceStatus, error) {
+	results, explanation, err := s.SearchAllExplained(ctx, query, inquiryID, channelID)
+	if err != nil {
+		return nil, SourceStatus{}, err
+	}
+	return results, explanation.Sources, nil
+}
+
+// SearchAllExplained is SearchAll that also returns how the results were
+// ranked. The explanation is logged at debug level and, when
+// DEBUG_STORE_SEARCH_EXPLANATIONS is set, stored with the raw responses.
+func (s *SearchService) SearchAllExplained(ctx context.Context, query string, inquiryID uint, channelID string) ([]storage.SearchResult, *SearchExplanation, error) {
 	var allResults []storage.SearchResult
+	var status SourceStatus
+	for _, source := range []string{"slack", "confluence"} {
+		if searchesSource(ctx, source) {
+			status.Searched = append(status.Searched, source)
+		}
+	}
+	plugins := s.activePlugins(ctx)
+	for _, plugin := range plugins {
+		status.Searched = append(status.Searched, plugin.Name())
+	}
 
-	// Extract keywords from the query for better searching
-	keywords := s.extractKeywords(query)
-	searchQuery := strings.Join(keywords, " ")
+	searchQuery := s.searchQuery(query)
 
-	logrus.WithFields(logrus.Fields{
+	loggerFrom(ctx).WithFields(logrus.Fields{
 		"original_query": query,
 		"search_query":   searchQuery,
 		"inquiry_id":     inquiryID,
 	}).Info("Starting search across all sources")
 
-	// Search Slack messages
-	if slackResults, err := s.searchSlack(ctx, searchQuery, inquiryID); err != nil {
-		logrus.WithError(err).Error("Failed to search Slack")
+	// Reuse results of an identical recent query before calling external APIs,
+	// keeping only the sources the search is restricted to
+	cached, hit := s.GetCachedResults(ctx, s.QueryHash(query))
+	if hit {
+		s.metrics.Incr("search.cache", map[string]string{"result": "hit"})
+		loggerFrom(ctx).WithField("inquiry_id", inquiryID).Info("Using cached search results")
+		for _, result := range cached {
+			if !searchesSource(ctx, result.Source) {
+				continue
+			}
+			result.InquiryID = inquiryID
+			allResults = append(allResults, result)
+		}
 	} else {
-		allResults = append(allResults, slackResults...)
-	}
+		s.metrics.Incr("search.cache", map[string]string{"result": "miss"})
+
+		// Search every source in parallel, each within its own timeout and all
+		// within the overall one, so a slow source doesn't cost the others' results
+		searchCtx, cancel := context.WithTimeout(ctx, s.cfg().SearchTotalTimeout)
+		var slackResults, confluenceResults []storage.SearchResult
+		var slackErr, confluenceErr error
+		var wg sync.WaitGroup
+		if searchesSource(ctx, "slack") {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				slackResults, slackErr = s.searchSlack(searchCtx, searchQuery, inquiryID)
+				s.metrics.Timing("search.duration", time.Since(start), map[string]string{"source": "slack", "status": outcomeTag(slackErr)})
+			}()
+		}
+		if searchesSource(ctx, "confluence") {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				confluenceResults, confluenceErr = s.searchConfluence(searchCtx, searchQuery, inquiryID)
+				s.metrics.Timing("search.duration", time.Since(start), map[string]string{"source": "confluence", "status": outcomeTag(confluenceErr)})
+			}()
+		}
+		pluginResults := make([][]storage.SearchResult, len(plugins))
+		pluginErrs := make([]error, len(plugins))
+		for i, plugin := range plugins {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				start := time.Now()
+				pluginResults[i], pluginErrs[i] = plugin.Search(searchCtx, searchQuery, inquiryID)
+				s.metrics.Timing("search.duration", time.Since(start), map[string]string{"source": plugin.Name(), "status": outcomeTag(pluginErrs[i])})
+			}()
+		}
+		wg.Wait()
+		cancel()
+
+		if slackErr != nil {
+			loggerFrom(ctx).WithError(slackErr).Error("Failed to search Slack")
+			status.Failed = append(status.Failed, "slack")
+		} else {
+			allResults = append(allResults, slackResults...)
+		}
+		if confluenceErr != nil {
+			loggerFrom(ctx).WithError(confluenceErr).Error("Failed to search Confluence")
+			status.Failed = append(status.Failed, "confluence")
+		} else {
+			allResults = append(allResults, confluenceResults...)
+		}
+		for i, plugin := range plugins {
+			if pluginErrs[i] != nil {
+				loggerFrom(ctx).WithError(pluginErrs[i]).WithField("source", plugin.Name()).Error("Failed to search plugin source")
+				status.Failed = append(status.Failed, plugin.Name())
+				continue
+			}
+			allResults = append(allResults, pluginResults[i]...)
+		}
 
-	// Search Confluence pages
-	if confluenceResults, err := s.searchConfluence(ctx, searchQuery, inquiryID); err != nil {
-		logrus.WithError(err).Error("Failed to search Confluence")
-	} else {
-		allResults = append(allResults, confluenceResults...)
+		// Only cache when every source was searched and answered, so a
+		// restricted search or a transient failure isn't reused
+		if !status.Partial() && !sourceFiltered(ctx) && s.cfg().SearchCacheTTL > 0 {
+			if err := s.CacheSearchResults(ctx, inquiryID, allResults); err != nil {
+				loggerFrom(ctx).WithError(err).WithField("inquiry_id", inquiryID).Warn("Failed to cache search results")
+			}
+		}
 	}
 
+	// Keep long messages and pages from bloating stored rows and the LLM context
+	s.TruncateResultContent(allResults, s.cfg().MaxContentBytes)
+
 	// Filter and rank results
-	filteredResults := s.filterAndRankResults(allResults)
+	filteredResults, explanation := s.rankResults(ctx, allResults, searchQuery, channelID)
+	explanation.InquiryID = inquiryID
+	explanation.Keywords = s.searchTerms(query)
+	explanation.Sources = status
+
+	// Supplement with what's new, at a fixed score rather than ranked against the query
+	if s.cfg().IncludeRecentPages && searchesSource(ctx, "confluence") {
+		recent := s.searchRecentPages(ctx, inquiryID, allResults)
+		s.TruncateResultContent(recent, s.cfg().MaxContentBytes)
+		for _, result := range recent {
+			candidate := newCandidateExplanation(result)
+			candidate.PassedThreshold = true
+			candidate.Selected = true
+			explanation.Candidates = append(explanation.Candidates, candidate)
+		}
+		filteredResults = append(filteredResults, recent...)
+		allResults = append(allResults, recent...)
+	}
+	s.recordExplanation(ctx, explanation)
+
+	// Save scored results to database
+	for _, result := range allResults {
+		if err := s.db.Create(&result).Error; err != nil {
+			loggerFrom(ctx).WithError(err).WithField("source", result.Source).Error("Failed to save search result")
+		}
+	}
 
-	logrus.WithFields(logrus.Fields{
+	loggerFrom(ctx).WithFields(logrus.Fields{
 		"total_results":    len(allResults),
 		"filtered_results": len(filteredResults),
+		"failed_sources":   status.Failed,
 		"inquiry_id":       inquiryID,
 	}).Info("Search completed")
 
-	return filteredResults, nil
+	return filteredResults, explanation, nil
 }
 
 // searchSlack searches for relevant messages in Slack
 func (s *SearchService) searchSlack(ctx context.Context, query string, inquiryID uint) ([]storage.SearchResult, error) {
-	_, cancelFn := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancelFn := context.WithTimeout(ctx, s.cfg().SlackSearchTimeout)
 	defer cancelFn()
-	messages, err := s.slack.SearchMessages(query, s.config.SearchDaysBack)
+	if s.cfg().SlackSearchIndex {
+		messages, indexed, err := s.searchSlackIndex(ctx, query)
+		if err != nil {
+			return nil, err
+		}
+		if indexed {
+			return s.slackResults(ctx, messages, inquiryID, query), nil
+		}
+		loggerFrom(ctx).Debug("Slack channel isn't indexed, searching with the Slack API")
+	}
+
+	var messages []SlackMessage
+	var raw []byte
+	var err error
+	if s.cfg().SearchThreads {
+		messages, raw, err = s.slack.SearchMessagesInThreadsRaw(ctx, query, s.cfg().SlackChannelID, s.cfg().SearchDaysBack)
+	} else {
+		messages, raw, err = s.slack.SearchMessagesRaw(ctx, query, s.cfg().SearchDaysBack)
+	}
 	if err != nil {
 		return nil, err
 	}
+	s.recordRawResponse(ctx, inquiryID, "slack", query, raw)
+	searchQuery := slackSearchQuery(query, s.slack.searchChannel(ctx, s.cfg().SlackChannelID), s.cfg().SearchDaysBack, time.Now())
+
+	return s.slackResults(ctx, messages, inquiryID, searchQuery), nil
+}
 
+// slackResults converts the Slack messages found by searchQuery into search
+// results, dropping noise
+func (s *SearchService) slackResults(ctx context.Context, messages []SlackMessage, inquiryID uint, searchQuery string) []storage.SearchResult {
 	var results []storage.SearchResult
+	var dropped int
 	for _, msg := range messages {
-		// Get user info for author name
-		author := msg.User
-		if user, err := s.slack.GetUserInfo(msg.User); err == nil && user.RealName != "" {
-			author = user.RealName
+		if s.noiseFilter().matches(msg) {
+			dropped++
+			continue
 		}
 
 		// Create search result
 		result := storage.SearchResult{
-			InquiryID:   inquiryID,
-			Source:      "slack",
-			SourceID:    msg.Timestamp,
-			Title:       "Slack Message",
-			Content:     msg.Text,
-			URL:         s.buildSlackMessageURL(msg.Channel, msg.Timestamp),
-			Score:       s.calculateRelevanceScore(msg.Text, query),
-			Author:      author,
-			CreatedDate: s.timestampToTime(msg.Timestamp),
+			InquiryID:         inquiryID,
+			Source:            "slack",
+			SourceID:          msg.Timestamp,
+			ChannelID:         msg.Channel,
+			Title:             "Slack Message",
+			Content:           msg.Text,
+			NormalizedContent: s.normalizeContent(msg.Text),
+			URL:               s.buildSlackMessageURL(msg.Channel, msg.Timestamp),
+			SearchQuery:       searchQuery,
+			Author:            msg.User,
+			CreatedDate:       s.timestampToTime(msg.Timestamp),
 		}
 
 		results = append(results, result)
 	}
-
-	// Save results to database
-	for _, result := range results {
-		if err := s.db.Create(&result).Error; err != nil {
-			logrus.WithError(err).Error("Failed to save Slack search result")
-		}
+	s.resolveAuthors(ctx, results)
+	if dropped > 0 {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": inquiryID,
+			"dropped":    dropped,
+		}).Debug("Dropped noise from Slack search results")
 	}
 
-	return results, nil
+	return results
 }
 
 // searchConfluence searches for relevant pages in Confluence
 func (s *SearchService) searchConfluence(ctx context.Context, query string, inquiryID uint) ([]storage.SearchResult, error) {
-	_, cancelFn := context.WithTimeout(ctx, 10*time.Second)
+	ctx, cancelFn := context.WithTimeout(ctx, s.cfg().ConfluenceSearchTimeout)
 	defer cancelFn()
-	pages, err := s.confluence.SearchPages(query)
+	if s.cfg().ConfluencePerSpaceSearch && len(s.confluence.spaceKeys()) > 1 {
+		return s.SearchConfluenceBySpace(ctx, query, inquiryID)
+	}
+
+	pages, raw, err := s.confluence.SearchPagesRaw(ctx, query)
 	if err != nil {
 		return nil, err
 	}
+	s.recordRawResponse(ctx, inquiryID, "confluence", query, raw)
+
+	return s.pageResults(ctx, pages, inquiryID, s.confluence.buildCQL(query)), nil
+}
 
+// pageResults converts Confluence pages returned by the CQL query cql into
+// search results for inquiryID
+func (s *SearchService) pageResults(ctx context.Context, pages []ConfluencePage, inquiryID uint, cql string) []storage.SearchResult {
 	var results []storage.SearchResult
 	for _, page := range pages {
+		if s.cfg().IncludePageComments && ctx.Err() == nil {
+			page.Content = s.withPageComments(ctx, page)
+		}
+
 		result := storage.SearchResult{
-			InquiryID:   inquiryID,
-			Source:      "confluence",
-			SourceID:    page.ID,
-			Title:       page.Title,
-			Content:     page.Content,
-			URL:         page.URL,
-			Score:       s.calculateRelevanceScore(page.Title+" "+page.Content, query),
-			Author:      page.Author,
-			CreatedDate: time.Now(), // Confluence API doesn't always provide creation date
+			InquiryID:         inquiryID,
+			Source:            "confluence",
+			SourceID:          page.ID,
+			Title:             page.Title,
+			Content:           page.Content,
+			NormalizedContent: s.normalizeContent(page.Title + " " + page.Content),
+			URL:               page.URL,
+			SpaceKey:          page.Space.Key,
+			SearchQuery:       cql,
+			Author:            page.Author,
+			SourceVersion:     page.Version.Number,
+			CreatedDate:       time.Now(), // Confluence API doesn't always provide creation date
 		}
 
 		results = append(results, result)
 	}
 
-	// Save results to database
-	for _, result := range results {
-		if err := s.db.Create(&result).Error; err != nil {
-			logrus.WithError(err).Error("Failed to save Confluence search result")
+	return results
+}
+
+// withPageComments returns the page's content followed by its comments, which
+// often correct or clarify the page. The content is returned unchanged when
+// the comments can't be fetched.
+func (s *SearchService) withPageComments(ctx context.Context, page ConfluencePage) string {
+	comments, err := s.confluence.pageComments(ctx, page.ID)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("page_id", page.ID).Warn("Failed to fetch Confluence page comments")
+		return page.Content
+	}
+	if len(comments) == 0 {
+		return page.Content
+	}
+
+	return page.Content + "\n\nComments:\n- " + strings.Join(comments, "\n- ")
+}
+
+// recordRawResponse persists a raw source response when debug storage is enabled.
+// Only response bodies are kept; configured credentials are redacted from them.
+func (s *SearchService) recordRawResponse(ctx context.Context, inquiryID uint, source, request string, raw []byte) {
+	if !s.cfg().DebugStoreRawResponses || len(raw) == 0 {
+		return
+	}
+
+	body := string(raw)
+	for _, secret := range []string{s.cfg().SlackBotToken, s.cfg().ConfluenceAPIToken, s.cfg().LiteLLMAPIKey} {
+		if secret != "" {
+			body = strings.ReplaceAll(body, secret, "[REDACTED]")
 		}
 	}
 
-	return results, nil
+	response := &storage.RawResponse{
+		InquiryID: inquiryID,
+		Source:    source,
+		Request:   request,
+		Body:      body,
+	}
+	if err := storage.SaveRawResponse(s.db, response, s.cfg().DebugRawResponseRetention); err != nil {
+		loggerFrom(ctx).WithError(err).WithField("source", source).Error("Failed to store raw response")
+	}
 }
 
 // extractKeywords extracts meaningful keywords from a query
@@ -176,54 +465,260 @@ func (s *SearchService) extractKeywords(query string) []string {
 	return keywords
 }
 
+// ExtractNamedEntities detects likely proper nouns in text: capitalised words
+// that don't start a sentence, hyphenated service-style names and all-caps acronyms.
+// Entities are returned in order of first appearance without duplicates.
+func (s *SearchService) ExtractNamedEntities(text string) []string {
+	text = slackMarkupPattern.ReplaceAllString(text, " ")
+
+	var entities []string
+	seen := make(map[string]bool)
+	for _, sentence := range sentenceBoundaryPattern.Split(text, -1) {
+		for i, word := range strings.Fields(sentence) {
+			token := strings.Trim(word, ".,!?;:()[]{}\"'*_~`")
+			token = strings.TrimSuffix(token, "'s")
+			if token == "" || commonCapitalisedWords[strings.ToLower(token)] {
+				continue
+			}
+
+			if !isNamedEntity(token, i == 0) || seen[strings.ToLower(token)] {
+				continue
+			}
+
+			seen[strings.ToLower(token)] = true
+			entities = append(entities, token)
+		}
+	}
+
+	return entities
+}
+
+// isNamedEntity reports whether token looks like a name. Plain capitalised words
+// only count when they don't start a sentence.
+func isNamedEntity(token string, sentenceStart bool) bool {
+	switch {
+	case acronymPattern.MatchString(token):
+		return true
+	case hyphenatedNamePattern.MatchString(token):
+		// Exclude dates and numeric ranges such as 2024-01-31
+		return strings.IndexFunc(token, unicode.IsLetter) >= 0
+	case camelCasePattern.MatchString(token):
+		return true
+	default:
+		return !sentenceStart && properNounPattern.MatchString(token)
+	}
+}
+
+// mergeSearchTerms appends entities missing from keywords, compared case-insensitively
+func mergeSearchTerms(keywords, entities []string) []string {
+	seen := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		seen[strings.ToLower(keyword)] = true
+	}
+
+	merged := keywords
+	for _, entity := range entities {
+		if !seen[strings.ToLower(entity)] {
+			seen[strings.ToLower(entity)] = true
+			merged = append(merged, entity)
+		}
+	}
+
+	return merged
+}
+
+// normalizeContent lowercases text and strips stop words and punctuation so it
+// can be scored and queried without re-extracting keywords
+func (s *SearchService) normalizeContent(text string) string {
+	return strings.Join(s.extractKeywords(text), " ")
+}
+
 // calculateRelevanceScore calculates a simple relevance score
 func (s *SearchService) calculateRelevanceScore(content, query string) float64 {
-	content = strings.ToLower(content)
-	query = strings.ToLower(query)
+	return s.scoreNormalizedContent(s.normalizeContent(content), s.extractKeywords(query))
+}
 
+// scoreNormalizedContent scores normalized content by the fraction of keywords it contains
+func (s *SearchService) scoreNormalizedContent(normalized string, keywords []string) float64 {
+	groups := make([][]string, len(keywords))
+	for i, keyword := range keywords {
+		groups[i] = []string{keyword}
+	}
+	return scoreKeywordGroups(normalized, groups)
+}
+
+// scoreKeywordGroups scores normalized content by the fraction of keyword
+// groups it contains, a group matching when any of its terms appears
+func scoreKeywordGroups(normalized string, groups [][]string) float64 {
 	// Simple scoring based on keyword matches
-	keywords := s.extractKeywords(query)
 	score := 0.0
 
-	for _, keyword := range keywords {
-		if strings.Contains(content, keyword) {
-			score += 1.0
+	for _, group := range groups {
+		for _, keyword := range group {
+			if strings.Contains(normalized, strings.ToLower(keyword)) {
+				score += 1.0
+				break
+			}
 		}
 	}
 
-	// Normalize by number of keywords
-	if len(keywords) > 0 {
-		score = score / float64(len(keywords))
+	// Normalize by number of keyword groups
+	if len(groups) > 0 {
+		score = score / float64(len(groups))
 	}
 
 	return score
 }
 
-// filterAndRankResults filters and ranks search results
-func (s *SearchService) filterAndRankResults(results []storage.SearchResult) []storage.SearchResult {
-	// Filter by minimum score
+// filterAndRankResults filters and ranks search results. When query is set, each
+// result is first scored in place against its normalized content; otherwise
+// existing scores are used as they are. Manual overrides skip scoring and
+// filtering and are returned first.
+func (s *SearchService) filterAndRankResults(results []storage.SearchResult, query, channelID string) []storage.SearchResult {
+	ranked, _ := s.rankResults(context.Background(), results, query, channelID)
+	return ranked
+}
+
+// rankResults is filterAndRankResults that also explains how every candidate
+// was scored and whether it was kept
+func (s *SearchService) rankResults(ctx context.Context, results []storage.SearchResult, query, channelID string) ([]storage.SearchResult, *SearchExplanation) {
+	if query != "" {
+		groups := s.keywordGroups(strings.Fields(query))
+		for i := range results {
+			if results[i].ManualOverride {
+				continue
+			}
+			if results[i].NormalizedContent == "" {
+				results[i].NormalizedContent = s.normalizeContent(results[i].Title + " " + results[i].Content)
+			}
+			results[i].Score = scoreKeywordGroups(results[i].NormalizedContent, groups)
+		}
+	}
+
+	explanation := &SearchExplanation{
+		Query:      query,
+		Candidates: make([]CandidateExplanation, len(results)),
+	}
+
+	// Manual overrides are always kept, ahead of the ranked results
+	var overrides, automated []storage.SearchResult
+	var automatedIdx []int
+	for i, result := range results {
+		explanation.Candidates[i] = newCandidateExplanation(result)
+		if result.ManualOverride {
+			explanation.Candidates[i].Selected = true
+			overrides = append(overrides, result)
+		} else {
+			automated = append(automated, result)
+			automatedIdx = append(automatedIdx, i)
+		}
+	}
+
+	threshold := s.AdjustThresholdDynamically(automated)
+	if threshold < s.cfg().SimilarityThreshold {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"threshold":     threshold,
+			"configured":    s.cfg().SimilarityThreshold,
+			"min_results":   s.cfg().MinResultCount,
+			"total_results": len(automated),
+		}).Warn("Too few results passed the similarity threshold, lowered it")
+	}
+	explanation.Threshold = threshold
+
+	// Filter by minimum score; filteredIdx tracks each result's candidate
 	var filtered []storage.SearchResult
-	for _, result := range results {
-		if result.Score >= s.config.SimilarityThreshold {
+	var filteredIdx []int
+	for i, result := range automated {
+		if result.Score >= threshold {
+			explanation.Candidates[automatedIdx[i]].PassedThreshold = true
 			filtered = append(filtered, result)
+			filteredIdx = append(filteredIdx, automatedIdx[i])
 		}
 	}
 
+	explanation.recordBoost("channel_relevance", filtered, filteredIdx, func() {
+		s.PrioritiseByChannelRelevance(filtered, channelID)
+	})
+	explanation.recordBoost("feedback", filtered, filteredIdx, func() {
+		s.applyFeedbackBoost(ctx, filtered)
+	})
+	explanation.recordBoost("source_weight", filtered, filteredIdx, func() {
+		s.applySourceWeights(ctx, filtered)
+	})
+
 	// Sort by score (highest first)
 	for i := 0; i < len(filtered)-1; i++ {
 		for j := i + 1; j < len(filtered); j++ {
 			if filtered[i].Score < filtered[j].Score {
 				filtered[i], filtered[j] = filtered[j], filtered[i]
+				filteredIdx[i], filteredIdx[j] = filteredIdx[j], filteredIdx[i]
 			}
 		}
 	}
 
+	if s.cfg().SemanticDedupEnabled && s.embedder != nil {
+		kept := s.semanticallyDistinct(ctx, filtered, s.cfg().SemanticDedupThreshold, s.cfg().MaxSearchResults)
+		distinct := make([]storage.SearchResult, len(kept))
+		distinctIdx := make([]int, len(kept))
+		for i, k := range kept {
+			distinct[i] = filtered[k]
+			distinctIdx[i] = filteredIdx[k]
+		}
+		filtered, filteredIdx = distinct, distinctIdx
+	}
+
 	// Limit results
-	if len(filtered) > s.config.MaxSearchResults {
-		filtered = filtered[:s.config.MaxSearchResults]
+	if len(filtered) > s.cfg().MaxSearchResults {
+		filtered = filtered[:s.cfg().MaxSearchResults]
 	}
+	for i := range filtered {
+		explanation.Candidates[filteredIdx[i]].Selected = true
+	}
+
+	return append(overrides, filtered...), explanation
+}
+
+// AdjustThresholdDynamically returns the similarity threshold to filter results
+// with: SimilarityThreshold, lowered by ThresholdDecayStep at a time while fewer
+// than MinResultCount results reach it, but never below MinThreshold
+func (s *SearchService) AdjustThresholdDynamically(results []storage.SearchResult) float64 {
+	threshold := s.cfg().SimilarityThreshold
+	if s.cfg().MinResultCount <= 0 || s.cfg().ThresholdDecayStep <= 0 {
+		return threshold
+	}
+
+	for threshold > s.cfg().MinThreshold && countAtLeast(results, threshold) < s.cfg().MinResultCount {
+		// Rounded so repeated steps of 0.1 land on 0.5, 0.4... rather than just below them
+		threshold = math.Round((threshold-s.cfg().ThresholdDecayStep)*1e9) / 1e9
+		threshold = math.Max(threshold, s.cfg().MinThreshold)
+	}
+
+	return threshold
+}
 
-	return filtered
+// countAtLeast counts the results scoring at least threshold
+func countAtLeast(results []storage.SearchResult, threshold float64) int {
+	count := 0
+	for _, result := range results {
+		if result.Score >= threshold {
+			count++
+		}
+	}
+	return count
+}
+
+// PrioritiseByChannelRelevance boosts the score of Slack results posted in
+// channelID, the inquiry's channel, by ChannelRelevanceBoost
+func (s *SearchService) PrioritiseByChannelRelevance(results []storage.SearchResult, channelID string) {
+	if channelID == "" || s.cfg().ChannelRelevanceBoost == 0 {
+		return
+	}
+
+	for i := range results {
+		if results[i].Source == "slack" && results[i].ChannelID == channelID {
+			results[i].Score += s.cfg().ChannelRelevanceBoost
+		}
+	}
 }
 
 // buildSlackMessageURL builds a URL to a Slack message
@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"golang.org/x/sync/errgroup"
+)
+
+// SearchConfluenceBySpace searches every configured Confluence space with its
+// own CQL query, concurrently, so that Confluence's relevance ranking across a
+// combined query can't crowd one space's pages out of the result limit. Each
+// result is scored against the query and annotated with its space; a page
+// found in more than one query is kept once, with its best score. It fails if
+// any space's search fails.
+func (s *SearchService) SearchConfluenceBySpace(ctx context.Context, query string, inquiryID uint) ([]storage.SearchResult, error) {
+	spaceKeys := s.confluence.spaceKeys()
+	groups := s.keywordGroups(strings.Fields(query))
+
+	perSpace := make([][]storage.SearchResult, len(spaceKeys))
+	group, groupCtx := errgroup.WithContext(ctx)
+	for i, spaceKey := range spaceKeys {
+		group.Go(func() error {
+			pages, raw, err := s.confluence.searchSpaceRaw(groupCtx, query, spaceKey)
+			if err != nil {
+				return fmt.Errorf("failed to search space %s: %w", spaceKey, err)
+			}
+			s.recordRawResponse(groupCtx, inquiryID, "confluence", query, raw)
+
+			cql := s.confluence.buildSpaceCQL(query, []string{spaceKey})
+			results := s.pageResults(groupCtx, pages, inquiryID, cql)
+			for j := range results {
+				if results[j].SpaceKey == "" {
+					results[j].SpaceKey = spaceKey
+				}
+				results[j].Score = scoreKeywordGroups(results[j].NormalizedContent, groups)
+			}
+			perSpace[i] = results
+			return nil
+		})
+	}
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return mergeSpaceResults(perSpace), nil
+}
+
+// mergeSpaceResults concatenates per-space results in space order, keeping
+// only the best-scoring result for each page
+func mergeSpaceResults(perSpace [][]storage.SearchResult) []storage.SearchResult {
+	var merged []storage.SearchResult
+	index := make(map[string]int)
+	for _, results := range perSpace {
+		for _, result := range results {
+			i, seen := index[result.SourceID]
+			if !seen {
+				index[result.SourceID] = len(merged)
+				merged = append(merged, result)
+				continue
+			}
+			if result.Score > merged[i].Score {
+				merged[i] = result
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// spacePages are the pages the fake Confluence holds, by space
+var spacePages = map[string][]string{
+	"DOCS": {"D1", "D2", "SHARED"},
+	"ENG":  {"E1", "E2"},
+	"OPS":  {"O1", "SHARED"},
+}
+
+// newSpaceSearchService returns a search service over a fake Confluence
+// holding spacePages. Like Confluence's relevance ranking, a combined query
+// over several spaces favours the first one: it returns that space's pages
+// before any other's, up to the result limit.
+func newSpaceSearchService(tb testing.TB, cfg *config.Config) *SearchService {
+	tb.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cql := r.URL.Query().Get("cql")
+		spaces := []string{strings.TrimPrefix(strings.Fields(cql)[0], "space=")}
+		if strings.HasPrefix(cql, "space in (") {
+			spaces = strings.Split(cql[len("space in ("):strings.Index(cql, ")")], ",")
+		}
+
+		type page struct {
+			ID    string              `json:"id"`
+			Title string              `json:"title"`
+			Space ConfluencePageSpace `json:"space"`
+		}
+		var results []page
+		for _, space := range spaces {
+			for _, id := range spacePages[space] {
+				results = append(results, page{ID: id, Title: "Deploy guide " + id, Space: ConfluencePageSpace{Key: space}})
+			}
+		}
+		if len(results) > cfg.MaxSearchResults {
+			results = results[:cfg.MaxSearchResults]
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"results": results, "size": len(results)})
+	}))
+	tb.Cleanup(server.Close)
+
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	cfg.ConfluenceAPIVersion = ConfluenceDC8
+	cfg.ConfluenceTimeout = time.Minute
+	cfg.ConfluenceSpaceKeys = []string{"DOCS", "ENG", "OPS"}
+	cfg.MaxSearchResults = 3
+
+	return NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(tb), cfg)
+}
+
+func TestSearchConfluenceBySpace(t *testing.T) {
+	service := newSpaceSearchService(t, config.LoadTestConfig())
+
+	combined, _, err := service.confluence.SearchPagesRaw(context.Background(), "deploy")
+	if err != nil {
+		t.Fatalf("SearchPagesRaw returned error: %v", err)
+	}
+	results, err := service.SearchConfluenceBySpace(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("SearchConfluenceBySpace returned error: %v", err)
+	}
+
+	if len(results) <= len(combined) {
+		t.Errorf("Expected more results than the combined query's %d, got %d", len(combined), len(results))
+	}
+
+	spaces := make(map[string]int)
+	ids := make(map[string]bool)
+	for _, result := range results {
+		if ids[result.SourceID] {
+			t.Errorf("Expected page %s once, got it again", result.SourceID)
+		}
+		ids[result.SourceID] = true
+		spaces[result.SpaceKey]++
+		if result.Score <= 0 {
+			t.Errorf("Expected page %s to be scored, got %v", result.SourceID, result.Score)
+		}
+	}
+	if spaces["DOCS"] != 3 || spaces["ENG"] != 2 || spaces["OPS"] != 1 {
+		t.Errorf("Expected results from every space, got %v", spaces)
+	}
+}
+
+func TestSearchConfluenceBySpace_CombinedQuery(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := newSpaceSearchService(t, cfg)
+
+	if cql := service.confluence.buildCQL("deploy"); !strings.HasPrefix(cql, "space in (DOCS,ENG,OPS) AND ") {
+		t.Errorf("Expected a combined query over every space, got %q", cql)
+	}
+
+	results, err := service.searchConfluence(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("searchConfluence returned error: %v", err)
+	}
+	if len(results) != cfg.MaxSearchResults {
+		t.Errorf("Expected the combined query without per-space search, got %d results", len(results))
+	}
+
+	cfg.ConfluencePerSpaceSearch = true
+	results, err = service.searchConfluence(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("searchConfluence returned error: %v", err)
+	}
+	if len(results) != 6 {
+		t.Errorf("Expected every space to be queried, got %d results", len(results))
+	}
+}
+
+func BenchmarkSearchConfluenceBySpace(b *testing.B) {
+	service := newSpaceSearchService(b, config.LoadTestConfig())
+	ctx := context.Background()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := service.SearchConfluenceBySpace(ctx, "deploy", 1); err != nil {
+			b.Fatalf("SearchConfluenceBySpace returned error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm/clause"
+)
+
+// QueryHash identifies queries that search for the same terms: the keywords
+// and named entities are lowercased, deduplicated and sorted before hashing,
+// so word order, stop words and punctuation don't matter
+func (s *SearchService) QueryHash(query string) string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, term := range mergeSearchTerms(s.extractKeywords(query), s.ExtractNamedEntities(query)) {
+		term = strings.ToLower(term)
+		if !seen[term] {
+			seen[term] = true
+			terms = append(terms, term)
+		}
+	}
+	sort.Strings(terms)
+
+	sum := sha256.Sum256([]byte(strings.Join(terms, " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// CacheSearchResults caches the unranked results of searching for the inquiry's
+// text for SearchCacheTTL, replacing any previous entry for the same query
+func (s *SearchService) CacheSearchResults(ctx context.Context, inquiryID uint, results []storage.SearchResult) error {
+	var inquiry storage.Inquiry
+	if err := s.db.Select("message_text").First(&inquiry, inquiryID).Error; err != nil {
+		return fmt.Errorf("failed to load inquiry: %w", err)
+	}
+
+	// Cached results belong to no inquiry until they are reused
+	cached := make([]storage.SearchResult, len(results))
+	for i, result := range results {
+		result.ID = 0
+		result.InquiryID = 0
+		result.CreatedAt = time.Time{}
+		result.UpdatedAt = time.Time{}
+		cached[i] = result
+	}
+
+	encoded, err := json.Marshal(cached)
+	if err != nil {
+		return fmt.Errorf("failed to encode search results: %w", err)
+	}
+
+	now := time.Now()
+	entry := &storage.SearchCache{
+		QueryHash: s.QueryHash(inquiry.MessageText),
+		Results:   encoded,
+		ExpiresAt: now.Add(s.cfg().SearchCacheTTL),
+	}
+	if err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "query_hash"}},
+		DoUpdates: clause.AssignmentColumns([]string{"results", "expires_at"}),
+	}).Create(entry).Error; err != nil {
+		return fmt.Errorf("failed to save search cache: %w", err)
+	}
+
+	// Prune entries nobody will read again
+	if err := s.db.Where("expires_at <= ?", now).Delete(&storage.SearchCache{}).Error; err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to prune expired search cache entries")
+	}
+
+	return nil
+}
+
+// InvalidateCachedResults drops the cached results for query so the next
+// search for it calls the external APIs again
+func (s *SearchService) InvalidateCachedResults(query string) error {
+	return s.db.Where("query_hash = ?", s.QueryHash(query)).Delete(&storage.SearchCache{}).Error
+}
+
+// GetCachedResults returns the cached results for a query hash from QueryHash,
+// reporting false when there is no entry or it has expired
+func (s *SearchService) GetCachedResults(ctx context.Context, hash string) ([]storage.SearchResult, bool) {
+	if s.cfg().SearchCacheTTL <= 0 {
+		return nil, false
+	}
+
+	var entry storage.SearchCache
+	err := s.db.Where("query_hash = ? AND expires_at > ?", hash, time.Now()).Limit(1).Find(&entry).Error
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to read search cache")
+		return nil, false
+	}
+	if entry.ID == 0 {
+		return nil, false
+	}
+
+	var results []storage.SearchResult
+	if err := json.Unmarshal(entry.Results, &results); err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to decode cached search results")
+		return nil, false
+	}
+
+	return results, true
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+// newCachingSearchService returns a search service over a fake Slack with one matching message
+func newCachingSearchService(t *testing.T) (*SearchService, *fakeSlack, *gorm.DB) {
+	t.Helper()
+
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "text": "Deploy the payment service with the CLI", "channel": {"id": "C1"}}
+	]}}`)
+	db := setupTestDB(t)
+
+	return NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg), fake, db
+}
+
+// createInquiry stores an inquiry for text and returns its ID
+func createInquiry(t *testing.T, db *gorm.DB, messageID, text string) uint {
+	t.Helper()
+
+	inquiry := &storage.Inquiry{MessageID: messageID, ChannelID: "C1", MessageText: text, Status: "pending"}
+	if err := db.Create(inquiry).Error; err != nil {
+		t.Fatalf("Failed to create inquiry: %v", err)
+	}
+	return inquiry.ID
+}
+
+func TestQueryHash(t *testing.T) {
+	service := &SearchService{config: config.LoadTestConfig()}
+
+	same := service.QueryHash("How do I deploy the Payment service?")
+	if service.QueryHash("payment service deploy") != same {
+		t.Error("Expected reworded query with the same terms to hash identically")
+	}
+	if service.QueryHash("How do I roll back the payment service?") == same {
+		t.Error("Expected queries with different terms to hash differently")
+	}
+}
+
+func TestSearchAll_CacheHit(t *testing.T) {
+	service, fake, db := newCachingSearchService(t)
+
+	first := createInquiry(t, db, "1.1", "How do I deploy the payment service?")
+	if _, _, err := service.SearchAll(context.Background(), "How do I deploy the payment service?", first, ""); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+
+	second := createInquiry(t, db, "2.2", "payment service deploy?")
+	results, _, err := service.SearchAll(context.Background(), "payment service deploy?", second, "")
+	if err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+
+	if calls := len(fake.callsTo("search.messages")); calls != 1 {
+		t.Errorf("Expected Slack to be searched once, got %d", calls)
+	}
+	if len(results) != 1 || results[0].InquiryID != second {
+		t.Fatalf("Expected cached result for the second inquiry, got %+v", results)
+	}
+
+	var stored int64
+	db.Model(&storage.SearchResult{}).Where("inquiry_id = ?", second).Count(&stored)
+	if stored != 1 {
+		t.Errorf("Expected cached result to be saved for the second inquiry, got %d", stored)
+	}
+}
+
+func TestSearchAll_CacheMiss(t *testing.T) {
+	service, fake, db := newCachingSearchService(t)
+
+	first := createInquiry(t, db, "1.1", "How do I deploy the payment service?")
+	if _, _, err := service.SearchAll(context.Background(), "How do I deploy the payment service?", first, ""); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	second := createInquiry(t, db, "2.2", "How do I roll back the payment service?")
+	if _, _, err := service.SearchAll(context.Background(), "How do I roll back the payment service?", second, ""); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+
+	if calls := len(fake.callsTo("search.messages")); calls != 2 {
+		t.Errorf("Expected Slack to be searched for each distinct query, got %d", calls)
+	}
+	if _, hit := service.GetCachedResults(context.Background(), service.QueryHash("unrelated question")); hit {
+		t.Error("Expected no cache entry for an unseen query")
+	}
+}
+
+func TestSearchAll_CacheExpiry(t *testing.T) {
+	service, fake, db := newCachingSearchService(t)
+
+	query := "How do I deploy the payment service?"
+	first := createInquiry(t, db, "1.1", query)
+	if _, _, err := service.SearchAll(context.Background(), query, first, ""); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	if _, hit := service.GetCachedResults(context.Background(), service.QueryHash(query)); !hit {
+		t.Fatal("Expected fresh cache entry")
+	}
+
+	db.Model(&storage.SearchCache{}).Where("1 = 1").Update("expires_at", time.Now().Add(-time.Minute))
+	if _, hit := service.GetCachedResults(context.Background(), service.QueryHash(query)); hit {
+		t.Error("Expected expired cache entry to be ignored")
+	}
+
+	second := createInquiry(t, db, "2.2", query)
+	if _, _, err := service.SearchAll(context.Background(), query, second, ""); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	if calls := len(fake.callsTo("search.messages")); calls != 2 {
+		t.Errorf("Expected Slack to be searched again after expiry, got %d", calls)
+	}
+	if _, hit := service.GetCachedResults(context.Background(), service.QueryHash(query)); !hit {
+		t.Error("Expected cache entry to be refreshed after expiry")
+	}
+}
+
+func TestReprocessInquiry_BypassesCache(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+	inquiry, _ := service.GetInquiryByMessageID("1.1")
+	if err := service.ReprocessInquiry(context.Background(), inquiry.ID); err != nil {
+		t.Fatalf("ReprocessInquiry returned error: %v", err)
+	}
+
+	if calls := len(fake.callsTo("search.messages")); calls != 2 {
+		t.Errorf("Expected reprocessing to search Slack again, got %d searches", calls)
+	}
+}
@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// lowDiversityThreshold is the diversity under which the results are considered
+// to come primarily from one source
+const lowDiversityThreshold = 0.3
+
+// ComputeSearchResultDiversity scores how evenly results spread over their
+// sources: the Shannon entropy of the source distribution normalised by its
+// maximum, from 0 when every result comes from one source to 1 when each
+// source contributes as many results as the others
+func (s *InquiryService) ComputeSearchResultDiversity(results []storage.SearchResult) float64 {
+	return searchResultDiversity(results)
+}
+
+// searchResultDiversity is ComputeSearchResultDiversity
+func searchResultDiversity(results []storage.SearchResult) float64 {
+	counts := sourceCounts(results)
+	if len(counts) < 2 {
+		return 0
+	}
+
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / float64(len(results))
+		entropy -= p * math.Log(p)
+	}
+	return entropy / math.Log(float64(len(counts)))
+}
+
+// dominantSource is the source most results come from, the first in
+// alphabetical order on a tie, or "" without results
+func dominantSource(results []storage.SearchResult) string {
+	counts := sourceCounts(results)
+	sources := make([]string, 0, len(counts))
+	for source := range counts {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	var dominant string
+	for _, source := range sources {
+		if dominant == "" || counts[source] > counts[dominant] {
+			dominant = source
+		}
+	}
+	return dominant
+}
+
+// sourceCounts counts the results from each source
+func sourceCounts(results []storage.SearchResult) map[string]int {
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Source]++
+	}
+	return counts
+}
+
+// lowDiversityNote tells the model its context mostly comes from one source,
+// or is "" when the results are diverse enough
+func lowDiversityNote(results []storage.SearchResult) string {
+	if len(results) == 0 || searchResultDiversity(results) >= lowDiversityThreshold {
+		return ""
+	}
+	return fmt.Sprintf("Note: context is primarily from %s.", dominantSource(results))
+}
+
+// recordResultDiversity observes the diversity of an inquiry's search results,
+// warning when they come primarily from one source
+func (s *InquiryService) recordResultDiversity(ctx context.Context, inquiry *storage.Inquiry, results []storage.SearchResult) {
+	if len(results) == 0 {
+		return
+	}
+
+	diversity := s.ComputeSearchResultDiversity(results)
+	s.metrics.Histogram("search.result_diversity", diversity, nil)
+	if diversity < lowDiversityThreshold {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id": inquiry.ID,
+			"diversity":  diversity,
+			"source":     dominantSource(results),
+			"results":    len(results),
+		}).Warn("Search results come primarily from one source")
+	}
+}
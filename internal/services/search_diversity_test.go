@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// resultsFrom returns one result per source given, in order
+func resultsFrom(sources ...string) []storage.SearchResult {
+	results := make([]storage.SearchResult, len(sources))
+	for i, source := range sources {
+		results[i] = storage.SearchResult{Source: source, Title: source, Content: "content", Score: 0.5}
+	}
+	return results
+}
+
+func TestComputeSearchResultDiversity(t *testing.T) {
+	service := newTestInquiryService(config.LoadTestConfig(), nil)
+
+	tests := []struct {
+		name     string
+		results  []storage.SearchResult
+		expected float64
+	}{
+		{name: "no results", results: nil, expected: 0},
+		{name: "single source", results: resultsFrom("slack", "slack", "slack"), expected: 0},
+		{name: "balanced two sources", results: resultsFrom("slack", "confluence", "slack", "confluence"), expected: 1},
+		{name: "balanced three sources", results: resultsFrom("slack", "confluence", "github"), expected: 1},
+		// -(0.75 ln 0.75 + 0.25 ln 0.25) / ln 2
+		{name: "skewed", results: resultsFrom("slack", "slack", "slack", "confluence"), expected: 0.8113},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := service.ComputeSearchResultDiversity(tt.results); math.Abs(got-tt.expected) > 0.0001 {
+				t.Errorf("Expected diversity %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLowDiversityNote(t *testing.T) {
+	mostlyConfluence := resultsFrom("slack")
+	for i := 0; i < 19; i++ {
+		mostlyConfluence = append(mostlyConfluence, resultsFrom("confluence")...)
+	}
+
+	tests := []struct {
+		name     string
+		results  []storage.SearchResult
+		expected string
+	}{
+		{name: "single source", results: resultsFrom("slack", "slack"), expected: "Note: context is primarily from slack."},
+		{name: "mostly one source", results: mostlyConfluence, expected: "Note: context is primarily from confluence."},
+		{name: "balanced", results: resultsFrom("slack", "confluence"), expected: ""},
+		{name: "no results", results: nil, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lowDiversityNote(tt.results); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestBuildContext_LowDiversityNote(t *testing.T) {
+	service := NewLLMService(config.LoadTestConfig())
+	inquiry := &storage.Inquiry{MessageText: "How do I deploy?"}
+
+	narrow := service.buildContext(context.Background(), inquiry, resultsFrom("confluence", "confluence"))
+	if !strings.Contains(narrow, "Note: context is primarily from confluence.") {
+		t.Errorf("Expected a note on single-source context, got %q", narrow)
+	}
+
+	balanced := service.buildContext(context.Background(), inquiry, resultsFrom("slack", "confluence"))
+	if strings.Contains(balanced, "primarily from") {
+		t.Errorf("Expected no note on balanced context, got %q", balanced)
+	}
+}
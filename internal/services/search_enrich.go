@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/slack-go/slack"
+)
+
+// resolveAuthors replaces the user IDs Slack results are authored by with the
+// users' real names, looking up to SLACK_ENRICH_CONCURRENCY users at once and
+// each user once. Lookups stop when the search runs out of time or Slack rate
+// limits them, leaving the remaining authors as user IDs.
+func (s *SearchService) resolveAuthors(ctx context.Context, results []storage.SearchResult) {
+	var userIDs []string
+	seen := make(map[string]bool)
+	for _, result := range results {
+		if result.Author != "" && !seen[result.Author] {
+			seen[result.Author] = true
+			userIDs = append(userIDs, result.Author)
+		}
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	names := make([]string, len(userIDs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(s.cfg().EnrichConcurrency, len(userIDs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				user, err := s.slack.GetUserInfo(userIDs[i])
+				var rateLimited *slack.RateLimitedError
+				if errors.As(err, &rateLimited) {
+					loggerFrom(ctx).WithError(err).Warn("Rate limited looking up Slack authors, keeping user IDs")
+					cancel()
+					continue
+				}
+				if err == nil && user.RealName != "" {
+					names[i] = user.RealName
+				}
+			}
+		}()
+	}
+	for i := range userIDs {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	resolved := make(map[string]string, len(userIDs))
+	for i, userID := range userIDs {
+		if names[i] != "" {
+			resolved[userID] = names[i]
+		}
+	}
+	for i := range results {
+		if name, ok := resolved[results[i].Author]; ok {
+			results[i].Author = name
+		}
+	}
+}
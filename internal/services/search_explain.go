@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// SearchExplanation records how every candidate result of a search was scored
+// and whether it was kept, for tuning the ranking. Keywords are the keywords
+// and named entities extracted from the inquiry, which Query expands with
+// their synonyms. Sources records which sources failed to answer.
+type SearchExplanation struct {
+	InquiryID  uint                   `json:"inquiry_id"`
+	Query      string                 `json:"query"`
+	Keywords   []string               `json:"keywords,omitempty"`
+	Sources    SourceStatus           `json:"sources"`
+	Threshold  float64                `json:"threshold"`
+	Candidates []CandidateExplanation `json:"candidates"`
+}
+
+// CandidateExplanation describes the ranking of one candidate result
+type CandidateExplanation struct {
+	Source   string `json:"source"`
+	SourceID string `json:"source_id"`
+	Title    string `json:"title"`
+	// SearchQuery is the query sent to the source that returned the candidate
+	SearchQuery string `json:"search_query,omitempty"`
+
+	// BaseScore is the keyword relevance score; FinalScore adds the boosts,
+	// keyed by name, applied to candidates that passed the threshold
+	BaseScore  float64            `json:"base_score"`
+	Boosts     map[string]float64 `json:"boosts,omitempty"`
+	FinalScore float64            `json:"final_score"`
+
+	ManualOverride  bool `json:"manual_override"`
+	PassedThreshold bool `json:"passed_threshold"`
+	// Selected reports whether the candidate was among the results passed on
+	// to build the answer context
+	Selected bool `json:"selected"`
+}
+
+// newCandidateExplanation starts the explanation of a scored result
+func newCandidateExplanation(result storage.SearchResult) CandidateExplanation {
+	return CandidateExplanation{
+		Source:         result.Source,
+		SourceID:       result.SourceID,
+		Title:          result.Title,
+		SearchQuery:    result.SearchQuery,
+		BaseScore:      result.Score,
+		FinalScore:     result.Score,
+		ManualOverride: result.ManualOverride,
+	}
+}
+
+// recordBoost runs boost over results, whose candidates are at the given
+// indexes, and records the score change it made to each as the named boost
+func (e *SearchExplanation) recordBoost(name string, results []storage.SearchResult, idx []int, boost func()) {
+	before := make([]float64, len(results))
+	for i, result := range results {
+		before[i] = result.Score
+	}
+
+	boost()
+
+	for i, result := range results {
+		delta := result.Score - before[i]
+		if delta == 0 {
+			continue
+		}
+		candidate := &e.Candidates[idx[i]]
+		if candidate.Boosts == nil {
+			candidate.Boosts = make(map[string]float64)
+		}
+		candidate.Boosts[name] = delta
+		candidate.FinalScore = result.Score
+	}
+}
+
+// recordExplanation logs an explanation at debug level and stores it with the
+// raw responses when DEBUG_STORE_SEARCH_EXPLANATIONS is set
+func (s *SearchService) recordExplanation(ctx context.Context, explanation *SearchExplanation) {
+	if logrus.IsLevelEnabled(logrus.DebugLevel) {
+		loggerFrom(ctx).WithFields(logrus.Fields{
+			"inquiry_id":   explanation.InquiryID,
+			"search_query": explanation.Query,
+			"keywords":     explanation.Keywords,
+		}).Debug("Search keywords")
+		for _, candidate := range explanation.Candidates {
+			loggerFrom(ctx).WithFields(logrus.Fields{
+				"inquiry_id":       explanation.InquiryID,
+				"source":           candidate.Source,
+				"source_id":        candidate.SourceID,
+				"base_score":       candidate.BaseScore,
+				"boosts":           candidate.Boosts,
+				"final_score":      candidate.FinalScore,
+				"threshold":        explanation.Threshold,
+				"manual_override":  candidate.ManualOverride,
+				"passed_threshold": candidate.PassedThreshold,
+				"selected":         candidate.Selected,
+			}).Debug("Search candidate")
+		}
+	}
+
+	if !s.cfg().DebugStoreSearchExplanations {
+		return
+	}
+
+	body, err := json.Marshal(explanation)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to encode search explanation")
+		return
+	}
+	response := &storage.RawResponse{
+		InquiryID: explanation.InquiryID,
+		Source:    "explanation",
+		Request:   explanation.Query,
+		Body:      string(body),
+	}
+	if err := storage.SaveRawResponse(s.db, response, s.cfg().DebugRawResponseRetention); err != nil {
+		loggerFrom(ctx).WithError(err).Error("Failed to store search explanation")
+	}
+}
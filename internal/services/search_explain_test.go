@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestRankResults_ExplainsBoosts(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0.5
+	cfg.ChannelRelevanceBoost = 0.2
+	cfg.FeedbackReranking = true
+	cfg.FeedbackBoost = 0.2
+	cfg.MaxSearchResults = 2
+	db := setupTestDB(t)
+	service := &SearchService{db: db, config: cfg}
+
+	// P1 was part of a helpful answer
+	inquiry := &storage.Inquiry{MessageID: "1", Status: "completed"}
+	db.Create(inquiry)
+	db.Create(&storage.SearchResult{InquiryID: inquiry.ID, Source: "confluence", SourceID: "P1", Score: 0.9})
+	db.Create(&storage.Feedback{InquiryID: inquiry.ID, UserID: "U1", Helpful: true})
+
+	_, explanation := service.rankResults(context.Background(), []storage.SearchResult{
+		{Source: "slack", SourceID: "S1", ChannelID: "C1", Score: 0.6},
+		{Source: "confluence", SourceID: "P1", Score: 0.7},
+		{Source: "slack", SourceID: "S2", ChannelID: "C2", Score: 0.55},
+		{Source: "slack", SourceID: "S3", ChannelID: "C1", Score: 0.2},
+		{Source: "confluence", SourceID: "P9", ManualOverride: true},
+	}, "", "C1")
+
+	if len(explanation.Candidates) != 5 || explanation.Threshold != 0.5 {
+		t.Fatalf("Expected every candidate explained against the threshold, got %+v", explanation)
+	}
+
+	expected := []struct {
+		boosts   map[string]float64
+		passed   bool
+		selected bool
+	}{
+		{boosts: map[string]float64{"channel_relevance": 0.2}, passed: true, selected: true},
+		{boosts: map[string]float64{"feedback": feedbackBoost(1, 0, 0.2)}, passed: true, selected: true},
+		// Passed but ranked out by MaxSearchResults
+		{passed: true},
+		// Below the threshold, so the channel boost never applies
+		{},
+		{passed: false, selected: true},
+	}
+	for i, want := range expected {
+		candidate := explanation.Candidates[i]
+		if candidate.PassedThreshold != want.passed || candidate.Selected != want.selected {
+			t.Errorf("Candidate %s: expected passed=%v selected=%v, got %+v", candidate.SourceID, want.passed, want.selected, candidate)
+		}
+		if len(candidate.Boosts) != len(want.boosts) {
+			t.Errorf("Candidate %s: expected boosts %v, got %v", candidate.SourceID, want.boosts, candidate.Boosts)
+		}
+		total := candidate.BaseScore
+		for name, amount := range want.boosts {
+			if math.Abs(candidate.Boosts[name]-amount) > 1e-9 {
+				t.Errorf("Candidate %s: expected %s boost %f, got %f", candidate.SourceID, name, amount, candidate.Boosts[name])
+			}
+			total += amount
+		}
+		if math.Abs(candidate.FinalScore-total) > 1e-9 {
+			t.Errorf("Candidate %s: expected final score %f, got %f", candidate.SourceID, total, candidate.FinalScore)
+		}
+	}
+	if !explanation.Candidates[4].ManualOverride {
+		t.Error("Expected the manual override to be marked")
+	}
+}
+
+func TestRecordExplanation(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		cfg := config.LoadTestConfig()
+		cfg.DebugStoreSearchExplanations = enabled
+		db := setupTestDB(t)
+		service := &SearchService{db: db, config: cfg}
+
+		service.recordExplanation(context.Background(), &SearchExplanation{
+			InquiryID:  3,
+			Query:      "deploy",
+			Candidates: []CandidateExplanation{{SourceID: "P1", Boosts: map[string]float64{"feedback": 0.1}}},
+		})
+
+		var stored []storage.RawResponse
+		db.Find(&stored)
+		if !enabled {
+			if len(stored) != 0 {
+				t.Errorf("Expected nothing stored when disabled, got %d", len(stored))
+			}
+			continue
+		}
+		if len(stored) != 1 || stored[0].InquiryID != 3 || stored[0].Source != "explanation" {
+			t.Fatalf("Expected one stored explanation, got %+v", stored)
+		}
+		var decoded SearchExplanation
+		if err := json.Unmarshal([]byte(stored[0].Body), &decoded); err != nil || decoded.Candidates[0].Boosts["feedback"] != 0.1 {
+			t.Errorf("Expected the explanation to round-trip, got %+v (%v)", decoded, err)
+		}
+	}
+}
+
+func TestProcessInquiry_StoresSearchKeywords(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run make deploy")
+	db := setupTestDB(t)
+	service := newTestInquiryService(cfg, db)
+
+	question := "How do I deploy the payment-service to Kubernetes?"
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", question, "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	var inquiry storage.Inquiry
+	if err := db.Where("message_id = ?", "1.1").First(&inquiry).Error; err != nil {
+		t.Fatalf("Failed to load inquiry: %v", err)
+	}
+
+	keywords := service.search.searchTerms(question)
+	if !strings.Contains(strings.Join(keywords, " "), "deploy") {
+		t.Fatalf("Expected deploy among the extracted keywords, got %v", keywords)
+	}
+	if inquiry.SearchKeywords != strings.Join(keywords, ",") {
+		t.Errorf("Expected stored keywords %v, got %q", keywords, inquiry.SearchKeywords)
+	}
+	if inquiry.SearchQuery != service.search.searchQuery(question) {
+		t.Errorf("Expected stored query %q, got %q", service.search.searchQuery(question), inquiry.SearchQuery)
+	}
+}
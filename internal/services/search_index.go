@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// slackIndexCandidates caps the indexed messages matching a keyword that are
+// scored for a search, most recent first
+const slackIndexCandidates = 500
+
+// likeEscaper escapes the LIKE wildcards in a keyword
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// IndexSlackChannel backfills the local Slack search index with the top-level
+// messages posted in channelID over the last SEARCH_DAYS_BACK days. Messages
+// already indexed are updated, so indexing a channel again picks up new and
+// edited messages.
+func (s *SearchService) IndexSlackChannel(ctx context.Context, channelID string) error {
+	since := time.Now().AddDate(0, 0, -s.cfg().SearchDaysBack)
+	messages, err := s.slack.ListRecentMessages(channelID, since)
+	if err != nil {
+		return fmt.Errorf("failed to read channel history: %w", err)
+	}
+
+	entries := make([]storage.SlackMessageIndex, 0, len(messages))
+	for _, msg := range messages {
+		if strings.TrimSpace(msg.Text) == "" {
+			continue
+		}
+		entries = append(entries, storage.SlackMessageIndex{
+			ChannelID: channelID,
+			MessageTS: msg.Timestamp,
+			UserID:    msg.User,
+			Text:      msg.Text,
+		})
+	}
+	if err := storage.UpsertSlackMessages(s.db.WithContext(ctx), entries); err != nil {
+		return fmt.Errorf("failed to index messages: %w", err)
+	}
+
+	loggerFrom(ctx).WithFields(logrus.Fields{
+		"channel_id": channelID,
+		"messages":   len(entries),
+	}).Info("Indexed Slack channel history")
+	return nil
+}
+
+// IndexSlackMessage adds a message posted after its channel was backfilled to
+// the local Slack search index, so the index doesn't go stale. Messages in
+// channels that were never backfilled are left to the Slack search API.
+func (s *SearchService) IndexSlackMessage(ctx context.Context, msg SlackMessage) error {
+	if !s.cfg().SlackSearchIndex || strings.TrimSpace(msg.Text) == "" {
+		return nil
+	}
+	indexed, err := s.slackChannelIndexed(ctx, msg.Channel)
+	if err != nil || !indexed {
+		return err
+	}
+
+	entry := storage.SlackMessageIndex{
+		ChannelID: msg.Channel,
+		MessageTS: msg.Timestamp,
+		UserID:    msg.User,
+		Text:      msg.Text,
+	}
+	if err := storage.UpsertSlackMessages(s.db.WithContext(ctx), []storage.SlackMessageIndex{entry}); err != nil {
+		return fmt.Errorf("failed to index message: %w", err)
+	}
+	return nil
+}
+
+// UnindexSlackMessage removes a deleted message from the local Slack search index
+func (s *SearchService) UnindexSlackMessage(ctx context.Context, channelID, ts string) error {
+	if err := s.db.WithContext(ctx).Where("channel_id = ? AND message_ts = ?", channelID, ts).Delete(&storage.SlackMessageIndex{}).Error; err != nil {
+		return fmt.Errorf("failed to remove message from index: %w", err)
+	}
+	return nil
+}
+
+// slackChannelIndexed reports whether any of channelID's messages are indexed
+func (s *SearchService) slackChannelIndexed(ctx context.Context, channelID string) (bool, error) {
+	if channelID == "" {
+		return false, nil
+	}
+
+	var indexed int64
+	if err := s.db.WithContext(ctx).Model(&storage.SlackMessageIndex{}).
+		Where("channel_id = ?", channelID).
+		Limit(1).Count(&indexed).Error; err != nil {
+		return false, fmt.Errorf("failed to check Slack search index: %w", err)
+	}
+	return indexed > 0, nil
+}
+
+// searchSlackIndex finds the indexed Slack messages from the last
+// SEARCH_DAYS_BACK days best matching query's keywords in SLACK_CHANNEL_ID.
+// It reports whether that channel is indexed at all; when it isn't, or no
+// channel is set and the whole workspace is searched, which the index can't
+// cover, the Slack search API has to be used instead.
+func (s *SearchService) searchSlackIndex(ctx context.Context, query string) ([]SlackMessage, bool, error) {
+	db := s.db.WithContext(ctx)
+	channelID := s.cfg().SlackChannelID
+	indexed, err := s.slackChannelIndexed(ctx, channelID)
+	if err != nil {
+		return nil, false, err
+	}
+	keywords := s.extractKeywords(query)
+	if !indexed || len(keywords) == 0 {
+		return nil, indexed, nil
+	}
+
+	conditions := make([]string, 0, len(keywords))
+	args := make([]interface{}, 0, len(keywords))
+	for _, keyword := range keywords {
+		conditions = append(conditions, `LOWER(text) LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+likeEscaper.Replace(keyword)+"%")
+	}
+	since := time.Now().AddDate(0, 0, -s.cfg().SearchDaysBack)
+
+	var candidates []storage.SlackMessageIndex
+	if err := db.Where("CAST(message_ts AS REAL) >= ?", since.Unix()).
+		Where("channel_id = ?", channelID).
+		Where(strings.Join(conditions, " OR "), args...).
+		Order("CAST(message_ts AS REAL) DESC").
+		Limit(slackIndexCandidates).
+		Find(&candidates).Error; err != nil {
+		return nil, true, fmt.Errorf("failed to search Slack index: %w", err)
+	}
+
+	groups := s.keywordGroups(keywords)
+	scores := make([]float64, len(candidates))
+	for i, candidate := range candidates {
+		scores[i] = scoreKeywordGroups(s.normalizeContent(candidate.Text), groups)
+	}
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return scores[order[i]] > scores[order[j]] })
+	if len(order) > s.cfg().MaxSearchResults {
+		order = order[:s.cfg().MaxSearchResults]
+	}
+
+	messages := make([]SlackMessage, 0, len(order))
+	for _, i := range order {
+		candidate := candidates[i]
+		messages = append(messages, SlackMessage{
+			ID:        candidate.MessageTS,
+			Channel:   candidate.ChannelID,
+			User:      candidate.UserID,
+			Text:      candidate.Text,
+			Timestamp: candidate.MessageTS,
+		})
+	}
+	return messages, true, nil
+}
+
+// IndexSlackChannel backfills the local Slack search index with channelID's history
+func (s *InquiryService) IndexSlackChannel(ctx context.Context, channelID string) error {
+	return s.search.IndexSlackChannel(ctx, channelID)
+}
+
+// IndexSlackMessage adds a newly posted message to the local Slack search index
+func (s *InquiryService) IndexSlackMessage(ctx context.Context, msg SlackMessage) error {
+	return s.search.IndexSlackMessage(ctx, msg)
+}
+
+// UnindexSlackMessage removes a deleted message from the local Slack search index
+func (s *InquiryService) UnindexSlackMessage(ctx context.Context, channelID, ts string) error {
+	return s.search.UnindexSlackMessage(ctx, channelID, ts)
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestIndexSlackChannel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchIndex = true
+	cfg.SlackChannelID = "C1"
+	fake := newFakeSlack(t, cfg)
+	recent := time.Now().Add(-time.Hour).Unix()
+	fake.respond("conversations.history", fmt.Sprintf(`{"ok": true, "has_more": false, "messages": [
+		{"type": "message", "user": "U1", "text": "Deploy the payment service with make deploy", "ts": "%[1]d.000300"},
+		{"type": "message", "user": "U2", "text": "Lunch at noon?", "ts": "%[1]d.000200"},
+		{"type": "message", "user": "U3", "text": "", "ts": "%[1]d.000100"}
+	]}`, recent))
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	if err := service.IndexSlackChannel(context.Background(), "C1"); err != nil {
+		t.Fatalf("IndexSlackChannel returned error: %v", err)
+	}
+	var indexed []storage.SlackMessageIndex
+	db.Order("message_ts").Find(&indexed)
+	if len(indexed) != 2 || indexed[0].ChannelID != "C1" || indexed[0].UserID != "U2" {
+		t.Fatalf("Expected the 2 messages with text to be indexed, got %+v", indexed)
+	}
+	if calls := fake.callsTo("conversations.history"); len(calls) != 1 || calls[0].Get("channel") != "C1" {
+		t.Errorf("Expected the channel history to be read, got %v", calls)
+	}
+
+	// Indexing again doesn't duplicate messages
+	if err := service.IndexSlackChannel(context.Background(), "C1"); err != nil {
+		t.Fatalf("IndexSlackChannel returned error: %v", err)
+	}
+	var count int64
+	db.Model(&storage.SlackMessageIndex{}).Count(&count)
+	if count != 2 {
+		t.Errorf("Expected reindexing to keep 2 messages, got %d", count)
+	}
+
+	results, err := service.searchSlack(context.Background(), "deploy payment", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Source != "slack" || results[0].ChannelID != "C1" || results[0].Content != "Deploy the payment service with make deploy" {
+		t.Errorf("Expected the matching indexed message, got %+v", results)
+	}
+	if calls := fake.callsTo("search.messages"); len(calls) != 0 {
+		t.Errorf("Expected the Slack search API not to be called, got %d calls", len(calls))
+	}
+}
+
+func TestSearchSlackIndex_Filters(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxSearchResults = 2
+	cfg.SearchDaysBack = 7
+	cfg.SlackChannelID = "C1"
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	ts := func(age time.Duration, seq int) string {
+		return fmt.Sprintf("%d.%06d", time.Now().Add(-age).Unix(), seq)
+	}
+	if err := storage.UpsertSlackMessages(db, []storage.SlackMessageIndex{
+		{ChannelID: "C1", MessageTS: ts(time.Hour, 1), Text: "deploy"},
+		{ChannelID: "C1", MessageTS: ts(2*time.Hour, 2), Text: "deploy the payment service"},
+		{ChannelID: "C1", MessageTS: ts(3*time.Hour, 3), Text: "payment refunds"},
+		{ChannelID: "C1", MessageTS: ts(30*24*time.Hour, 4), Text: "deploy the payment service, old thread"},
+		{ChannelID: "C1", MessageTS: ts(time.Hour, 5), Text: "100% unrelated"},
+	}); err != nil {
+		t.Fatalf("UpsertSlackMessages returned error: %v", err)
+	}
+
+	messages, indexed, err := service.searchSlackIndex(context.Background(), "deploy payment")
+	if err != nil || !indexed {
+		t.Fatalf("Expected a search of the index, got indexed=%v err=%v", indexed, err)
+	}
+	// Best-matching first, capped at MAX_SEARCH_RESULTS, leaving out messages older than SEARCH_DAYS_BACK
+	if len(messages) != 2 || messages[0].Text != "deploy the payment service" || messages[1].Text != "deploy" {
+		t.Errorf("Unexpected messages %+v", messages)
+	}
+
+	// LIKE wildcards in the query match literally, so 1_0% doesn't match 100%
+	if messages, _, _ := service.searchSlackIndex(context.Background(), "1_0%"); len(messages) != 0 {
+		t.Errorf("Expected wildcards to match literally, got %+v", messages)
+	}
+}
+
+func TestSearchSlack_EmptyIndexUsesAPI(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchIndex = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [{"channel": {"id": "C1"}, "user": "U1", "text": "deploy with make", "ts": "1.1"}]}}`)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	results, err := service.searchSlack(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+	if len(results) != 1 || len(fake.callsTo("search.messages")) != 1 {
+		t.Errorf("Expected the Slack search API to be used while nothing is indexed, got %+v", results)
+	}
+}
+
+func TestSearchSlack_IndexesMessagesNewerThanBackfill(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchIndex = true
+	cfg.SlackChannelID = "C1"
+	fake := newFakeSlack(t, cfg)
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+	ctx := context.Background()
+
+	backfilled := fmt.Sprintf("%d.000100", time.Now().Add(-24*time.Hour).Unix())
+	if err := storage.UpsertSlackMessages(db, []storage.SlackMessageIndex{{ChannelID: "C1", MessageTS: backfilled, Text: "lunch plans"}}); err != nil {
+		t.Fatalf("UpsertSlackMessages returned error: %v", err)
+	}
+
+	posted := fmt.Sprintf("%d.000100", time.Now().Unix())
+	for _, msg := range []SlackMessage{
+		{Channel: "C1", User: "U1", Text: "Deploy the payment service with make deploy", Timestamp: posted},
+		// C2 was never backfilled, so the Slack search API covers it
+		{Channel: "C2", User: "U1", Text: "Deploy the payment service", Timestamp: posted},
+	} {
+		if err := service.IndexSlackMessage(ctx, msg); err != nil {
+			t.Fatalf("IndexSlackMessage returned error: %v", err)
+		}
+	}
+
+	results, err := service.searchSlack(ctx, "deploy payment", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].SourceID != posted || results[0].ChannelID != "C1" {
+		t.Errorf("Expected the message posted after the backfill, got %+v", results)
+	}
+	if calls := fake.callsTo("search.messages"); len(calls) != 0 {
+		t.Errorf("Expected the Slack search API not to be called, got %d calls", len(calls))
+	}
+	var unindexed int64
+	db.Model(&storage.SlackMessageIndex{}).Where("channel_id = ?", "C2").Count(&unindexed)
+	if unindexed != 0 {
+		t.Errorf("Expected messages in channels never backfilled not to be indexed, got %d", unindexed)
+	}
+
+	if err := service.UnindexSlackMessage(ctx, "C1", posted); err != nil {
+		t.Fatalf("UnindexSlackMessage returned error: %v", err)
+	}
+	if results, _ := service.searchSlack(ctx, "deploy payment", 1); len(results) != 0 {
+		t.Errorf("Expected a deleted message to leave the index, got %+v", results)
+	}
+}
+
+func TestSearchSlack_WorkspaceWideUsesAPI(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchIndex = true
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [{"channel": {"id": "C2"}, "user": "U1", "text": "deploy with make", "ts": "1.1"}]}}`)
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	// Only C1 was backfilled, so the index can't answer for the whole workspace
+	recent := fmt.Sprintf("%d.000100", time.Now().Unix())
+	if err := storage.UpsertSlackMessages(db, []storage.SlackMessageIndex{{ChannelID: "C1", MessageTS: recent, Text: "deploy with make"}}); err != nil {
+		t.Fatalf("UpsertSlackMessages returned error: %v", err)
+	}
+
+	results, err := service.searchSlack(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].ChannelID != "C2" || len(fake.callsTo("search.messages")) != 1 {
+		t.Errorf("Expected the Slack search API to cover the channels that aren't indexed, got %+v", results)
+	}
+}
+
+func TestSearchSlack_UnindexedChannelUsesAPI(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchIndex = true
+	cfg.SlackChannelID = "C2"
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [{"channel": {"id": "C2"}, "user": "U1", "text": "deploy with make", "ts": "1.1"}]}}`)
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	recent := fmt.Sprintf("%d.000100", time.Now().Unix())
+	if err := storage.UpsertSlackMessages(db, []storage.SlackMessageIndex{{ChannelID: "C1", MessageTS: recent, Text: "deploy with make"}}); err != nil {
+		t.Fatalf("UpsertSlackMessages returned error: %v", err)
+	}
+
+	results, err := service.searchSlack(context.Background(), "deploy", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].ChannelID != "C2" || len(fake.callsTo("search.messages")) != 1 {
+		t.Errorf("Expected the Slack search API to cover the channel that isn't indexed, got %+v", results)
+	}
+}
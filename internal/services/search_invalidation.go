@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// InvalidateConfluencePage marks every answer built on the page as stale, so
+// the stale reprocessing job regenerates it, and drops cached searches that
+// returned the page. It reports how many search results were marked.
+func (s *SearchService) InvalidateConfluencePage(pageID string) (int64, error) {
+	marked, err := storage.MarkSearchResultsStale(s.db, "confluence", pageID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark search results stale: %w", err)
+	}
+
+	var entries []storage.SearchCache
+	if err := s.db.Find(&entries).Error; err != nil {
+		return marked, fmt.Errorf("failed to load search cache: %w", err)
+	}
+	for _, entry := range entries {
+		var results []storage.SearchResult
+		if err := json.Unmarshal(entry.Results, &results); err != nil {
+			continue
+		}
+		for _, result := range results {
+			if result.Source == "confluence" && result.SourceID == pageID {
+				if err := s.db.Delete(&entry).Error; err != nil {
+					return marked, fmt.Errorf("failed to drop cached search: %w", err)
+				}
+				break
+			}
+		}
+	}
+
+	return marked, nil
+}
+
+// CheckPageVersions compares the version of every Confluence page behind a
+// current answer with the page's latest version and invalidates the answers
+// and cached searches built on pages edited since
+func (s *SearchService) CheckPageVersions(ctx context.Context) error {
+	var pages []struct {
+		SourceID      string
+		SourceVersion int
+	}
+	if err := s.db.Model(&storage.SearchResult{}).
+		Select("source_id, MAX(source_version) AS source_version").
+		Where("source = ? AND stale = ? AND source_version > 0", "confluence", false).
+		Group("source_id").
+		Scan(&pages).Error; err != nil {
+		return fmt.Errorf("failed to load answered pages: %w", err)
+	}
+
+	for _, page := range pages {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		current, err := s.confluence.GetPageVersion(page.SourceID)
+		if err != nil {
+			logrus.WithError(err).WithField("page_id", page.SourceID).Warn("Failed to check Confluence page version")
+			continue
+		}
+		if current <= page.SourceVersion {
+			continue
+		}
+
+		marked, err := s.InvalidateConfluencePage(page.SourceID)
+		if err != nil {
+			return err
+		}
+		logrus.WithFields(logrus.Fields{
+			"page_id":     page.SourceID,
+			"old_version": page.SourceVersion,
+			"new_version": current,
+			"marked":      marked,
+		}).Info("Confluence page changed, invalidated answers built on it")
+	}
+
+	return nil
+}
+
+// RunPageVersionCheckLoop periodically checks answered Confluence pages for edits until ctx is cancelled
+func (s *SearchService) RunPageVersionCheckLoop(ctx context.Context) {
+	runPeriodically(ctx, "page_version_check", s.cfg().PageVersionCheckInterval, s.CheckPageVersions)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+// newVersionedSearchService serves the given current page versions from a fake Confluence
+func newVersionedSearchService(t *testing.T, versions map[string]int) (*SearchService, *gorm.DB) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageID := r.URL.Path[len("/rest/api/content/"):]
+		version, ok := versions[pageID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = fmt.Fprintf(w, `{"id": %q, "version": {"number": %d}}`, pageID, version)
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	db := setupTestDB(t)
+
+	return NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg), db
+}
+
+func TestGetPageVersion(t *testing.T) {
+	service, _ := newVersionedSearchService(t, map[string]int{"P1": 7})
+
+	version, err := service.confluence.GetPageVersion("P1")
+	if err != nil || version != 7 {
+		t.Errorf("Expected version 7, got %d (%v)", version, err)
+	}
+	if _, err := service.confluence.GetPageVersion("missing"); err == nil {
+		t.Error("Expected error for a missing page")
+	}
+}
+
+func TestCheckPageVersions_InvalidatesEditedPages(t *testing.T) {
+	service, db := newVersionedSearchService(t, map[string]int{"P1": 4, "P2": 2})
+
+	edited := createInquiry(t, db, "1", "How do I deploy?")
+	unchanged := createInquiry(t, db, "2", "How do I roll back?")
+	db.Create(&storage.SearchResult{InquiryID: edited, Source: "confluence", SourceID: "P1", SourceVersion: 3})
+	db.Create(&storage.SearchResult{InquiryID: unchanged, Source: "confluence", SourceID: "P2", SourceVersion: 2})
+
+	if err := service.CacheSearchResults(context.Background(), edited, []storage.SearchResult{{Source: "confluence", SourceID: "P1", SourceVersion: 3}}); err != nil {
+		t.Fatalf("CacheSearchResults returned error: %v", err)
+	}
+	if err := service.CacheSearchResults(context.Background(), unchanged, []storage.SearchResult{{Source: "confluence", SourceID: "P2", SourceVersion: 2}}); err != nil {
+		t.Fatalf("CacheSearchResults returned error: %v", err)
+	}
+
+	if err := service.CheckPageVersions(context.Background()); err != nil {
+		t.Fatalf("CheckPageVersions returned error: %v", err)
+	}
+
+	var stale []storage.SearchResult
+	db.Where("stale = ?", true).Find(&stale)
+	if len(stale) != 1 || stale[0].SourceID != "P1" {
+		t.Errorf("Expected only the edited page's result to be stale, got %+v", stale)
+	}
+	if _, ok := service.GetCachedResults(context.Background(), service.QueryHash("How do I deploy?")); ok {
+		t.Error("Expected the cached search returning the edited page to be dropped")
+	}
+	if _, ok := service.GetCachedResults(context.Background(), service.QueryHash("How do I roll back?")); !ok {
+		t.Error("Expected the cached search for the unchanged page to survive")
+	}
+}
+
+func TestCheckPageVersions_SkipsUnversionedAndUnreachablePages(t *testing.T) {
+	service, db := newVersionedSearchService(t, map[string]int{"P1": 9})
+
+	inquiry := createInquiry(t, db, "1", "How do I deploy?")
+	// Fetched before versions were recorded
+	db.Create(&storage.SearchResult{InquiryID: inquiry, Source: "confluence", SourceID: "P1"})
+	db.Create(&storage.SearchResult{InquiryID: inquiry, Source: "confluence", SourceID: "deleted", SourceVersion: 1})
+
+	if err := service.CheckPageVersions(context.Background()); err != nil {
+		t.Fatalf("CheckPageVersions returned error: %v", err)
+	}
+
+	var stale int64
+	db.Model(&storage.SearchResult{}).Where("stale = ?", true).Count(&stale)
+	if stale != 0 {
+		t.Errorf("Expected no results to be marked stale, got %d", stale)
+	}
+}
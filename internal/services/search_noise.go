@@ -0,0 +1,44 @@
+package services
+
+import (
+	"regexp"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+// slackNoise drops bot messages, reminders and notifications from Slack
+// search results so that human discussion is what gets scored
+type slackNoise struct {
+	userIDs  map[string]bool
+	patterns []*regexp.Regexp
+}
+
+// newSlackNoise compiles the noise filters configured in cfg. Invalid patterns
+// are rejected by Config.Validate, so here they only disable the patterns.
+func newSlackNoise(cfg *config.Config) slackNoise {
+	noise := slackNoise{userIDs: make(map[string]bool, len(cfg.SlackNoiseUserIDs))}
+	for _, userID := range cfg.SlackNoiseUserIDs {
+		noise.userIDs[userID] = true
+	}
+	patterns, err := cfg.SlackNoiseRegexps()
+	if err != nil {
+		logrus.WithError(err).Warn("Invalid SLACK_NOISE_PATTERNS, filtering Slack results by user only")
+		return noise
+	}
+	noise.patterns = patterns
+	return noise
+}
+
+// matches reports whether msg is noise
+func (n slackNoise) matches(msg SlackMessage) bool {
+	if n.userIDs[msg.User] {
+		return true
+	}
+	for _, pattern := range n.patterns {
+		if pattern.MatchString(msg.Text) {
+			return true
+		}
+	}
+	return false
+}
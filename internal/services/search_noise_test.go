@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestSearchSlack_DropsNoise(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackNoiseUserIDs = []string{"UCIBOT"}
+	cfg.SlackNoisePatterns = []string{`^Reminder:`, `(?i)build #\d+ (passed|failed)`}
+
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "user": "UCIBOT", "text": "Deployed payment service to production", "channel": {"id": "C1"}},
+		{"ts": "1.2", "user": "U1", "text": "Reminder: deploy freeze starts Friday", "channel": {"id": "C1"}},
+		{"ts": "1.3", "user": "U2", "text": "Build #42 FAILED on deploy-payment", "channel": {"id": "C1"}},
+		{"ts": "1.4", "user": "U3", "text": "You deploy the payment service with the CLI", "channel": {"id": "C1"}}
+	]}}`)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	results, err := service.searchSlack(context.Background(), "deploy payment service", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].SourceID != "1.4" {
+		t.Errorf("Expected only the genuine message to be kept, got %+v", results)
+	}
+}
+
+func TestSearchSlack_KeepsEverythingWithoutNoiseFilters(t *testing.T) {
+	cfg := config.LoadTestConfig()
+
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "user": "UCIBOT", "text": "Deployed payment service to production", "channel": {"id": "C1"}},
+		{"ts": "1.2", "user": "U1", "text": "Reminder: deploy freeze starts Friday", "channel": {"id": "C1"}}
+	]}}`)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	results, err := service.searchSlack(context.Background(), "deploy payment service", 1)
+	if err != nil {
+		t.Fatalf("searchSlack returned error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Errorf("Expected both messages to be kept, got %d", len(results))
+	}
+}
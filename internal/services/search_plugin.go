@@ -0,0 +1,36 @@
+package services
+
+import (
+	"context"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// SearchPlugin is a search source besides Slack and Confluence. Registered
+// plugins are searched in parallel with them, and their results are scored,
+// ranked and cached alongside theirs.
+type SearchPlugin interface {
+	// Name is the Source of the plugin's results, also accepted by --source
+	Name() string
+	// Enabled reports whether the plugin is configured to search
+	Enabled() bool
+	// Search returns the plugin's matches for query, unscored
+	Search(ctx context.Context, query string, inquiryID uint) ([]storage.SearchResult, error)
+}
+
+// RegisterPlugin adds plugin to the sources searched by SearchAll. Plugins are
+// registered at startup, before the first search.
+func (s *SearchService) RegisterPlugin(plugin SearchPlugin) {
+	s.plugins = append(s.plugins, plugin)
+}
+
+// activePlugins returns the enabled plugins that searches made with ctx include
+func (s *SearchService) activePlugins(ctx context.Context) []SearchPlugin {
+	var active []SearchPlugin
+	for _, plugin := range s.plugins {
+		if plugin.Enabled() && searchesSource(ctx, plugin.Name()) {
+			active = append(active, plugin)
+		}
+	}
+	return active
+}
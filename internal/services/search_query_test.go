@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestSearchAll_RecordsSearchQuery(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackChannelID = "C1"
+	service := newTimeoutSearchService(t, cfg, 0, 0)
+
+	_, explanation, err := service.SearchAllExplained(context.Background(), "deploy payment service", 1, "")
+	if err != nil {
+		t.Fatalf("SearchAllExplained returned error: %v", err)
+	}
+
+	var stored []storage.SearchResult
+	if err := service.db.Where("inquiry_id = ?", 1).Find(&stored).Error; err != nil {
+		t.Fatalf("Failed to load search results: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Fatalf("Expected a Slack and a Confluence result, got %d", len(stored))
+	}
+
+	expected := map[string]string{
+		"slack":      "deploy payment service in:C1 after:",
+		"confluence": `space=DOCS AND text ~ "deploy payment service"`,
+	}
+	for _, result := range stored {
+		if result.SearchQuery == "" {
+			t.Errorf("Expected the %s result to record its query", result.Source)
+		} else if !strings.HasPrefix(result.SearchQuery, expected[result.Source]) {
+			t.Errorf("Expected the %s query to start with %q, got %q", result.Source, expected[result.Source], result.SearchQuery)
+		}
+	}
+
+	for _, candidate := range explanation.Candidates {
+		if candidate.SearchQuery == "" {
+			t.Errorf("Expected the explanation of %s %s to include its query", candidate.Source, candidate.SourceID)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// searchRecentPages returns the Confluence pages modified in the last
+// RecentPagesDaysBack days that aren't among seen, scored RecentPageScore.
+// They supplement the main search as "what's new" context, so a failure to
+// fetch them is only logged.
+func (s *SearchService) searchRecentPages(ctx context.Context, inquiryID uint, seen []storage.SearchResult) []storage.SearchResult {
+	recentCtx, cancel := context.WithTimeout(ctx, s.cfg().ConfluenceSearchTimeout)
+	defer cancel()
+
+	pages, err := s.confluence.searchRecent(recentCtx, s.cfg().RecentPagesDaysBack)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to fetch recently modified Confluence pages")
+		return nil
+	}
+
+	found := make(map[string]bool, len(seen))
+	for _, result := range seen {
+		if result.Source == "confluence" {
+			found[result.SourceID] = true
+		}
+	}
+
+	var results []storage.SearchResult
+	for _, page := range pages {
+		if found[page.ID] {
+			continue
+		}
+		results = append(results, storage.SearchResult{
+			InquiryID:         inquiryID,
+			Source:            "confluence",
+			SourceID:          page.ID,
+			Title:             page.Title,
+			Content:           page.Content,
+			NormalizedContent: s.normalizeContent(page.Title + " " + page.Content),
+			URL:               page.URL,
+			Author:            page.Author,
+			SourceVersion:     page.Version.Number,
+			Score:             s.cfg().RecentPageScore,
+			CreatedDate:       time.Now(),
+		})
+	}
+
+	return results
+}
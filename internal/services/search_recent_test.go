@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newRecentConfluence serves P1 for keyword searches, and P1 and P2 as the
+// recently modified pages, recording every CQL query it receives
+func newRecentConfluence(t *testing.T) (*config.Config, *[]string) {
+	t.Helper()
+
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rest/api/content/search" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		cql := r.URL.Query().Get("cql")
+		queries = append(queries, cql+" limit="+r.URL.Query().Get("limit"))
+		if strings.Contains(cql, "lastModified") {
+			_, _ = w.Write([]byte(`{"results": [
+				{"id": "P2", "title": "Release notes", "content": "<p>New build pipeline</p>"},
+				{"id": "P1", "title": "Deploying", "content": "<p>Run make deploy</p>"}
+			]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"results": [{"id": "P1", "title": "Deploying", "content": "<p>Run make deploy</p>"}]}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	return cfg, &queries
+}
+
+func TestSearchRecent(t *testing.T) {
+	cfg, queries := newRecentConfluence(t)
+
+	pages, err := NewConfluenceService(cfg).SearchRecent(14)
+	if err != nil {
+		t.Fatalf("SearchRecent returned error: %v", err)
+	}
+
+	if len(pages) != 2 || pages[0].ID != "P2" {
+		t.Errorf("Expected the recent pages in order, got %+v", pages)
+	}
+	expected := `space=DOCS AND lastModified >= now("-14d") ORDER BY lastModified DESC limit=5`
+	if len(*queries) != 1 || (*queries)[0] != expected {
+		t.Errorf("Expected query %q, got %q", expected, *queries)
+	}
+}
+
+func TestSearchAll_IncludesRecentPages(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		cfg, _ := newRecentConfluence(t)
+		cfg.IncludeRecentPages = enabled
+		service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+		results, _, err := service.SearchAll(context.Background(), "deploy", 1, "C1")
+		if err != nil {
+			t.Fatalf("SearchAll returned error: %v", err)
+		}
+
+		scores := make(map[string][]float64)
+		for _, result := range results {
+			scores[result.SourceID] = append(scores[result.SourceID], result.Score)
+		}
+		if len(scores["P1"]) != 1 {
+			t.Errorf("enabled=%v: expected the matching page exactly once, got %v", enabled, scores)
+		}
+		if enabled && (len(scores["P2"]) != 1 || scores["P2"][0] != 0.6) {
+			t.Errorf("Expected the recent page with the fixed score, got %v", scores)
+		}
+		if !enabled && len(scores["P2"]) != 0 {
+			t.Errorf("Expected no recent pages when disabled, got %v", scores)
+		}
+	}
+}
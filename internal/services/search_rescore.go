@@ -0,0 +1,17 @@
+package services
+
+import (
+	"context"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// RescoreStored reranks an inquiry's stored results with the current scoring
+// configuration, without searching Slack or Confluence again. Each result's
+// base score is recomputed in place; the returned results are the ones that
+// would now be used, best first, with boosts applied.
+func (s *SearchService) RescoreStored(ctx context.Context, inquiry *storage.Inquiry, results []storage.SearchResult) ([]storage.SearchResult, *SearchExplanation) {
+	ranked, explanation := s.rankResults(ctx, results, s.searchQuery(inquiry.MessageText), inquiry.ChannelID)
+	explanation.InquiryID = inquiry.ID
+	return ranked, explanation
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestRescoreStored(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+	inquiry := &storage.Inquiry{ChannelID: "C1", MessageText: "How do I deploy the payment service?"}
+
+	stored := func() []storage.SearchResult {
+		return []storage.SearchResult{
+			{Source: "confluence", SourceID: "P1", Title: "Deploying the payment service", Score: 0.1},
+			{Source: "slack", SourceID: "1.1", Content: "payment service alerts", Score: 0.9},
+			{Source: "slack", SourceID: "2.2", Content: "lunch plans", Score: 0.8},
+		}
+	}
+
+	results := stored()
+	ranked, explanation := service.RescoreStored(context.Background(), inquiry, results)
+	if len(ranked) != 1 || ranked[0].SourceID != "P1" {
+		t.Errorf("Expected only the page matching every keyword to rank, got %+v", ranked)
+	}
+	if results[2].Score != 0 {
+		t.Errorf("Expected stored scores to be recomputed in place, got %v", results[2].Score)
+	}
+	if len(explanation.Candidates) != 3 || explanation.Threshold != cfg.SimilarityThreshold {
+		t.Errorf("Unexpected explanation %+v", explanation)
+	}
+
+	// A lower threshold in the current config lets the partial match through
+	cfg.SimilarityThreshold = 0.5
+	ranked, _ = service.RescoreStored(context.Background(), inquiry, stored())
+	if len(ranked) != 2 || ranked[1].SourceID != "1.1" {
+		t.Errorf("Expected the partial match to rank second under the new threshold, got %+v", ranked)
+	}
+}
+
+func TestRescoreInquiry_Persist(t *testing.T) {
+	for _, persist := range []bool{false, true} {
+		cfg := config.LoadTestConfig()
+		db := setupTestDB(t)
+		service := newTestInquiryService(cfg, db)
+
+		inquiry := &storage.Inquiry{MessageID: "1.1", MessageText: "How do I deploy?"}
+		db.Create(inquiry)
+		result := &storage.SearchResult{InquiryID: inquiry.ID, Source: "slack", SourceID: "1.1", Content: "lunch plans", Score: 0.9}
+		db.Create(result)
+
+		if _, _, err := service.RescoreInquiry(context.Background(), inquiry.ID, persist); err != nil {
+			t.Fatalf("RescoreInquiry returned error: %v", err)
+		}
+
+		var reloaded storage.SearchResult
+		db.First(&reloaded, result.ID)
+		if persist && reloaded.Score != 0 {
+			t.Errorf("Expected the recomputed score to be persisted, got %v", reloaded.Score)
+		}
+		if !persist && reloaded.Score != 0.9 {
+			t.Errorf("Expected the stored score to be untouched, got %v", reloaded.Score)
+		}
+	}
+}
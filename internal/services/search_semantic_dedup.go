@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"math"
+	"sort"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// Embedder turns texts into vectors whose cosine similarity to one another
+// tells how close in meaning the texts are; LLMService is one. A nil vector
+// marks a text that could not be embedded.
+type Embedder interface {
+	GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// embeddingCacheLimit caps the result embeddings kept between searches; the
+// cache is emptied once it is reached
+const embeddingCacheLimit = 10000
+
+// cachedEmbedding is a result's embedding with a hash of the text it was made from
+type cachedEmbedding struct {
+	textHash  uint64
+	embedding []float64
+}
+
+// SetEmbedder makes the service use e to find semantically duplicate results
+func (s *SearchService) SetEmbedder(e Embedder) {
+	s.embedder = e
+}
+
+// FilterDuplicatesBySemanticSimilarity greedily selects results in score
+// order, skipping any whose embedding has a cosine similarity of at least
+// threshold to an already selected result. Results that fail to embed are
+// kept. Without an embedder the results are only sorted.
+func (s *SearchService) FilterDuplicatesBySemanticSimilarity(results []storage.SearchResult, threshold float64) []storage.SearchResult {
+	sorted := append([]storage.SearchResult(nil), results...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Score > sorted[j].Score
+	})
+	if s.embedder == nil {
+		return sorted
+	}
+
+	kept := s.semanticallyDistinct(context.Background(), sorted, threshold, 0)
+	distinct := make([]storage.SearchResult, len(kept))
+	for i, k := range kept {
+		distinct[i] = sorted[k]
+	}
+	return distinct
+}
+
+// semanticallyDistinct returns the indexes of the results, already in score
+// order, that FilterDuplicatesBySemanticSimilarity selects. Results are only
+// embedded until limit are selected, or all of them with a limit of 0, in
+// batches of as many as are still needed, within SEARCH_TOTAL_TIMEOUT.
+func (s *SearchService) semanticallyDistinct(ctx context.Context, results []storage.SearchResult, threshold float64, limit int) []int {
+	if timeout := s.cfg().SearchTotalTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var kept []int
+	var selected [][]float64
+	for next := 0; next < len(results); {
+		batch := len(results) - next
+		if limit > 0 {
+			if len(kept) == limit {
+				break
+			}
+			batch = min(batch, limit-len(kept))
+		}
+		embeddings := s.embedResults(ctx, results[next:next+batch])
+
+		for j, embedding := range embeddings {
+			i := next + j
+			if embedding == nil {
+				kept = append(kept, i)
+				continue
+			}
+			if isSemanticDuplicate(embedding, selected, threshold) {
+				s.metrics.Incr("search.semantic_duplicate", map[string]string{"source": results[i].Source})
+				continue
+			}
+			kept = append(kept, i)
+			selected = append(selected, embedding)
+		}
+		next += batch
+	}
+	return kept
+}
+
+// isSemanticDuplicate reports whether embedding is at least threshold similar
+// to any of the selected embeddings
+func isSemanticDuplicate(embedding []float64, selected [][]float64, threshold float64) bool {
+	for _, other := range selected {
+		if cosineSimilarity(embedding, other) >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// embedResults returns the embedding of each result, reusing those cached by
+// source and ID and embedding the rest in one request. Results that fail to
+// embed get a nil embedding.
+func (s *SearchService) embedResults(ctx context.Context, results []storage.SearchResult) [][]float64 {
+	embeddings := make([][]float64, len(results))
+	hashes := make([]uint64, len(results))
+	var missing []int
+	var texts []string
+
+	s.embeddingMu.Lock()
+	for i, result := range results {
+		text := result.Title + " " + result.Content
+		hashes[i] = embeddingTextHash(text)
+		if cached, ok := s.embeddings[embeddingCacheKey(result)]; ok && cached.textHash == hashes[i] {
+			embeddings[i] = cached.embedding
+			continue
+		}
+		missing = append(missing, i)
+		texts = append(texts, text)
+	}
+	s.embeddingMu.Unlock()
+
+	if len(missing) == 0 {
+		return embeddings
+	}
+
+	generated, err := s.embedder.GenerateEmbeddings(ctx, texts)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("results", len(missing)).Warn("Failed to embed search results, keeping them")
+		return embeddings
+	}
+
+	s.embeddingMu.Lock()
+	defer s.embeddingMu.Unlock()
+	for j, i := range missing {
+		if j >= len(generated) || len(generated[j]) == 0 {
+			loggerFrom(ctx).WithFields(logrus.Fields{
+				"source":    results[i].Source,
+				"source_id": results[i].SourceID,
+			}).Warn("Failed to embed search result, keeping it")
+			continue
+		}
+		embeddings[i] = generated[j]
+
+		if s.embeddings == nil || len(s.embeddings) >= embeddingCacheLimit {
+			s.embeddings = make(map[string]cachedEmbedding)
+		}
+		s.embeddings[embeddingCacheKey(results[i])] = cachedEmbedding{textHash: hashes[i], embedding: generated[j]}
+	}
+	return embeddings
+}
+
+// embeddingCacheKey identifies a result in the embedding cache
+func embeddingCacheKey(result storage.SearchResult) string {
+	return result.Source + ":" + result.SourceID
+}
+
+// embeddingTextHash lets a cached embedding be told apart from one of an
+// edited result, whose source and ID stay the same
+func embeddingTextHash(text string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(text))
+	return h.Sum64()
+}
+
+// cosineSimilarity is the cosine of the angle between a and b, or 0 when
+// their lengths differ or either is all zeros
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// fakeEmbedder embeds a result by its title, failing for titles without a
+// vector, and blocks until ctx is done when block is set
+type fakeEmbedder struct {
+	vectors  map[string][]float64
+	embedded []string
+	calls    int
+	block    bool
+}
+
+func (f *fakeEmbedder) GenerateEmbeddings(ctx context.Context, texts []string) ([][]float64, error) {
+	f.calls++
+	if f.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	f.embedded = append(f.embedded, texts...)
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		for title, vector := range f.vectors {
+			if text == title+" content" {
+				embeddings[i] = vector
+			}
+		}
+	}
+	return embeddings, nil
+}
+
+// scoredResults returns one result per title, scored in descending order
+func scoredResults(titles ...string) []storage.SearchResult {
+	results := make([]storage.SearchResult, len(titles))
+	for i, title := range titles {
+		results[i] = storage.SearchResult{Source: "slack", SourceID: title, Title: title, Content: "content", Score: 0.9 - float64(i)*0.05}
+	}
+	return results
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []float64
+		expected float64
+	}{
+		{name: "identical", a: []float64{1, 2, 3}, b: []float64{1, 2, 3}, expected: 1},
+		{name: "scaled", a: []float64{1, 2}, b: []float64{2, 4}, expected: 1},
+		{name: "orthogonal", a: []float64{1, 0}, b: []float64{0, 1}, expected: 0},
+		{name: "opposite", a: []float64{1, 0}, b: []float64{-1, 0}, expected: -1},
+		{name: "different lengths", a: []float64{1, 0}, b: []float64{1, 0, 0}, expected: 0},
+		{name: "zero vector", a: []float64{0, 0}, b: []float64{1, 0}, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cosineSimilarity(tt.a, tt.b); math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilterDuplicatesBySemanticSimilarity(t *testing.T) {
+	embedder := &fakeEmbedder{vectors: map[string][]float64{
+		"deploy guide":      {1, 0, 0},
+		"deploy guide copy": {0.99, 0.1, 0},
+		"deploy thread":     {0.8, 0.6, 0},
+		"oncall rota":       {0, 0, 1},
+	}}
+	service := NewSearchService(nil, nil, setupTestDB(t), config.LoadTestConfig())
+	service.SetEmbedder(embedder)
+
+	// Out of score order, so the copy comes first
+	results := scoredResults("deploy guide", "deploy guide copy", "deploy thread", "oncall rota", "unembeddable")
+	results[0], results[1] = results[1], results[0]
+
+	tests := []struct {
+		name      string
+		threshold float64
+		expected  []string
+	}{
+		{name: "drops near copies", threshold: 0.95, expected: []string{"deploy guide", "deploy thread", "oncall rota", "unembeddable"}},
+		{name: "drops related results", threshold: 0.75, expected: []string{"deploy guide", "oncall rota", "unembeddable"}},
+		{name: "keeps distinct results", threshold: 1.01, expected: []string{"deploy guide", "deploy guide copy", "deploy thread", "oncall rota", "unembeddable"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered := service.FilterDuplicatesBySemanticSimilarity(results, tt.threshold)
+
+			if len(filtered) != len(tt.expected) {
+				t.Fatalf("Expected %d results, got %d: %+v", len(tt.expected), len(filtered), filtered)
+			}
+			for i, title := range tt.expected {
+				if filtered[i].Title != title {
+					t.Errorf("Result %d: expected %q, got %q", i, title, filtered[i].Title)
+				}
+			}
+		})
+	}
+
+	if results[0].Title != "deploy guide copy" {
+		t.Error("Expected the input results to be left in their order")
+	}
+	// One batch per filter, after the first only retrying the result that failed to embed
+	if embedder.calls != len(tests) || len(embedder.embedded) != len(results)+len(tests)-1 {
+		t.Errorf("Expected one batch per filter with embeddings cached, got %d calls for %d texts", embedder.calls, len(embedder.embedded))
+	}
+}
+
+func TestFilterDuplicatesBySemanticSimilarity_CachesEmbeddings(t *testing.T) {
+	embedder := &fakeEmbedder{vectors: map[string][]float64{
+		"deploy guide": {1, 0},
+		"oncall rota":  {0, 1},
+		"edited":       {1, 1},
+	}}
+	service := NewSearchService(nil, nil, setupTestDB(t), config.LoadTestConfig())
+	service.SetEmbedder(embedder)
+
+	service.FilterDuplicatesBySemanticSimilarity(scoredResults("deploy guide", "oncall rota"), 0.95)
+	service.FilterDuplicatesBySemanticSimilarity(scoredResults("deploy guide", "oncall rota", "release notes"), 0.95)
+
+	if embedder.calls != 2 || len(embedder.embedded) != 3 {
+		t.Errorf("Expected only the new result embedded again, got %d calls for %v", embedder.calls, embedder.embedded)
+	}
+
+	// A result whose content changed is embedded afresh
+	edited := scoredResults("deploy guide")
+	edited[0].Title = "edited"
+	service.FilterDuplicatesBySemanticSimilarity(edited, 0.95)
+	if last := embedder.embedded[len(embedder.embedded)-1]; last != "edited content" {
+		t.Errorf("Expected the edited result to be embedded again, got %q", last)
+	}
+}
+
+func TestFilterDuplicatesBySemanticSimilarity_Deadline(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SearchTotalTimeout = 50 * time.Millisecond
+	service := NewSearchService(nil, nil, setupTestDB(t), cfg)
+	service.SetEmbedder(&fakeEmbedder{block: true})
+
+	start := time.Now()
+	filtered := service.FilterDuplicatesBySemanticSimilarity(scoredResults("deploy guide", "deploy guide copy"), 0.95)
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected embedding to give up at the deadline, took %v", elapsed)
+	}
+	if len(filtered) != 2 {
+		t.Errorf("Expected results that failed to embed to be kept, got %+v", filtered)
+	}
+}
+
+func TestRankResults_SemanticDedup(t *testing.T) {
+	embedder := &fakeEmbedder{vectors: map[string][]float64{
+		"deploy guide":      {1, 0},
+		"deploy guide copy": {1, 0.01},
+		"oncall rota":       {0, 1},
+		"release notes":     {0.5, 0.5},
+	}}
+	results := scoredResults("deploy guide", "deploy guide copy", "oncall rota", "release notes")
+
+	tests := []struct {
+		name     string
+		enabled  bool
+		expected []string
+		embedded int
+	}{
+		{name: "disabled", enabled: false, expected: []string{"deploy guide", "deploy guide copy", "oncall rota"}},
+		// The copy is dropped, so the next result takes its place, and
+		// nothing past MaxSearchResults selected results is embedded
+		{name: "enabled", enabled: true, expected: []string{"deploy guide", "oncall rota", "release notes"}, embedded: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.SimilarityThreshold = 0.5
+			cfg.MaxSearchResults = 3
+			cfg.SemanticDedupEnabled = tt.enabled
+			cfg.SemanticDedupThreshold = 0.95
+			embedder.embedded = nil
+			service := NewSearchService(nil, nil, setupTestDB(t), cfg)
+			service.SetEmbedder(embedder)
+
+			ranked, explanation := service.rankResults(context.Background(), append([]storage.SearchResult(nil), results...), "", "C1")
+
+			if len(ranked) != len(tt.expected) {
+				t.Fatalf("Expected %d results, got %d: %+v", len(tt.expected), len(ranked), ranked)
+			}
+			for i, title := range tt.expected {
+				if ranked[i].Title != title {
+					t.Errorf("Result %d: expected %q, got %q", i, title, ranked[i].Title)
+				}
+			}
+			for _, candidate := range explanation.Candidates {
+				want := false
+				for _, title := range tt.expected {
+					want = want || candidate.Title == title
+				}
+				if candidate.Selected != want {
+					t.Errorf("Candidate %s: expected selected=%v", candidate.Title, want)
+				}
+			}
+			if len(embedder.embedded) != tt.embedded {
+				t.Errorf("Expected %d results embedded, got %d", tt.embedded, len(embedder.embedded))
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// sourceFlag scopes a command's search to the comma-separated sources following it
+const sourceFlag = "--source="
+
+// searchSources are the sources a search can be restricted to
+var searchSources = map[string]bool{"slack": true, "confluence": true, GitHubSource: true}
+
+// sourceFilterKey is the context key holding the sources a search is restricted to
+type sourceFilterKey struct{}
+
+// ParseSourceFlags splits command text into the query and the sources its
+// --source flags restrict the search to, e.g. "--source=confluence deploy
+// steps" searches only Confluence for "deploy steps". No flags leaves the
+// sources nil, searching everywhere.
+func ParseSourceFlags(text string) (string, []string, error) {
+	var words, sources []string
+	for _, word := range strings.Fields(text) {
+		value, isFlag := strings.CutPrefix(word, sourceFlag)
+		if !isFlag {
+			words = append(words, word)
+			continue
+		}
+
+		for _, source := range strings.Split(value, ",") {
+			source = strings.ToLower(strings.TrimSpace(source))
+			if !searchSources[source] {
+				return "", nil, fmt.Errorf("unknown source %q; use slack, confluence or github", source)
+			}
+			sources = append(sources, source)
+		}
+	}
+	return strings.Join(words, " "), sources, nil
+}
+
+// WithSourceFilter returns ctx restricting searches made with it to sources;
+// no sources searches everywhere
+func WithSourceFilter(ctx context.Context, sources []string) context.Context {
+	if len(sources) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, sourceFilterKey{}, sources)
+}
+
+// searchesSource reports whether searches made with ctx include source
+func searchesSource(ctx context.Context, source string) bool {
+	sources, _ := ctx.Value(sourceFilterKey{}).([]string)
+	if len(sources) == 0 {
+		return true
+	}
+	for _, allowed := range sources {
+		if allowed == source {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceFiltered reports whether searches made with ctx skip any source
+func sourceFiltered(ctx context.Context) bool {
+	for source := range searchSources {
+		if !searchesSource(ctx, source) {
+			return true
+		}
+	}
+	return false
+}
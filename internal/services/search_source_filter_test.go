@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestParseSourceFlags(t *testing.T) {
+	tests := []struct {
+		text    string
+		query   string
+		sources []string
+		wantErr bool
+	}{
+		{text: "how do I deploy", query: "how do I deploy"},
+		{text: "--source=confluence deploy steps", query: "deploy steps", sources: []string{"confluence"}},
+		{text: "deploy --source=Slack steps", query: "deploy steps", sources: []string{"slack"}},
+		{text: "deploy --source=slack,confluence", query: "deploy", sources: []string{"slack", "confluence"}},
+		{text: "--source=slack --source=confluence deploy", query: "deploy", sources: []string{"slack", "confluence"}},
+		{text: "--source=jira deploy", wantErr: true},
+		{text: "--source= deploy", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		query, sources, err := ParseSourceFlags(tt.text)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseSourceFlags(%q) expected an error", tt.text)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSourceFlags(%q) returned error: %v", tt.text, err)
+			continue
+		}
+		if query != tt.query || !reflect.DeepEqual(sources, tt.sources) {
+			t.Errorf("ParseSourceFlags(%q) = %q, %v; want %q, %v", tt.text, query, sources, tt.query, tt.sources)
+		}
+	}
+}
+
+func TestSearchAll_SourceFilterSkipsOtherSources(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchTimeout = 5 * time.Second
+	cfg.SearchTotalTimeout = 5 * time.Second
+	// Slack answering slowly shows whether it was searched at all
+	service := newTimeoutSearchService(t, cfg, time.Second, 0)
+
+	ctx := WithSourceFilter(context.Background(), []string{"confluence"})
+	start := time.Now()
+	results, _, err := service.SearchAll(ctx, "deploy payment service", 1, "")
+	if err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].Source != "confluence" {
+		t.Errorf("Expected only the Confluence result, got %+v", results)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected Slack not to be searched, took %v", elapsed)
+	}
+
+	// A filtered search must not be cached as the unfiltered one
+	counts := sources(t, service)
+	if counts["slack"] != 1 || counts["confluence"] != 1 {
+		t.Errorf("Expected an unfiltered search to use both sources, got %v", counts)
+	}
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"github.com/sirupsen/logrus"
+)
+
+// sourceWeightsKey is the KVStore key of the feedback-derived source weights
+const sourceWeightsKey = "search.source_weights"
+
+// minSourceWeightSamples is the number of (result, feedback) pairs needed
+// before source weights are derived from them
+const minSourceWeightSamples = 20
+
+// maxSourceWeightAdjustment is how far a perfectly correlated source's weight moves from 1
+const maxSourceWeightAdjustment = 0.5
+
+// WeightSourcesByFeedback derives a score multiplier per source from how
+// well its results appearing in answers correlate with helpful feedback,
+// and stores them for ranking. A source whose results go with helpful
+// answers gets a weight above 1, one whose results go with unhelpful ones
+// below 1. Weights are left unchanged until there is enough feedback.
+func (s *SearchService) WeightSourcesByFeedback(ctx context.Context) (map[string]float64, error) {
+	// Only results that scored high enough to be used count as part of an answer
+	var rows []struct {
+		Source  string
+		Helpful bool
+	}
+	if err := s.db.Table("search_results").
+		Select("search_results.source, feedbacks.helpful").
+		Joins("JOIN feedbacks ON feedbacks.inquiry_id = search_results.inquiry_id").
+		Where("search_results.deleted_at IS NULL AND search_results.score >= ?", s.cfg().SimilarityThreshold).
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load feedback history: %w", err)
+	}
+
+	if len(rows) < minSourceWeightSamples {
+		logrus.WithField("samples", len(rows)).Info("Not enough feedback to weight sources yet")
+		return nil, nil
+	}
+
+	helpful := make([]float64, len(rows))
+	sources := make(map[string]bool)
+	for i, row := range rows {
+		if row.Helpful {
+			helpful[i] = 1
+		}
+		sources[row.Source] = true
+	}
+
+	weights := make(map[string]float64, len(sources))
+	for source := range sources {
+		fromSource := make([]float64, len(rows))
+		for i, row := range rows {
+			if row.Source == source {
+				fromSource[i] = 1
+			}
+		}
+		weights[source] = 1 + maxSourceWeightAdjustment*pearson(fromSource, helpful)
+	}
+
+	if err := s.kv.Set(sourceWeightsKey, weights); err != nil {
+		return nil, fmt.Errorf("failed to store source weights: %w", err)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"samples": len(rows),
+		"weights": weights,
+	}).Info("Updated source weights from feedback")
+
+	return weights, nil
+}
+
+// RunSourceWeightLoop periodically recomputes source weights until ctx is cancelled
+func (s *SearchService) RunSourceWeightLoop(ctx context.Context) {
+	if !s.cfg().SourceWeighting {
+		return
+	}
+
+	runPeriodically(ctx, "source_weights", s.cfg().SourceWeightInterval, func(ctx context.Context) error {
+		_, err := s.WeightSourcesByFeedback(ctx)
+		return err
+	})
+}
+
+// SourceWeights returns the stored source weights, or nil if none have been computed
+func (s *SearchService) SourceWeights(ctx context.Context) map[string]float64 {
+	var weights map[string]float64
+	if _, err := s.kv.Get(sourceWeightsKey, &weights); err != nil {
+		loggerFrom(ctx).WithError(err).Warn("Failed to load source weights")
+		return nil
+	}
+	return weights
+}
+
+// applySourceWeights multiplies each result's score by its source's weight
+func (s *SearchService) applySourceWeights(ctx context.Context, results []storage.SearchResult) {
+	if !s.cfg().SourceWeighting || len(results) == 0 {
+		return
+	}
+
+	weights := s.SourceWeights(ctx)
+	for i := range results {
+		if weight, ok := weights[results[i].Source]; ok {
+			results[i].Score *= weight
+		}
+	}
+}
+
+// pearson returns the Pearson correlation coefficient of xs and ys, or 0 when
+// either doesn't vary
+func pearson(xs, ys []float64) float64 {
+	n := float64(len(xs))
+	if n == 0 {
+		return 0
+	}
+
+	var sumX, sumY float64
+	for i := range xs {
+		sumX += xs[i]
+		sumY += ys[i]
+	}
+	meanX, meanY := sumX/n, sumY/n
+
+	var cov, varX, varY float64
+	for i := range xs {
+		dx, dy := xs[i]-meanX, ys[i]-meanY
+		cov += dx * dy
+		varX += dx * dx
+		varY += dy * dy
+	}
+	if varX == 0 || varY == 0 {
+		return 0
+	}
+
+	return cov / math.Sqrt(varX*varY)
+}
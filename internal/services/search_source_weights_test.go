@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+	"gorm.io/gorm"
+)
+
+func TestPearson(t *testing.T) {
+	tests := []struct {
+		name     string
+		xs, ys   []float64
+		expected float64
+	}{
+		{name: "perfectly correlated", xs: []float64{0, 1, 0, 1}, ys: []float64{0, 1, 0, 1}, expected: 1},
+		{name: "perfectly anti-correlated", xs: []float64{0, 1, 0, 1}, ys: []float64{1, 0, 1, 0}, expected: -1},
+		{name: "uncorrelated", xs: []float64{0, 0, 1, 1}, ys: []float64{0, 1, 0, 1}, expected: 0},
+		{name: "constant", xs: []float64{1, 1, 1}, ys: []float64{0, 1, 0}, expected: 0},
+		{name: "empty", expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if r := pearson(tt.xs, tt.ys); math.Abs(r-tt.expected) > 1e-9 {
+				t.Errorf("Expected %v, got %v", tt.expected, r)
+			}
+		})
+	}
+}
+
+// seedSourceFeedback creates count answered inquiries, each built on one
+// result from source and voted helpful or not
+func seedSourceFeedback(db *gorm.DB, source string, helpful bool, count int) {
+	for i := 0; i < count; i++ {
+		inquiry := &storage.Inquiry{MessageID: fmt.Sprintf("%s-%v-%d", source, helpful, i), Status: "completed"}
+		db.Create(inquiry)
+		db.Create(&storage.SearchResult{InquiryID: inquiry.ID, Source: source, SourceID: inquiry.MessageID, Score: 0.9})
+		db.Create(&storage.Feedback{InquiryID: inquiry.ID, UserID: "U1", Helpful: helpful})
+	}
+}
+
+func TestWeightSourcesByFeedback(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SourceWeighting = true
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	// Confluence results mostly lead to helpful answers and Slack results to unhelpful ones
+	seedSourceFeedback(db, "confluence", true, 9)
+	seedSourceFeedback(db, "confluence", false, 1)
+	seedSourceFeedback(db, "slack", true, 2)
+	seedSourceFeedback(db, "slack", false, 8)
+
+	weights, err := service.WeightSourcesByFeedback(context.Background())
+	if err != nil {
+		t.Fatalf("WeightSourcesByFeedback returned error: %v", err)
+	}
+	if weights["confluence"] <= 1 || weights["slack"] >= 1 {
+		t.Errorf("Expected Confluence weighted up and Slack down, got %v", weights)
+	}
+	if stored := service.SourceWeights(context.Background()); stored["slack"] != weights["slack"] {
+		t.Errorf("Expected weights to be stored, got %v", stored)
+	}
+
+	// Slack's higher raw score no longer wins
+	results := service.filterAndRankResults([]storage.SearchResult{
+		{Source: "slack", SourceID: "1.1", Score: 0.9},
+		{Source: "confluence", SourceID: "P1", Score: 0.8},
+	}, "", "")
+	if results[0].Source != "confluence" {
+		t.Errorf("Expected the Confluence result to rank first, got %+v", results)
+	}
+}
+
+func TestWeightSourcesByFeedback_NotEnoughFeedback(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	seedSourceFeedback(db, "slack", false, 3)
+
+	weights, err := service.WeightSourcesByFeedback(context.Background())
+	if err != nil {
+		t.Fatalf("WeightSourcesByFeedback returned error: %v", err)
+	}
+	if weights != nil || service.SourceWeights(context.Background()) != nil {
+		t.Errorf("Expected no weights from too little feedback, got %v", weights)
+	}
+}
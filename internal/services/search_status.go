@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// sourceNames are the display names of the search sources
+var sourceNames = map[string]string{"slack": "Slack", "confluence": "Confluence", GitHubSource: "GitHub"}
+
+// sourceStatusKey is the context key holding the SourceStatus of the search an answer is built on
+type sourceStatusKey struct{}
+
+// SourceStatus reports which sources a search asked and which of them failed,
+// so results missing a source's contribution can be told apart from complete ones
+type SourceStatus struct {
+	Searched []string `json:"searched"`
+	Failed   []string `json:"failed,omitempty"`
+}
+
+// Partial reports whether a source failed to return results
+func (s SourceStatus) Partial() bool {
+	return len(s.Failed) > 0
+}
+
+// available returns the searched sources that didn't fail
+func (s SourceStatus) available() []string {
+	var available []string
+	for _, source := range s.Searched {
+		failed := false
+		for _, f := range s.Failed {
+			failed = failed || f == source
+		}
+		if !failed {
+			available = append(available, source)
+		}
+	}
+	return available
+}
+
+// withSourceStatus returns ctx carrying the status of the search an answer is built on
+func withSourceStatus(ctx context.Context, status SourceStatus) context.Context {
+	return context.WithValue(ctx, sourceStatusKey{}, status)
+}
+
+// sourceStatusFrom returns the search status set on ctx with withSourceStatus
+func sourceStatusFrom(ctx context.Context) SourceStatus {
+	status, _ := ctx.Value(sourceStatusKey{}).(SourceStatus)
+	return status
+}
+
+// partialResultsNote tells readers which sources were unavailable, e.g.
+// "(Confluence was unavailable; this answer is based only on Slack)", where
+// subject is what was built on the search. It is empty when no source failed.
+func partialResultsNote(status SourceStatus, subject string) string {
+	if !status.Partial() {
+		return ""
+	}
+
+	verb := "was"
+	if len(status.Failed) > 1 {
+		verb = "were"
+	}
+	note := fmt.Sprintf("(%s %s unavailable", joinSourceNames(status.Failed), verb)
+	if available := status.available(); len(available) > 0 {
+		note += fmt.Sprintf("; %s based only on %s", subject, joinSourceNames(available))
+	}
+	return note + ")"
+}
+
+// joinSourceNames lists sources by display name, e.g. "Slack and Confluence"
+func joinSourceNames(sources []string) string {
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = sourceNames[source]
+		if names[i] == "" {
+			names[i] = source
+		}
+	}
+	return strings.Join(names, " and ")
+}
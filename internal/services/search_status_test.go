@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestPartialResultsNote(t *testing.T) {
+	tests := []struct {
+		name   string
+		status SourceStatus
+		want   string
+	}{
+		{name: "complete", status: SourceStatus{Searched: []string{"slack", "confluence"}}},
+		{name: "confluence down", status: SourceStatus{Searched: []string{"slack", "confluence"}, Failed: []string{"confluence"}},
+			want: "(Confluence was unavailable; this answer is based only on Slack)"},
+		{name: "everything down", status: SourceStatus{Searched: []string{"slack", "confluence"}, Failed: []string{"slack", "confluence"}},
+			want: "(Slack and Confluence were unavailable)"},
+		{name: "only source down", status: SourceStatus{Searched: []string{"slack"}, Failed: []string{"slack"}},
+			want: "(Slack was unavailable)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := partialResultsNote(tt.status, "this answer is"); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestSearchAll_ReportsFailedSources(t *testing.T) {
+	tests := []struct {
+		name         string
+		sources      []string
+		timeout      time.Duration
+		wantSearched []string
+		wantFailed   []string
+	}{
+		{name: "all answered", timeout: time.Second, wantSearched: []string{"slack", "confluence"}},
+		{name: "confluence timed out", timeout: 50 * time.Millisecond,
+			wantSearched: []string{"slack", "confluence"}, wantFailed: []string{"confluence"}},
+		{name: "restricted to the failing source", sources: []string{"confluence"}, timeout: 50 * time.Millisecond,
+			wantSearched: []string{"confluence"}, wantFailed: []string{"confluence"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ConfluenceSearchTimeout = tt.timeout
+			cfg.SlackSearchTimeout = 5 * time.Second
+			cfg.SearchTotalTimeout = 5 * time.Second
+			service := newTimeoutSearchService(t, cfg, 0, 200*time.Millisecond)
+
+			ctx := WithSourceFilter(context.Background(), tt.sources)
+			_, status, err := service.SearchAll(ctx, "deploy payment service", 1, "")
+			if err != nil {
+				t.Fatalf("SearchAll returned error: %v", err)
+			}
+			if !reflect.DeepEqual(status.Searched, tt.wantSearched) || !reflect.DeepEqual(status.Failed, tt.wantFailed) {
+				t.Errorf("Expected searched %v and failed %v, got %+v", tt.wantSearched, tt.wantFailed, status)
+			}
+			if status.Partial() != (len(tt.wantFailed) > 0) {
+				t.Errorf("Expected partial %v, got %v", len(tt.wantFailed) > 0, status.Partial())
+			}
+		})
+	}
+}
+
+func TestProcessInquiry_NotesUnavailableSources(t *testing.T) {
+	confluence := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(confluence.Close)
+
+	for _, enabled := range []bool{true, false} {
+		cfg := config.LoadTestConfig()
+		cfg.PartialResultsNote = enabled
+		cfg.ConfluenceBaseURL = confluence.URL
+		cfg.ConfluenceAPIToken = "token"
+		cfg.ConfluenceAPIVersion = ConfluenceDC8
+		fake := newFakeSlack(t, cfg)
+		fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+		newFakeLLM(t, cfg, "Run make deploy")
+		service := newTestInquiryService(cfg, setupTestDB(t))
+
+		if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+			t.Fatalf("ProcessInquiry returned error: %v", err)
+		}
+
+		replies := postsTo(fake.callsTo("chat.postMessage"), "C1")
+		if len(replies) != 1 {
+			t.Fatalf("Expected one reply, got %d", len(replies))
+		}
+		noted := strings.Contains(replies[0], "_(Confluence was unavailable; this answer is based only on Slack)_")
+		if noted != enabled {
+			t.Errorf("Expected note %v with PARTIAL_RESULTS_NOTE=%v, got %q", enabled, enabled, replies[0])
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SynonymDictionary maps lowercased terms to the terms also searched for
+// them, e.g. container to docker, pod and k8s
+type SynonymDictionary map[string][]string
+
+// LoadSynonymDictionary reads a YAML mapping of terms to lists of synonyms
+func LoadSynonymDictionary(path string) (SynonymDictionary, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read synonym dictionary: %w", err)
+	}
+
+	var raw map[string][]string
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse synonym dictionary: %w", err)
+	}
+
+	dictionary := make(SynonymDictionary, len(raw))
+	for term, synonyms := range raw {
+		key := strings.ToLower(term)
+		dictionary[key] = append(dictionary[key], synonyms...)
+	}
+	return dictionary, nil
+}
+
+// SearchWithSynonyms reduces query to its keywords and named entities, like
+// searchQuery, followed by the synonyms of each of them. Without a
+// SYNONYM_DICT_FILE the terms are returned unexpanded; when the dictionary
+// can't be loaded they are returned along with the error.
+func (s *SearchService) SearchWithSynonyms(query string) (expanded string, err error) {
+	terms := s.searchTerms(query)
+
+	dictionary, err := s.synonymDictionary()
+	if err != nil {
+		return strings.Join(terms, " "), err
+	}
+
+	return strings.Join(expandSynonyms(terms, dictionary), " "), nil
+}
+
+// searchTerms extracts the keywords and named entities of query
+func (s *SearchService) searchTerms(query string) []string {
+	return mergeSearchTerms(s.extractKeywords(query), s.ExtractNamedEntities(query))
+}
+
+// expandSynonyms appends the synonyms of terms to them, skipping terms
+// already present regardless of case
+func expandSynonyms(terms []string, dictionary SynonymDictionary) []string {
+	seen := make(map[string]bool, len(terms))
+	for _, term := range terms {
+		seen[strings.ToLower(term)] = true
+	}
+
+	expanded := terms
+	for _, term := range terms {
+		for _, synonym := range dictionary[strings.ToLower(term)] {
+			if !seen[strings.ToLower(synonym)] {
+				seen[strings.ToLower(synonym)] = true
+				expanded = append(expanded, synonym)
+			}
+		}
+	}
+	return expanded
+}
+
+// keywordGroups groups the keywords of a query expanded by SearchWithSynonyms
+// with the synonyms added for them, so that a result scores for a keyword
+// when it contains the keyword or any of its synonyms and the synonyms don't
+// count as keywords of their own. Without a dictionary every keyword is its
+// own group.
+func (s *SearchService) keywordGroups(keywords []string) [][]string {
+	dictionary, _ := s.synonymDictionary()
+
+	present := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		present[strings.ToLower(keyword)] = true
+	}
+
+	grouped := make(map[string]bool, len(keywords))
+	var groups [][]string
+	for _, keyword := range keywords {
+		key := strings.ToLower(keyword)
+		if grouped[key] {
+			continue
+		}
+		grouped[key] = true
+
+		group := []string{keyword}
+		for _, synonym := range dictionary[key] {
+			if synonymKey := strings.ToLower(synonym); present[synonymKey] && !grouped[synonymKey] {
+				grouped[synonymKey] = true
+				group = append(group, synonym)
+			}
+		}
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// synonymDictionary returns the dictionary at SYNONYM_DICT_FILE, loading it
+// on first use and again whenever a reload points at a different file
+func (s *SearchService) synonymDictionary() (SynonymDictionary, error) {
+	path := s.cfg().SynonymDictFile
+	if path == "" {
+		return nil, nil
+	}
+
+	s.synonymsMu.Lock()
+	defer s.synonymsMu.Unlock()
+
+	if s.synonyms != nil && s.synonymsPath == path {
+		return s.synonyms, nil
+	}
+	dictionary, err := LoadSynonymDictionary(path)
+	if err != nil {
+		return nil, err
+	}
+	s.synonyms, s.synonymsPath = dictionary, path
+	return dictionary, nil
+}
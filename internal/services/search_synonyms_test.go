@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// writeSynonyms writes content to a synonym dictionary file and returns its path
+func writeSynonyms(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "synonyms.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Failed to write synonym dictionary: %v", err)
+	}
+	return path
+}
+
+func TestSearchWithSynonyms(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SynonymDictFile = writeSynonyms(t, "Container: [docker, pod, k8s]\ndeploy: [release, Container]\n")
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{query: "Why does my container crash?", expected: "container crash docker pod k8s"},
+		{query: "how to deploy a container", expected: "deploy container release docker pod k8s"},
+		{query: "where are the logs", expected: "logs"},
+	}
+
+	for _, tt := range tests {
+		expanded, err := service.SearchWithSynonyms(tt.query)
+		if err != nil {
+			t.Fatalf("SearchWithSynonyms returned error: %v", err)
+		}
+		if expanded != tt.expected {
+			t.Errorf("SearchWithSynonyms(%q) = %q, expected %q", tt.query, expanded, tt.expected)
+		}
+	}
+}
+
+func TestSearchWithSynonyms_NoDictionary(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	expanded, err := service.SearchWithSynonyms("container crash")
+	if err != nil || expanded != "container crash" {
+		t.Errorf("Expected the unexpanded keywords, got %q, %v", expanded, err)
+	}
+}
+
+func TestSearchWithSynonyms_InvalidDictionary(t *testing.T) {
+	for name, path := range map[string]string{
+		"missing":   filepath.Join(t.TempDir(), "missing.yaml"),
+		"malformed": writeSynonyms(t, "container: docker: pod"),
+	} {
+		cfg := config.LoadTestConfig()
+		cfg.SynonymDictFile = path
+		service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+		expanded, err := service.SearchWithSynonyms("container crash")
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+		if expanded != "container crash" {
+			t.Errorf("%s: expected the unexpanded keywords, got %q", name, expanded)
+		}
+	}
+}
+
+func TestSearchAll_SearchesSynonyms(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SynonymDictFile = writeSynonyms(t, "container: [docker]\n")
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	if _, _, err := service.SearchAll(context.Background(), "container crash", 1, "C1"); err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+
+	calls := fake.callsTo("search.messages")
+	if len(calls) != 1 || !strings.HasPrefix(calls[0].Get("query"), "container crash docker") {
+		t.Errorf("Expected Slack to be searched with the synonyms, got %v", calls)
+	}
+}
+
+func TestRankResults_SynonymsDontDiluteScores(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SynonymDictFile = writeSynonyms(t, "container: [docker, pod, k8s]\n")
+	cfg.SimilarityThreshold = 0.7
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+
+	query := service.searchQuery("How do I deploy a container?")
+	results := []storage.SearchResult{
+		{Source: "confluence", SourceID: "P1", Title: "Deploy a container", Content: "Steps to deploy"},
+		{Source: "confluence", SourceID: "P2", Title: "Docker deploys", Content: "deploy the image"},
+		{Source: "confluence", SourceID: "P3", Title: "Deploy calendar", Content: "When to deploy"},
+	}
+
+	ranked := service.filterAndRankResults(results, query, "")
+
+	if len(ranked) != 2 {
+		t.Fatalf("Expected the pages matching deploy and container or a synonym, got %+v", ranked)
+	}
+	for _, result := range ranked {
+		if result.Score != 1 {
+			t.Errorf("Expected %s to match every keyword, got score %v", result.SourceID, result.Score)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// newTimeoutSearchService returns a search service over a fake Slack and a
+// fake Confluence that each return one match after the given delays
+func newTimeoutSearchService(t *testing.T, cfg *config.Config, slackDelay, confluenceDelay time.Duration) *SearchService {
+	t.Helper()
+
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "text": "Deploy the payment service with the CLI", "channel": {"id": "C1"}}
+	]}}`)
+	fake.delay("search.messages", slackDelay)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(confluenceDelay):
+		case <-r.Context().Done():
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results": [{"id": "P1", "title": "Deploy payment service"}], "size": 1}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg.ConfluenceBaseURL = server.URL
+	cfg.ConfluenceAPIToken = "token"
+	cfg.ConfluenceAPIVersion = ConfluenceDC8
+	cfg.ConfluenceTimeout = time.Minute
+	cfg.SimilarityThreshold = 0
+
+	return NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), setupTestDB(t), cfg)
+}
+
+// sources counts search results per source
+func sources(t *testing.T, service *SearchService) map[string]int {
+	t.Helper()
+
+	results, _, err := service.SearchAll(context.Background(), "deploy payment service", 1, "")
+	if err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Source]++
+	}
+	return counts
+}
+
+func TestSearchAll_SlowConfluenceKeepsSlackResults(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.ConfluenceSearchTimeout = 50 * time.Millisecond
+	service := newTimeoutSearchService(t, cfg, 0, time.Second)
+
+	start := time.Now()
+	counts := sources(t, service)
+
+	if counts["slack"] != 1 || counts["confluence"] != 0 {
+		t.Errorf("Expected only the Slack result, got %v", counts)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected SearchAll to stop waiting for Confluence, took %v", elapsed)
+	}
+}
+
+func TestSearchAll_SlowSlackKeepsConfluenceResults(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchTimeout = 50 * time.Millisecond
+	service := newTimeoutSearchService(t, cfg, time.Second, 0)
+
+	counts := sources(t, service)
+	if counts["slack"] != 0 || counts["confluence"] != 1 {
+		t.Errorf("Expected only the Confluence result, got %v", counts)
+	}
+}
+
+func TestSearchAll_SourcesRunInParallel(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SlackSearchTimeout = time.Second
+	cfg.ConfluenceSearchTimeout = time.Second
+	cfg.SearchTotalTimeout = 2 * time.Second
+	service := newTimeoutSearchService(t, cfg, 200*time.Millisecond, 200*time.Millisecond)
+
+	start := time.Now()
+	counts := sources(t, service)
+
+	if counts["slack"] != 1 || counts["confluence"] != 1 {
+		t.Errorf("Expected results from both sources, got %v", counts)
+	}
+	if elapsed := time.Since(start); elapsed > 350*time.Millisecond {
+		t.Errorf("Expected sources to be searched in parallel, took %v", elapsed)
+	}
+}
+
+func TestSearchAll_TotalTimeout(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SearchTotalTimeout = 50 * time.Millisecond
+	service := newTimeoutSearchService(t, cfg, time.Second, time.Second)
+
+	start := time.Now()
+	counts := sources(t, service)
+
+	if len(counts) != 0 {
+		t.Errorf("Expected no results when the overall timeout expires, got %v", counts)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Expected SearchAll to be bounded by the overall timeout, took %v", elapsed)
+	}
+}
@@ -0,0 +1,51 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+// truncationMarker ends content cut by TruncateResultContent
+const truncationMarker = "…"
+
+// TruncateResultContent caps the Content of each result at maxBytes, cutting
+// at the last word boundary that fits and marking the cut with an ellipsis.
+// Scores are unaffected, being computed from NormalizedContent. A maxBytes of
+// 0 or less leaves the content whole.
+func (s *SearchService) TruncateResultContent(results []storage.SearchResult, maxBytes int) {
+	if maxBytes <= 0 {
+		return
+	}
+	for i := range results {
+		results[i].Content = truncateAtWord(results[i].Content, maxBytes)
+	}
+}
+
+// truncateAtWord shortens text to at most maxBytes bytes, including the
+// truncation marker, without splitting words or UTF-8 sequences. A single word
+// longer than the limit is cut at a character boundary.
+func truncateAtWord(text string, maxBytes int) string {
+	if len(text) <= maxBytes {
+		return text
+	}
+
+	budget := maxBytes - len(truncationMarker)
+	marker := truncationMarker
+	if budget <= 0 {
+		budget, marker = maxBytes, ""
+	}
+
+	// Back off to the start of a character, then to the end of the last whole word
+	cut := budget
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	if wordEnd := strings.LastIndexFunc(text[:cut+1], unicode.IsSpace); wordEnd > 0 {
+		cut = wordEnd
+	}
+
+	return strings.TrimRightFunc(text[:cut], unicode.IsSpace) + marker
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestTruncateResultContent(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		maxBytes int
+		expected string
+	}{
+		{name: "empty", content: "", maxBytes: 10, expected: ""},
+		{name: "under limit", content: "deploy", maxBytes: 10, expected: "deploy"},
+		{name: "exact limit", content: "deploy now", maxBytes: 10, expected: "deploy now"},
+		{name: "over limit", content: "deploy the payment service", maxBytes: 16, expected: "deploy the…"},
+		{name: "cut at space", content: "deploy the payment", maxBytes: 13, expected: "deploy the…"},
+		{name: "single long word", content: "supercalifragilistic", maxBytes: 10, expected: "superca…"},
+		{name: "multibyte", content: "デプロイ手順", maxBytes: 10, expected: "デプ…"},
+		{name: "disabled", content: "deploy the payment service", maxBytes: 0, expected: "deploy the payment service"},
+	}
+
+	service := NewSearchService(nil, nil, nil, config.LoadTestConfig())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := []storage.SearchResult{{Content: tt.content}}
+			service.TruncateResultContent(results, tt.maxBytes)
+
+			got := results[0].Content
+			if got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+			if tt.maxBytes > 0 && len(got) > tt.maxBytes {
+				t.Errorf("Expected at most %d bytes, got %d", tt.maxBytes, len(got))
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("Expected valid UTF-8, got %q", got)
+			}
+		})
+	}
+}
+
+func TestSearchAll_TruncatesStoredContent(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.MaxContentBytes = 100
+	cfg.SimilarityThreshold = 0
+	long := "Deploy the payment service with the CLI " + strings.Repeat("and then check the dashboards ", 50)
+
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "text": "`+long+`", "channel": {"id": "C1"}}
+	]}}`)
+	db := setupTestDB(t)
+	service := NewSearchService(NewSlackService(cfg), NewConfluenceService(cfg), db, cfg)
+
+	results, _, err := service.SearchAll(context.Background(), "deploy payment service", 1, "")
+	if err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	if len(results) != 1 || len(results[0].Content) > 100 {
+		t.Fatalf("Expected one result truncated to 100 bytes, got %+v", results)
+	}
+
+	var stored storage.SearchResult
+	if err := db.Where("inquiry_id = ?", 1).First(&stored).Error; err != nil {
+		t.Fatalf("Failed to load stored result: %v", err)
+	}
+	if len(stored.Content) > 100 || !strings.HasSuffix(stored.Content, truncationMarker) {
+		t.Errorf("Expected the stored content truncated, got %d bytes: %q", len(stored.Content), stored.Content)
+	}
+}
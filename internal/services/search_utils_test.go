@@ -1,6 +1,8 @@
 package services
 
 import (
+	"context"
+	"math"
 	"strings"
 	"testing"
 
@@ -113,10 +115,9 @@ func TestCalculateRelevanceScore(t *testing.T) {
 }
 
 func TestFilterAndRankResults(t *testing.T) {
-	cfg := &config.Config{
-		SimilarityThreshold: 0.5,
-		MaxSearchResults:    3,
-	}
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0.5
+	cfg.MaxSearchResults = 3
 	service := &SearchService{config: cfg}
 
 	results := []storage.SearchResult{
@@ -128,7 +129,7 @@ func TestFilterAndRankResults(t *testing.T) {
 		{Score: 0.3, Title: "Very low score (should be filtered)"},
 	}
 
-	filtered := service.filterAndRankResults(results)
+	filtered := service.filterAndRankResults(results, "", "")
 
 	// Should filter out scores below threshold (0.5) and limit to MaxSearchResults
 	// 4 results have scores >= 0.5, but MaxSearchResults is 3
@@ -258,3 +259,290 @@ func TestSearchService_EdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestRecordRawResponse(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		db := setupTestDB(t)
+		service := &SearchService{db: db, config: config.LoadTestConfig()}
+
+		service.recordRawResponse(context.Background(), 1, "slack", "deploy", []byte(`{"ok":true}`))
+
+		var count int64
+		db.Model(&storage.RawResponse{}).Count(&count)
+		if count != 0 {
+			t.Errorf("Expected no raw responses when disabled, got %d", count)
+		}
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		db := setupTestDB(t)
+		cfg := config.LoadTestConfig()
+		cfg.DebugStoreRawResponses = true
+		cfg.ConfluenceAPIToken = "secret-token"
+		service := &SearchService{db: db, config: cfg}
+
+		service.recordRawResponse(context.Background(), 7, "confluence", "deploy", []byte(`{"token":"secret-token"}`))
+
+		var stored []storage.RawResponse
+		db.Find(&stored)
+		if len(stored) != 1 {
+			t.Fatalf("Expected 1 raw response, got %d", len(stored))
+		}
+		if stored[0].InquiryID != 7 || stored[0].Source != "confluence" {
+			t.Errorf("Unexpected raw response metadata: %+v", stored[0])
+		}
+		if strings.Contains(stored[0].Body, "secret-token") {
+			t.Errorf("Expected credentials to be redacted, got %s", stored[0].Body)
+		}
+	})
+}
+
+func TestFilterAndRankResults_ChannelRelevance(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0.5
+	cfg.ChannelRelevanceBoost = 0.2
+	service := &SearchService{config: cfg}
+
+	results := []storage.SearchResult{
+		{Source: "slack", ChannelID: "C_GENERAL", Score: 0.8, Title: "Cross-channel"},
+		{Source: "slack", ChannelID: "C_ONCALL", Score: 0.8, Title: "Same channel"},
+		{Source: "confluence", Score: 0.8, Title: "Doc"},
+	}
+
+	filtered := service.filterAndRankResults(results, "", "C_ONCALL")
+	if len(filtered) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(filtered))
+	}
+	if filtered[0].Title != "Same channel" {
+		t.Errorf("Expected same-channel result to rank first, got '%s'", filtered[0].Title)
+	}
+	if filtered[1].Score != 0.8 || filtered[2].Score != 0.8 {
+		t.Errorf("Expected other results to keep their scores, got %f and %f", filtered[1].Score, filtered[2].Score)
+	}
+}
+
+func TestPrioritiseByChannelRelevance(t *testing.T) {
+	tests := []struct {
+		name      string
+		channelID string
+		boost     float64
+		expected  []float64
+	}{
+		{name: "boosts same channel", channelID: "C_ONCALL", boost: 0.2, expected: []float64{0.5, 0.7, 0.5}},
+		{name: "no channel", channelID: "", boost: 0.2, expected: []float64{0.5, 0.5, 0.5}},
+		{name: "disabled", channelID: "C_ONCALL", boost: 0, expected: []float64{0.5, 0.5, 0.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.ChannelRelevanceBoost = tt.boost
+			service := &SearchService{config: cfg}
+
+			results := []storage.SearchResult{
+				{Source: "slack", ChannelID: "C_GENERAL", Score: 0.5},
+				{Source: "slack", ChannelID: "C_ONCALL", Score: 0.5},
+				{Source: "confluence", ChannelID: "C_ONCALL", Score: 0.5},
+			}
+			service.PrioritiseByChannelRelevance(results, tt.channelID)
+
+			for i, expected := range tt.expected {
+				if math.Abs(results[i].Score-expected) > 1e-9 {
+					t.Errorf("Result %d: expected score %v, got %v", i, expected, results[i].Score)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractNamedEntities(t *testing.T) {
+	service := &SearchService{}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{
+			name:     "service name and acronym",
+			text:     "Hey team, payment-api keeps returning 502s from the GKE cluster. Any idea?",
+			expected: []string{"payment-api", "GKE"},
+		},
+		{
+			name:     "proper nouns mid-sentence",
+			text:     "Does anyone know how Spinnaker talks to ArgoCD in the Foundation setup?",
+			expected: []string{"Spinnaker", "ArgoCD", "Foundation"},
+		},
+		{
+			name:     "sentence-start words are skipped",
+			text:     "Deploys are failing. Anyone seen this? Rollback worked for me",
+			expected: nil,
+		},
+		{
+			name:     "slack markup and chatter are ignored",
+			text:     "<@U12345> FYI the CI job for <#C999|platform> is red :sob: since 2024-01-31, ok?",
+			expected: []string{"CI"},
+		},
+		{
+			name:     "duplicates and possessives",
+			text:     "Where is Kubernetes's config for auth-gateway? I think Kubernetes's docs mention auth-gateway.",
+			expected: []string{"Kubernetes", "auth-gateway"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := service.ExtractNamedEntities(tt.text)
+
+			if len(result) != len(tt.expected) {
+				t.Fatalf("Expected entities %v, got %v", tt.expected, result)
+			}
+			for i, entity := range result {
+				if entity != tt.expected[i] {
+					t.Errorf("Expected entity '%s', got '%s'", tt.expected[i], entity)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeSearchTerms(t *testing.T) {
+	merged := mergeSearchTerms([]string{"payment-api", "failing"}, []string{"payment-api", "GKE"})
+
+	expected := []string{"payment-api", "failing", "GKE"}
+	if strings.Join(merged, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+}
+
+func TestSearchAll_StoresNormalizedContent(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": [
+		{"ts": "1.1", "text": "Deploy the Payment service with the CLI!", "channel": {"id": "C1"}}
+	]}}`)
+	db := setupTestDB(t)
+	slackService := NewSlackService(cfg)
+	service := NewSearchService(slackService, NewConfluenceService(cfg), db, cfg)
+
+	results, _, err := service.SearchAll(context.Background(), "How do I deploy the payment service?", 1, "")
+	if err != nil {
+		t.Fatalf("SearchAll returned error: %v", err)
+	}
+	if len(results) != 1 || results[0].Score != 1.0 {
+		t.Fatalf("Expected one fully matching result, got %+v", results)
+	}
+
+	var stored storage.SearchResult
+	if err := db.First(&stored).Error; err != nil {
+		t.Fatalf("Expected search result to be stored: %v", err)
+	}
+	if stored.NormalizedContent != "deploy payment service cli" {
+		t.Errorf("Expected normalized content 'deploy payment service cli', got '%s'", stored.NormalizedContent)
+	}
+	if stored.Score != 1.0 {
+		t.Errorf("Expected stored score 1.0, got %f", stored.Score)
+	}
+}
+
+func TestFilterAndRankResults_ScoresNormalizedContent(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0.5
+	service := &SearchService{config: cfg}
+
+	results := []storage.SearchResult{
+		{Title: "Matches", Content: "ignored when normalized content is set", NormalizedContent: "deploy service production"},
+		{Title: "Half", NormalizedContent: "deploy staging"},
+		{Title: "Fallback", Content: "How to DEPLOY the Service"},
+		{Title: "Miss", NormalizedContent: "unrelated chatter"},
+	}
+
+	filtered := service.filterAndRankResults(results, "deploy service", "")
+	if len(filtered) != 3 {
+		t.Fatalf("Expected 3 results above threshold, got %+v", filtered)
+	}
+	if results[0].Score != 1.0 || results[1].Score != 0.5 || results[3].Score != 0 {
+		t.Errorf("Unexpected scores: %f, %f, %f", results[0].Score, results[1].Score, results[3].Score)
+	}
+	if results[2].NormalizedContent == "" || results[2].Score != 1.0 {
+		t.Errorf("Expected missing normalized content to be computed, got %+v", results[2])
+	}
+}
+
+func TestFilterAndRankResults_ManualOverride(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SimilarityThreshold = 0.5
+	cfg.MaxSearchResults = 1
+	service := &SearchService{config: cfg}
+
+	results := []storage.SearchResult{
+		{Title: "Ranked", NormalizedContent: "deploy service"},
+		{Title: "Runner-up", NormalizedContent: "deploy service"},
+		{Title: "Pinned", NormalizedContent: "unrelated chatter", ManualOverride: true},
+	}
+
+	filtered := service.filterAndRankResults(results, "deploy service", "")
+	if len(filtered) != 2 {
+		t.Fatalf("Expected the override plus one ranked result, got %+v", filtered)
+	}
+	if filtered[0].Title != "Pinned" || filtered[0].Score != 0 {
+		t.Errorf("Expected the unscored override first, got %+v", filtered[0])
+	}
+	if filtered[1].Title != "Ranked" {
+		t.Errorf("Expected the best ranked result after the override, got %+v", filtered[1])
+	}
+}
+
+func TestAdjustThresholdDynamically(t *testing.T) {
+	tests := []struct {
+		name           string
+		scores         []float64
+		minResultCount int
+		expected       float64
+	}{
+		{name: "enough results", scores: []float64{0.9, 0.3}, minResultCount: 1, expected: 0.7},
+		{name: "lowered until one passes", scores: []float64{0.45, 0.3}, minResultCount: 1, expected: 0.4},
+		{name: "lowered exactly onto a score", scores: []float64{0.5}, minResultCount: 1, expected: 0.5},
+		{name: "lowered until two pass", scores: []float64{0.65, 0.3}, minResultCount: 2, expected: 0.3},
+		{name: "stops at the floor", scores: []float64{0.1}, minResultCount: 1, expected: 0.2},
+		{name: "no results", minResultCount: 1, expected: 0.2},
+		{name: "disabled", scores: []float64{0.1}, minResultCount: 0, expected: 0.7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.LoadTestConfig()
+			cfg.MinResultCount = tt.minResultCount
+			service := &SearchService{config: cfg}
+
+			var results []storage.SearchResult
+			for _, score := range tt.scores {
+				results = append(results, storage.SearchResult{Score: score})
+			}
+
+			if threshold := service.AdjustThresholdDynamically(results); threshold != tt.expected {
+				t.Errorf("Expected threshold %v, got %v", tt.expected, threshold)
+			}
+		})
+	}
+}
+
+func TestFilterAndRankResults_DynamicThreshold(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	service := &SearchService{config: cfg}
+
+	results := []storage.SearchResult{
+		{Source: "confluence", SourceID: "P1", Score: 0.55},
+		{Source: "confluence", SourceID: "P2", Score: 0.25},
+		{Source: "confluence", SourceID: "P3", Score: 0.1},
+	}
+
+	_, explanation := service.rankResults(context.Background(), results, "", "")
+	filtered := service.filterAndRankResults(results, "", "")
+	if len(filtered) != 1 || filtered[0].SourceID != "P1" {
+		t.Errorf("Expected only the best result once the threshold dropped to 0.5, got %+v", filtered)
+	}
+	if explanation.Threshold != 0.5 {
+		t.Errorf("Expected the explanation to record the lowered threshold, got %v", explanation.Threshold)
+	}
+}
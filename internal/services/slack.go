@@ -1,18 +1,64 @@
 package services
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
 	"github.com/sirupsen/logrus"
 	"github.com/slack-go/slack"
+	"golang.org/x/sync/singleflight"
 )
 
 // SlackService handles Slack API interactions
 type SlackService struct {
-	client *slack.Client
-	config *config.Config
+	// configMu guards client and config, which Reload replaces
+	configMu sync.RWMutex
+	client   *slack.Client
+	config   *config.Config
+
+	botUserMu sync.Mutex
+	botUserID string
+
+	// channelNames caches the names searches are scoped with, by channel ID
+	channelNamesMu sync.Mutex
+	channelNames   map[string]string
+
+	// users caches the users GetUserInfo looked up, by ID; userFlight shares
+	// a lookup between callers asking for the same user at once
+	usersMu    sync.Mutex
+	users      map[string]*slack.User
+	userFlight singleflight.Group
+}
+
+// ErrMessageNotFound is returned when Slack has no message at the requested timestamp
+var ErrMessageNotFound = errors.New("message not found")
+
+// maxFileContentBytes is the largest file GetFileContent downloads
+const maxFileContentBytes = 100 * 1024
+
+// maxImageBytes is the largest image GetImageDataURI downloads
+const maxImageBytes = 5 * 1024 * 1024
+
+// userMessageSubtypes are the subtypes of messages written by users, which are
+// always answerable; join notices, bot posts and other subtypes are not unless
+// listed in ANSWERABLE_MESSAGE_SUBTYPES
+var userMessageSubtypes = map[string]bool{
+	"":                 true,
+	"thread_broadcast": true,
+	"file_share":       true,
+	"me_message":       true,
 }
 
 // SlackMessage represents a Slack message
@@ -23,6 +69,8 @@ type SlackMessage struct {
 	Text      string
 	Timestamp string
 	ThreadTS  string
+	Reactions []slack.ItemReaction
+	Files     []slack.File
 }
 
 // NewSlackService creates a new Slack service instance
@@ -30,7 +78,13 @@ func NewSlackService(cfg *config.Config) *SlackService {
 	var client *slack.Client
 
 	if cfg.SlackBotToken != "" {
-		client = slack.New(cfg.SlackBotToken)
+		options := []slack.Option{
+			slack.OptionHTTPClient(&http.Client{Transport: rawBodyTransport{base: http.DefaultTransport}}),
+		}
+		if cfg.SlackAPIURL != "" {
+			options = append(options, slack.OptionAPIURL(cfg.SlackAPIURL))
+		}
+		client = slack.New(cfg.SlackBotToken, options...)
 	}
 
 	return &SlackService{
@@ -39,66 +93,297 @@ func NewSlackService(cfg *config.Config) *SlackService {
 	}
 }
 
+// rawBodyKey is the context key of the buffer captureRawBody copies response bodies into
+type rawBodyKey struct{}
+
+// captureRawBody returns a context whose Slack API response bodies are copied
+// into the returned buffer as the client reads them, so debug storage keeps
+// what Slack actually sent rather than what slack-go parsed out of it
+func captureRawBody(ctx context.Context) (context.Context, *bytes.Buffer) {
+	var raw bytes.Buffer
+	return context.WithValue(ctx, rawBodyKey{}, &raw), &raw
+}
+
+// rawBodyTransport tees the response bodies of requests made with a
+// captureRawBody context into its buffer
+type rawBodyTransport struct {
+	base http.RoundTripper
+}
+
+func (t rawBodyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	if raw, ok := req.Context().Value(rawBodyKey{}).(*bytes.Buffer); ok {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.TeeReader(resp.Body, raw), resp.Body}
+	}
+	return resp, nil
+}
+
+// Reload switches the service to cfg, rebuilding the Slack client for the new
+// token and API URL. Calls already in flight finish with the previous client.
+func (s *SlackService) Reload(cfg *config.Config) {
+	reloaded := NewSlackService(cfg)
+	s.configMu.Lock()
+	s.client = reloaded.client
+	s.config = cfg
+	s.configMu.Unlock()
+
+	s.botUserMu.Lock()
+	s.botUserID = ""
+	s.botUserMu.Unlock()
+
+	s.channelNamesMu.Lock()
+	s.channelNames = nil
+	s.channelNamesMu.Unlock()
+
+	s.usersMu.Lock()
+	s.users = nil
+	s.usersMu.Unlock()
+}
+
+// api returns the current Slack client, nil without a bot token
+func (s *SlackService) api() *slack.Client {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.client
+}
+
+// cfg returns the current configuration
+func (s *SlackService) cfg() *config.Config {
+	s.configMu.RLock()
+	defer s.configMu.RUnlock()
+	return s.config
+}
+
+// BotUserID returns the user ID the bot token belongs to, looked up once and cached
+func (s *SlackService) BotUserID() (string, error) {
+	client := s.api()
+	if client == nil {
+		return "", fmt.Errorf("missing Slack client configuration")
+	}
+
+	s.botUserMu.Lock()
+	defer s.botUserMu.Unlock()
+
+	if s.botUserID == "" {
+		identity, err := client.AuthTest()
+		if err != nil {
+			return "", fmt.Errorf("failed to identify bot user: %w", err)
+		}
+		s.botUserID = identity.UserID
+	}
+
+	return s.botUserID, nil
+}
+
 // GetMessage retrieves a specific message from Slack
 func (s *SlackService) GetMessage(channelID, messageTS string) (*SlackMessage, error) {
-	if s.client == nil {
+	client := s.api()
+	if client == nil {
 		return nil, fmt.Errorf("missing Slack client configuration")
 	}
 
 	// Get conversation history with the specific message
 	params := &slack.GetConversationHistoryParameters{
 		ChannelID: channelID,
+		Oldest:    messageTS,
 		Latest:    messageTS,
 		Limit:     1,
 		Inclusive: true,
 	}
 
-	history, err := s.client.GetConversationHistory(params)
+	history, err := client.GetConversationHistory(params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get message: %w", err)
 	}
 
-	if len(history.Messages) == 0 {
-		return nil, fmt.Errorf("message not found")
+	// History can include other messages, such as join notices, around the
+	// requested one; only the message at messageTS is the one asked for
+	for _, msg := range history.Messages {
+		if msg.Timestamp != messageTS {
+			continue
+		}
+		if !s.isAnswerableSubtype(msg.SubType) {
+			return nil, fmt.Errorf("message %s in %s has subtype %q, which is not answered", messageTS, channelID, msg.SubType)
+		}
+
+		return &SlackMessage{
+			ID:        msg.Timestamp,
+			Channel:   channelID,
+			User:      msg.User,
+			Text:      msg.Text,
+			Timestamp: msg.Timestamp,
+			ThreadTS:  msg.ThreadTimestamp,
+			Files:     msg.Files,
+		}, nil
 	}
 
-	msg := history.Messages[0]
-	return &SlackMessage{
-		ID:        msg.Timestamp,
-		Channel:   channelID,
-		User:      msg.User,
-		Text:      msg.Text,
-		Timestamp: msg.Timestamp,
-		ThreadTS:  msg.ThreadTimestamp,
-	}, nil
+	return nil, fmt.Errorf("%w: no message at %s in %s", ErrMessageNotFound, messageTS, channelID)
 }
 
-// SearchMessages searches for messages in a channel
-func (s *SlackService) SearchMessages(query string, daysBack int) ([]SlackMessage, error) {
-	if s.client == nil {
-		return nil, fmt.Errorf("missing Slack client configuration")
+// GetFileContent downloads the content of a text file shared in Slack. Files
+// that aren't text, or are larger than maxFileContentBytes, are not downloaded.
+func (s *SlackService) GetFileContent(ctx context.Context, file slack.File) (string, error) {
+	if s.api() == nil {
+		return "", fmt.Errorf("missing Slack client configuration")
+	}
+	if !isTextFile(file) {
+		return "", fmt.Errorf("file %s is %q, not text", file.ID, file.Mimetype)
+	}
+	if file.Size > maxFileContentBytes {
+		return "", fmt.Errorf("file %s is %d bytes, over the %d byte limit", file.ID, file.Size, maxFileContentBytes)
+	}
+
+	content, err := s.downloadFile(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	return content.String(), nil
+}
+
+// GetImageDataURI downloads a JPEG or PNG image shared in Slack as a base64
+// data URI. Other files, and images larger than maxImageBytes, are not downloaded.
+func (s *SlackService) GetImageDataURI(ctx context.Context, file slack.File) (string, error) {
+	if s.api() == nil {
+		return "", fmt.Errorf("missing Slack client configuration")
+	}
+	if !isImageFile(file) {
+		return "", fmt.Errorf("file %s is %q, not a JPEG or PNG image", file.ID, file.Mimetype)
+	}
+	if file.Size > maxImageBytes {
+		return "", fmt.Errorf("file %s is %d bytes, over the %d byte limit", file.ID, file.Size, maxImageBytes)
+	}
+
+	content, err := s.downloadFile(ctx, file)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + file.Mimetype + ";base64," + base64.StdEncoding.EncodeToString(content.Bytes()), nil
+}
+
+// downloadFile downloads a file shared in Slack with the bot token
+func (s *SlackService) downloadFile(ctx context.Context, file slack.File) (*bytes.Buffer, error) {
+	downloadURL := file.URLPrivateDownload
+	if downloadURL == "" {
+		downloadURL = file.URLPrivate
 	}
+	var content bytes.Buffer
+	if err := s.api().GetFileContext(ctx, downloadURL, &content); err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	return &content, nil
+}
+
+// isImageFile reports whether file is an image vision models accept
+func isImageFile(file slack.File) bool {
+	return file.Mimetype == "image/jpeg" || file.Mimetype == "image/png"
+}
+
+// isTextFile reports whether file holds text: plain text, snippets and
+// structured text such as JSON or YAML
+func isTextFile(file slack.File) bool {
+	if strings.HasPrefix(file.Mimetype, "text/") {
+		return true
+	}
+	switch file.Mimetype {
+	case "application/json", "application/xml", "application/x-yaml", "application/yaml":
+		return true
+	}
+	return false
+}
+
+// isAnswerableSubtype reports whether messages of subtype may be answered
+func (s *SlackService) isAnswerableSubtype(subtype string) bool {
+	if userMessageSubtypes[subtype] {
+		return true
+	}
+	for _, allowed := range s.cfg().AnswerableSubtypes {
+		if allowed == subtype {
+			return true
+		}
+	}
+	return false
+}
 
-	// Calculate the date range
-	now := time.Now()
+// slackSearchQuery is the search.messages query for query in channel over
+// the daysBack days before now, in any channel when channel is ""
+func slackSearchQuery(query, channel string, daysBack int, now time.Time) string {
 	after := now.AddDate(0, 0, -daysBack)
+	if channel == "" {
+		return fmt.Sprintf("%s after:%s", query, after.Format("2006-01-02"))
+	}
+	return fmt.Sprintf("%s in:%s after:%s", query, channel, after.Format("2006-01-02"))
+}
+
+// searchChannel is what searches in channelID are scoped with. Slack search's
+// in: filter expects a channel name, so with SLACK_SEARCH_CHANNEL_NAMES the ID
+// is resolved to its #name, looked up once and cached. When the name can't be
+// looked up the search isn't scoped at all, which beats silently finding nothing.
+// Channels already given as a #name are used as they are.
+func (s *SlackService) searchChannel(ctx context.Context, channelID string) string {
+	if !s.cfg().SearchChannelNames || channelID == "" || strings.HasPrefix(channelID, "#") {
+		return channelID
+	}
+
+	s.channelNamesMu.Lock()
+	name, ok := s.channelNames[channelID]
+	s.channelNamesMu.Unlock()
+	if ok {
+		return name
+	}
+
+	channel, err := s.api().GetConversationInfoContext(ctx, &slack.GetConversationInfoInput{ChannelID: channelID})
+	if err == nil && channel.Name == "" {
+		err = fmt.Errorf("channel has no name")
+	}
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("channel_id", channelID).Warn("Failed to look up channel name, searching all channels")
+		return ""
+	}
+
+	name = "#" + channel.Name
+	s.channelNamesMu.Lock()
+	if s.channelNames == nil {
+		s.channelNames = make(map[string]string)
+	}
+	s.channelNames[channelID] = name
+	s.channelNamesMu.Unlock()
+	return name
+}
+
+// SearchMessages searches for messages in a channel
+func (s *SlackService) SearchMessages(query string, daysBack int) ([]SlackMessage, error) {
+	messages, _, err := s.SearchMessagesRaw(context.Background(), query, daysBack)
+	return messages, err
+}
+
+// SearchMessagesRaw searches for messages in a channel and also returns the
+// search response as JSON for debugging. The request is abandoned when ctx is done.
+func (s *SlackService) SearchMessagesRaw(ctx context.Context, query string, daysBack int) ([]SlackMessage, []byte, error) {
+	client := s.api()
+	if client == nil {
+		return nil, nil, fmt.Errorf("missing Slack client configuration")
+	}
 
 	// Build search query
-	searchQuery := fmt.Sprintf("%s in:%s after:%s",
-		query,
-		s.config.SlackChannelID,
-		after.Format("2006-01-02"))
+	searchQuery := slackSearchQuery(query, s.searchChannel(ctx, s.cfg().SlackChannelID), daysBack, time.Now())
 
 	// Perform search
 	searchParams := slack.SearchParameters{
-		Count: s.config.MaxSearchResults,
+		Count: s.cfg().MaxSearchResults,
 		Sort:  "timestamp",
 	}
 
-	searchResult, err := s.client.SearchMessages(searchQuery, searchParams)
+	searchCtx, raw := captureRawBody(ctx)
+	searchResult, err := client.SearchMessagesContext(searchCtx, searchQuery, searchParams)
 	if err != nil {
-		logrus.WithError(err).WithField("query", searchQuery).Error("Failed to search Slack messages")
-		return nil, fmt.Errorf("failed to search messages: %w", err)
+		loggerFrom(ctx).WithError(err).WithField("query", searchQuery).Error("Failed to search Slack messages")
+		return nil, nil, fmt.Errorf("failed to search messages: %w", err)
 	}
 
 	// Convert to our message format
@@ -113,16 +398,321 @@ func (s *SlackService) SearchMessages(query string, daysBack int) ([]SlackMessag
 		})
 	}
 
+	return messages, raw.Bytes(), nil
+}
+
+// SearchMessagesInThreads searches channelID like SearchMessages, but also
+// returns the other messages in the threads that matching replies belong to
+func (s *SlackService) SearchMessagesInThreads(query, channelID string, daysBack int) ([]SlackMessage, error) {
+	messages, _, err := s.SearchMessagesInThreadsRaw(context.Background(), query, channelID, daysBack)
+	return messages, err
+}
+
+// SearchMessagesInThreadsRaw is SearchMessagesInThreads that also returns the
+// search response as JSON for debugging. Each thread is fetched once, and
+// messages already matched by the search aren't repeated.
+func (s *SlackService) SearchMessagesInThreadsRaw(ctx context.Context, query, channelID string, daysBack int) ([]SlackMessage, []byte, error) {
+	client := s.api()
+	if client == nil {
+		return nil, nil, fmt.Errorf("missing Slack client configuration")
+	}
+
+	searchQuery := slackSearchQuery(query, s.searchChannel(ctx, channelID), daysBack, time.Now())
+	searchParams := slack.SearchParameters{
+		Count:         s.cfg().MaxSearchResults,
+		Sort:          "timestamp",
+		SortDirection: "asc",
+	}
+
+	searchCtx, raw := captureRawBody(ctx)
+	searchResult, err := client.SearchMessagesContext(searchCtx, searchQuery, searchParams)
+	if err != nil {
+		loggerFrom(ctx).WithError(err).WithField("query", searchQuery).Error("Failed to search Slack messages")
+		return nil, nil, fmt.Errorf("failed to search messages: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	messages := make([]SlackMessage, 0, len(searchResult.Matches))
+	for _, match := range searchResult.Matches {
+		messages = append(messages, SlackMessage{
+			ID:        match.Timestamp,
+			Channel:   match.Channel.ID,
+			User:      match.User,
+			Text:      match.Text,
+			Timestamp: match.Timestamp,
+			ThreadTS:  permalinkThreadTS(match.Permalink),
+		})
+		seen[match.Channel.ID+":"+match.Timestamp] = true
+	}
+
+	matched := len(messages)
+	fetched := make(map[string]bool)
+	for _, msg := range messages[:matched] {
+		if msg.ThreadTS == "" || msg.ThreadTS == msg.Timestamp || fetched[msg.Channel+":"+msg.ThreadTS] {
+			continue
+		}
+		fetched[msg.Channel+":"+msg.ThreadTS] = true
+
+		replies, err := s.threadReplies(ctx, msg.Channel, msg.ThreadTS)
+		if err != nil {
+			// The matched reply is still useful without its siblings
+			loggerFrom(ctx).WithError(err).WithField("thread_ts", msg.ThreadTS).Warn("Failed to fetch thread of matching reply")
+			continue
+		}
+		for _, reply := range replies {
+			if !seen[reply.Channel+":"+reply.Timestamp] {
+				seen[reply.Channel+":"+reply.Timestamp] = true
+				messages = append(messages, reply)
+			}
+		}
+	}
+
+	return messages, raw.Bytes(), nil
+}
+
+// permalinkThreadTS extracts the parent timestamp from the permalink of a
+// thread reply, returning "" for top-level messages
+func permalinkThreadTS(permalink string) string {
+	parsed, err := url.Parse(permalink)
+	if err != nil {
+		return ""
+	}
+	return parsed.Query().Get("thread_ts")
+}
+
+// GetThreadReplies retrieves all messages in a thread, including the parent message
+func (s *SlackService) GetThreadReplies(channelID, threadTS string) ([]SlackMessage, error) {
+	return s.threadReplies(context.Background(), channelID, threadTS)
+}
+
+// threadReplies is GetThreadReplies, abandoned when ctx is done
+func (s *SlackService) threadReplies(ctx context.Context, channelID, threadTS string) ([]SlackMessage, error) {
+	client := s.api()
+	if client == nil {
+		return nil, fmt.Errorf("missing Slack client configuration")
+	}
+
+	var messages []SlackMessage
+	params := &slack.GetConversationRepliesParameters{
+		ChannelID: channelID,
+		Timestamp: threadTS,
+	}
+	for {
+		replies, hasMore, nextCursor, err := client.GetConversationRepliesContext(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get thread replies: %w", err)
+		}
+
+		for _, msg := range replies {
+			messages = append(messages, SlackMessage{
+				ID:        msg.Timestamp,
+				Channel:   channelID,
+				User:      msg.User,
+				Text:      msg.Text,
+				Timestamp: msg.Timestamp,
+				ThreadTS:  msg.ThreadTimestamp,
+			})
+		}
+
+		if !hasMore || nextCursor == "" {
+			break
+		}
+		params.Cursor = nextCursor
+	}
+
+	return messages, nil
+}
+
+// GetReplyCount returns how many replies the thread started by the message at ts has
+func (s *SlackService) GetReplyCount(ctx context.Context, channelID, ts string) (int, error) {
+	client := s.api()
+	if client == nil {
+		return 0, fmt.Errorf("missing Slack client configuration")
+	}
+
+	// The parent message comes first and carries the thread's reply count
+	replies, _, _, err := client.GetConversationRepliesContext(ctx, &slack.GetConversationRepliesParameters{
+		ChannelID: channelID,
+		Timestamp: ts,
+		Limit:     1,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get thread replies: %w", err)
+	}
+	if len(replies) == 0 {
+		return 0, nil
+	}
+
+	return replies[0].ReplyCount, nil
+}
+
+// AddReaction reacts to the message at ts with emoji
+func (s *SlackService) AddReaction(channelID, ts, emoji string) error {
+	client := s.api()
+	if client == nil {
+		return fmt.Errorf("missing Slack client configuration")
+	}
+
+	if err := client.AddReaction(emoji, slack.NewRefToMessage(channelID, ts)); err != nil {
+		return fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetMessageReactions returns the reactions on the message at timestamp
+func (s *SlackService) GetMessageReactions(channelID, timestamp string) ([]slack.ItemReaction, error) {
+	client := s.api()
+	if client == nil {
+		return nil, fmt.Errorf("missing Slack client configuration")
+	}
+
+	reactions, err := client.GetReactions(slack.NewRefToMessage(channelID, timestamp), slack.NewGetReactionsParameters())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+
+	return reactions, nil
+}
+
+// ListRecentMessages retrieves the top-level messages posted in a channel since
+// the given time, oldest first
+func (s *SlackService) ListRecentMessages(channelID string, since time.Time) ([]SlackMessage, error) {
+	client := s.api()
+	if client == nil {
+		return nil, fmt.Errorf("missing Slack client configuration")
+	}
+
+	var messages []SlackMessage
+	params := &slack.GetConversationHistoryParameters{
+		ChannelID: channelID,
+		Oldest:    fmt.Sprintf("%d.000000", since.Unix()),
+		Limit:     200,
+	}
+	for {
+		history, err := client.GetConversationHistory(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list messages: %w", err)
+		}
+
+		for _, msg := range history.Messages {
+			messages = append(messages, SlackMessage{
+				ID:        msg.Timestamp,
+				Channel:   channelID,
+				User:      msg.User,
+				Text:      msg.Text,
+				Timestamp: msg.Timestamp,
+				ThreadTS:  msg.ThreadTimestamp,
+				Reactions: msg.Reactions,
+			})
+		}
+
+		if !history.HasMore || history.ResponseMetaData.NextCursor == "" {
+			break
+		}
+		params.Cursor = history.ResponseMetaData.NextCursor
+	}
+
+	// Slack returns history newest first
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+
 	return messages, nil
 }
 
+// GetFileMessage retrieves a shared file as a message: its title, plus the text of
+// commentID when set. Channel and Timestamp point at the message that shared the file.
+func (s *SlackService) GetFileMessage(fileID, commentID string) (*SlackMessage, error) {
+	client := s.api()
+	if client == nil {
+		return nil, fmt.Errorf("missing Slack client configuration")
+	}
+
+	file, comments, _, err := client.GetFileInfo(fileID, 100, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	message := &SlackMessage{
+		ID:   fileID,
+		User: file.User,
+		Text: file.Title,
+	}
+
+	if commentID != "" {
+		found := false
+		for _, comment := range comments {
+			if comment.ID == commentID {
+				message.ID = commentID
+				message.User = comment.User
+				message.Text = strings.TrimSpace(file.Title + "\n" + comment.Comment)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("file comment not found")
+		}
+	}
+
+	// Reply where the file was first shared, preferring public channels
+	for _, shares := range []map[string][]slack.ShareFileInfo{file.Shares.Public, file.Shares.Private} {
+		if channelID, ts, ok := earliestShare(shares); ok {
+			message.Channel = channelID
+			message.Timestamp = ts
+			return message, nil
+		}
+	}
+
+	return nil, fmt.Errorf("file has not been shared in any channel")
+}
+
+// earliestShare returns the channel and timestamp of the earliest share in
+// shares, breaking ties by channel ID so the choice doesn't depend on map order
+func earliestShare(shares map[string][]slack.ShareFileInfo) (string, string, bool) {
+	var channel, ts string
+	var earliest float64
+	found := false
+	for channelID, infos := range shares {
+		for _, info := range infos {
+			at, err := strconv.ParseFloat(info.Ts, 64)
+			if err != nil {
+				continue
+			}
+			if !found || at < earliest || (at == earliest && channelID < channel) {
+				channel, ts, earliest, found = channelID, info.Ts, at, true
+			}
+		}
+	}
+	return channel, ts, found
+}
+
+// identityOptions post as the RESPONSE_USERNAME persona with its icon instead
+// of the bot's own name and icon, when configured (requires chat:write.customize)
+func (s *SlackService) identityOptions() []slack.MsgOption {
+	var options []slack.MsgOption
+	if s.cfg().ResponseUsername != "" {
+		options = append(options, slack.MsgOptionUsername(s.cfg().ResponseUsername))
+	}
+	if s.cfg().ResponseIconEmoji != "" {
+		options = append(options, slack.MsgOptionIconEmoji(s.cfg().ResponseIconEmoji))
+	}
+	if s.cfg().ResponseIconURL != "" {
+		options = append(options, slack.MsgOptionIconURL(s.cfg().ResponseIconURL))
+	}
+	return options
+}
+
 // PostMessage sends a message to a Slack channel
 func (s *SlackService) PostMessage(channelID, text string) (string, error) {
-	if s.client == nil {
+	client := s.api()
+	if client == nil {
 		return "", fmt.Errorf("missing Slack client configuration")
 	}
 
-	_, timestamp, err := s.client.PostMessage(channelID, slack.MsgOptionText(text, false))
+	options := append([]slack.MsgOption{slack.MsgOptionText(text, false)}, s.identityOptions()...)
+	_, timestamp, err := client.PostMessage(channelID, options...)
 	if err != nil {
 		return "", fmt.Errorf("failed to post message: %w", err)
 	}
@@ -132,15 +722,16 @@ func (s *SlackService) PostMessage(channelID, text string) (string, error) {
 
 // PostThreadReply sends a reply to a message thread
 func (s *SlackService) PostThreadReply(channelID, threadTS, text string) (string, error) {
-	if s.client == nil {
+	client := s.api()
+	if client == nil {
 		return "", fmt.Errorf("missing Slack client configuration")
 	}
 
-	_, timestamp, err := s.client.PostMessage(
-		channelID,
+	options := append([]slack.MsgOption{
 		slack.MsgOptionText(text, false),
 		slack.MsgOptionTS(threadTS),
-	)
+	}, s.identityOptions()...)
+	_, timestamp, err := client.PostMessage(channelID, options...)
 	if err != nil {
 		return "", fmt.Errorf("failed to post thread reply: %w", err)
 	}
@@ -148,27 +739,215 @@ func (s *SlackService) PostThreadReply(channelID, threadTS, text string) (string
 	return timestamp, nil
 }
 
-// GetUserInfo retrieves user information
+// UpdateMessage replaces the text of the bot's message at ts
+func (s *SlackService) UpdateMessage(channelID, ts, text string) error {
+	client := s.api()
+	if client == nil {
+		return fmt.Errorf("missing Slack client configuration")
+	}
+
+	if _, _, _, err := client.UpdateMessage(channelID, ts, slack.MsgOptionText(text, false)); err != nil {
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMessage deletes the bot's message at ts
+func (s *SlackService) DeleteMessage(channelID, ts string) error {
+	client := s.api()
+	if client == nil {
+		return fmt.Errorf("missing Slack client configuration")
+	}
+
+	if _, _, err := client.DeleteMessage(channelID, ts); err != nil {
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	return nil
+}
+
+// UploadSnippet uploads content as a text snippet in a thread
+func (s *SlackService) UploadSnippet(channelID, threadTS, title, content string) error {
+	client := s.api()
+	if client == nil {
+		return fmt.Errorf("missing Slack client configuration")
+	}
+
+	_, err := client.UploadFile(slack.FileUploadParameters{
+		Content:         content,
+		Filetype:        "text",
+		Filename:        "answer.txt",
+		Title:           title,
+		Channels:        []string{channelID},
+		ThreadTimestamp: threadTS,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload snippet: %w", err)
+	}
+
+	return nil
+}
+
+// PostEphemeral sends a message only userID can see in a channel
+func (s *SlackService) PostEphemeral(channelID, userID, text string) error {
+	client := s.api()
+	if client == nil {
+		return fmt.Errorf("missing Slack client configuration")
+	}
+
+	options := append([]slack.MsgOption{slack.MsgOptionText(text, false)}, s.identityOptions()...)
+	if _, err := client.PostEphemeral(channelID, userID, options...); err != nil {
+		return fmt.Errorf("failed to post ephemeral message: %w", err)
+	}
+
+	return nil
+}
+
+// CreateCanvas creates a channel canvas from markdown content and returns its ID.
+// slack-go has no canvases support, so the method is called directly.
+func (s *SlackService) CreateCanvas(channelID, title, content string) (string, error) {
+	if s.api() == nil {
+		return "", fmt.Errorf("missing Slack client configuration")
+	}
+
+	documentContent, err := json.Marshal(map[string]string{"type": "markdown", "markdown": content})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode canvas content: %w", err)
+	}
+
+	form := url.Values{
+		"channel_id":       {channelID},
+		"title":            {title},
+		"document_content": {string(documentContent)},
+	}
+
+	apiURL := s.cfg().SlackAPIURL
+	if apiURL == "" {
+		apiURL = slack.APIURL
+	}
+
+	req, err := http.NewRequest("POST", apiURL+"canvases.create", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+s.cfg().SlackBotToken)
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create canvas: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			logrus.WithError(err).Error("failed to close response body")
+		}
+	}()
+
+	var result struct {
+		OK       bool   `json:"ok"`
+		Error    string `json:"error"`
+		CanvasID string `json:"canvas_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode canvas response: %w", err)
+	}
+	if !result.OK {
+		return "", fmt.Errorf("failed to create canvas: %s", result.Error)
+	}
+
+	return result.CanvasID, nil
+}
+
+// GetMessagePermalink returns the permalink of the message at ts in channelID
+func (s *SlackService) GetMessagePermalink(channelID, ts string) (string, error) {
+	client := s.api()
+	if client == nil {
+		return "", fmt.Errorf("missing Slack client configuration")
+	}
+
+	permalink, err := client.GetPermalink(&slack.PermalinkParameters{Channel: channelID, Ts: ts})
+	if err != nil {
+		return "", fmt.Errorf("failed to get message permalink: %w", err)
+	}
+
+	return permalink, nil
+}
+
+// GetFilePermalink returns the permalink of a file, including canvases
+func (s *SlackService) GetFilePermalink(fileID string) (string, error) {
+	client := s.api()
+	if client == nil {
+		return "", fmt.Errorf("missing Slack client configuration")
+	}
+
+	file, _, _, err := client.GetFileInfo(fileID, 0, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	return file.Permalink, nil
+}
+
+// GetUserInfo retrieves user information, looked up once per user and cached
 func (s *SlackService) GetUserInfo(userID string) (*slack.User, error) {
-	if s.client == nil {
+	client := s.api()
+	if client == nil {
 		return nil, fmt.Errorf("missing Slack client configuration")
 	}
 
-	user, err := s.client.GetUserInfo(userID)
+	s.usersMu.Lock()
+	user, ok := s.users[userID]
+	s.usersMu.Unlock()
+	if ok {
+		return user, nil
+	}
+
+	value, err, _ := s.userFlight.Do(userID, func() (interface{}, error) {
+		user, err := client.GetUserInfo(userID)
+		if err != nil {
+			return nil, err
+		}
+
+		s.usersMu.Lock()
+		if s.users == nil {
+			s.users = make(map[string]*slack.User)
+		}
+		s.users[userID] = user
+		s.usersMu.Unlock()
+		return user, nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
 
-	return user, nil
+	return value.(*slack.User), nil
+}
+
+// SetStatus updates the custom status of a Slack user. An empty userID targets
+// the user the token belongs to, and empty text and emoji clear the status.
+func (s *SlackService) SetStatus(userID, statusText, statusEmoji string) error {
+	client := s.api()
+	if client == nil {
+		return fmt.Errorf("missing Slack client configuration")
+	}
+
+	if err := client.SetUserCustomStatusWithUser(userID, statusText, statusEmoji, 0); err != nil {
+		return fmt.Errorf("failed to set status: %w", err)
+	}
+
+	return nil
 }
 
 // ValidateToken validates the Slack bot token
 func (s *SlackService) ValidateToken() error {
-	if s.client == nil {
+	client := s.api()
+	if client == nil {
 		return fmt.Errorf("missing Slack client configuration")
 	}
 
-	_, err := s.client.AuthTest()
+	_, err := client.AuthTest()
 	if err != nil {
 		return fmt.Errorf("invalid Slack token: %w", err)
 	}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+)
+
+// highlightSnippet returns about window characters of content centred on the
+// first keyword hit, with every keyword occurrence bolded in Slack mrkdwn.
+// Keywords match case-insensitively at the start of a word. Without a hit the
+// snippet is the start of content. Cut ends are marked with "...".
+func highlightSnippet(content string, keywords []string, window int) string {
+	text := []rune(strings.Join(strings.Fields(content), " "))
+	if len(text) == 0 || window <= 0 {
+		return ""
+	}
+
+	lower := make([]rune, len(text))
+	for i, r := range text {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	terms := make([][]rune, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			terms = append(terms, []rune(strings.ToLower(keyword)))
+		}
+	}
+
+	start, end := 0, len(text)
+	if len(text) > window {
+		hit := -1
+		for i := range lower {
+			if matchTermAt(lower, i, terms) > 0 {
+				hit = i
+				break
+			}
+		}
+
+		if hit >= 0 {
+			start = hit - window/2
+		}
+		if start+window > len(text) {
+			start = len(text) - window
+		}
+		if start < 0 {
+			start = 0
+		}
+		end = start + window
+
+		// Avoid cutting words in half at either end
+		if start > 0 {
+			for start < hit && text[start-1] != ' ' {
+				start++
+			}
+		}
+		if end < len(text) {
+			for trimmed := end; trimmed > start; trimmed-- {
+				if text[trimmed] == ' ' {
+					end = trimmed
+					break
+				}
+			}
+		}
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("...")
+	}
+	for i := start; i < end; {
+		length := matchTermAt(lower, i, terms)
+		if length == 0 || i+length > end {
+			b.WriteRune(text[i])
+			i++
+			continue
+		}
+		b.WriteString("*")
+		b.WriteString(string(text[i : i+length]))
+		b.WriteString("*")
+		i += length
+	}
+	if end < len(text) {
+		b.WriteString("...")
+	}
+
+	return b.String()
+}
+
+// matchTermAt returns the length of the longest term starting at a word
+// boundary at position i of text, or zero when none matches
+func matchTermAt(text []rune, i int, terms [][]rune) int {
+	if i > 0 && (unicode.IsLetter(text[i-1]) || unicode.IsDigit(text[i-1])) {
+		return 0
+	}
+
+	longest := 0
+	for _, term := range terms {
+		if len(term) <= longest || i+len(term) > len(text) {
+			continue
+		}
+		if string(text[i:i+len(term)]) == string(term) {
+			longest = len(term)
+		}
+	}
+	return longest
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	long := strings.Repeat("filler text ", 20) + "run the deploy script from the release branch " + strings.Repeat("more words ", 20)
+
+	tests := []struct {
+		name     string
+		content  string
+		keywords []string
+		window   int
+		expected string
+	}{
+		{
+			name:     "short content is highlighted in full",
+			content:  "How to Deploy the service",
+			keywords: []string{"deploy"},
+			window:   100,
+			expected: "How to *Deploy* the service",
+		},
+		{
+			name:     "every occurrence is bolded",
+			content:  "deploy staging then deploy production",
+			keywords: []string{"deploy", "production"},
+			window:   100,
+			expected: "*deploy* staging then *deploy* *production*",
+		},
+		{
+			name:     "only matches at word starts",
+			content:  "redeploy or deployment",
+			keywords: []string{"deploy"},
+			window:   100,
+			expected: "redeploy or *deploy*ment",
+		},
+		{
+			name:     "whitespace is collapsed",
+			content:  "line one\n\nline   two",
+			keywords: nil,
+			window:   100,
+			expected: "line one line two",
+		},
+		{
+			name:     "window is centred on the first hit",
+			content:  long,
+			keywords: []string{"deploy"},
+			window:   40,
+			expected: "...filler text run the *deploy* script from...",
+		},
+		{
+			name:     "no hit falls back to the start",
+			content:  long,
+			keywords: []string{"kubernetes"},
+			window:   30,
+			expected: "filler text filler text filler...",
+		},
+		{
+			name:     "hit near the end anchors the window to the end",
+			content:  "alpha beta gamma delta epsilon zeta eta theta",
+			keywords: []string{"theta"},
+			window:   20,
+			expected: "...zeta eta *theta*",
+		},
+		{
+			name:     "empty content",
+			content:  "",
+			keywords: []string{"deploy"},
+			window:   100,
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := highlightSnippet(tt.content, tt.keywords, tt.window)
+			if result != tt.expected {
+				t.Errorf("highlightSnippet() = %q, expected %q", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGenerateFallbackResponse_HighlightsSnippets(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	cfg.SnippetWindow = 40
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	content := strings.Repeat("unrelated chatter ", 10) + "the rollback runbook lives in the ops space"
+	response := service.generateFallbackResponse("How do I rollback?", []storage.SearchResult{
+		{Source: "confluence", Title: "Runbooks", Content: content},
+	})
+
+	if !strings.Contains(response, "the *rollback* runbook") {
+		t.Errorf("Expected highlighted snippet around the match, got:\n%s", response)
+	}
+	if strings.Contains(response, strings.Repeat("unrelated chatter ", 3)) {
+		t.Errorf("Expected snippet to skip leading content, got:\n%s", response)
+	}
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"sync"
+	"time"
+)
+
+// InquiryStats keeps running counters of inquiry processing since startup
+type InquiryStats struct {
+	mu           sync.Mutex
+	startedAt    time.Time
+	processed    int64
+	succeeded    int64
+	failed       int64
+	rejected     int64
+	totalLatency time.Duration
+}
+
+// StatsSnapshot is a point-in-time copy of the inquiry counters
+type StatsSnapshot struct {
+	Since          time.Time
+	Processed      int64
+	Succeeded      int64
+	Failed         int64
+	Rejected       int64
+	SuccessRate    float64
+	AverageLatency time.Duration
+}
+
+// NewInquiryStats creates a new set of counters starting now
+func NewInquiryStats() *InquiryStats {
+	return &InquiryStats{startedAt: time.Now()}
+}
+
+// RecordOutcome records a finished inquiry and how long it took
+func (s *InquiryStats) RecordOutcome(success bool, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.processed++
+	if success {
+		s.succeeded++
+	} else {
+		s.failed++
+	}
+	s.totalLatency += latency
+}
+
+// RecordRejected records an inquiry turned away because the queue was full
+func (s *InquiryStats) RecordRejected() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rejected++
+}
+
+// Snapshot returns the current counter values
+func (s *InquiryStats) Snapshot() StatsSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := StatsSnapshot{
+		Since:     s.startedAt,
+		Processed: s.processed,
+		Succeeded: s.succeeded,
+		Failed:    s.failed,
+		Rejected:  s.rejected,
+	}
+	if s.processed > 0 {
+		snapshot.SuccessRate = float64(s.succeeded) / float64(s.processed)
+		snapshot.AverageLatency = s.totalLatency / time.Duration(s.processed)
+	}
+
+	return snapshot
+}
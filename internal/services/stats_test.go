@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+func TestInquiryStats_ConcurrentRecords(t *testing.T) {
+	stats := NewInquiryStats()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stats.RecordOutcome(i%4 != 0, 10*time.Millisecond)
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := stats.Snapshot()
+	if snapshot.Processed != 100 {
+		t.Errorf("Expected 100 processed, got %d", snapshot.Processed)
+	}
+	if snapshot.Succeeded != 75 || snapshot.Failed != 25 {
+		t.Errorf("Expected 75 succeeded and 25 failed, got %d and %d", snapshot.Succeeded, snapshot.Failed)
+	}
+	if snapshot.SuccessRate != 0.75 {
+		t.Errorf("Expected success rate 0.75, got %f", snapshot.SuccessRate)
+	}
+	if snapshot.AverageLatency != 10*time.Millisecond {
+		t.Errorf("Expected average latency 10ms, got %s", snapshot.AverageLatency)
+	}
+}
+
+func TestInquiryStats_Empty(t *testing.T) {
+	snapshot := NewInquiryStats().Snapshot()
+
+	if snapshot.Processed != 0 || snapshot.SuccessRate != 0 || snapshot.AverageLatency != 0 {
+		t.Errorf("Expected zero values for empty stats, got %+v", snapshot)
+	}
+}
+
+func TestProcessInquiry_UpdatesStatsConcurrently(t *testing.T) {
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "answer")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ts := fmt.Sprintf("%d.000100", 1700000000+i)
+			if err := service.ProcessInquiry(context.Background(), ts, "C1", "U1", "deploy", ts, ""); err != nil {
+				t.Errorf("ProcessInquiry returned error: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	snapshot := service.Stats()
+	if snapshot.Processed != 5 || snapshot.Succeeded != 5 {
+		t.Errorf("Expected 5 successful inquiries, got %+v", snapshot)
+	}
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// wsClientBuffer is how many events a client may fall behind before it's dropped
+	wsClientBuffer = 16
+	// wsWriteTimeout bounds sending one event to a client
+	wsWriteTimeout = 10 * time.Second
+)
+
+// InquiryStatusEvent is pushed to WebSocket clients when an inquiry changes status
+type InquiryStatusEvent struct {
+	ID        uint      `json:"id"`
+	Status    string    `json:"status"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WebSocketService pushes inquiry status events to connected admin UI clients
+type WebSocketService struct {
+	config   *config.Config
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	clients map[*wsClient]struct{}
+}
+
+// wsClient is a connection and the queue of events waiting to be written to it
+type wsClient struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// NewWebSocketService creates a new WebSocket service instance
+func NewWebSocketService(cfg *config.Config) *WebSocketService {
+	return &WebSocketService{
+		config: cfg,
+		upgrader: websocket.Upgrader{
+			// Clients authenticate with a token rather than cookies, so
+			// connections from the admin UI's own origin are safe to accept
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		clients: make(map[*wsClient]struct{}),
+	}
+}
+
+// Reload switches the service to cfg, e.g. a rotated admin token
+func (s *WebSocketService) Reload(cfg *config.Config) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.config = cfg
+}
+
+// ServeHTTP upgrades a request carrying the admin API token in its token
+// query parameter to a WebSocket that receives every InquiryStatusEvent
+func (s *WebSocketService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	adminToken := s.config.AdminAPIToken
+	s.mu.Unlock()
+
+	if adminToken == "" {
+		logrus.Warn("WebSocket connection attempted but ADMIN_API_TOKEN is not configured")
+		http.Error(w, "admin API not configured", http.StatusServiceUnavailable)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.URL.Query().Get("token")), []byte(adminToken)) != 1 {
+		http.Error(w, "invalid admin token", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already replied with the error
+		logrus.WithError(err).Warn("Failed to upgrade WebSocket connection")
+		return
+	}
+
+	client := &wsClient{conn: conn, send: make(chan []byte, wsClientBuffer)}
+	s.mu.Lock()
+	s.clients[client] = struct{}{}
+	s.mu.Unlock()
+
+	go client.writeLoop()
+
+	// Clients only listen; reading detects when they go away
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	s.remove(client)
+}
+
+// Broadcast sends event to every connected client. Clients too far behind
+// to take it are disconnected rather than holding up the others.
+func (s *WebSocketService) Broadcast(event InquiryStatusEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		logrus.WithError(err).Error("Failed to marshal inquiry status event")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		select {
+		case client.send <- data:
+		default:
+			logrus.Warn("WebSocket client too slow, disconnecting")
+			delete(s.clients, client)
+			close(client.send)
+		}
+	}
+}
+
+// ClientCount returns the number of connected clients
+func (s *WebSocketService) ClientCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
+
+// Close disconnects every client, e.g. on shutdown
+func (s *WebSocketService) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for client := range s.clients {
+		delete(s.clients, client)
+		close(client.send)
+	}
+}
+
+// remove disconnects client if it is still connected
+func (s *WebSocketService) remove(client *wsClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.clients[client]; ok {
+		delete(s.clients, client)
+		close(client.send)
+	}
+}
+
+// writeLoop writes queued events to the connection until the queue is
+// closed or a write fails, then closes the connection
+func (c *wsClient) writeLoop() {
+	defer func() { _ = c.conn.Close() }()
+
+	for data := range c.send {
+		_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+		if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return
+		}
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	_ = c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
+)
+
+// dialWebSocket connects to ws's server with token, returning the connection
+// or the response to a rejected handshake
+func dialWebSocket(t *testing.T, server *httptest.Server, token string) (*websocket.Conn, *http.Response, error) {
+	t.Helper()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/?token=" + token
+	conn, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if conn != nil {
+		t.Cleanup(func() { _ = conn.Close() })
+	}
+	return conn, resp, err
+}
+
+// newWebSocketServer serves a WebSocket service accepting the admin token "secret"
+func newWebSocketServer(t *testing.T) (*WebSocketService, *httptest.Server) {
+	t.Helper()
+
+	cfg := config.LoadTestConfig()
+	cfg.AdminAPIToken = "secret"
+	ws := NewWebSocketService(cfg)
+	server := httptest.NewServer(ws)
+	t.Cleanup(server.Close)
+	t.Cleanup(ws.Close)
+	return ws, server
+}
+
+func TestWebSocketService_RejectsInvalidToken(t *testing.T) {
+	_, server := newWebSocketServer(t)
+
+	_, resp, err := dialWebSocket(t, server, "wrong")
+	if err == nil {
+		t.Fatal("Expected the handshake to be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected 401, got %v", resp)
+	}
+}
+
+func TestWebSocketService_BroadcastsToEveryClient(t *testing.T) {
+	ws, server := newWebSocketServer(t)
+
+	var conns []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		conn, _, err := dialWebSocket(t, server, "secret")
+		if err != nil {
+			t.Fatalf("Failed to connect: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+	if !waitFor(t, func() bool { return ws.ClientCount() == 2 }) {
+		t.Fatalf("Expected 2 clients, got %d", ws.ClientCount())
+	}
+
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ws.Broadcast(InquiryStatusEvent{ID: 7, Status: "completed", UpdatedAt: updatedAt})
+
+	for i, conn := range conns {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		var event InquiryStatusEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("Client %d failed to read event: %v", i, err)
+		}
+		if event.ID != 7 || event.Status != "completed" || !event.UpdatedAt.Equal(updatedAt) {
+			t.Errorf("Client %d got unexpected event %+v", i, event)
+		}
+	}
+}
+
+func TestWebSocketService_RemovesDisconnectedClients(t *testing.T) {
+	ws, server := newWebSocketServer(t)
+
+	conn, _, err := dialWebSocket(t, server, "secret")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if !waitFor(t, func() bool { return ws.ClientCount() == 1 }) {
+		t.Fatal("Expected the client to connect")
+	}
+
+	_ = conn.Close()
+	if !waitFor(t, func() bool { return ws.ClientCount() == 0 }) {
+		t.Errorf("Expected the client to be removed, got %d", ws.ClientCount())
+	}
+}
+
+func TestProcessInquiry_BroadcastsStatusChanges(t *testing.T) {
+	ws, server := newWebSocketServer(t)
+	conn, _, err := dialWebSocket(t, server, "secret")
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	if !waitFor(t, func() bool { return ws.ClientCount() == 1 }) {
+		t.Fatal("Expected the client to connect")
+	}
+
+	cfg := config.LoadTestConfig()
+	fake := newFakeSlack(t, cfg)
+	fake.respond("search.messages", `{"ok": true, "messages": {"matches": []}}`)
+	newFakeLLM(t, cfg, "Run make deploy")
+	service := newTestInquiryService(cfg, setupTestDB(t))
+	service.SetWebSocket(ws)
+
+	if err := service.ProcessInquiry(context.Background(), "1.1", "C1", "U1", "How do I deploy?", "1.1", ""); err != nil {
+		t.Fatalf("ProcessInquiry returned error: %v", err)
+	}
+
+	var statuses []string
+	for _, expected := range []string{"pending", "processing", "completed"} {
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		var event InquiryStatusEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("Failed to read event after %v: %v", statuses, err)
+		}
+		statuses = append(statuses, event.Status)
+		if event.Status != expected || event.ID == 0 || event.UpdatedAt.IsZero() {
+			t.Errorf("Expected a %s event, got %+v", expected, event)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AuditLog records a change made through the admin API
+type AuditLog struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+
+	UserID       string `json:"user_id"`       // who made the change, from X-Admin-User
+	Action       string `json:"action"`        // e.g. requeue, publish_faq
+	ResourceType string `json:"resource_type"` // e.g. inquiry
+	ResourceID   string `json:"resource_id"`
+}
+
+// AppendAuditLog stores an audit log entry
+func AppendAuditLog(db *gorm.DB, event AuditLog) error {
+	if err := db.Create(&event).Error; err != nil {
+		return fmt.Errorf("failed to append audit log: %w", err)
+	}
+	return nil
+}
+
+// ListAuditLog returns up to limit audit log entries created since the given
+// time, newest first
+func ListAuditLog(db *gorm.DB, since time.Time, limit int) ([]AuditLog, error) {
+	var events []AuditLog
+	if err := db.Where("created_at >= ?", since).Order("created_at DESC, id DESC").Limit(limit).Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+	return events, nil
+}
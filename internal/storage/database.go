@@ -6,6 +6,7 @@ import (
 
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
 )
 
@@ -53,5 +54,76 @@ func InitDB(dbPath string) (*gorm.DB, error) {
 		return nil, err
 	}
 
+	if err := db.AutoMigrate(&RawResponse{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&GreetedChannel{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&SearchCache{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&Feedback{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&AnswerVersion{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&KVEntry{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&AuditLog{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&SlackMessageIndex{}); err != nil {
+		return nil, err
+	}
+
+	if err := db.AutoMigrate(&Webhook{}); err != nil {
+		return nil, err
+	}
+
 	return db, nil
 }
+
+// SaveRawResponse stores a raw response and prunes all but the newest retain rows
+func SaveRawResponse(db *gorm.DB, response *RawResponse, retain int) error {
+	if err := db.Create(response).Error; err != nil {
+		return err
+	}
+
+	if retain <= 0 {
+		return nil
+	}
+
+	return db.Where("id NOT IN (?)", db.Model(&RawResponse{}).Select("id").Order("id DESC").Limit(retain)).
+		Delete(&RawResponse{}).Error
+}
+
+// UpsertSlackMessages stores indexed Slack messages, updating the author and
+// text of messages already indexed
+func UpsertSlackMessages(db *gorm.DB, messages []SlackMessageIndex) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel_id"}, {Name: "message_ts"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "text", "updated_at"}),
+	}).CreateInBatches(messages, 100).Error
+}
+
+// MarkSearchResultsStale flags every search result pointing at the given source
+// item as stale and returns how many rows were marked
+func MarkSearchResultsStale(db *gorm.DB, source, sourceID string) (int64, error) {
+	result := db.Model(&SearchResult{}).
+		Where("source = ? AND source_id = ? AND stale = ?", source, sourceID, false).
+		Update("stale", true)
+	return result.RowsAffected, result.Error
+}
@@ -397,3 +397,108 @@ func TestDatabase_Timestamps(t *testing.T) {
 		t.Error("CreatedAt should not change during updates")
 	}
 }
+
+func TestSaveRawResponse_Retention(t *testing.T) {
+	db := setupTestDatabase(t)
+	if err := db.AutoMigrate(&RawResponse{}); err != nil {
+		t.Fatalf("Failed to migrate RawResponse: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := SaveRawResponse(db, &RawResponse{InquiryID: uint(i), Source: "slack"}, 3); err != nil {
+			t.Fatalf("Failed to save raw response: %v", err)
+		}
+	}
+
+	var stored []RawResponse
+	db.Order("id ASC").Find(&stored)
+	if len(stored) != 3 {
+		t.Fatalf("Expected 3 retained raw responses, got %d", len(stored))
+	}
+	if stored[0].InquiryID != 3 {
+		t.Errorf("Expected oldest rows to be pruned, first retained inquiry is %d", stored[0].InquiryID)
+	}
+}
+
+func TestKVStore(t *testing.T) {
+	db := setupTestDatabase(t)
+	if err := db.AutoMigrate(&KVEntry{}); err != nil {
+		t.Fatalf("Failed to migrate KVEntry: %v", err)
+	}
+	store := NewKVStore(db)
+
+	var weights map[string]float64
+	if found, err := store.Get("weights", &weights); err != nil || found {
+		t.Fatalf("Expected missing key, got found=%v err=%v", found, err)
+	}
+
+	for _, value := range []map[string]float64{{"slack": 0.8}, {"slack": 0.9, "confluence": 1.1}} {
+		if err := store.Set("weights", value); err != nil {
+			t.Fatalf("Set returned error: %v", err)
+		}
+	}
+
+	found, err := store.Get("weights", &weights)
+	if err != nil || !found {
+		t.Fatalf("Expected stored key, got found=%v err=%v", found, err)
+	}
+	if weights["slack"] != 0.9 || weights["confluence"] != 1.1 {
+		t.Errorf("Expected the latest value, got %v", weights)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	db := setupTestDatabase(t)
+	if err := db.AutoMigrate(&AuditLog{}); err != nil {
+		t.Fatalf("Failed to migrate AuditLog: %v", err)
+	}
+
+	for _, action := range []string{"requeue", "rescore", "publish_faq"} {
+		if err := AppendAuditLog(db, AuditLog{UserID: "ops", Action: action, ResourceType: "inquiry", ResourceID: "1"}); err != nil {
+			t.Fatalf("AppendAuditLog returned error: %v", err)
+		}
+	}
+	db.Model(&AuditLog{}).Where("action = ?", "requeue").Update("created_at", time.Now().AddDate(0, 0, -2))
+
+	events, err := ListAuditLog(db, time.Now().AddDate(0, 0, -1), 100)
+	if err != nil {
+		t.Fatalf("ListAuditLog returned error: %v", err)
+	}
+	if len(events) != 2 || events[0].Action != "publish_faq" || events[1].Action != "rescore" {
+		t.Errorf("Expected the recent entries newest first, got %+v", events)
+	}
+
+	if events, _ := ListAuditLog(db, time.Now().AddDate(0, 0, -7), 1); len(events) != 1 || events[0].Action != "publish_faq" {
+		t.Errorf("Expected the limit to keep the newest entry, got %+v", events)
+	}
+}
+
+func TestUpsertSlackMessages(t *testing.T) {
+	db := setupTestDatabase(t)
+	if err := db.AutoMigrate(&SlackMessageIndex{}); err != nil {
+		t.Fatalf("Failed to migrate SlackMessageIndex: %v", err)
+	}
+
+	if err := UpsertSlackMessages(db, []SlackMessageIndex{
+		{ChannelID: "C1", MessageTS: "1.1", UserID: "U1", Text: "How do I deploy?"},
+		{ChannelID: "C1", MessageTS: "2.2", UserID: "U2", Text: "Use the pipeline"},
+	}); err != nil {
+		t.Fatalf("UpsertSlackMessages returned error: %v", err)
+	}
+	// Indexing again updates edited messages instead of duplicating them
+	if err := UpsertSlackMessages(db, []SlackMessageIndex{
+		{ChannelID: "C1", MessageTS: "2.2", UserID: "U2", Text: "Use the deploy pipeline"},
+		{ChannelID: "C2", MessageTS: "2.2", UserID: "U3", Text: "Same timestamp, other channel"},
+	}); err != nil {
+		t.Fatalf("UpsertSlackMessages returned error: %v", err)
+	}
+
+	var indexed []SlackMessageIndex
+	db.Order("channel_id, message_ts").Find(&indexed)
+	if len(indexed) != 3 {
+		t.Fatalf("Expected 3 indexed messages, got %d", len(indexed))
+	}
+	if indexed[1].Text != "Use the deploy pipeline" {
+		t.Errorf("Expected the edited text to be stored, got %q", indexed[1].Text)
+	}
+}
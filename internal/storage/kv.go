@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// KVEntry is one JSON-encoded value in the key-value store
+type KVEntry struct {
+	Key       string    `gorm:"primaryKey" json:"key"`
+	Value     []byte    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KVStore keeps small pieces of derived state, such as computed weights, that
+// must survive restarts and be shared between replicas
+type KVStore struct {
+	db *gorm.DB
+}
+
+// NewKVStore creates a key-value store backed by db
+func NewKVStore(db *gorm.DB) *KVStore {
+	return &KVStore{db: db}
+}
+
+// Get decodes the value stored under key into dest, reporting whether it was set
+func (s *KVStore) Get(key string, dest interface{}) (bool, error) {
+	var entry KVEntry
+	err := s.db.Where("key = ?", key).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", key, err)
+	}
+
+	if err := json.Unmarshal(entry.Value, dest); err != nil {
+		return false, fmt.Errorf("failed to decode %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// Set stores value under key, replacing any previous value
+func (s *KVStore) Set(key string, value interface{}) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", key, err)
+	}
+
+	return s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&KVEntry{Key: key, Value: encoded}).Error
+}
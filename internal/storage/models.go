@@ -20,12 +20,55 @@ type Inquiry struct {
 	MessageText string `json:"message_text"`
 	Timestamp   string `json:"timestamp"`
 
+	// User who asked for the answer, e.g. by reacting; the author unless someone else did
+	RequestedBy string `gorm:"index" json:"requested_by,omitempty"`
+
 	// Processing details
-	Status          string     `json:"status"` // pending, processing, completed, failed
+	Status          string     `json:"status"` // pending, processing, completed, failed, dead_letter
 	ProcessedAt     *time.Time `json:"processed_at,omitempty"`
 	ResponseSent    bool       `json:"response_sent"`
 	ResponseText    string     `json:"response_text"`
 	ThreadTimestamp string     `json:"thread_timestamp"`
+	Model           string     `json:"model"`                 // LLM model used to generate the response
+	RequestedModel  string     `json:"requested_model"`       // model the trigger emoji asked for, empty for the default
+	Category        string     `gorm:"index" json:"category"` // deployment, access, incident, how-to, other
+	Source          string     `gorm:"index" json:"source"`   // slack, dm, api
+	ProcessingNode  string     `json:"processing_node"`       // hostname of the instance that last processed it
+
+	// Hash of the normalized question, and the earlier inquiry whose answer was reused
+	ContentHash   string `gorm:"index" json:"content_hash,omitempty"`
+	DuplicateOfID *uint  `json:"duplicate_of_id,omitempty"`
+
+	// When the question message was deleted from Slack
+	SourceDeletedAt *time.Time `json:"source_deleted_at,omitempty"`
+
+	// Failure details; inquiries that keep failing are dead-lettered
+	RetryCount    int    `json:"retry_count"`
+	FailureReason string `json:"failure_reason,omitempty"`
+
+	// ID of the Slack canvas the answer was published to, if any
+	ExternalDocumentID string `json:"external_document_id,omitempty"`
+
+	// Answer refresh details
+	RefreshAfter     *time.Time `gorm:"index" json:"refresh_after,omitempty"`
+	RefreshOfferedAt *time.Time `json:"refresh_offered_at,omitempty"`
+
+	// Query sent to the search sources and the comma-separated keywords it was built from
+	SearchQuery    string `json:"search_query,omitempty"`
+	SearchKeywords string `json:"search_keywords,omitempty"`
+
+	// How confident the model was in the answer (0-1), and whether it was scored at all
+	ConfidenceScore  float64 `json:"confidence_score"`
+	ConfidenceScored bool    `json:"confidence_scored"`
+
+	// Tokens the answer's LLM request used, and the share of the search result
+	// context that fit in its prompt (0-1); all 0 when no model answered
+	PromptTokens     int     `json:"prompt_tokens,omitempty"`
+	CompletionTokens int     `json:"completion_tokens,omitempty"`
+	ContextRatio     float64 `json:"context_ratio,omitempty"`
+
+	// ID of the Confluence FAQ page the answer was published to, if any
+	FAQPageID string `json:"faq_page_id,omitempty"`
 
 	// Search results relationship
 	SearchResults []SearchResult `gorm:"foreignKey:InquiryID;constraint:OnDelete:CASCADE" json:"search_results,omitempty"`
@@ -41,15 +84,34 @@ type SearchResult struct {
 	InquiryID uint `gorm:"not null;index" json:"inquiry_id"`
 
 	// Source information
-	Source   string `json:"source"`    // slack, confluence
-	SourceID string `json:"source_id"` // message timestamp or page ID
-	Title    string `json:"title"`
-	Content  string `json:"content"`
-	URL      string `json:"url"`
+	Source    string `json:"source"`     // slack, confluence, or explanation for search explanations
+	SourceID  string `json:"source_id"`  // message timestamp or page ID
+	ChannelID string `json:"channel_id"` // Slack channel the message was posted in
+	SpaceKey  string `json:"space_key"`  // Confluence space the page belongs to
+	Title     string `json:"title"`
+	Content   string `json:"content"`
+	URL       string `json:"url"`
+
+	// NormalizedContent is Content lowercased with stop words and punctuation
+	// stripped, populated at insert time for scoring and full-text queries
+	NormalizedContent string `json:"normalized_content"`
+
+	// SearchQuery is the exact query sent to the source's search API that
+	// returned this result: the Slack search string or Confluence CQL
+	SearchQuery string `json:"search_query"`
 
 	// Relevance scoring
 	Score float64 `json:"score"`
 
+	// ManualOverride marks results pinned by an operator; they skip scoring and
+	// are always included in the answer context
+	ManualOverride bool `json:"manual_override"`
+
+	// Stale marks results whose source has changed since the answer was generated
+	Stale bool `gorm:"index" json:"stale"`
+	// SourceVersion is the version of the source item when it was fetched; 0 when unknown
+	SourceVersion int `json:"source_version,omitempty"`
+
 	// Additional metadata
 	Author      string    `json:"author"`
 	CreatedDate time.Time `json:"created_date"`
@@ -74,3 +136,84 @@ type ReactionEvent struct {
 	Processed bool  `json:"processed"`
 	InquiryID *uint `json:"inquiry_id,omitempty"`
 }
+
+// RawResponse stores the raw body of an external API response for debugging
+type RawResponse struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	InquiryID uint   `gorm:"index" json:"inquiry_id"`
+	Source    string `json:"source"`  // slack, confluence
+	Request   string `json:"request"` // query sent to the source
+	Body      string `json:"body"`
+}
+
+// Feedback records whether a user found an answer helpful, from a reaction on the answer
+type Feedback struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	InquiryID uint   `gorm:"uniqueIndex:idx_feedback_inquiry_user;not null" json:"inquiry_id"`
+	UserID    string `gorm:"uniqueIndex:idx_feedback_inquiry_user;not null" json:"user_id"`
+	Helpful   bool   `json:"helpful"`
+	// Rating is the 1-5 score given with /inquiry-feedback, 0 for reaction votes
+	Rating int `json:"rating,omitempty"`
+}
+
+// AnswerVersion is one candidate answer generated for an inquiry in ensemble
+// mode, the judge's merge of the candidates, or a shadow model's answer
+type AnswerVersion struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	InquiryID uint   `gorm:"index;not null" json:"inquiry_id"`
+	Kind      string `json:"kind"`  // candidate, merged, shadow
+	Model     string `json:"model"` // model that wrote it
+	Content   string `json:"content"`
+	Error     string `json:"error,omitempty"` // why a candidate has no content
+	Selected  bool   `json:"selected"`        // whether this version was posted
+}
+
+// SearchCache stores the unranked results of a search so identical queries can
+// reuse them until ExpiresAt
+type SearchCache struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	QueryHash string    `gorm:"uniqueIndex;not null" json:"query_hash"`
+	Results   []byte    `json:"results"` // JSON-encoded []SearchResult
+	ExpiresAt time.Time `gorm:"index" json:"expires_at"`
+}
+
+// SlackMessageIndex is a Slack message backfilled from channel history, searched
+// locally instead of through the Slack search API
+type SlackMessageIndex struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	ChannelID string `gorm:"uniqueIndex:idx_slack_message_index_message;not null" json:"channel_id"`
+	MessageTS string `gorm:"uniqueIndex:idx_slack_message_index_message;not null" json:"message_ts"`
+	UserID    string `json:"user_id"`
+	Text      string `json:"text"`
+}
+
+// Webhook is an external endpoint notified of inquiry lifecycle events
+type Webhook struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	URL    string   `gorm:"not null" json:"url"`
+	Secret string   `gorm:"not null" json:"-"`             // signs the payloads, never returned
+	Events []string `gorm:"serializer:json" json:"events"` // e.g. inquiry.completed
+}
+
+// GreetedChannel records a channel the bot has posted its introduction in
+type GreetedChannel struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+
+	ChannelID string `gorm:"uniqueIndex" json:"channel_id"`
+}
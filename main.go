@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,6 +13,7 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/config"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/handlers"
+	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/metrics"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/services"
 	"github.com/kouzoh/foundation-inquiry-slack-bot/internal/storage"
 	"github.com/sirupsen/logrus"
@@ -25,6 +27,9 @@ func main() {
 
 	// Initialize configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		logrus.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Set up logging
 	setupLogging(cfg.Env)
@@ -35,18 +40,74 @@ func main() {
 		logrus.Fatalf("Failed to initialize database: %v", err)
 	}
 
+	// Initialize metrics
+	metricsSink, err := metrics.New(cfg.MetricsBackend, cfg.StatsDAddr)
+	if err != nil {
+		logrus.Fatalf("Failed to initialize metrics: %v", err)
+	}
+
 	// Initialize services
 	slackService := services.NewSlackService(cfg)
 	confluenceService := services.NewConfluenceService(cfg)
+	if cfg.ConfluenceBaseURL != "" && cfg.ConfluenceAPIToken != "" {
+		if err := confluenceService.ValidateConnection(); err != nil {
+			if errors.Is(err, services.ErrConfluenceSpaceNotFound) && cfg.ConfluenceMissingSpace == "fail" {
+				logrus.Fatalf("Invalid Confluence configuration: %v", err)
+			}
+			logrus.WithError(err).Warn("Confluence validation failed; missing spaces are left out of searches")
+		}
+	}
 	llmService := services.NewLLMService(cfg)
+	if cfg.LiteLLMAPIKey != "" && cfg.LiteLLMBaseURL != "" {
+		if err := llmService.ValidateAPIKey(); err != nil {
+			logrus.Fatalf("Invalid LLM credentials: %v", err)
+		}
+	}
 	searchService := services.NewSearchService(slackService, confluenceService, db, cfg)
+	searchService.SetEmbedder(llmService)
+	githubPlugin := services.NewGitHubSearchPlugin(cfg)
+	searchService.RegisterPlugin(githubPlugin)
 	inquiryService := services.NewInquiryService(searchService, slackService, llmService, db, cfg)
+	wsService := services.NewWebSocketService(cfg)
+	llmService.SetMetrics(metricsSink)
+	searchService.SetMetrics(metricsSink)
+	inquiryService.SetMetrics(metricsSink)
+	inquiryService.SetWebSocket(wsService)
+
+	// Inquiries left processing by a previous run will never finish
+	if err := inquiryService.CleanupProcessingInquiries(context.Background()); err != nil {
+		logrus.Fatalf("Failed to clean up processing inquiries: %v", err)
+	}
 
 	// Initialize handlers
 	handlers := handlers.New(inquiryService, slackService, cfg)
 
 	// Set up router
-	router := setupRouter(handlers, cfg)
+	router := setupRouter(handlers, cfg, metrics.Handler(metricsSink), wsService)
+
+	// Background jobs stop when the server shuts down
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go inquiryService.RunWorkers(jobsCtx)
+	go inquiryService.RunAnswerRefreshLoop(jobsCtx)
+	go inquiryService.RunStaleReprocessLoop(jobsCtx)
+	go inquiryService.RunDeferredLoop(jobsCtx)
+	go inquiryService.RunWeeklyReportLoop(jobsCtx)
+	go searchService.RunPageVersionCheckLoop(jobsCtx)
+	go searchService.RunSourceWeightLoop(jobsCtx)
+
+	// Pick up configuration changes on SIGHUP
+	cfg.WatchForReload(jobsCtx, func(newCfg *config.Config) {
+		setupLogging(newCfg.Env)
+		slackService.Reload(newCfg)
+		confluenceService.Reload(newCfg)
+		llmService.Reload(newCfg)
+		searchService.Reload(newCfg)
+		githubPlugin.Reload(newCfg)
+		inquiryService.Reload(newCfg)
+		wsService.Reload(newCfg)
+		handlers.Reload(newCfg)
+	})
 
 	// Create server
 	srv := &http.Server{
@@ -67,6 +128,10 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logrus.Info("Shutting down server...")
+	stopJobs()
+
+	// Shutdown doesn't wait for hijacked connections, so close them explicitly
+	wsService.Close()
 
 	// Give outstanding requests a deadline for completion
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -91,7 +156,7 @@ func setupLogging(env string) {
 	}
 }
 
-func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
+func setupRouter(h *handlers.Handler, cfg *config.Config, metricsHandler, wsHandler http.Handler) *gin.Engine {
 	if cfg.Env == "production" {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -106,6 +171,11 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 		})
 	})
 
+	// Metrics scrape endpoint, for backends that are pulled rather than pushed to
+	if metricsHandler != nil {
+		router.GET("/metrics", gin.WrapH(metricsHandler))
+	}
+
 	// Slack webhook endpoints
 	api := router.Group("/api/v1")
 	{
@@ -114,5 +184,33 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 		api.POST("/slack/interactive", h.HandleInteractiveComponents)
 	}
 
+	// Inquiry status stream for the admin UI, authenticated by a token query
+	// parameter since browsers can't set headers on WebSocket requests
+	if wsHandler != nil {
+		api.GET("/ws", gin.WrapH(wsHandler))
+	}
+
+	// Admin endpoints
+	admin := api.Group("", h.RequireAdminToken)
+	{
+		admin.POST("/channels/:id/summarise", h.HandleSummariseChannel)
+		admin.POST("/inquiries", h.HandleCreateInquiry)
+		admin.GET("/inquiries/:id", h.HandleGetInquiry)
+		admin.GET("/inquiries/dead-letter", h.HandleListDeadLetter)
+		admin.POST("/inquiries/:id/requeue", h.HandleRequeueInquiry)
+		admin.POST("/inquiries/:id/rescore", h.HandleRescoreInquiry)
+		admin.POST("/inquiries/:id/publish-faq", h.HandlePublishFAQ)
+		admin.GET("/stats/contributors", h.HandleTopContributors)
+		admin.GET("/stats/answer-efficiency", h.HandleAnswerEfficiency)
+		admin.POST("/admin/poll-missed-reactions", h.HandlePollMissedReactions)
+		admin.POST("/admin/index-channel", h.HandleIndexChannel)
+		admin.GET("/admin/audit-log", h.HandleListAuditLog)
+		admin.POST("/webhooks", h.HandleCreateWebhook)
+		admin.GET("/webhooks", h.HandleListWebhooks)
+		admin.GET("/webhooks/:id", h.HandleGetWebhook)
+		admin.PUT("/webhooks/:id", h.HandleUpdateWebhook)
+		admin.DELETE("/webhooks/:id", h.HandleDeleteWebhook)
+	}
+
 	return router
 }